@@ -16,7 +16,7 @@ func (d *Document) GetArrayLength(path string) (int, error) {
 	}
 
 	if node.Kind != yaml.SequenceNode {
-		return 0, fmt.Errorf("path %s: expected sequence node", path)
+		return 0, fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", node))
 	}
 
 	return len(node.Content), nil
@@ -114,6 +114,23 @@ func getOrCreateArrayNode(root *yaml.Node, path string) (*yaml.Node, error) {
 
 // AppendToArray appends a value to an array at the specified path
 func (d *Document) AppendToArray(path string, value interface{}) error {
+	generation := d.beginNodeIndexEdit()
+	defer d.endNodeIndexEdit()
+	if err := d.appendToArray(path, value); err != nil {
+		return err
+	}
+	if root, err := d.mappingRoot(); err == nil {
+		d.keepNodeIndex(generation, root, splitPath(path))
+	}
+	return nil
+}
+
+// appendToArray appends value to the array at path, creating it if missing
+func (d *Document) appendToArray(path string, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
 	// Save the original array style before modification
 	originalStyle, err := d.getArrayStyle(path)
 	if err != nil {
@@ -149,11 +166,7 @@ func (d *Document) AppendToArray(path string, value interface{}) error {
 
 				arrayNode.Content = append(arrayNode.Content, valueNode)
 
-				content, err := d.ToBytes()
-				if err != nil {
-					return err
-				}
-				d.raw = string(content)
+				d.markDirty()
 
 				// Apply the original style if we had one
 				if originalStyle != nil {
@@ -182,11 +195,7 @@ func (d *Document) AppendToArray(path string, value interface{}) error {
 
 			arrayNode.Content = append(arrayNode.Content, valueNode)
 
-			content, err := d.ToBytes()
-			if err != nil {
-				return err
-			}
-			d.raw = string(content)
+			d.markDirty()
 
 			// Apply the original style if we had one
 			if originalStyle != nil {
@@ -200,7 +209,7 @@ func (d *Document) AppendToArray(path string, value interface{}) error {
 
 		// Path exists, check if it's an array
 		if existingNode.Kind != yaml.SequenceNode {
-			return fmt.Errorf("path %s: not an array", path)
+			return fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", existingNode))
 		}
 
 		// It's an array, append to it
@@ -209,13 +218,12 @@ func (d *Document) AppendToArray(path string, value interface{}) error {
 			return err
 		}
 
+		if parent, keyIndex, err := d.findMappingEntry(path); err == nil {
+			d.keepTrailingComment(parent.Content[keyIndex], existingNode)
+		}
 		existingNode.Content = append(existingNode.Content, valueNode)
 
-		content, err := d.ToBytes()
-		if err != nil {
-			return err
-		}
-		d.raw = string(content)
+		d.markDirty()
 
 		// Apply the original style if we had one
 		if originalStyle != nil {
@@ -240,11 +248,7 @@ func (d *Document) AppendToArray(path string, value interface{}) error {
 
 	arrayNode.Content = append(arrayNode.Content, valueNode)
 
-	content, err := d.ToBytes()
-	if err != nil {
-		return err
-	}
-	d.raw = string(content)
+	d.markDirty()
 
 	// Apply the original style if we had one
 	if originalStyle != nil {
@@ -258,6 +262,10 @@ func (d *Document) AppendToArray(path string, value interface{}) error {
 
 // RemoveFromArray removes an element from an array at the specified path and index
 func (d *Document) RemoveFromArray(path string, index int) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
 	root, err := d.mappingRoot()
 	if err != nil {
 		return err
@@ -268,21 +276,21 @@ func (d *Document) RemoveFromArray(path string, index int) error {
 	}
 
 	if index < 0 || index >= len(arrayNode.Content) {
-		return fmt.Errorf("array index out of bounds: %d", index)
+		return fmt.Errorf("path %s: %w", path, &ErrIndexOutOfBounds{Index: index, Length: len(arrayNode.Content)})
 	}
 
 	arrayNode.Content = append(arrayNode.Content[:index], arrayNode.Content[index+1:]...)
 
-	content, err := d.ToBytes()
-	if err != nil {
-		return err
-	}
-	d.raw = string(content)
+	d.markDirty()
 	return nil
 }
 
 // UpdateArrayElement updates an element in an array at the specified path and index
 func (d *Document) UpdateArrayElement(path string, index int, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
 	root, err := d.mappingRoot()
 	if err != nil {
 		return err
@@ -293,7 +301,7 @@ func (d *Document) UpdateArrayElement(path string, index int, value interface{})
 	}
 
 	if index < 0 || index >= len(arrayNode.Content) {
-		return fmt.Errorf("array index out of bounds: %d", index)
+		return fmt.Errorf("path %s: %w", path, &ErrIndexOutOfBounds{Index: index, Length: len(arrayNode.Content)})
 	}
 
 	valueNode, err := interfaceToNode(value)
@@ -311,16 +319,16 @@ func (d *Document) UpdateArrayElement(path string, index int, value interface{})
 
 	arrayNode.Content[index] = valueNode
 
-	content, err := d.ToBytes()
-	if err != nil {
-		return err
-	}
-	d.raw = string(content)
+	d.markDirty()
 	return nil
 }
 
 // InsertIntoArray inserts a value into an array at the specified path and index
 func (d *Document) InsertIntoArray(path string, index int, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
 	root, err := d.mappingRoot()
 	if err != nil {
 		return err
@@ -333,7 +341,7 @@ func (d *Document) InsertIntoArray(path string, index int, value interface{}) er
 	}
 
 	if index < 0 || index > len(arrayNode.Content) {
-		return fmt.Errorf("array index out of bounds: %d", index)
+		return fmt.Errorf("path %s: %w", path, &ErrIndexOutOfBounds{Index: index, Length: len(arrayNode.Content)})
 	}
 
 	valueNode, err := interfaceToNode(value)
@@ -346,11 +354,7 @@ func (d *Document) InsertIntoArray(path string, index int, value interface{}) er
 
 	arrayNode.Content = append(arrayNode.Content[:index], append([]*yaml.Node{valueNode}, arrayNode.Content[index:]...)...)
 
-	content, err := d.ToBytes()
-	if err != nil {
-		return err
-	}
-	d.raw = string(content)
+	d.markDirty()
 	return nil
 }
 
@@ -361,15 +365,118 @@ func (d *Document) GetArrayElement(path string, index int) (interface{}, error)
 		return nil, err
 	}
 
+	node = resolveAlias(node)
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", node))
+	}
+
+	if index < 0 || index >= len(node.Content) {
+		return nil, fmt.Errorf("path %s: %w", path, &ErrIndexOutOfBounds{Index: index, Length: len(node.Content)})
+	}
+
+	return d.toInterface(node.Content[index])
+}
+
+// GetArrayElementByName returns the first mapping in the array at path whose nameKey field equals nameValue,
+// e.g. GetArrayElementByName("env", "name", "LOG_LEVEL") for lists keyed by name like kubernetes env entries
+func (d *Document) GetArrayElementByName(path, nameKey, nameValue string) (map[string]interface{}, error) {
+	node, index, err := d.findArrayElementByName(path, nameKey, nameValue)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := nodeToInterface(node.Content[index])
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// SetArrayElementByName replaces the first mapping in the array at path whose nameKey field equals nameValue,
+// keeping its position and comments. Unlike an index, the name keeps addressing the element after reordering.
+func (d *Document) SetArrayElementByName(path, nameKey, nameValue string, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	_, index, err := d.findArrayElementByName(path, nameKey, nameValue)
+	if err != nil {
+		return err
+	}
+	return d.UpdateArrayElement(path, index, value)
+}
+
+// findArrayElementByName returns the array at path and the index of the first mapping element
+// whose nameKey field is the scalar nameValue
+func (d *Document) findArrayElementByName(path, nameKey, nameValue string) (*yaml.Node, int, error) {
+	node, err := d.getNode(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	node = resolveAlias(node)
+	if node.Kind != yaml.SequenceNode {
+		return nil, 0, fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", node))
+	}
+
+	for i, item := range node.Content {
+		item = resolveAlias(item)
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if name, found := findKeyInMapping(item, nameKey); found {
+			name = resolveAlias(name)
+			if name.Kind == yaml.ScalarNode && name.Value == nameValue {
+				return node, i, nil
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("path %s: no element with %s %s", path, nameKey, nameValue)
+}
+
+// GetArrayElementAs decodes the element at index of the array at path into out, which must be a pointer
+func (d *Document) GetArrayElementAs(path string, index int, out interface{}) error {
+	node, err := d.getNode(path)
+	if err != nil {
+		return err
+	}
+
+	node = resolveAlias(node)
 	if node.Kind != yaml.SequenceNode {
-		return nil, fmt.Errorf("path %s: expected sequence node", path)
+		return fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", node))
 	}
 
 	if index < 0 || index >= len(node.Content) {
-		return nil, fmt.Errorf("path %s: index %d out of bounds", path, index)
+		return fmt.Errorf("path %s: %w", path, &ErrIndexOutOfBounds{Index: index, Length: len(node.Content)})
+	}
+
+	if err := node.Content[index].Decode(out); err != nil {
+		return fmt.Errorf("path %s: failed to decode element %d: %w", path, index, err)
+	}
+	return nil
+}
+
+// GetSliceAs decodes every element of the array at path into a T, such as a struct with yaml tags,
+// so a list of containers or tasks can be ranged over without type assertions.
+// Go methods can't have type parameters, so it takes the document as an argument.
+func GetSliceAs[T any](d *Document, path string) ([]T, error) {
+	node, err := d.getNode(path)
+	if err != nil {
+		return nil, err
 	}
 
-	return nodeToInterface(node.Content[index])
+	node = resolveAlias(node)
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", node))
+	}
+
+	result := make([]T, len(node.Content))
+	for i, item := range node.Content {
+		if err := item.Decode(&result[i]); err != nil {
+			return nil, fmt.Errorf("path %s: failed to decode element %d: %w", path, i, err)
+		}
+	}
+	return result, nil
 }
 
 // GetTypedArrayElement returns a typed element from an array at the specified path and index
@@ -383,7 +490,7 @@ func (d *Document) GetTypedArrayElement(path string, index int, targetType strin
 	case "string":
 		str, ok := value.(string)
 		if !ok {
-			return nil, fmt.Errorf("path %s[%d]: expected string, got %T", path, index, value)
+			return nil, fmt.Errorf("path %s[%d]: %w", path, index, typeMismatch("string", value))
 		}
 		return str, nil
 	case "int":
@@ -393,7 +500,7 @@ func (d *Document) GetTypedArrayElement(path string, index int, targetType strin
 		case string:
 			return strconv.ParseInt(v, 10, 64)
 		default:
-			return nil, fmt.Errorf("path %s[%d]: expected integer, got %T", path, index, value)
+			return nil, fmt.Errorf("path %s[%d]: %w", path, index, typeMismatch("integer", value))
 		}
 	case "float":
 		switch v := value.(type) {
@@ -404,7 +511,7 @@ func (d *Document) GetTypedArrayElement(path string, index int, targetType strin
 		case string:
 			return strconv.ParseFloat(v, 64)
 		default:
-			return nil, fmt.Errorf("path %s[%d]: expected float, got %T", path, index, value)
+			return nil, fmt.Errorf("path %s[%d]: %w", path, index, typeMismatch("float", value))
 		}
 	case "bool":
 		switch v := value.(type) {
@@ -421,7 +528,7 @@ func (d *Document) GetTypedArrayElement(path string, index int, targetType strin
 				return nil, fmt.Errorf("path %s[%d]: invalid boolean value: %s", path, index, v)
 			}
 		default:
-			return nil, fmt.Errorf("path %s[%d]: expected boolean, got %T", path, index, value)
+			return nil, fmt.Errorf("path %s[%d]: %w", path, index, typeMismatch("boolean", value))
 		}
 	default:
 		return nil, fmt.Errorf("path %s[%d]: unsupported type: %s", path, index, targetType)
@@ -430,33 +537,17 @@ func (d *Document) GetTypedArrayElement(path string, index int, targetType strin
 
 // getNode returns the YAML node at the specified path
 func (d *Document) getNode(path string) (*yaml.Node, error) {
-	root, err := d.mappingRoot()
+	root, err := d.queryRoot()
 	if err != nil {
 		return nil, err
 	}
+	path = d.rootPath(path)
 	if path == "" {
 		return root, nil
 	}
 
-	node := root
-	parts := strings.Split(path, ".")
-	for _, part := range parts {
-		if node.Kind != yaml.MappingNode {
-			return nil, fmt.Errorf("path %s: expected mapping node", path)
-		}
-		found := false
-		for i := 0; i < len(node.Content); i += 2 {
-			if node.Content[i].Value == part {
-				node = node.Content[i+1]
-				found = true
-				break
-			}
-		}
-		if !found {
-			return nil, fmt.Errorf("path %s: key %s not found", path, part)
-		}
-	}
-	return node, nil
+	node, _, err := d.lookupNode(root, path)
+	return node, err
 }
 
 // getArrayNode returns an array node at the specified path
@@ -475,16 +566,16 @@ func getArrayNode(root *yaml.Node, path string) (*yaml.Node, error) {
 				return nil, err
 			}
 			if current.Kind != yaml.SequenceNode {
-				return nil, fmt.Errorf("path %s: not an array", path)
+				return nil, fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", current))
 			}
 			if idx < 0 || idx >= len(current.Content) {
-				return nil, fmt.Errorf("array index out of bounds: %d", idx)
+				return nil, fmt.Errorf("path %s: %w", path, &ErrIndexOutOfBounds{Index: idx, Length: len(current.Content)})
 			}
 			current = current.Content[idx]
 			continue
 		}
 		if current.Kind != yaml.MappingNode {
-			return nil, fmt.Errorf("path %s: not a map", path)
+			return nil, fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", current))
 		}
 		found := false
 		for j := 0; j < len(current.Content); j += 2 {
@@ -495,12 +586,12 @@ func getArrayNode(root *yaml.Node, path string) (*yaml.Node, error) {
 			}
 		}
 		if !found {
-			return nil, fmt.Errorf("path %s: key not found", path)
+			return nil, fmt.Errorf("path %s: key %s %w", path, part, ErrPathNotFound)
 		}
 	}
 
 	if current.Kind != yaml.SequenceNode {
-		return nil, fmt.Errorf("path %s: not an array", path)
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", current))
 	}
 	return current, nil
 }
@@ -516,55 +607,40 @@ func (d *Document) getArrayStyle(path string) (*ArrayStyle, error) {
 
 	// Fallback: analyze the current array in raw content
 	if d.raw != "" {
-		lines := strings.Split(d.raw, "\n")
-		for i, line := range lines {
-			trimmed := strings.TrimSpace(line)
-			if strings.Contains(trimmed, ":") {
-				if idx := strings.Index(trimmed, ":"); idx > 0 {
-					key := strings.TrimSpace(trimmed[:idx])
-					if key == path {
-						value := line[idx+1:]
-
-						style := &ArrayStyle{
-							Indentation: getLineIndentation(line),
-						}
-
-						if strings.Contains(value, "[") {
-							style.IsFlow = true
-							trimmedValue := strings.TrimSpace(value)
-
-							if strings.HasPrefix(trimmedValue, "[") && strings.HasSuffix(trimmedValue, "]") {
-								// Single line flow array
-								arrayContent := trimmedValue[1 : len(trimmedValue)-1]
-
-								// Check for spaces around elements
-								if strings.Contains(arrayContent, " , ") ||
-									(strings.HasPrefix(arrayContent, " ") && strings.HasSuffix(arrayContent, " ")) {
-									style.HasSpaces = true
-								} else if !strings.Contains(arrayContent, " ") {
-									style.IsCompact = true
-								}
-							} else if strings.HasSuffix(trimmedValue, "[") {
-								// Multiline flow array - check following lines
-								style.IsMultiline = true
-
-								// Look for closing bracket to determine if it's really multiline
-								for j := i + 1; j < len(lines); j++ {
-									nextLine := lines[j]
-									if strings.Contains(nextLine, "]") {
-										break
-									}
-								}
-							}
-						} else {
-							// Block style array
-							style.IsFlow = false
-						}
-
-						return style, nil
+		index := d.rawIndex()
+		if lines := index.keyLinesOf(path); len(lines) > 0 {
+			line := index.lines[lines[0]]
+			value := line[strings.Index(strings.TrimSpace(line), ":")+1:]
+
+			style := &ArrayStyle{
+				Indentation: getLineIndentation(line),
+			}
+
+			if strings.Contains(value, "[") {
+				style.IsFlow = true
+				trimmedValue := strings.TrimSpace(value)
+
+				if strings.HasPrefix(trimmedValue, "[") && strings.HasSuffix(trimmedValue, "]") {
+					// Single line flow array
+					arrayContent := trimmedValue[1 : len(trimmedValue)-1]
+
+					// Check for spaces around elements
+					if strings.Contains(arrayContent, " , ") ||
+						(strings.HasPrefix(arrayContent, " ") && strings.HasSuffix(arrayContent, " ")) {
+						style.HasSpaces = true
+					} else if !strings.Contains(arrayContent, " ") {
+						style.IsCompact = true
 					}
+				} else if strings.HasSuffix(trimmedValue, "[") {
+					// Multiline flow array
+					style.IsMultiline = true
 				}
+			} else {
+				// Block style array
+				style.IsFlow = false
 			}
+
+			return style, nil
 		}
 	}
 
@@ -582,32 +658,26 @@ func (d *Document) applyArrayStyle(path string, style *ArrayStyle) error {
 	}
 
 	// Update the cached style
-	if d.formattingCache == nil {
-		d.formattingCache = detectFormattingInfoOptimized(d.raw)
+	info := d.formattingInfo()
+	if info.ArrayStyles == nil {
+		info.ArrayStyles = make(map[string]*ArrayStyle)
 	}
-	if d.formattingCache.ArrayStyles == nil {
-		d.formattingCache.ArrayStyles = make(map[string]*ArrayStyle)
-	}
-	d.formattingCache.ArrayStyles[path] = style
+	info.ArrayStyles[path] = style
 
 	// If this is a multiline flow array, store the original format for later restoration
 	if style.IsFlow && style.IsMultiline {
-		if d.formattingCache.FlowObjectStyles == nil {
-			d.formattingCache.FlowObjectStyles = make(map[string]string)
+		if info.FlowObjectStyles == nil {
+			info.FlowObjectStyles = make(map[string]string)
 		}
 
 		// Try to find the original multiline format in the raw content
 		if originalFormat := d.findOriginalArrayFormat(path); originalFormat != "" {
-			d.formattingCache.FlowObjectStyles[path] = originalFormat
+			info.FlowObjectStyles[path] = originalFormat
 		}
 	}
 
 	// Force re-application of formatting
-	content, err := d.ToBytes()
-	if err != nil {
-		return err
-	}
-	d.raw = string(content)
+	d.markDirty()
 
 	return nil
 }
@@ -618,41 +688,79 @@ func (d *Document) findOriginalArrayFormat(path string) string {
 		return ""
 	}
 
-	lines := strings.Split(d.raw, "\n")
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.Contains(trimmed, ":") {
-			if idx := strings.Index(trimmed, ":"); idx > 0 {
-				key := strings.TrimSpace(trimmed[:idx])
-				if key == path {
-					value := strings.TrimSpace(line[idx+1:])
-
-					// Check if this is the start of a multiline flow array
-					if strings.HasSuffix(value, "[") || (strings.Contains(value, "[") && !strings.Contains(value, "]")) {
-						// Collect the multiline array
-						var result strings.Builder
-
-						// Start from the opening bracket
-						if strings.Contains(value, "[") {
-							startIdx := strings.Index(value, "[")
-							result.WriteString(value[startIdx:])
-						}
-
-						// Continue collecting until we find the closing bracket
-						bracketCount := strings.Count(result.String(), "[") - strings.Count(result.String(), "]")
-
-						for j := i + 1; j < len(lines) && bracketCount > 0; j++ {
-							result.WriteString("\n")
-							result.WriteString(lines[j])
-							bracketCount += strings.Count(lines[j], "[") - strings.Count(lines[j], "]")
-						}
-
-						return result.String()
-					}
-				}
+	index := d.rawIndex()
+	lines := index.lines
+	for _, i := range index.keyLinesOf(path) {
+		line := lines[i]
+		value := strings.TrimSpace(line[strings.Index(strings.TrimSpace(line), ":")+1:])
+
+		// Check if this is the start of a multiline flow array
+		if strings.HasSuffix(value, "[") || (strings.Contains(value, "[") && !strings.Contains(value, "]")) {
+			// Collect the multiline array
+			var result strings.Builder
+
+			// Start from the opening bracket
+			if strings.Contains(value, "[") {
+				startIdx := strings.Index(value, "[")
+				result.WriteString(value[startIdx:])
 			}
+
+			// Continue collecting until we find the closing bracket
+			bracketCount := strings.Count(result.String(), "[") - strings.Count(result.String(), "]")
+
+			for j := i + 1; j < len(lines) && bracketCount > 0; j++ {
+				result.WriteString("\n")
+				result.WriteString(lines[j])
+				bracketCount += strings.Count(lines[j], "[") - strings.Count(lines[j], "]")
+			}
+
+			return result.String()
 		}
 	}
 
 	return ""
 }
+
+// keepTrailingComment moves a comment that directly follows the last item of a block sequence
+// from holder (the sequence's key or the document) onto that item, so that an appended item
+// starts after the comment instead of taking it over
+func (d *Document) keepTrailingComment(holder, seq *yaml.Node) {
+	if holder.FootComment == "" || seq.Style&yaml.FlowStyle != 0 || len(seq.Content) == 0 {
+		return
+	}
+
+	last := seq.Content[len(seq.Content)-1]
+	target := last
+	if last.Kind == yaml.MappingNode && len(last.Content) >= 2 {
+		target = last.Content[len(last.Content)-2]
+	}
+	if target.FootComment != "" {
+		return
+	}
+
+	// Only comments without a blank line before them belong to the item
+	first := strings.TrimSpace(strings.SplitN(holder.FootComment, "\n", 2)[0])
+	lines := d.rawIndex().lines
+	for i := lastLine(last); i > 0 && i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == first {
+			target.FootComment = holder.FootComment
+			holder.FootComment = ""
+			return
+		}
+		if trimmed == "" || (!strings.HasPrefix(trimmed, "#") && indentOf(lines[i]) < seq.Column) {
+			return
+		}
+	}
+}
+
+// lastLine returns the highest line number used by node or its children
+func lastLine(node *yaml.Node) int {
+	line := node.Line
+	for _, child := range node.Content {
+		if childLine := lastLine(child); childLine > line {
+			line = childLine
+		}
+	}
+	return line
+}
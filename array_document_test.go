@@ -1,6 +1,8 @@
 package yamler
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -77,6 +79,20 @@ func TestArrayDocumentSupport(t *testing.T) {
 			expectedOutput: `- task1
 - task2
 - task3
+`,
+		},
+		{
+			name: "add_element_after_trailing_comment",
+			input: `- name: build
+  run: make
+# build done`,
+			operation: func(d *Document) error {
+				return d.AddArrayElement(map[string]interface{}{"name": "deploy"})
+			},
+			expectedOutput: `- name: build
+  run: make
+  # build done
+- name: deploy
 `,
 		},
 	}
@@ -158,3 +174,396 @@ func TestArrayDocumentGetters(t *testing.T) {
 		t.Errorf("Expected timeout=60, got %v", timeoutInt)
 	}
 }
+
+func TestArrayDocumentNegativeIndex(t *testing.T) {
+	input := `- name: play1
+  hosts: web
+- name: play2
+  hosts: db
+- name: play3
+  hosts: cache
+`
+
+	tests := []struct {
+		index   int
+		want    interface{}
+		wantErr bool
+	}{
+		{index: 0, want: "play1"},
+		{index: -1, want: "play3"},
+		{index: -3, want: "play1"},
+		{index: 3, wantErr: true},
+		{index: -4, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("index %d", tt.index), func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			got, err := doc.GetArrayDocumentElement(tt.index, "name")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetArrayDocumentElement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetArrayDocumentElement() = %v, want %v", got, tt.want)
+			}
+
+			err = doc.SetArrayElement(tt.index, "hosts", "changed")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetArrayElement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			hosts, err := doc.GetArrayDocumentElement(tt.index, "hosts")
+			if err != nil || hosts != "changed" {
+				t.Errorf("hosts after SetArrayElement() = %v, %v", hosts, err)
+			}
+		})
+	}
+}
+
+func TestArrayDocumentElements(t *testing.T) {
+	doc, err := Load("- a\n- b\n- c\n- d\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		start, end int
+		want       []interface{}
+		wantErr    bool
+	}{
+		{start: 0, end: 4, want: []interface{}{"a", "b", "c", "d"}},
+		{start: 1, end: 3, want: []interface{}{"b", "c"}},
+		{start: 0, end: -1, want: []interface{}{"a", "b", "c"}},
+		{start: -2, end: 4, want: []interface{}{"c", "d"}},
+		{start: 2, end: 2, want: []interface{}{}},
+		{start: 0, end: 5, wantErr: true},
+		{start: -5, end: 2, wantErr: true},
+		{start: 3, end: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("[%d:%d]", tt.start, tt.end), func(t *testing.T) {
+			got, err := doc.GetArrayDocumentElements(tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetArrayDocumentElements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArrayDocumentElements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	mapDoc, err := Load("key: value\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := mapDoc.GetArrayDocumentElements(0, 1); err == nil {
+		t.Error("GetArrayDocumentElements() on a mapping document should fail")
+	}
+}
+
+func TestArrayDocumentFullPaths(t *testing.T) {
+	input := `- hosts: web
+  tasks:
+    - name: install
+    - name: copy config # second
+      copy:
+        src: app.conf
+- hosts: db
+  matrix:
+    - [1, 2]
+    - [3, 4]
+`
+
+	tests := []struct {
+		name      string
+		operation func(*Document) error
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:      "nested index with new key",
+			operation: func(d *Document) error { return d.Set("[0].tasks[1].copy.mode", "0644") },
+			expected: `- hosts: web
+  tasks:
+    - name: install
+    - name: copy config # second
+      copy:
+        src: app.conf
+        mode: "0644"
+- hosts: db
+  matrix:
+    - [1, 2]
+    - [3, 4]
+`,
+		},
+		{
+			name:      "intermediate keys are created",
+			operation: func(d *Document) error { return d.SetArrayElement(-1, "vars.db.port", 5432) },
+			expected: `- hosts: web
+  tasks:
+    - name: install
+    - name: copy config # second
+      copy:
+        src: app.conf
+- hosts: db
+  matrix:
+    - [1, 2]
+    - [3, 4]
+  vars:
+    db:
+      port: 5432
+`,
+		},
+		{
+			name:      "nested arrays",
+			operation: func(d *Document) error { return d.SetArrayElement(1, "matrix[1][0]", 9) },
+			expected: `- hosts: web
+  tasks:
+    - name: install
+    - name: copy config # second
+      copy:
+        src: app.conf
+- hosts: db
+  matrix:
+    - [1, 2]
+    - [9, 4]
+`,
+		},
+		{
+			name:      "wildcards",
+			operation: func(d *Document) error { return d.SetAll("[*].tasks[*].name", "step") },
+			expected: `- hosts: web
+  tasks:
+    - name: step
+    - name: step # second
+      copy:
+        src: app.conf
+- hosts: db
+  matrix:
+    - [1, 2]
+    - [3, 4]
+`,
+		},
+		{
+			name:      "key under an array",
+			operation: func(d *Document) error { return d.Set("[0].tasks.name", "x") },
+			wantErr:   true,
+		},
+		{
+			name:      "index out of bounds",
+			operation: func(d *Document) error { return d.Set("[0].tasks[5]", "x") },
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = tt.operation(doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("operation error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result, _ := doc.String(); result != tt.expected {
+				t.Errorf("result:\n%s\nexpected:\n%s", result, tt.expected)
+			}
+		})
+	}
+
+	doc, err := Load(input)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	getters := []struct {
+		path string
+		want interface{}
+	}{
+		{path: "[0].tasks[1].copy.src", want: "app.conf"},
+		{path: "[1].matrix[1][1]", want: int64(4)},
+		{path: "hosts", want: "web"},
+	}
+	for _, tt := range getters {
+		if got, err := doc.Get(tt.path); err != nil || got != tt.want {
+			t.Errorf("Get(%q) = %v, %v, want %v", tt.path, got, err, tt.want)
+		}
+	}
+	if got, err := doc.GetArrayDocumentElement(0, "tasks[1].copy.src"); err != nil || got != "app.conf" {
+		t.Errorf("GetArrayDocumentElement() = %v, %v, want app.conf", got, err)
+	}
+	names, err := doc.GetAll("[*].tasks[*].name")
+	if err != nil || len(names) != 2 || names["[0].tasks[1].name"] != "copy config" {
+		t.Errorf("GetAll() = %v, %v", names, err)
+	}
+}
+
+func TestArrayDocumentElementOperations(t *testing.T) {
+	input := `# inventory
+
+# web play
+- name: web # frontend
+  hosts: web
+
+# db play
+- name: db
+  hosts: db
+
+- name: cache
+  hosts: cache
+`
+	withTrailingComment := input + "# cache done\n"
+
+	tests := []struct {
+		name      string
+		input     string
+		operation func(*Document) error
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:      "move first to end",
+			input:     withTrailingComment,
+			operation: func(d *Document) error { return d.MoveArrayElement(0, -1) },
+			expected: `# inventory
+
+# db play
+- name: db
+  hosts: db
+
+- name: cache
+  hosts: cache
+  # cache done
+
+# web play
+- name: web # frontend
+  hosts: web
+`,
+		},
+		{
+			name:      "move within",
+			operation: func(d *Document) error { return d.MoveArrayElement(1, 0) },
+			expected: `# inventory
+
+# db play
+- name: db
+  hosts: db
+
+# web play
+- name: web # frontend
+  hosts: web
+
+- name: cache
+  hosts: cache
+`,
+		},
+		{
+			name:      "remove first",
+			operation: func(d *Document) error { return d.RemoveArrayElement(0) },
+			expected: `# inventory
+
+# db play
+- name: db
+  hosts: db
+
+- name: cache
+  hosts: cache
+`,
+		},
+		{
+			name:      "remove last with its comment",
+			input:     withTrailingComment,
+			operation: func(d *Document) error { return d.RemoveArrayElement(-1) },
+			expected: `# inventory
+
+# web play
+- name: web # frontend
+  hosts: web
+
+# db play
+- name: db
+  hosts: db
+`,
+		},
+		{
+			name:      "insert before last",
+			operation: func(d *Document) error { return d.InsertArrayElement(-1, map[string]interface{}{"name": "lb"}) },
+			expected: `# inventory
+
+# web play
+- name: web # frontend
+  hosts: web
+
+# db play
+- name: db
+  hosts: db
+- name: lb
+
+- name: cache
+  hosts: cache
+`,
+		},
+		{
+			name:      "insert at end",
+			input:     "- task1\n- task2\n",
+			operation: func(d *Document) error { return d.InsertArrayElement(2, "task3") },
+			expected:  "- task1\n- task2\n- task3\n",
+		},
+		{
+			name:      "insert out of bounds",
+			operation: func(d *Document) error { return d.InsertArrayElement(4, "x") },
+			wantErr:   true,
+		},
+		{
+			name:      "remove out of bounds",
+			operation: func(d *Document) error { return d.RemoveArrayElement(3) },
+			wantErr:   true,
+		},
+		{
+			name:      "move out of bounds",
+			operation: func(d *Document) error { return d.MoveArrayElement(0, -4) },
+			wantErr:   true,
+		},
+		{
+			name:      "mapping document",
+			input:     "name: web\n",
+			operation: func(d *Document) error { return d.RemoveArrayElement(0) },
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := tt.input
+			if src == "" {
+				src = input
+			}
+			doc, err := Load(src)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = tt.operation(doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("operation error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result, _ := doc.String(); result != tt.expected {
+				t.Errorf("result:\n%s\nexpected:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
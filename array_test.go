@@ -1,6 +1,9 @@
 package yamler
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -118,6 +121,30 @@ func TestDocument_AppendToArray(t *testing.T) {
 			want:    "key: [1, 2, 3]\nnonexistent: [4]\n",
 			wantErr: false,
 		},
+		{
+			name:    "append after task with trailing comment",
+			content: "tasks:\n  - name: build\n    run: make\n    # build done\n",
+			path:    "tasks",
+			value:   map[string]interface{}{"name": "deploy", "run": "ship"},
+			want:    "tasks:\n  - name: build\n    run: make\n    # build done\n  - name: deploy\n    run: ship\n",
+			wantErr: false,
+		},
+		{
+			name:    "append after comment at dash level",
+			content: "tasks:\n  - name: build\n    run: make\n  # build done\nother: 1\n",
+			path:    "tasks",
+			value:   map[string]interface{}{"name": "deploy", "run": "ship"},
+			want:    "tasks:\n  - name: build\n    run: make\n    # build done\n  - name: deploy\n    run: ship\nother: 1\n",
+			wantErr: false,
+		},
+		{
+			name:    "section comment after blank line stays after array",
+			content: "tasks:\n  - name: build\n\n# Other settings\nother: 1\n",
+			path:    "tasks",
+			value:   map[string]interface{}{"name": "deploy"},
+			want:    "tasks:\n  - name: build\n  - name: deploy\n\n# Other settings\nother: 1\n",
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -578,6 +605,130 @@ func TestDocument_GetArrayElement(t *testing.T) {
 	}
 }
 
+func TestDocument_GetArrayElementAs(t *testing.T) {
+	type envVar struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	}
+	type port struct {
+		ContainerPort int `yaml:"containerPort"`
+	}
+
+	doc, err := Load(`env:
+  - name: LOG_LEVEL
+    value: debug
+  - name: PORT
+    value: "8080"
+ports:
+  - containerPort: http
+names: [a, b]
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var env envVar
+	if err := doc.GetArrayElementAs("env", 1, &env); err != nil {
+		t.Fatalf("GetArrayElementAs() error = %v", err)
+	}
+	if env != (envVar{Name: "PORT", Value: "8080"}) {
+		t.Errorf("GetArrayElementAs() = %+v", env)
+	}
+
+	var name string
+	if err := doc.GetArrayElementAs("names", 0, &name); err != nil || name != "a" {
+		t.Errorf("GetArrayElementAs() = %q, %v, want %q", name, err, "a")
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		index int
+		out   interface{}
+	}{
+		{name: "index out of bounds", path: "env", index: 2, out: &envVar{}},
+		{name: "negative index", path: "env", index: -1, out: &envVar{}},
+		{name: "not an array", path: "env[0].name", index: 0, out: &envVar{}},
+		{name: "missing path", path: "volumes", index: 0, out: &envVar{}},
+		{name: "type mismatch", path: "ports", index: 0, out: &port{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := doc.GetArrayElementAs(tt.path, tt.index, tt.out); err == nil {
+				t.Error("GetArrayElementAs() expected error")
+			}
+		})
+	}
+}
+
+func TestGetSliceAs(t *testing.T) {
+	type container struct {
+		Name  string   `yaml:"name"`
+		Image string   `yaml:"image"`
+		Ports []int    `yaml:"ports"`
+		Args  []string `yaml:"args,omitempty"`
+	}
+
+	doc, err := Load(`spec:
+  containers:
+    - name: web # frontend
+      image: nginx
+      ports: [80, 443]
+    - name: sidecar
+      image: envoy
+      ports: []
+      args: [--debug]
+  hosts: &hosts
+    - a.local
+    - b.local
+  mirrors: *hosts
+  empty: []
+  bad:
+    - name: x
+      ports: http
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	containers, err := GetSliceAs[container](doc, "spec.containers")
+	if err != nil {
+		t.Fatalf("GetSliceAs() error = %v", err)
+	}
+	want := []container{
+		{Name: "web", Image: "nginx", Ports: []int{80, 443}},
+		{Name: "sidecar", Image: "envoy", Ports: []int{}, Args: []string{"--debug"}},
+	}
+	if !reflect.DeepEqual(containers, want) {
+		t.Errorf("GetSliceAs() = %+v, want %+v", containers, want)
+	}
+
+	maps, err := GetSliceAs[map[string]interface{}](doc, "spec.containers")
+	if err != nil || len(maps) != 2 || maps[1]["image"] != "envoy" {
+		t.Errorf("GetSliceAs[map]() = %v, %v", maps, err)
+	}
+
+	hosts, err := GetSliceAs[string](doc, "spec.mirrors")
+	if err != nil || !reflect.DeepEqual(hosts, []string{"a.local", "b.local"}) {
+		t.Errorf("GetSliceAs() through alias = %v, %v", hosts, err)
+	}
+
+	empty, err := GetSliceAs[container](doc, "spec.empty")
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("GetSliceAs() of empty array = %#v, %v, want an empty slice", empty, err)
+	}
+
+	for _, path := range []string{"spec", "spec.missing", "spec.bad"} {
+		if _, err := GetSliceAs[container](doc, path); err == nil {
+			t.Errorf("GetSliceAs(%q) expected error", path)
+		}
+	}
+	if _, err := GetSliceAs[container](doc, "spec.bad"); err == nil || !strings.Contains(err.Error(), "element 0") {
+		t.Errorf("GetSliceAs() error = %v, want it to name element 0", err)
+	}
+}
+
 func TestDocument_GetTypedArrayElement(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -717,3 +868,335 @@ func TestDocument_GetTypedArrayElement(t *testing.T) {
 		})
 	}
 }
+
+func TestFlowArrayQuotedCommas(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantElements []string
+		wantAfterSet string
+		wantAppended string
+	}{
+		{
+			name:         "default style",
+			content:      "tags: [\"a,b\", \"c\"]\nname: x\n",
+			wantElements: []string{"a,b", "c"},
+			wantAfterSet: "tags: [\"a,b\", \"c\"]\nname: y\n",
+			wantAppended: "tags: [\"a,b\", \"c\", 'd,e']\nname: y\n",
+		},
+		{
+			name:         "spaced style",
+			content:      "tags: [ \"a,b\" , c ]\nname: x\n",
+			wantElements: []string{"a,b", "c"},
+			wantAfterSet: "tags: [ \"a,b\" , c ]\nname: y\n",
+			wantAppended: "tags: [ \"a,b\" , c , 'd,e' ]\nname: y\n",
+		},
+		{
+			name:         "compact style",
+			content:      "tags: [\"a,b\",c]\nname: x\n",
+			wantElements: []string{"a,b", "c"},
+			wantAfterSet: "tags: [\"a,b\",c]\nname: y\n",
+			wantAppended: "tags: [\"a,b\",c,'d,e']\nname: y\n",
+		},
+		{
+			name:         "brackets and escaped quotes inside quotes",
+			content:      "tags: ['a,[b]', \"x\\\",y\"]\nname: x\n",
+			wantElements: []string{"a,[b]", "x\",y"},
+			wantAfterSet: "tags: ['a,[b]', \"x\\\",y\"]\nname: y\n",
+			wantAppended: "tags: ['a,[b]', \"x\\\",y\", 'd,e']\nname: y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			length, err := doc.GetArrayLength("tags")
+			if err != nil {
+				t.Fatalf("GetArrayLength() error = %v", err)
+			}
+			if length != len(tt.wantElements) {
+				t.Errorf("GetArrayLength() = %d, want %d", length, len(tt.wantElements))
+			}
+
+			elements, err := doc.GetStringSlice("tags")
+			if err != nil {
+				t.Fatalf("GetStringSlice() error = %v", err)
+			}
+			for i, want := range tt.wantElements {
+				if i >= len(elements) || elements[i] != want {
+					t.Errorf("GetStringSlice() = %q, want %q", elements, tt.wantElements)
+					break
+				}
+			}
+
+			if err := doc.Set("name", "y"); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			got, _ := doc.String()
+			if got != tt.wantAfterSet {
+				t.Errorf("after Set() = %q, want %q", got, tt.wantAfterSet)
+			}
+
+			if err := doc.AppendToArray("tags", "d,e"); err != nil {
+				t.Fatalf("AppendToArray() error = %v", err)
+			}
+			got, _ = doc.String()
+			if got != tt.wantAppended {
+				t.Errorf("after AppendToArray() = %q, want %q", got, tt.wantAppended)
+			}
+
+			length, _ = doc.GetArrayLength("tags")
+			if length != len(tt.wantElements)+1 {
+				t.Errorf("GetArrayLength() after append = %d, want %d", length, len(tt.wantElements)+1)
+			}
+		})
+	}
+}
+
+func TestSplitFlowObjectPartsQuotes(t *testing.T) {
+	parts := splitFlowObjectParts(`"a,b", 'c,''d', [e, f], {g: "h,i"}`)
+	want := []string{`"a,b"`, ` 'c,''d'`, ` [e, f]`, ` {g: "h,i"}`}
+	if len(parts) != len(want) {
+		t.Fatalf("splitFlowObjectParts() = %q, want %q", parts, want)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("splitFlowObjectParts()[%d] = %q, want %q", i, parts[i], want[i])
+		}
+	}
+}
+
+func TestDocument_ArrayAliases(t *testing.T) {
+	content := `defaults:
+  image: &image nginx:1.25
+  env: &env
+    - DEBUG=0
+  limits: &limits
+    cpu: 1
+services:
+  - *image
+  - redis:7
+flow: [*image, busybox]
+envs: *env
+resources:
+  - *limits
+`
+
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	element, err := doc.GetArrayElement("services", 0)
+	if err != nil || element != "nginx:1.25" {
+		t.Errorf("GetArrayElement(services, 0) = %v, %v, want nginx:1.25", element, err)
+	}
+
+	slice, err := doc.GetSlice("services")
+	if err != nil || !deepEqual(slice, []interface{}{"nginx:1.25", "redis:7"}) {
+		t.Errorf("GetSlice(services) = %v, %v", slice, err)
+	}
+
+	strs, err := doc.GetStringSlice("flow")
+	if err != nil || !reflect.DeepEqual(strs, []string{"nginx:1.25", "busybox"}) {
+		t.Errorf("GetStringSlice(flow) = %v, %v", strs, err)
+	}
+
+	// An aliased sequence can be indexed like the original
+	env, err := doc.GetArrayElement("envs", 0)
+	if err != nil || env != "DEBUG=0" {
+		t.Errorf("GetArrayElement(envs, 0) = %v, %v, want DEBUG=0", env, err)
+	}
+
+	cpu, err := doc.Get("resources[0].cpu")
+	if err != nil || cpu != int64(1) {
+		t.Errorf("Get(resources[0].cpu) = %v, %v, want 1", cpu, err)
+	}
+
+	// Reading aliases must not change the output
+	if got, _ := doc.String(); got != content {
+		t.Errorf("String() =\n%s\nwant:\n%s", got, content)
+	}
+}
+
+func TestDocument_ArrayElementByName(t *testing.T) {
+	content := `spec:
+  containers:
+    - name: app
+      env:
+        - name: LOG_LEVEL
+          value: info # default
+        - name: PORT
+          value: "8080"
+services:
+  - name: web
+    image: nginx
+  - plain
+  - name: db
+    image: postgres
+`
+
+	t.Run("get", func(t *testing.T) {
+		doc, err := Load(content)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		tests := []struct {
+			path, key, value string
+			want             map[string]interface{}
+			wantErr          bool
+		}{
+			{path: "services", key: "name", value: "db", want: map[string]interface{}{"name": "db", "image": "postgres"}},
+			{path: "spec.containers[0].env", key: "name", value: "PORT", want: map[string]interface{}{"name": "PORT", "value": "8080"}},
+			{path: "services", key: "name", value: "cache", wantErr: true},
+			{path: "services", key: "image", value: "nginx", want: map[string]interface{}{"name": "web", "image": "nginx"}},
+			{path: "spec", key: "name", value: "app", wantErr: true},
+			{path: "missing", key: "name", value: "x", wantErr: true},
+		}
+
+		for _, tt := range tests {
+			got, err := doc.GetArrayElementByName(tt.path, tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetArrayElementByName(%s, %s, %s) error = %v, wantErr %v", tt.path, tt.key, tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArrayElementByName(%s, %s, %s) = %v, want %v", tt.path, tt.key, tt.value, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		doc, err := Load(content)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		err = doc.SetArrayElementByName("spec.containers[0].env", "name", "LOG_LEVEL", map[string]interface{}{
+			"name":  "LOG_LEVEL",
+			"value": "debug",
+		})
+		if err != nil {
+			t.Fatalf("SetArrayElementByName() error = %v", err)
+		}
+		if err := doc.SetArrayElementByName("services", "name", "cache", "x"); err == nil {
+			t.Error("SetArrayElementByName() expected error for a missing element")
+		}
+
+		got, err := doc.GetArrayElementByName("spec.containers[0].env", "name", "LOG_LEVEL")
+		if err != nil {
+			t.Fatalf("GetArrayElementByName() error = %v", err)
+		}
+		if got["value"] != "debug" {
+			t.Errorf("value = %v, want debug", got["value"])
+		}
+
+		// Neighbours keep their position
+		port, err := doc.GetArrayElement("spec.containers[0].env", 1)
+		if err != nil {
+			t.Fatalf("GetArrayElement() error = %v", err)
+		}
+		if port.(map[string]interface{})["name"] != "PORT" {
+			t.Errorf("element 1 = %v, want PORT entry", port)
+		}
+	})
+}
+
+func TestDocument_DeferredSerialization(t *testing.T) {
+	content := `# services
+ports: [80, 443]
+tags:
+  - web
+name: app
+`
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := doc.AppendToArray("tags", fmt.Sprintf("t%d", i)); err != nil {
+			t.Fatalf("AppendToArray() error = %v", err)
+		}
+	}
+	if err := doc.UpdateArrayElement("ports", 1, 8443); err != nil {
+		t.Fatalf("UpdateArrayElement() error = %v", err)
+	}
+	if err := doc.RemoveFromArray("tags", 0); err != nil {
+		t.Fatalf("RemoveFromArray() error = %v", err)
+	}
+
+	// Edits only change the node tree, the text is rendered once on output
+	if !doc.dirty || doc.raw != content {
+		t.Fatalf("edits re-rendered the document before output")
+	}
+
+	want := `# services
+ports: [80, 8443]
+tags:
+  - t0
+  - t1
+  - t2
+name: app
+`
+	if got, _ := doc.String(); got != want {
+		t.Errorf("String() =\n%s\nwant:\n%s", got, want)
+	}
+	if doc.dirty || doc.raw != want {
+		t.Errorf("output did not become the raw content")
+	}
+
+	// Operations working on the text see the pending edits
+	doc, err = Load("a:\n  b: 1\nc:\n  d: 2\ne:\n    f: 1\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := doc.Set("c.d", 3); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := doc.FixIndentation(); err != nil {
+		t.Fatalf("FixIndentation() error = %v", err)
+	}
+	if got, _ := doc.GetInt("c.d"); got != 3 {
+		t.Errorf("GetInt(c.d) after FixIndentation = %d, want 3", got)
+	}
+}
+
+func TestDocument_RawIndexReused(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&content, "list%d: [a, b]\nblock%d:\n  - a\n", i, i)
+	}
+	doc, err := Load(content.String())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Appending to many arrays splits the original text once
+	var index *rawIndex
+	for i := 0; i < 50; i++ {
+		if err := doc.AppendToArray(fmt.Sprintf("list%d", i), "c"); err != nil {
+			t.Fatalf("AppendToArray() error = %v", err)
+		}
+		if err := doc.AppendToArray(fmt.Sprintf("block%d", i), "c"); err != nil {
+			t.Fatalf("AppendToArray() error = %v", err)
+		}
+		if index == nil {
+			index = doc.lineIndex
+		} else if doc.lineIndex != index {
+			t.Fatalf("raw content was indexed again after %d edits", i)
+		}
+	}
+
+	got, err := doc.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	if !strings.Contains(got, "list7: [a, b, c]\nblock7:\n  - a\n  - c\n") {
+		t.Errorf("String() lost array styles:\n%s", got)
+	}
+}
@@ -2,6 +2,7 @@ package yamler
 
 import (
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 )
@@ -131,6 +132,34 @@ func BenchmarkGet(b *testing.B) {
 	}
 }
 
+// BenchmarkCompiledGet compares a deep Get on a document and on its compiled snapshot
+func BenchmarkCompiledGet(b *testing.B) {
+	doc, err := Load(generateLargeYAML(1000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	compiled, err := doc.Compile()
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := "app.servers[999].config.timeout"
+
+	b.Run("document", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := doc.GetInt(path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("compiled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := compiled.GetInt(path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 // BenchmarkArrayOperations tests array manipulation performance
 func BenchmarkArrayOperations(b *testing.B) {
 	yamlContent := `
@@ -154,6 +183,25 @@ app:
 		}
 	})
 
+	b.Run("AppendToManyArraysInLargeDocument", func(b *testing.B) {
+		var large strings.Builder
+		for i := 0; i < 5000; i++ {
+			fmt.Fprintf(&large, "list%d: [a, b]\n", i)
+		}
+		doc, err := Load(large.String())
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			err = doc.AppendToArray(fmt.Sprintf("list%d", i%5000), "c")
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
 	b.Run("UpdateArrayElement", func(b *testing.B) {
 		doc, err := Load(yamlContent)
 		if err != nil {
@@ -447,6 +495,33 @@ func BenchmarkPathParsing(b *testing.B) {
 	})
 }
 
+// BenchmarkGetAllQueryCache tests repeated wildcard queries with and without the query cache
+func BenchmarkGetAllQueryCache(b *testing.B) {
+	yamlContent := generateLargeYAML(100)
+
+	for _, enabled := range []bool{true, false} {
+		name := "Uncached"
+		if enabled {
+			name = "Cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			doc, err := Load(yamlContent)
+			if err != nil {
+				b.Fatal(err)
+			}
+			doc.SetQueryCacheEnabled(enabled)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := doc.GetAll("**.name")
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 // BenchmarkRepeatedOperations tests performance with repeated operations on same document
 func BenchmarkRepeatedOperations(b *testing.B) {
 	yamlContent := generateLargeYAML(100)
@@ -485,3 +560,29 @@ func BenchmarkRepeatedOperations(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkEditStream edits one top-level key of a large document without loading the rest
+func BenchmarkEditStream(b *testing.B) {
+	var builder strings.Builder
+	for i := 0; i < 50000; i++ {
+		builder.WriteString(fmt.Sprintf("key%d:\n  host: host%d.example.com\n  port: %d\n", i, i, 8000+i%1000))
+	}
+	content := builder.String()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := EditStream(strings.NewReader(content), io.Discard, func(entry *StreamEntry) error {
+			if entry.Key != "key25000" {
+				return nil
+			}
+			doc, err := entry.Document()
+			if err != nil {
+				return err
+			}
+			return doc.Set("key25000.port", 9999)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
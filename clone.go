@@ -0,0 +1,267 @@
+package yamler
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Clone returns an independent deep copy of the document: its node tree, original content,
+// formatting and comment alignment settings. Edits to the copy never affect the original.
+// The copy is writable even if the original is frozen.
+func (d *Document) Clone() *Document {
+	clones := make(map[*yaml.Node]*yaml.Node)
+	root := cloneTree(d.root, clones)
+	if root != nil {
+		relinkAliases(root, clones)
+	}
+	clone := &Document{
+		root:                      root,
+		raw:                       d.raw,
+		dirty:                     d.dirty,
+		arrayRoot:                 d.arrayRoot,
+		trailingNewlines:          d.trailingNewlines,
+		preserveDocumentSeparator: d.preserveDocumentSeparator,
+		exactTrailingNewlines:     d.exactTrailingNewlines,
+		formattingCache:           cloneFormattingInfo(d.formattingCache),
+		header:                    d.header,
+		queryCache:                newQueryCache(),
+		keepTags:                  d.keepTags,
+		serialize:                 d.serialize,
+		alignComments:             d.alignComments,
+		schema:                    d.schema,
+	}
+	if d.pinned != nil {
+		clone.pinned = append([]byte(nil), d.pinned...)
+	}
+	if d.templates != nil {
+		clone.templates = d.templates.clone()
+	}
+
+	// Per-node formatting is keyed by node, so move it over to the copied nodes
+	if d.sequenceSpacing != nil {
+		clone.sequenceSpacing = make(map[*yaml.Node]*sequenceSpacing, len(d.sequenceSpacing))
+		for node, spacing := range d.sequenceSpacing {
+			items := make([]*yaml.Node, len(spacing.items))
+			for i, item := range spacing.items {
+				items[i] = clones[item]
+			}
+			clone.sequenceSpacing[clones[node]] = &sequenceSpacing{items: items, blanks: append([]int(nil), spacing.blanks...)}
+		}
+	}
+	if d.explicitKeys != nil {
+		clone.explicitKeys = make(map[*yaml.Node]bool, len(d.explicitKeys))
+		for node, explicit := range d.explicitKeys {
+			clone.explicitKeys[clones[node]] = explicit
+		}
+	}
+	if d.schemaTags != nil {
+		clone.schemaTags = make(map[*yaml.Node]string, len(d.schemaTags))
+		for node, tag := range d.schemaTags {
+			if copied := clones[node]; copied != nil {
+				clone.schemaTags[copied] = tag
+			}
+		}
+	}
+	if d.trailingCommas != nil {
+		clone.trailingCommas = make(map[*yaml.Node]bool, len(d.trailingCommas))
+		for node, comma := range d.trailingCommas {
+			clone.trailingCommas[clones[node]] = comma
+		}
+	}
+	return clone
+}
+
+// cloneTree deep-copies a node tree, recording each copy in clones.
+// Aliases still point into the original tree until relinkAliases runs.
+func cloneTree(node *yaml.Node, clones map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	copied := *node
+	clones[node] = &copied
+	if len(node.Content) > 0 {
+		copied.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			copied.Content[i] = cloneTree(child, clones)
+		}
+	}
+	return &copied
+}
+
+// relinkAliases points alias nodes of a copied tree at the copies of their anchors
+func relinkAliases(node *yaml.Node, clones map[*yaml.Node]*yaml.Node) {
+	if node.Alias != nil {
+		if anchor, ok := clones[node.Alias]; ok {
+			node.Alias = anchor
+		}
+	}
+	for _, child := range node.Content {
+		relinkAliases(child, clones)
+	}
+}
+
+// cloneFormattingInfo deep-copies formatting info, or returns nil for nil
+func cloneFormattingInfo(info *FormattingInfo) *FormattingInfo {
+	if info == nil {
+		return nil
+	}
+	clone := *info
+	clone.EmptyLines = maps.Clone(info.EmptyLines)
+	clone.FlowStyles = maps.Clone(info.FlowStyles)
+	clone.ScalarStyles = maps.Clone(info.ScalarStyles)
+	clone.MultilineFlow = maps.Clone(info.MultilineFlow)
+	clone.ZeroIndentArrays = maps.Clone(info.ZeroIndentArrays)
+	clone.CommentAlignment = maps.Clone(info.CommentAlignment)
+	clone.KeyIndents = maps.Clone(info.KeyIndents)
+	clone.FlowObjectStyles = maps.Clone(info.FlowObjectStyles)
+	clone.QuotedScalars = maps.Clone(info.QuotedScalars)
+	clone.SectionBreaks = maps.Clone(info.SectionBreaks)
+	clone.arrayIndents = maps.Clone(info.arrayIndents)
+	if info.ArrayStyles != nil {
+		clone.ArrayStyles = make(map[string]*ArrayStyle, len(info.ArrayStyles))
+		for key, style := range info.ArrayStyles {
+			styleCopy := *style
+			clone.ArrayStyles[key] = &styleCopy
+		}
+	}
+	if info.pathArrayStyles != nil {
+		clone.pathArrayStyles = make(map[string]*ArrayStyle, len(info.pathArrayStyles))
+		for path, style := range info.pathArrayStyles {
+			styleCopy := *style
+			clone.pathArrayStyles[path] = &styleCopy
+		}
+	}
+	return &clone
+}
+
+// CopyFrom copies the subtree at srcPath of src to dstPath, with its comments, flow or block
+// styles and quoting. An existing value at dstPath is replaced in place; otherwise the key is
+// appended to dstPath's parent mapping, which is created if missing. Aliases to anchors outside
+// the subtree are expanded and merge keys using them inlined, since the anchors are not copied.
+func (d *Document) CopyFrom(src *Document, srcPath, dstPath string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if src == nil {
+		return fmt.Errorf("source document is required")
+	}
+	if dstPath == "" {
+		return fmt.Errorf("empty destination path")
+	}
+
+	node, err := src.getNode(srcPath)
+	if err != nil {
+		return err
+	}
+	var srcKey *yaml.Node
+	if srcPath != "" {
+		if root, err := src.queryRoot(); err == nil {
+			srcKey = findEntryKey(root, node)
+		}
+	}
+	value := cloneSubtree(node)
+
+	existing, err := d.getNode(dstPath)
+	switch {
+	case err == nil:
+		dstKey := findEntryKey(d.root, existing)
+		*existing = *value
+		if srcKey != nil && dstKey != nil {
+			copyKeyComments(dstKey, srcKey)
+		}
+	case errors.Is(err, ErrPathNotFound):
+		targetPath, key := "", dstPath
+		if idx := strings.LastIndex(dstPath, "."); idx != -1 {
+			targetPath, key = dstPath[:idx], dstPath[idx+1:]
+		}
+		if key == "" || strings.Contains(key, "[") {
+			return fmt.Errorf("path %s: expected mapping key", dstPath)
+		}
+		target, err := d.moveTarget(targetPath)
+		if err != nil {
+			return fmt.Errorf("path %s: %w", dstPath, err)
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		if srcKey != nil {
+			copyKeyComments(keyNode, srcKey)
+		}
+		target.Content = append(target.Content, keyNode, value)
+	default:
+		return err
+	}
+
+	d.markDirty()
+	return nil
+}
+
+// cloneSubtree deep-copies node for use in another tree, expanding aliases whose anchor lies outside it
+func cloneSubtree(node *yaml.Node) *yaml.Node {
+	clones := make(map[*yaml.Node]*yaml.Node)
+	copied := cloneTree(node, clones)
+	relinkAliases(copied, clones)
+
+	copies := make(map[*yaml.Node]bool, len(clones))
+	for _, clone := range clones {
+		copies[clone] = true
+	}
+	expandForeignAliases(copied, copies)
+	return copied
+}
+
+// expandForeignAliases replaces aliases pointing outside the copied nodes with copies of their targets
+func expandForeignAliases(node *yaml.Node, copies map[*yaml.Node]bool) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil && !copies[node.Alias] {
+		expanded := cloneSubtree(node.Alias)
+		expanded.Anchor = ""
+		expanded.HeadComment, expanded.LineComment, expanded.FootComment = node.HeadComment, node.LineComment, node.FootComment
+		*node = *expanded
+		return
+	}
+	if node.Kind == yaml.MappingNode {
+		inlineForeignMerges(node, copies)
+	}
+	for _, child := range node.Content {
+		expandForeignAliases(child, copies)
+	}
+}
+
+// inlineForeignMerges replaces "<<: *anchor" entries whose anchor lies outside the copied nodes
+// with the merged keys the mapping does not set itself
+func inlineForeignMerges(mapping *yaml.Node, copies map[*yaml.Node]bool) {
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if key.Value != "<<" || value.Kind != yaml.AliasNode || value.Alias == nil || copies[value.Alias] {
+			content = append(content, key, value)
+			continue
+		}
+		merged := resolveAlias(value)
+		if merged.Kind != yaml.MappingNode {
+			content = append(content, key, value)
+			continue
+		}
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if _, exists := findKeyInMapping(mapping, merged.Content[j].Value); !exists {
+				content = append(content, cloneSubtree(merged.Content[j]), cloneSubtree(merged.Content[j+1]))
+			}
+		}
+	}
+	mapping.Content = content
+}
+
+// copyKeyComments copies the comments of a source key that has any
+func copyKeyComments(dst, src *yaml.Node) {
+	if src.HeadComment != "" {
+		dst.HeadComment = src.HeadComment
+	}
+	if src.LineComment != "" {
+		dst.LineComment = src.LineComment
+	}
+	if src.FootComment != "" {
+		dst.FootComment = src.FootComment
+	}
+}
@@ -0,0 +1,189 @@
+package yamler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocument_Clone(t *testing.T) {
+	input := `# base config
+server:
+  host: 127.0.0.1   # bind address
+  port: 8080
+
+defaults: &defaults
+  retries: 3
+database:
+  <<: *defaults
+  host: db.internal
+features:
+  - auth
+  - api
+`
+	base, err := Load(input)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	base.SetAbsoluteCommentAlignment(30)
+	baseOutput, err := base.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+
+	dev := base.Clone()
+	if got, err := dev.String(); err != nil || got != baseOutput {
+		t.Fatalf("Clone().String() = %q, %v; want %q", got, err, baseOutput)
+	}
+
+	if err := dev.Set("server.port", 3000); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := dev.Set("defaults.retries", 1); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := dev.AppendToArray("features", "debug"); err != nil {
+		t.Fatalf("AppendToArray() error = %v", err)
+	}
+	dev.DisableCommentAlignment()
+
+	// The base document is untouched
+	if got, err := base.String(); err != nil || got != baseOutput {
+		t.Errorf("base after editing clone = %q, %v; want %q", got, err, baseOutput)
+	}
+
+	// Aliases in the clone follow the clone's anchors
+	devOutput, err := dev.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	reloaded, err := Load(devOutput)
+	if err != nil {
+		t.Fatalf("Load(clone output) error = %v", err)
+	}
+	var database struct{ Retries int }
+	if err := reloaded.Decode("database", &database); err != nil || database.Retries != 1 {
+		t.Errorf("clone database = %+v, %v; want retries 1", database, err)
+	}
+	if features, _ := dev.GetStringSlice("features"); len(features) != 3 {
+		t.Errorf("clone features = %v, want 3 items", features)
+	}
+}
+
+func TestDocument_CloneFrozen(t *testing.T) {
+	doc, err := Load("name: app\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	doc.Freeze()
+
+	clone := doc.Clone()
+	if clone.IsFrozen() {
+		t.Error("Clone() of a frozen document is frozen")
+	}
+	if err := clone.Set("name", "other"); err != nil {
+		t.Fatalf("Set() on clone error = %v", err)
+	}
+	if got, _ := clone.String(); got != "name: other\n" {
+		t.Errorf("clone = %q, want %q", got, "name: other\n")
+	}
+	if got, _ := doc.String(); got != "name: app\n" {
+		t.Errorf("original = %q, want %q", got, "name: app\n")
+	}
+}
+
+func TestDocument_CopyFrom(t *testing.T) {
+	src, err := Load(`defaults: &defaults
+  retries: 3
+services:
+  # Public API
+  api:
+    image: "app:1.2"   # pinned
+    ports: [80, 443]
+    env:
+      - name: MODE
+        value: 'prod'
+    <<: *defaults
+`)
+	if err != nil {
+		t.Fatalf("Load(src) error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		dst     string
+		srcPath string
+		dstPath string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "new key keeps comments, styles and quoting",
+			dst:     "services:\n  web:\n    image: nginx\n",
+			srcPath: "services.api",
+			dstPath: "services.api",
+			want:    "services:\n  web:\n    image: nginx\n  # Public API\n  api:\n    image: \"app:1.2\" # pinned\n    ports: [80, 443]\n    env:\n      - name: MODE\n        value: 'prod'\n    retries: 3\n",
+		},
+		{
+			name:    "replace existing value",
+			dst:     "name: x\nports: [8080] # old\nz: 1\n",
+			srcPath: "services.api.ports",
+			dstPath: "ports",
+			want:    "name: x\nports: [80, 443]\nz: 1\n",
+		},
+		{
+			name:    "missing parents are created",
+			dst:     "name: x\n",
+			srcPath: "services.api.env[0]",
+			dstPath: "deploy.first",
+			want:    "name: x\ndeploy:\n  first:\n    name: MODE\n    value: 'prod'\n",
+		},
+		{
+			name:    "missing source",
+			dst:     "name: x\n",
+			srcPath: "services.db",
+			dstPath: "db",
+			wantErr: "key db not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst, err := Load(tt.dst)
+			if err != nil {
+				t.Fatalf("Load(dst) error = %v", err)
+			}
+			err = dst.CopyFrom(src, tt.srcPath, tt.dstPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CopyFrom() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CopyFrom() error = %v", err)
+			}
+			got, err := dst.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CopyFrom() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// The copy is independent of the source
+	dst, err := Load("name: x\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := dst.CopyFrom(src, "services.api", "api"); err != nil {
+		t.Fatalf("CopyFrom() error = %v", err)
+	}
+	if err := dst.Set("api.image", "other"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if image, _ := src.GetString("services.api.image"); image != "app:1.2" {
+		t.Errorf("source image = %q after editing the copy", image)
+	}
+}
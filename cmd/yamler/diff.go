@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Winter0rbit/yamler"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newDiffCmd() *cobra.Command {
+	var jsonPatch bool
+	cmd := &cobra.Command{
+		Use:   "diff A B",
+		Short: "Print the values that differ between two documents",
+		Long: `Compare the data of two documents, ignoring formatting and comments. Each
+difference is printed as one line: "- path: value" for removed values, "+ path: value"
+for added ones and "~ path: old -> new" for changed ones. With --json-patch the
+differences are printed as an RFC 6902 JSON Patch that turns A into B instead.
+The exit status is 0 when the documents are equal, 1 when they differ and 2 on errors.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var docs [2]*yamler.Document
+			var values [2]interface{}
+			for i, name := range args {
+				doc, err := loadDocument(cmd, name)
+				if err != nil {
+					return err
+				}
+				if err := doc.Decode("", &values[i]); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				docs[i] = doc
+			}
+
+			var changes []string
+			diffValues("", values[0], values[1], &changes)
+			if len(changes) == 0 {
+				if jsonPatch {
+					_, err := io.WriteString(cmd.OutOrStdout(), "[]\n")
+					return err
+				}
+				return nil
+			}
+			if jsonPatch {
+				patch, err := docs[0].DiffAsJSONPatch(docs[1])
+				if err != nil {
+					return err
+				}
+				changes = []string{string(patch)}
+			}
+
+			out := cmd.OutOrStdout()
+			for _, change := range changes {
+				if _, err := io.WriteString(out, change+"\n"); err != nil {
+					return err
+				}
+			}
+			return errDifferent
+		},
+	}
+	cmd.Flags().BoolVar(&jsonPatch, "json-patch", false, "print the differences as a JSON Patch")
+	return cmd
+}
+
+// diffValues appends a line for each difference between a and b below path
+func diffValues(path string, a, b interface{}, changes *[]string) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			diffMaps(path, a, b, changes)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			diffSlices(path, a, b, changes)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, fmt.Sprintf("~ %s: %s -> %s", displayPath(path), formatValue(a), formatValue(b)))
+	}
+}
+
+// diffMaps compares two mappings key by key in sorted order
+func diffMaps(path string, a, b map[string]interface{}, changes *[]string) {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := joinPath(path, key)
+		av, inA := a[key]
+		bv, inB := b[key]
+		switch {
+		case !inB:
+			*changes = append(*changes, fmt.Sprintf("- %s: %s", child, formatValue(av)))
+		case !inA:
+			*changes = append(*changes, fmt.Sprintf("+ %s: %s", child, formatValue(bv)))
+		default:
+			diffValues(child, av, bv, changes)
+		}
+	}
+}
+
+// diffSlices compares two sequences element by element
+func diffSlices(path string, a, b []interface{}, changes *[]string) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		child := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(b):
+			*changes = append(*changes, fmt.Sprintf("- %s: %s", child, formatValue(a[i])))
+		case i >= len(a):
+			*changes = append(*changes, fmt.Sprintf("+ %s: %s", child, formatValue(b[i])))
+		default:
+			diffValues(child, a[i], b[i], changes)
+		}
+	}
+}
+
+// joinPath appends a key to a path, quoting keys that contain path syntax
+func joinPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]\"") {
+		key = fmt.Sprintf("%q", key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// displayPath names the document root "." so that root-level changes have a path
+func displayPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// formatValue renders a value as single-line flow YAML
+func formatValue(value interface{}) string {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	setFlowStyle(&node)
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setFlowStyle switches every collection below node to flow style
+func setFlowStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style = yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		setFlowStyle(child)
+	}
+}
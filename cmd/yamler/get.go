@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Winter0rbit/yamler"
+	"github.com/spf13/cobra"
+)
+
+func newGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get FILE PATH",
+		Short: "Print the value at a path",
+		Long: `Print the value at PATH. Scalars are printed as plain text, mappings and
+sequences as YAML. Paths use the library syntax, e.g. server.port or items[0].name.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			doc, err := loadDocument(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			return printValue(cmd, doc, args[1])
+		},
+	}
+}
+
+// printValue prints the scalar at path as text, or the collection at path as YAML
+func printValue(cmd *cobra.Command, doc *yamler.Document, path string) error {
+	kind, err := doc.Kind(path)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+
+	switch kind {
+	case yamler.KindNull:
+		_, err = fmt.Fprintln(out, "null")
+		return err
+	case yamler.KindScalar:
+		value, err := doc.Get(path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, value)
+		return err
+	}
+
+	if path != "" {
+		if doc, err = doc.SubDocument(path); err != nil {
+			return err
+		}
+	}
+	content, err := doc.ToBytes()
+	if err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	_, err = out.Write(content)
+	return err
+}
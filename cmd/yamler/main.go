@@ -0,0 +1,79 @@
+// Command yamler reads and edits YAML files from the shell while preserving their formatting,
+// comments and key order:
+//
+//	yamler get config.yaml server.port
+//	yamler set -i config.yaml spec.replicas 5
+//	yamler merge base.yaml override.yaml
+//	yamler diff a.yaml b.yaml
+//
+// A file name of "-" reads standard input.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Winter0rbit/yamler"
+	"github.com/spf13/cobra"
+)
+
+// errDifferent makes the process exit with status 1 without printing an error, like diff(1)
+var errDifferent = errors.New("documents differ")
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		if !errors.Is(err, errDifferent) {
+			fmt.Fprintln(os.Stderr, "yamler:", err)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode returns 1 for documents that differ and 2 for failures
+func exitCode(err error) int {
+	if errors.Is(err, errDifferent) {
+		return 1
+	}
+	return 2
+}
+
+// newRootCmd builds the yamler command with all subcommands
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "yamler",
+		Short:         "Read and edit YAML files while preserving formatting and comments",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.AddCommand(newGetCmd(), newSetCmd(), newMergeCmd(), newDiffCmd())
+	return root
+}
+
+// loadDocument loads the named file, or standard input for "-"
+func loadDocument(cmd *cobra.Command, name string) (*yamler.Document, error) {
+	if name != "-" {
+		return yamler.LoadFile(name)
+	}
+	return yamler.LoadReader(cmd.InOrStdin())
+}
+
+// editDocument applies edit to the named file and prints the result, or with inPlace writes it back
+// to the file through yamler.EditFile, so the file's diff holds only the edited lines
+func editDocument(cmd *cobra.Command, name string, inPlace bool, edit func(doc *yamler.Document) error) error {
+	if inPlace {
+		if name == "-" {
+			return fmt.Errorf("cannot edit standard input in place")
+		}
+		return yamler.EditFile(name, edit)
+	}
+	doc, err := loadDocument(cmd, name)
+	if err != nil {
+		return err
+	}
+	if err := edit(doc); err != nil {
+		return err
+	}
+	_, err = doc.WriteTo(cmd.OutOrStdout())
+	return err
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const baseYAML = `# service config
+server:
+  host: localhost   # bind
+  port: 8080
+spec:
+  replicas: 2
+  containers:
+    - name: app
+      image: "app:1"
+`
+
+const overrideYAML = `spec:
+  replicas: 4
+extra: [1, 2]
+`
+
+// run executes the yamler command with args and returns its standard output
+func run(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetArgs(args)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// writeFile writes content to a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestCommands(t *testing.T) {
+	base := writeFile(t, "base.yaml", baseYAML)
+	override := writeFile(t, "override.yaml", overrideYAML)
+
+	tests := []struct {
+		name    string
+		stdin   string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "get scalar",
+			args: []string{"get", base, "server.port"},
+			want: "8080\n",
+		},
+		{
+			name: "get mapping",
+			args: []string{"get", base, "server"},
+			want: "host: localhost # bind\nport: 8080\n",
+		},
+		{
+			name:  "get from stdin",
+			stdin: baseYAML,
+			args:  []string{"get", "-", "spec.containers[0].name"},
+			want:  "app\n",
+		},
+		{
+			name:    "get missing key",
+			args:    []string{"get", base, "server.tls"},
+			wantErr: "key tls not found",
+		},
+		{
+			name: "set parses value",
+			args: []string{"set", base, "spec.replicas", "5"},
+			want: strings.Replace(baseYAML, "replicas: 2", "replicas: 5", 1),
+		},
+		{
+			name: "set string",
+			args: []string{"set", "--string", base, "server.port", "9090"},
+			want: strings.Replace(baseYAML, "port: 8080", `port: "9090"`, 1),
+		},
+		{
+			name: "merge",
+			args: []string{"merge", base, override},
+			want: strings.Replace(baseYAML, "replicas: 2", "replicas: 4", 1) + "extra: [1, 2]\n",
+		},
+		{
+			name:    "merge with invalid strategy",
+			args:    []string{"merge", "--arrays", "zip", base, override},
+			wantErr: "invalid --arrays",
+		},
+		{
+			name: "diff",
+			args: []string{"diff", base, override},
+			want: "+ extra: [1, 2]\n- server: {host: localhost, port: 8080}\n" +
+				"- spec.containers: [{image: 'app:1', name: app}]\n~ spec.replicas: 2 -> 4\n",
+			wantErr: "documents differ",
+		},
+		{
+			name: "diff as JSON patch",
+			args: []string{"diff", "--json-patch", base, override},
+			want: `[{"op":"remove","path":"/server"},{"op":"replace","path":"/spec/replicas","value":4},` +
+				`{"op":"remove","path":"/spec/containers"},{"op":"add","path":"/extra","value":[1,2]}]` + "\n",
+			wantErr: "documents differ",
+		},
+		{
+			name: "diff of equal documents",
+			args: []string{"diff", base, base},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, tt.stdin, tt.args...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("output:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetInPlace(t *testing.T) {
+	// Spacing the renderer would normalize survives on lines the edit does not touch
+	input := strings.Replace(baseYAML, "port: 8080", "port:    8080", 1)
+	path := writeFile(t, "config.yaml", input)
+	if out, err := run(t, "", "set", "-i", path, "server.host", "example.com"); err != nil || out != "" {
+		t.Fatalf("set -i = %q, %v", out, err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := strings.Replace(input, "host: localhost", "host: example.com", 1)
+	if string(got) != want {
+		t.Errorf("file after set -i:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := run(t, baseYAML, "set", "-i", "-", "a", "1"); err == nil {
+		t.Error("set -i on standard input succeeded")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if got := exitCode(errDifferent); got != 1 {
+		t.Errorf("exitCode(errDifferent) = %d, want 1", got)
+	}
+	if got := exitCode(errors.New("boom")); got != 2 {
+		t.Errorf("exitCode(error) = %d, want 2", got)
+	}
+}
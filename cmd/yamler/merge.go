@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Winter0rbit/yamler"
+	"github.com/spf13/cobra"
+)
+
+func newMergeCmd() *cobra.Command {
+	var inPlace bool
+	var arrays, nulls string
+	cmd := &cobra.Command{
+		Use:   "merge BASE OVERRIDE...",
+		Short: "Deep merge documents into a base document",
+		Long: `Merge each OVERRIDE into BASE in order and print the result, or write it back
+to BASE with -i. The formatting and comments of BASE are kept.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := mergeOptions(arrays, nulls)
+			if err != nil {
+				return err
+			}
+			overrides := make([]*yamler.Document, 0, len(args)-1)
+			for _, name := range args[1:] {
+				override, err := loadDocument(cmd, name)
+				if err != nil {
+					return err
+				}
+				overrides = append(overrides, override)
+			}
+			return editDocument(cmd, args[0], inPlace, func(base *yamler.Document) error {
+				for i, override := range overrides {
+					if err := base.MergeWithOptions(override, opts); err != nil {
+						return fmt.Errorf("merge %s: %w", args[i+1], err)
+					}
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "write the result back to BASE")
+	cmd.Flags().StringVar(&arrays, "arrays", "replace", "array strategy: replace, append or key=NAME to merge items by a key")
+	cmd.Flags().StringVar(&nulls, "nulls", "overwrite", "null handling: overwrite, ignore or delete")
+	return cmd
+}
+
+// mergeOptions parses the --arrays and --nulls flags
+func mergeOptions(arrays, nulls string) (yamler.MergeOptions, error) {
+	var opts yamler.MergeOptions
+	switch {
+	case arrays == "replace":
+		opts.ArrayStrategy = yamler.ArrayReplace
+	case arrays == "append":
+		opts.ArrayStrategy = yamler.ArrayAppend
+	case strings.HasPrefix(arrays, "key="):
+		opts.ArrayStrategy = yamler.MergeByKey(strings.TrimPrefix(arrays, "key="))
+	default:
+		return opts, fmt.Errorf("invalid --arrays %q: want replace, append or key=NAME", arrays)
+	}
+
+	switch nulls {
+	case "overwrite":
+		opts.NullHandling = yamler.NullOverwrites
+	case "ignore":
+		opts.NullHandling = yamler.NullIgnored
+	case "delete":
+		opts.NullHandling = yamler.NullDeletes
+	default:
+		return opts, fmt.Errorf("invalid --nulls %q: want overwrite, ignore or delete", nulls)
+	}
+	return opts, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Winter0rbit/yamler"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newSetCmd() *cobra.Command {
+	var inPlace, asString bool
+	cmd := &cobra.Command{
+		Use:   "set FILE PATH VALUE",
+		Short: "Set the value at a path",
+		Long: `Set PATH to VALUE and print the document, or write it back with -i.
+VALUE is parsed as YAML, so 5 is an integer, true a boolean and [a, b] a list;
+use --string to store it as a string as-is.`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, raw := args[1], args[2]
+			var value interface{}
+			if !asString {
+				if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+					return fmt.Errorf("invalid value %q: %w", raw, err)
+				}
+			}
+			return editDocument(cmd, args[0], inPlace, func(doc *yamler.Document) error {
+				if asString {
+					return doc.SetString(path, raw)
+				}
+				return doc.Set(path, value)
+			})
+		},
+	}
+	cmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "write the result back to FILE")
+	cmd.Flags().BoolVarP(&asString, "string", "s", false, "store VALUE as a string instead of parsing it")
+	return cmd
+}
@@ -0,0 +1,220 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommentPosition selects which comment of an entry SetComment writes
+type CommentPosition int
+
+const (
+	// CommentHead is the comment block on the lines above the entry
+	CommentHead CommentPosition = iota
+	// CommentLine is the comment after the value on the entry's line
+	CommentLine
+	// CommentFoot is the comment block on the lines below the entry
+	CommentFoot
+)
+
+// Comments holds the comments of an entry, without their "#" markers
+type Comments struct {
+	Head string
+	Line string
+	Foot string
+}
+
+// GetComment returns the head, line and foot comments of the key or array element at path
+func (d *Document) GetComment(path string) (Comments, error) {
+	key, value, err := d.entryNodes(path)
+	if err != nil {
+		return Comments{}, err
+	}
+
+	holder := value
+	if key != nil {
+		holder = key
+	}
+	line, foot := value.LineComment, holder.FootComment
+	if key != nil {
+		if line == "" {
+			line = key.LineComment
+		}
+		if value.FootComment != "" {
+			foot = strings.TrimPrefix(foot+"\n"+value.FootComment, "\n")
+		}
+	}
+	return Comments{
+		Head: stripCommentMarkers(holder.HeadComment),
+		Line: stripCommentMarkers(line),
+		Foot: stripCommentMarkers(foot),
+	}, nil
+}
+
+// SetComment sets one comment of the key or array element at path, replacing the previous one.
+// Lines without a leading "#" get "# " added; empty text removes the comment.
+func (d *Document) SetComment(path, text string, position CommentPosition) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	key, value, err := d.entryNodes(path)
+	if err != nil {
+		return err
+	}
+	comment := addCommentMarkers(text)
+
+	switch position {
+	case CommentHead:
+		if key == nil {
+			value.HeadComment = comment
+			break
+		}
+		d.moveBlankLines(key, func() { key.HeadComment = comment })
+	case CommentLine:
+		if strings.Contains(text, "\n") {
+			return fmt.Errorf("path %s: line comment must be a single line", path)
+		}
+		value.LineComment = ""
+		if key == nil {
+			value.LineComment = comment
+			break
+		}
+		key.LineComment = ""
+		// yaml.v3 writes a block collection's line comment after its key
+		if (value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode) && value.Style&yaml.FlowStyle == 0 {
+			key.LineComment = comment
+		} else {
+			value.LineComment = comment
+		}
+	case CommentFoot:
+		if key == nil {
+			value.FootComment = comment
+			break
+		}
+		key.FootComment = comment
+		value.FootComment = ""
+	default:
+		return fmt.Errorf("invalid comment position: %d", position)
+	}
+
+	d.markDirty()
+	return nil
+}
+
+// DeleteComment removes the head, line and foot comments of the key or array element at path
+func (d *Document) DeleteComment(path string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	key, value, err := d.entryNodes(path)
+	if err != nil {
+		return err
+	}
+
+	if key != nil {
+		d.moveBlankLines(key, func() { key.HeadComment = "" })
+		key.LineComment = ""
+		key.FootComment = ""
+	}
+	value.HeadComment = ""
+	value.LineComment = ""
+	value.FootComment = ""
+
+	d.markDirty()
+	return nil
+}
+
+// entryNodes returns the value at path and its mapping key, or a nil key for array elements
+func (d *Document) entryNodes(path string) (*yaml.Node, *yaml.Node, error) {
+	if path == "" {
+		return nil, nil, fmt.Errorf("empty path")
+	}
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, nil, err
+	}
+	value := root
+	for _, part := range splitPathParts(path) {
+		if value, err = navigateToNode(value, part, path); err != nil {
+			return nil, nil, err
+		}
+	}
+	return findEntryKey(root, value), value, nil
+}
+
+// nodeAt returns the node at path, or the document root for an empty path.
+// Unlike getNode it also works in array-root documents.
+func (d *Document) nodeAt(path string) (*yaml.Node, error) {
+	if path == "" {
+		return d.queryRoot()
+	}
+	_, value, err := d.entryNodes(path)
+	return value, err
+}
+
+// findEntryKey returns the key node whose value is target, or nil if target is not a mapping value
+func findEntryKey(node, target *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1] == target {
+				return node.Content[i]
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if key := findEntryKey(child, target); key != nil {
+			return key
+		}
+	}
+	return nil
+}
+
+// moveBlankLines applies a head comment change to key, keeping the blank lines before the entry,
+// which FormattingInfo.EmptyLines tracks by the first head comment line
+func (d *Document) moveBlankLines(key *yaml.Node, change func()) {
+	before := blankLinesKey(key)
+	change()
+	after := blankLinesKey(key)
+	info := d.formattingCache
+	if info == nil || before == after {
+		return
+	}
+	if blanks, ok := info.EmptyLines[before]; ok {
+		delete(info.EmptyLines, before)
+		info.EmptyLines[after] = blanks
+	}
+}
+
+// addCommentMarkers turns comment text into yaml.v3 comment lines starting with "#"
+func addCommentMarkers(text string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), "#"):
+			lines[i] = strings.TrimSpace(line)
+		case strings.TrimSpace(line) == "":
+			lines[i] = "#"
+		default:
+			lines[i] = "# " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripCommentMarkers removes the "#" and the space after it from each comment line
+func stripCommentMarkers(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "#")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,200 @@
+package yamler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const commentsInput = `# Server settings
+server:
+  host: 0.0.0.0 # bind address
+  port: 8080
+
+# Feature flags
+features:
+  - auth # login
+  - api
+`
+
+func TestDocument_GetComment(t *testing.T) {
+	doc, err := Load(commentsInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want Comments
+	}{
+		{"server", Comments{Head: "Server settings"}},
+		{"server.host", Comments{Line: "bind address"}},
+		{"server.port", Comments{}},
+		{"features", Comments{Head: "Feature flags"}},
+		{"features[0]", Comments{Line: "login"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := doc.GetComment(tt.path)
+			if err != nil {
+				t.Fatalf("GetComment() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetComment() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := doc.GetComment("server.missing"); err == nil {
+		t.Error("GetComment() of a missing key should fail")
+	}
+}
+
+func TestDocument_SetComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		text     string
+		position CommentPosition
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "head comment on nested key",
+			path:     "server.port",
+			text:     "Public port\nchange with care",
+			position: CommentHead,
+			want:     "# Server settings\nserver:\n  host: 0.0.0.0 # bind address\n  # Public port\n  # change with care\n  port: 8080\n\n# Feature flags\nfeatures:\n  - auth # login\n  - api\n",
+		},
+		{
+			name:     "replace head comment keeps blank line",
+			path:     "features",
+			text:     "# Enabled features",
+			position: CommentHead,
+			want:     "# Server settings\nserver:\n  host: 0.0.0.0 # bind address\n  port: 8080\n\n# Enabled features\nfeatures:\n  - auth # login\n  - api\n",
+		},
+		{
+			name:     "line comment on scalar",
+			path:     "server.port",
+			text:     "default",
+			position: CommentLine,
+			want:     "# Server settings\nserver:\n  host: 0.0.0.0 # bind address\n  port: 8080 # default\n\n# Feature flags\nfeatures:\n  - auth # login\n  - api\n",
+		},
+		{
+			name:     "line comment on mapping",
+			path:     "server",
+			text:     "HTTP",
+			position: CommentLine,
+			want:     "# Server settings\nserver: # HTTP\n  host: 0.0.0.0 # bind address\n  port: 8080\n\n# Feature flags\nfeatures:\n  - auth # login\n  - api\n",
+		},
+		{
+			name:     "line comment on array element",
+			path:     "features[1]",
+			text:     "v2",
+			position: CommentLine,
+			want:     "# Server settings\nserver:\n  host: 0.0.0.0 # bind address\n  port: 8080\n\n# Feature flags\nfeatures:\n  - auth # login\n  - api # v2\n",
+		},
+		{
+			name:     "empty text removes",
+			path:     "server.host",
+			text:     "",
+			position: CommentLine,
+			want:     "# Server settings\nserver:\n  host: 0.0.0.0\n  port: 8080\n\n# Feature flags\nfeatures:\n  - auth # login\n  - api\n",
+		},
+		{
+			name:     "multi-line line comment",
+			path:     "server.host",
+			text:     "a\nb",
+			position: CommentLine,
+			wantErr:  "single line",
+		},
+		{
+			name:     "missing path",
+			path:     "server.missing",
+			text:     "x",
+			position: CommentHead,
+			wantErr:  "missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(commentsInput)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.SetComment(tt.path, tt.text, tt.position)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SetComment() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetComment() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetComment() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetCommentOnNewKey(t *testing.T) {
+	doc, err := Load("name: app\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := doc.Set("timeout", 30); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.SetComment("timeout", "Request timeout in seconds", CommentHead); err != nil {
+		t.Fatalf("SetComment() error = %v", err)
+	}
+	if err := doc.SetComment("timeout", "end of settings", CommentFoot); err != nil {
+		t.Fatalf("SetComment() error = %v", err)
+	}
+
+	want := "name: app\n# Request timeout in seconds\ntimeout: 30\n# end of settings\n"
+	if got, _ := doc.String(); got != want {
+		t.Errorf("result:\n%s\nwant:\n%s", got, want)
+	}
+	comments, err := doc.GetComment("timeout")
+	if err != nil {
+		t.Fatalf("GetComment() error = %v", err)
+	}
+	if comments.Head != "Request timeout in seconds" || comments.Foot != "end of settings" {
+		t.Errorf("GetComment() = %+v", comments)
+	}
+}
+
+func TestDocument_DeleteComment(t *testing.T) {
+	doc, err := Load(commentsInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, path := range []string{"features", "server.host", "features[0]"} {
+		if err := doc.DeleteComment(path); err != nil {
+			t.Fatalf("DeleteComment(%s) error = %v", path, err)
+		}
+	}
+
+	want := "# Server settings\nserver:\n  host: 0.0.0.0\n  port: 8080\n\nfeatures:\n  - auth\n  - api\n"
+	if got, _ := doc.String(); got != want {
+		t.Errorf("DeleteComment() result:\n%s\nwant:\n%s", got, want)
+	}
+
+	doc.Freeze()
+	if err := doc.DeleteComment("server"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteComment() on frozen document error = %v, want ErrReadOnly", err)
+	}
+	if err := doc.SetComment("server", "x", CommentHead); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetComment() on frozen document error = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,171 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompiledDocument is an immutable snapshot of a document for hot-path reads.
+// Every path is resolved once by Compile into a flat index, so Get is a single map lookup
+// without walking the tree. It is safe for concurrent use, and the document it was
+// compiled from stays editable; later edits are not visible through it.
+type CompiledDocument struct {
+	values    map[string]interface{}
+	arrayRoot bool
+}
+
+// Compile returns a read-only snapshot of the document indexed by path.
+// Paths have the form GetAll reports, e.g. servers[0].host or labels["app.kubernetes.io/name"].
+func (d *Document) Compile() (*CompiledDocument, error) {
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+	value, err := d.toInterface(root)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CompiledDocument{values: make(map[string]interface{}), arrayRoot: d.isArrayRoot()}
+	c.index("", value)
+	return c, nil
+}
+
+// index records value at path and every value below it
+func (c *CompiledDocument) index(path string, value interface{}) {
+	c.values[path] = value
+	c.indexChildren(path, value)
+}
+
+// indexChildren records the entries of a mapping or sequence value at path
+func (c *CompiledDocument) indexChildren(path string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			c.index(appendPathKey(path, key), child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			c.index(fmt.Sprintf("%s[%d]", path, i), child)
+		}
+	case TaggedValue:
+		// The tag belongs to the collection at path, its entries are indexed as usual
+		c.indexChildren(path, v.Value)
+	}
+}
+
+// lookup returns the value at path; in array documents a path without a leading index addresses
+// the first element, like Document.Get
+func (c *CompiledDocument) lookup(path string) (interface{}, error) {
+	if c.arrayRoot && path != "" && !strings.HasPrefix(path, "[") {
+		path = "[0]." + path
+	}
+	value, ok := c.values[path]
+	if !ok {
+		return nil, fmt.Errorf("path %s: %w", path, ErrPathNotFound)
+	}
+	return value, nil
+}
+
+// Has reports whether the snapshot contains path
+func (c *CompiledDocument) Has(path string) bool {
+	_, err := c.lookup(path)
+	return err == nil
+}
+
+// Get returns the value at path. Mappings and sequences are returned as copies,
+// so callers can't change the snapshot.
+func (c *CompiledDocument) Get(path string) (interface{}, error) {
+	value, err := c.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	return copyValue(value), nil
+}
+
+// GetString returns the string value at path
+func (c *CompiledDocument) GetString(path string) (string, error) {
+	value, err := c.lookup(path)
+	if err != nil {
+		return "", err
+	}
+	return asString(path, value)
+}
+
+// GetInt returns the integer value at path
+func (c *CompiledDocument) GetInt(path string) (int64, error) {
+	value, err := c.lookup(path)
+	if err != nil {
+		return 0, err
+	}
+	return asInt(path, value)
+}
+
+// GetFloat returns the float value at path
+func (c *CompiledDocument) GetFloat(path string) (float64, error) {
+	value, err := c.lookup(path)
+	if err != nil {
+		return 0, err
+	}
+	return asFloat(path, value)
+}
+
+// GetBool returns the boolean value at path
+func (c *CompiledDocument) GetBool(path string) (bool, error) {
+	value, err := c.lookup(path)
+	if err != nil {
+		return false, err
+	}
+	return asBool(path, value)
+}
+
+// GetSlice returns a copy of the array at path
+func (c *CompiledDocument) GetSlice(path string) ([]interface{}, error) {
+	value, err := c.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	slice, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %s: %w", path, typeMismatch("slice", value))
+	}
+	return slice, nil
+}
+
+// GetMap returns a copy of the mapping at path
+func (c *CompiledDocument) GetMap(path string) (map[string]interface{}, error) {
+	value, err := c.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %s: %w", path, typeMismatch("map", value))
+	}
+	return m, nil
+}
+
+// copyValue returns a deep copy of the maps and slices in value; scalars are immutable and shared
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = copyValue(child)
+		}
+		return copied
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = copyValue(child)
+		}
+		return copied
+	case TaggedValue:
+		return TaggedValue{Tag: v.Tag, Value: copyValue(v.Value)}
+	default:
+		return value
+	}
+}
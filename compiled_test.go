@@ -0,0 +1,131 @@
+package yamler
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDocument_Compile(t *testing.T) {
+	doc, err := Load(`app:
+  name: api
+  port: 8080
+  ratio: 0.5
+  debug: yes
+  tags: [a, b]
+  labels:
+    app.kubernetes.io/name: api
+servers:
+  - host: one
+  - host: two
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	compiled, err := doc.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{path: "app.name", want: "api"},
+		{path: "app.port", want: int64(8080)},
+		{path: "app.tags", want: []interface{}{"a", "b"}},
+		{path: "app.tags[1]", want: "b"},
+		{path: `app.labels["app.kubernetes.io/name"]`, want: "api"},
+		{path: "servers[1].host", want: "two"},
+		{path: "servers[0]", want: map[string]interface{}{"host": "one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := compiled.Get(tt.path)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get() = %v, want %v", got, tt.want)
+			}
+			if want, _ := doc.Get(tt.path); !reflect.DeepEqual(got, want) {
+				t.Errorf("Get() = %v, document has %v", got, want)
+			}
+		})
+	}
+
+	if s, err := compiled.GetString("app.name"); err != nil || s != "api" {
+		t.Errorf("GetString() = %q, %v", s, err)
+	}
+	if i, err := compiled.GetInt("app.port"); err != nil || i != 8080 {
+		t.Errorf("GetInt() = %d, %v", i, err)
+	}
+	if f, err := compiled.GetFloat("app.ratio"); err != nil || f != 0.5 {
+		t.Errorf("GetFloat() = %v, %v", f, err)
+	}
+	if b, err := compiled.GetBool("app.debug"); err != nil || !b {
+		t.Errorf("GetBool() = %v, %v", b, err)
+	}
+	if _, err := compiled.GetInt("app.name"); err == nil {
+		t.Error("GetInt() on a string should fail")
+	}
+	if _, err := compiled.Get("app.missing"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Get() missing path error = %v, want ErrPathNotFound", err)
+	}
+	if !compiled.Has("servers[1]") || compiled.Has("servers[2]") {
+		t.Error("Has() reports wrong paths")
+	}
+
+	// Results are copies and edits to the document are not visible
+	tags, _ := compiled.GetSlice("app.tags")
+	tags[0] = "changed"
+	m, _ := compiled.GetMap("app")
+	m["name"] = "changed"
+	if err := doc.Set("app.name", "edited"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if s, _ := compiled.GetString("app.tags[0]"); s != "a" {
+		t.Errorf("snapshot changed through GetSlice(): %q", s)
+	}
+	if s, _ := compiled.GetString("app.name"); s != "api" {
+		t.Errorf("snapshot changed after edit: %q", s)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := compiled.GetInt("app.port"); err != nil {
+					t.Errorf("concurrent GetInt() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDocument_CompileArrayDocument(t *testing.T) {
+	doc, err := Load("- name: web\n  hosts: [a]\n- name: db\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	compiled, err := doc.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	if s, err := compiled.GetString("[1].name"); err != nil || s != "db" {
+		t.Errorf("GetString([1].name) = %q, %v", s, err)
+	}
+	if s, err := compiled.GetString("hosts[0]"); err != nil || s != "a" {
+		t.Errorf("GetString(hosts[0]) = %q, %v", s, err)
+	}
+	all, err := compiled.GetSlice("")
+	if err != nil || len(all) != 2 {
+		t.Errorf("GetSlice(\"\") = %v, %v", all, err)
+	}
+}
@@ -2,6 +2,7 @@ package yamler
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -9,44 +10,128 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// splitPath splits a path into parts, handling array indices
+// splitPath splits a path into parts, handling array indices and quoted keys
 func splitPath(path string) []string {
 	if path == "" {
 		return nil
 	}
 
-	parts := strings.Split(path, ".")
+	parts := splitPathParts(path)
 	result := make([]string, 0, len(parts))
 
 	for _, part := range parts {
-		// Handle array indices
-		if strings.Contains(part, "[") {
-			// Find the array name and index
-			idx := strings.Index(part, "[")
-			if idx > 0 {
-				arrayName := part[:idx]
-				arrayIndex := part[idx:]
-				result = append(result, arrayName, arrayIndex)
-			} else {
-				result = append(result, part)
+		if prefix, key, ok := parseQuotedKey(part); ok {
+			if prefix != "" {
+				result = appendIndexedPart(result, prefix)
 			}
-		} else {
-			result = append(result, part)
+			result = append(result, key)
+			continue
 		}
+		result = appendIndexedPart(result, part)
 	}
 
 	return result
 }
 
+// appendIndexedPart appends a path part, splitting off array indices, including nested ones like matrix[0][1]
+func appendIndexedPart(result []string, part string) []string {
+	idx := strings.Index(part, "[")
+	if idx < 0 || !strings.HasSuffix(part, "]") {
+		return append(result, part)
+	}
+	if idx > 0 {
+		result = append(result, part[:idx])
+	}
+	for _, index := range strings.SplitAfter(part[idx:], "]") {
+		if index != "" {
+			result = append(result, index)
+		}
+	}
+	return result
+}
+
+// splitPathParts splits a path on dots, keeping quoted keys like annotations["prometheus.io/scrape"] intact
+func splitPathParts(path string) []string {
+	if !strings.Contains(path, `["`) {
+		return parsePath(path)
+	}
+
+	var parts []string
+	start := 0
+	inQuote := false
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case inQuote && c == '\\':
+			i++
+		case inQuote && c == '"':
+			inQuote = false
+		case !inQuote && c == '"' && i > 0 && path[i-1] == '[':
+			inQuote = true
+		case !inQuote && c == '.':
+			parts = append(parts, path[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, path[start:])
+}
+
+// parseQuotedKey splits a path part like annotations["a.b"] into its prefix and unquoted key
+func parseQuotedKey(part string) (prefix, key string, ok bool) {
+	if !strings.HasSuffix(part, `"]`) {
+		return "", "", false
+	}
+	idx := strings.Index(part, `["`)
+	if idx == -1 {
+		return "", "", false
+	}
+
+	key, err := strconv.Unquote(part[idx+1 : len(part)-1])
+	if err != nil {
+		return "", "", false
+	}
+	return part[:idx], key, true
+}
+
+// appendPathKey appends a mapping key to a path, quoting keys that contain path separators
+func appendPathKey(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
 // nodeToInterface converts a YAML node to a Go interface{}
 func nodeToInterface(node *yaml.Node) (interface{}, error) {
+	return convertNode(node, false, SchemaCore)
+}
+
+// toInterface converts a node like nodeToInterface, keeping custom tags as TaggedValue in CloudFormation mode
+func (d *Document) toInterface(node *yaml.Node) (interface{}, error) {
+	return convertNode(node, d.keepTags, d.schema)
+}
+
+// convertNode converts a YAML node to a Go interface{}, wrapping custom-tagged values in TaggedValue if keepTags is set.
+// schema decides how integers such as 0755 are read.
+func convertNode(node *yaml.Node, keepTags bool, schema Schema) (interface{}, error) {
+	value, err := convertContent(node, keepTags, schema)
+	if err != nil || !keepTags || !isCustomTag(node.Tag) {
+		return value, err
+	}
+	return TaggedValue{Tag: node.Tag, Value: value}, nil
+}
+
+// convertContent converts the value of a node, ignoring a custom tag on the node itself
+func convertContent(node *yaml.Node, keepTags bool, schema Schema) (interface{}, error) {
 	switch node.Kind {
 	case yaml.ScalarNode:
-		return scalarToInterface(node)
+		return scalarToInterface(node, schema)
 	case yaml.SequenceNode:
 		var result []interface{}
 		for _, item := range node.Content {
-			value, err := nodeToInterface(item)
+			value, err := convertNode(item, keepTags, schema)
 			if err != nil {
 				return nil, err
 			}
@@ -56,29 +141,58 @@ func nodeToInterface(node *yaml.Node) (interface{}, error) {
 	case yaml.MappingNode:
 		result := make(map[string]interface{})
 		for i := 0; i < len(node.Content); i += 2 {
-			key := node.Content[i].Value
-			value, err := nodeToInterface(node.Content[i+1])
+			key, err := keyString(node.Content[i])
+			if err != nil {
+				return nil, err
+			}
+			value, err := convertNode(node.Content[i+1], keepTags, schema)
 			if err != nil {
 				return nil, err
 			}
 			result[key] = value
 		}
 		return result, nil
+	case yaml.AliasNode:
+		if node.Alias == nil {
+			return nil, fmt.Errorf("unresolved alias: %s", node.Value)
+		}
+		return convertNode(node.Alias, keepTags, schema)
 	default:
 		return nil, fmt.Errorf("unsupported node kind: %v", node.Kind)
 	}
 }
 
+// keyString returns the string form of a mapping key: scalars as written (1: a gives "1"),
+// collections in flow style ([x]: y gives "[x]")
+func keyString(key *yaml.Node) (string, error) {
+	key = resolveAlias(key)
+	if key.Kind == yaml.ScalarNode {
+		return key.Value, nil
+	}
+
+	flow := *key
+	flow.Style |= yaml.FlowStyle
+	flow.HeadComment, flow.LineComment, flow.FootComment = "", "", ""
+	out, err := yaml.Marshal(&flow)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // scalarToInterface converts a scalar YAML node to a Go interface{}
-func scalarToInterface(node *yaml.Node) (interface{}, error) {
+func scalarToInterface(node *yaml.Node, schema Schema) (interface{}, error) {
 	switch node.Tag {
 	case "!!str":
 		return node.Value, nil
 	case "!!int":
-		return strconv.ParseInt(node.Value, 10, 64)
+		return parseInt(node.Value, schema)
 	case "!!float":
-		return strconv.ParseFloat(node.Value, 64)
+		return parseFloat(node.Value)
 	case "!!bool":
+		if b, ok := yaml11Bool(node.Value); ok {
+			return b, nil
+		}
 		return strconv.ParseBool(node.Value)
 	case "!!null":
 		return nil, nil
@@ -97,7 +211,7 @@ func interfaceToNode(v interface{}) (*yaml.Node, error) {
 	case int64:
 		return createScalarNode("!!int", fmt.Sprintf("%d", val)), nil
 	case float64:
-		return createScalarNode("!!float", fmt.Sprintf("%g", val)), nil
+		return createScalarNode("!!float", formatFloat(val, 'f', -1)), nil
 	case bool:
 		return createScalarNode("!!bool", fmt.Sprintf("%t", val)), nil
 	case []interface{}:
@@ -114,6 +228,8 @@ func interfaceToNode(v interface{}) (*yaml.Node, error) {
 		return createMapNode(val)
 	case []map[string]interface{}:
 		return createMapSliceNode(val)
+	case TaggedValue:
+		return val.node()
 	case nil:
 		return createNullNode(), nil
 	default:
@@ -178,6 +294,25 @@ func createInt64SliceNode(val []int64) (*yaml.Node, error) {
 	return node, nil
 }
 
+// formatFloat renders a float for YAML using strconv.FormatFloat with the given format and precision.
+// Whole numbers keep a ".0" suffix so they are read back as floats, and NaN/Inf use YAML spelling.
+func formatFloat(value float64, format byte, prec int) string {
+	switch {
+	case math.IsNaN(value):
+		return ".nan"
+	case math.IsInf(value, 1):
+		return ".inf"
+	case math.IsInf(value, -1):
+		return "-.inf"
+	}
+
+	s := strconv.FormatFloat(value, format, prec, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
 // createFloat64SliceNode creates a sequence node from []float64
 func createFloat64SliceNode(val []float64) (*yaml.Node, error) {
 	node := &yaml.Node{
@@ -185,7 +320,7 @@ func createFloat64SliceNode(val []float64) (*yaml.Node, error) {
 		Tag:  "!!seq",
 	}
 	for _, item := range val {
-		node.Content = append(node.Content, createScalarNode("!!float", fmt.Sprintf("%g", item)))
+		node.Content = append(node.Content, createScalarNode("!!float", formatFloat(item, 'f', -1)))
 	}
 	return node, nil
 }
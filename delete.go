@@ -0,0 +1,185 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Delete removes the mapping key or array element at path together with its value.
+// Comments attached to the removed entry go with it; the comments and blank lines around it stay.
+func (d *Document) Delete(path string) error {
+	generation := d.beginNodeIndexEdit()
+	defer d.endNodeIndexEdit()
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("empty path")
+	}
+
+	root, err := d.mappingRoot()
+	if err != nil {
+		return err
+	}
+	node, aliased, err := d.lookupNode(root, path)
+	if err != nil {
+		return err
+	}
+	if node == root {
+		return fmt.Errorf("path %s: cannot delete the document root", path)
+	}
+
+	removed := map[*yaml.Node]bool{node: true}
+	if err := checkAnchorsUnused(root, removed, path); err != nil {
+		return err
+	}
+	removeNodes(root, removed, d.formattingCache)
+
+	d.markDirty()
+	// Removing an array element renumbers the ones after it, so the whole parent is dropped from the index.
+	// Through an alias the node is removed at its anchor, somewhere else entirely.
+	if parts := splitPath(path); !aliased {
+		d.keepNodeIndex(generation, root, parts[:len(parts)-1])
+	}
+	return nil
+}
+
+// DeleteAll removes every mapping key or array element matching the wildcard pattern
+// and returns the number of matches. Nothing is removed if a match holds an anchor used elsewhere.
+func (d *Document) DeleteAll(pattern string) (int, error) {
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+	if pattern == "" {
+		return 0, fmt.Errorf("empty pattern")
+	}
+
+	root, err := d.queryRoot()
+	if err != nil {
+		return 0, err
+	}
+	matches, err := d.matchNodes(root, pattern, MatchOptions{})
+	if err != nil {
+		return 0, err
+	}
+	if len(matches) == 0 {
+		return 0, nil
+	}
+
+	removed := make(map[*yaml.Node]bool, len(matches))
+	for _, match := range matches {
+		removed[match.node] = true
+	}
+	if err := checkAnchorsUnused(root, removed, pattern); err != nil {
+		return 0, err
+	}
+	removeNodes(root, removed, d.formattingCache)
+
+	d.markDirty()
+	return len(matches), nil
+}
+
+// removeNodes drops the mapping entries and sequence items whose value is in removed, at any depth.
+// The foot comment of a removed entry moves to the entry before it, since it closes the block,
+// and blank lines before a removed key move to the key after it so sections stay apart.
+func removeNodes(node *yaml.Node, removed map[*yaml.Node]bool, info *FormattingInfo) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		kept := node.Content[:0]
+		blankLines := 0
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if removed[value] {
+				if len(kept) > 0 {
+					keepFootComment(kept[len(kept)-2], key.FootComment)
+					keepFootComment(kept[len(kept)-2], value.FootComment)
+				}
+				if info != nil && blankLines == 0 {
+					blankLines = info.EmptyLines[blankLinesKey(key)]
+				}
+				continue
+			}
+			if info != nil && blankLines > 0 && len(kept) > 0 && info.EmptyLines[blankLinesKey(key)] == 0 {
+				info.EmptyLines[blankLinesKey(key)] = blankLines
+			}
+			blankLines = 0
+			kept = append(kept, key, value)
+		}
+		node.Content = kept
+	case yaml.SequenceNode:
+		kept := node.Content[:0]
+		for _, item := range node.Content {
+			if removed[item] {
+				if len(kept) > 0 {
+					keepFootComment(kept[len(kept)-1], item.FootComment)
+				}
+				continue
+			}
+			kept = append(kept, item)
+		}
+		node.Content = kept
+	}
+
+	for _, child := range node.Content {
+		removeNodes(child, removed, info)
+	}
+}
+
+// blankLinesKey returns the key FormattingInfo.EmptyLines tracks for a mapping entry:
+// its first head comment line if it has one, otherwise the key itself
+func blankLinesKey(key *yaml.Node) string {
+	if key.HeadComment != "" {
+		return strings.TrimSpace(strings.SplitN(key.HeadComment, "\n", 2)[0])
+	}
+	return key.Value
+}
+
+// keepFootComment appends a foot comment of a removed entry to node
+func keepFootComment(node *yaml.Node, comment string) {
+	if comment == "" {
+		return
+	}
+	if node.FootComment != "" {
+		node.FootComment += "\n"
+	}
+	node.FootComment += comment
+}
+
+// checkAnchorsUnused returns an error if a removed subtree defines an anchor that an alias outside it uses
+func checkAnchorsUnused(root *yaml.Node, removed map[*yaml.Node]bool, path string) error {
+	anchors := make(map[*yaml.Node]bool)
+	var collect func(node *yaml.Node)
+	collect = func(node *yaml.Node) {
+		if node.Anchor != "" {
+			anchors[node] = true
+		}
+		for _, child := range node.Content {
+			collect(child)
+		}
+	}
+	for node := range removed {
+		collect(node)
+	}
+	if len(anchors) == 0 {
+		return nil
+	}
+
+	var check func(node *yaml.Node) error
+	check = func(node *yaml.Node) error {
+		if removed[node] {
+			return nil
+		}
+		if node.Kind == yaml.AliasNode && anchors[node.Alias] {
+			return fmt.Errorf("path %s: anchor %s is still referenced", path, node.Alias.Anchor)
+		}
+		for _, child := range node.Content {
+			if err := check(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(root)
+}
@@ -0,0 +1,233 @@
+package yamler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocument_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "top-level key",
+			input: "a: 1\nb: 2\nc: 3\n",
+			path:  "b",
+			want:  "a: 1\nc: 3\n",
+		},
+		{
+			name:  "key with comments and blank line",
+			input: "# top\na: 1\n\n# section b\nb: 2 # bee\nc: 3\n\nd: 4\n",
+			path:  "b",
+			want:  "# top\na: 1\n\nc: 3\n\nd: 4\n",
+		},
+		{
+			name:  "subtree between sections",
+			input: "a: 1\n\nb:\n  x: 1\n  y: 2\n\nc: 3\n",
+			path:  "b",
+			want:  "a: 1\n\nc: 3\n",
+		},
+		{
+			name:  "nested key keeps foot comment",
+			input: "b:\n  x: 1\n  y: 2\n  # end of b\n\nc: 3\n",
+			path:  "b.y",
+			want:  "b:\n  x: 1\n  # end of b\n\nc: 3\n",
+		},
+		{
+			name:  "block array element",
+			input: "list:\n  - a\n  - b # second\n  - c\nz: 1\n",
+			path:  "list[1]",
+			want:  "list:\n  - a\n  - c\nz: 1\n",
+		},
+		{
+			name:  "flow array element",
+			input: "list: [a, b, c]\nz: 1\n",
+			path:  "list[0]",
+			want:  "list: [b, c]\nz: 1\n",
+		},
+		{
+			name:  "alias",
+			input: "base: &b\n  x: 1\nuse: *b\n",
+			path:  "use",
+			want:  "base: &b\n  x: 1\n",
+		},
+		{
+			name:    "referenced anchor",
+			input:   "base: &b\n  x: 1\nuse: *b\n",
+			path:    "base",
+			wantErr: "anchor b is still referenced",
+		},
+		{
+			name:    "missing key",
+			input:   "a: 1\n",
+			path:    "b",
+			wantErr: "key b not found",
+		},
+		{
+			name:    "empty path",
+			input:   "a: 1\n",
+			path:    "",
+			wantErr: "empty path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.Delete(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Delete() error = %v, want %q", err, tt.wantErr)
+				}
+				if got, _ := doc.String(); got != tt.input {
+					t.Errorf("failed Delete() changed the document:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Delete() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_DeleteAll(t *testing.T) {
+	input := `services:
+  web:
+    image: nginx
+    debug: true
+  api:
+    image: app
+    debug: false
+
+  worker:
+    image: app
+servers:
+  - host: a
+    password: x
+  - host: b
+    password: y
+`
+	tests := []struct {
+		name      string
+		pattern   string
+		wantCount int
+		want      string
+	}{
+		{
+			name:      "key under every service",
+			pattern:   "services.*.debug",
+			wantCount: 2,
+			want: `services:
+  web:
+    image: nginx
+  api:
+    image: app
+
+  worker:
+    image: app
+servers:
+  - host: a
+    password: x
+  - host: b
+    password: y
+`,
+		},
+		{
+			name:      "recursive match",
+			pattern:   "**.password",
+			wantCount: 2,
+			want: `services:
+  web:
+    image: nginx
+    debug: true
+  api:
+    image: app
+    debug: false
+
+  worker:
+    image: app
+servers:
+  - host: a
+  - host: b
+`,
+		},
+		{
+			name:      "no matches",
+			pattern:   "services.*.ports",
+			wantCount: 0,
+			want:      input,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			count, err := doc.DeleteAll(tt.pattern)
+			if err != nil {
+				t.Fatalf("DeleteAll() error = %v", err)
+			}
+			if count != tt.wantCount {
+				t.Errorf("DeleteAll() count = %d, want %d", count, tt.wantCount)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DeleteAll() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("frozen document", func(t *testing.T) {
+		doc, err := Load(input)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		doc.Freeze()
+		if _, err := doc.DeleteAll("**.password"); err != ErrReadOnly {
+			t.Errorf("DeleteAll() error = %v, want ErrReadOnly", err)
+		}
+		if err := doc.Delete("servers"); err != ErrReadOnly {
+			t.Errorf("Delete() error = %v, want ErrReadOnly", err)
+		}
+	})
+
+	t.Run("query cache sees deletion", func(t *testing.T) {
+		doc, err := Load(input)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if matches, _ := doc.GetAll("services.*.image"); len(matches) != 3 {
+			t.Fatalf("GetAll() = %v, want 3 matches", matches)
+		}
+		if err := doc.Delete("services.worker"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if matches, _ := doc.GetAll("services.*.image"); len(matches) != 2 {
+			t.Errorf("GetAll() after Delete = %v, want 2 matches", matches)
+		}
+	})
+}
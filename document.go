@@ -2,10 +2,15 @@ package yamler
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode"
 
 	"gopkg.in/yaml.v3"
 )
@@ -24,20 +29,57 @@ var stringBuilderPool = sync.Pool{
 	},
 }
 
+// maxPathCacheEntries bounds the path cache; once full, new paths are parsed without being cached
+const maxPathCacheEntries = 10000
+
 // Cache for parsed paths to avoid repeated string splitting
-var pathCache = sync.Map{} // string -> []string
+var (
+	pathCache         = sync.Map{} // string -> []string
+	pathCacheSize     atomic.Int64
+	pathCacheDisabled atomic.Bool
+)
 
 // parsePath splits a path and caches the result
 func parsePath(path string) []string {
+	if pathCacheDisabled.Load() {
+		return strings.Split(path, ".")
+	}
 	if cached, ok := pathCache.Load(path); ok {
 		return cached.([]string)
 	}
 
 	parts := strings.Split(path, ".")
-	pathCache.Store(path, parts)
+	if pathCacheSize.Load() < maxPathCacheEntries {
+		if _, loaded := pathCache.LoadOrStore(path, parts); !loaded {
+			pathCacheSize.Add(1)
+		}
+	}
 	return parts
 }
 
+// SetPathCacheEnabled turns the global path parsing cache on or off.
+// The cache speeds up repeated operations on the same paths at the cost of memory
+// (bounded to maxPathCacheEntries); disabling it also clears it.
+func SetPathCacheEnabled(enabled bool) {
+	pathCacheDisabled.Store(!enabled)
+	if !enabled {
+		ClearPathCache()
+	}
+}
+
+// ClearPathCache removes all cached paths so their memory can be reclaimed
+func ClearPathCache() {
+	pathCache.Range(func(key, _ interface{}) bool {
+		if _, loaded := pathCache.LoadAndDelete(key); loaded {
+			pathCacheSize.Add(-1)
+		}
+		return true
+	})
+}
+
+// ErrReadOnly is returned by mutating methods of a frozen document
+var ErrReadOnly = errors.New("document is read-only")
+
 // Document represents a YAML document with preserved formatting
 type Document struct {
 	root                      *yaml.Node
@@ -48,6 +90,78 @@ type Document struct {
 	exactTrailingNewlines     bool // Whether to preserve exact trailing newline behavior (from LoadBytes)
 	// Performance optimization: cache formatting info
 	formattingCache *FormattingInfo
+	// Lines of raw, split once for the edits that look up the original text
+	lineIndex *rawIndex
+	// House style set with SetSerializeOptions
+	serialize SerializeOptions
+	// Whether inline comments are lined up on every render, set by Normalize
+	alignComments bool
+	frozen        bool // Whether mutations are rejected with ErrReadOnly
+	// Blank lines between block sequence items, keyed by sequence node
+	sequenceSpacing map[*yaml.Node]*sequenceSpacing
+	// Scalar keys written in explicit "? key" form in the original content
+	explicitKeys map[*yaml.Node]bool
+	// Flow collections with (true) or explicitly without (false) a comma after the last item
+	trailingCommas map[*yaml.Node]bool
+	// Comment block at the top of the original content, restored byte for byte
+	header *headerBlock
+	// Output of SetMinimalDiff, returned by ToBytes until the next change
+	pinned []byte
+	// The node tree changed since raw was rendered; ToBytes renders it again
+	dirty bool
+	// Number of mutator calls, so callers can tell whether a document was edited
+	edits uint64
+	// Wildcard query results, shared with sub-document views and cleared on mutation
+	queryCache *queryCache
+	// State saved by Begin, restored by Rollback
+	snapshot *Document
+	// Whether getters return custom-tagged values as TaggedValue (LoadOptions.CloudFormation)
+	keepTags bool
+	// Schema plain scalars are typed by (LoadOptions.Schema)
+	schema Schema
+	// Plain scalars the schema typed differently than yaml.v3, with their tag
+	schemaTags map[*yaml.Node]string
+	// Template actions of a document loaded with LoadTemplate, swapped for placeholders while rendering
+	templates *templateRegistry
+}
+
+// Freeze makes the document read-only: all setters, array mutators and formatting settings return ErrReadOnly.
+// Getters on a frozen document never mutate it, so they can run from multiple goroutines without locking.
+// Rendering temporarily retags nodes, so Freeze renders the document once and ToBytes returns that output.
+func (d *Document) Freeze() {
+	if d.frozen {
+		return
+	}
+	if d.raw != "" {
+		d.formattingInfo()
+	}
+	if d.pinned == nil {
+		if content, err := d.renderContent(); err == nil {
+			d.pinned = content
+		}
+	}
+	d.frozen = true
+}
+
+// IsFrozen reports whether the document has been frozen
+func (d *Document) IsFrozen() bool {
+	return d.frozen
+}
+
+// checkWritable returns ErrReadOnly if the document is frozen. Every mutator calls it first.
+func (d *Document) checkWritable() error {
+	if d.frozen {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// markChanged records a successful change of the node tree: it drops cached query results
+// and pinned output and counts the edit
+func (d *Document) markChanged() {
+	d.invalidateQueries()
+	d.pinned = nil
+	d.edits++
 }
 
 // mappingRoot returns the root MappingNode of the document
@@ -83,8 +197,20 @@ func LoadBytes(content []byte) (*Document, error) {
 	return doc, nil
 }
 
-// Load parses a YAML string and preserves its formatting
+// LoadReader loads a YAML document from r, such as an HTTP body or an embedded file, and preserves its formatting
+func LoadReader(r io.Reader) (*Document, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	return LoadBytes(content)
+}
+
+// Load parses a YAML string and preserves its formatting. Plain scalars are typed by the YAML 1.2
+// core schema; use LoadWithOptions for another schema.
 func Load(content string) (*Document, error) {
+	content, lineEnding, bom := stripLineEndings(content)
 	if content == "" {
 		// Create empty document
 		return &Document{
@@ -97,20 +223,14 @@ func Load(content string) (*Document, error) {
 					},
 				},
 			},
+			queryCache: newQueryCache(),
 		}, nil
 	}
 
 	// Count trailing newlines
 	trailingNewlines := 0
-	for i := len(content) - 1; i >= 0; i-- {
-		if content[i] == '\n' {
-			trailingNewlines++
-		} else if content[i] == '\r' {
-			// Skip carriage returns
-			continue
-		} else {
-			break
-		}
+	for i := len(content) - 1; i >= 0 && content[i] == '\n'; i-- {
+		trailingNewlines++
 	}
 
 	var node yaml.Node
@@ -122,12 +242,20 @@ func Load(content string) (*Document, error) {
 		root:             &node,
 		raw:              content,
 		trailingNewlines: trailingNewlines,
+		queryCache:       newQueryCache(),
 	}
 
 	// Initialize formatting cache if we have raw content
 	if content != "" {
 		doc.formattingCache = detectFormattingInfoOptimized(content)
+		doc.formattingCache.LineEnding = lineEnding
+		doc.formattingCache.HasBOM = bom
+		doc.sequenceSpacing = detectSequenceSpacing(&node, content)
+		doc.explicitKeys = detectExplicitKeys(&node, content)
+		doc.trailingCommas = detectTrailingCommas(&node, content)
+		doc.header = detectHeader(&node, content)
 	}
+	doc.applySchema()
 
 	// Detect if this is an array document root
 	if doc.isArrayRoot() {
@@ -157,8 +285,26 @@ func (d *Document) sequenceRoot() (*yaml.Node, error) {
 	return root, nil
 }
 
-// SetArrayElement sets a value in an array document at the specified index and path
+// elementIndex resolves an index into an array of the given length; negative indices count from the end
+func elementIndex(index, length int) (int, error) {
+	resolved := index
+	if resolved < 0 {
+		resolved += length
+	}
+	if resolved < 0 || resolved >= length {
+		return 0, &ErrIndexOutOfBounds{Index: index, Length: length}
+	}
+	return resolved, nil
+}
+
+// SetArrayElement sets a value in an array document at the specified index and path.
+// A negative index counts from the end, so -1 is the last element.
+// The path is resolved like Set, so it may contain indices ("tasks[3].copy.mode") and missing keys are created.
 func (d *Document) SetArrayElement(index int, path string, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
 	// Do not preserve document separators for array element operations
 	d.preserveDocumentSeparator = false
 
@@ -171,8 +317,9 @@ func (d *Document) SetArrayElement(index int, path string, value interface{}) er
 		return err
 	}
 
-	if index < 0 || index >= len(root.Content) {
-		return fmt.Errorf("array index %d out of bounds (length: %d)", index, len(root.Content))
+	index, err = elementIndex(index, len(root.Content))
+	if err != nil {
+		return err
 	}
 
 	element := root.Content[index]
@@ -183,18 +330,20 @@ func (d *Document) SetArrayElement(index int, path string, value interface{}) er
 			return err
 		}
 		root.Content[index] = newNode
+		d.markDirty()
 		return nil
 	}
 
-	// Set value in the element (assuming it's a mapping)
-	if element.Kind != yaml.MappingNode {
-		return fmt.Errorf("array element at index %d is not a mapping", index)
+	if err := d.setInNode(element, splitPath(path), path, value); err != nil {
+		return err
 	}
-
-	return d.setValueInNode(element, path, value)
+	d.markDirty()
+	return nil
 }
 
-// GetArrayDocumentElement gets a value from an array document at the specified index and path
+// GetArrayDocumentElement gets a value from an array document at the specified index and path.
+// A negative index counts from the end, so -1 is the last element.
+// The path is resolved like Get, so it may contain indices like "tasks[3].copy.mode".
 func (d *Document) GetArrayDocumentElement(index int, path string) (interface{}, error) {
 	if !d.isArrayRoot() {
 		return nil, fmt.Errorf("document root is not an array")
@@ -205,30 +354,64 @@ func (d *Document) GetArrayDocumentElement(index int, path string) (interface{},
 		return nil, err
 	}
 
-	if index < 0 || index >= len(root.Content) {
-		return nil, fmt.Errorf("array index %d out of bounds (length: %d)", index, len(root.Content))
+	index, err = elementIndex(index, len(root.Content))
+	if err != nil {
+		return nil, err
 	}
 
 	element := root.Content[index]
 	if path == "" {
 		// Return entire element
-		return nodeToInterface(element)
+		return d.toInterface(element)
 	}
 
-	// Get value from the element
-	return d.getValueFromNode(element, path)
+	node := element
+	for _, part := range splitPathParts(path) {
+		if node, err = navigateToNode(node, part, path); err != nil {
+			return nil, err
+		}
+	}
+	return d.toInterface(node)
 }
 
-// AddArrayElement adds a new element to an array document
-func (d *Document) AddArrayElement(value interface{}) error {
-	// Do not preserve document separators for array element operations
-	d.preserveDocumentSeparator = false
-
+// GetArrayDocumentElements returns the elements of an array document from start up to, but not including, end.
+// Negative bounds count from the end: (0, -1) skips the last element, (-2, n) returns the last two.
+func (d *Document) GetArrayDocumentElements(start, end int) ([]interface{}, error) {
 	if !d.isArrayRoot() {
-		return fmt.Errorf("document root is not an array")
+		return nil, fmt.Errorf("document root is not an array")
 	}
 
 	root, err := d.sequenceRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	length := len(root.Content)
+	from, to := start, end
+	if from < 0 {
+		from += length
+	}
+	if to < 0 {
+		to += length
+	}
+	if from < 0 || to > length || from > to {
+		return nil, fmt.Errorf("array range [%d:%d] out of bounds (length: %d)", start, end, length)
+	}
+
+	elements := make([]interface{}, 0, to-from)
+	for _, element := range root.Content[from:to] {
+		value, err := d.toInterface(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return elements, nil
+}
+
+// AddArrayElement adds a new element to an array document
+func (d *Document) AddArrayElement(value interface{}) error {
+	root, err := d.editableArrayRoot()
 	if err != nil {
 		return err
 	}
@@ -238,102 +421,119 @@ func (d *Document) AddArrayElement(value interface{}) error {
 		return err
 	}
 
+	d.keepLastElementComment(root)
 	root.Content = append(root.Content, newNode)
+	d.markDirty()
 	return nil
 }
 
-// setValueInNode sets a value in a specific node using a path
-func (d *Document) setValueInNode(node *yaml.Node, path string, value interface{}) error {
-	// This is a simplified version - could be extended to use the full Set logic
-	parts := parsePath(path)
-	current := node
-
-	for i, part := range parts {
-		if i == len(parts)-1 {
-			// Last part - set the value
-			return d.setDirectValue(current, part, value)
-		}
+// InsertArrayElement inserts a new element into an array document before index.
+// An index equal to the length appends; a negative index counts from the end, so -1 inserts before the last element.
+func (d *Document) InsertArrayElement(index int, value interface{}) error {
+	root, err := d.editableArrayRoot()
+	if err != nil {
+		return err
+	}
 
-		// Navigate to the next level
-		found := false
-		for j := 0; j < len(current.Content); j += 2 {
-			if current.Content[j].Value == part {
-				current = current.Content[j+1]
-				found = true
-				break
-			}
-		}
+	length := len(root.Content)
+	resolved := index
+	if resolved < 0 {
+		resolved += length
+	}
+	if resolved < 0 || resolved > length {
+		return &ErrIndexOutOfBounds{Index: index, Length: length}
+	}
 
-		if !found {
-			return fmt.Errorf("path not found: %s", path)
-		}
+	newNode, err := interfaceToNode(value)
+	if err != nil {
+		return err
 	}
 
+	if resolved == length {
+		d.keepLastElementComment(root)
+	}
+	root.Content = append(root.Content[:resolved], append([]*yaml.Node{newNode}, root.Content[resolved:]...)...)
+	d.markDirty()
 	return nil
 }
 
-// getValueFromNode gets a value from a specific node using a path
-func (d *Document) getValueFromNode(node *yaml.Node, path string) (interface{}, error) {
-	parts := parsePath(path)
-	current := node
-
-	for _, part := range parts {
-		if current.Kind != yaml.MappingNode {
-			return nil, fmt.Errorf("cannot navigate path in non-mapping node")
-		}
-
-		// Find the key
-		found := false
-		for j := 0; j < len(current.Content); j += 2 {
-			if current.Content[j].Value == part {
-				current = current.Content[j+1]
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			return nil, fmt.Errorf("key not found: %s", part)
-		}
+// RemoveArrayElement removes an element from an array document together with its comments.
+// A negative index counts from the end, so -1 is the last element.
+func (d *Document) RemoveArrayElement(index int) error {
+	root, err := d.editableArrayRoot()
+	if err != nil {
+		return err
 	}
 
-	return nodeToInterface(current)
-}
+	index, err = elementIndex(index, len(root.Content))
+	if err != nil {
+		return err
+	}
 
-// setDirectValue sets a direct value in a mapping node
-func (d *Document) setDirectValue(node *yaml.Node, key string, value interface{}) error {
-	if node.Kind != yaml.MappingNode {
-		return fmt.Errorf("cannot set value in non-mapping node")
+	if index == len(root.Content)-1 {
+		// A comment directly after the last element goes with it
+		d.keepLastElementComment(root)
 	}
+	root.Content = append(root.Content[:index], root.Content[index+1:]...)
+	d.markDirty()
+	return nil
+}
 
-	// Find existing key
-	for i := 0; i < len(node.Content); i += 2 {
-		if node.Content[i].Value == key {
-			// Update existing value
-			newNode, err := interfaceToNode(value)
-			if err != nil {
-				return err
-			}
-			node.Content[i+1] = newNode
-			return nil
-		}
+// MoveArrayElement moves an element of an array document from one index to another,
+// so that it ends up at index to; its comments move with it.
+// Negative indices count from the end, so MoveArrayElement(0, -1) moves the first element to the end.
+func (d *Document) MoveArrayElement(from, to int) error {
+	root, err := d.editableArrayRoot()
+	if err != nil {
+		return err
 	}
 
-	// Add new key-value pair
-	keyNode := &yaml.Node{
-		Kind:  yaml.ScalarNode,
-		Tag:   "!!str",
-		Value: key,
+	from, err = elementIndex(from, len(root.Content))
+	if err != nil {
+		return err
 	}
-	valueNode, err := interfaceToNode(value)
+	to, err = elementIndex(to, len(root.Content))
 	if err != nil {
 		return err
 	}
+	if from == to {
+		return nil
+	}
+	if last := len(root.Content) - 1; from == last || to == last {
+		d.keepLastElementComment(root)
+	}
 
-	node.Content = append(node.Content, keyNode, valueNode)
+	element := root.Content[from]
+	rest := append(root.Content[:from:from], root.Content[from+1:]...)
+	root.Content = append(rest[:to:to], append([]*yaml.Node{element}, rest[to:]...)...)
+	d.markDirty()
 	return nil
 }
 
+// editableArrayRoot returns the root sequence of an array document for an element operation
+func (d *Document) editableArrayRoot() (*yaml.Node, error) {
+	if err := d.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	// Do not preserve document separators for array element operations
+	d.preserveDocumentSeparator = false
+
+	if !d.isArrayRoot() {
+		return nil, fmt.Errorf("document root is not an array")
+	}
+
+	return d.sequenceRoot()
+}
+
+// keepLastElementComment attaches a comment directly after the last element of the root sequence
+// to that element, so it stays with it when elements are added, removed or reordered around it
+func (d *Document) keepLastElementComment(root *yaml.Node) {
+	if d.root != nil && d.root.Kind == yaml.DocumentNode {
+		d.keepTrailingComment(d.root, root)
+	}
+}
+
 // Save writes the YAML document to a file while preserving formatting
 func (d *Document) Save(filename string) error {
 	content, err := d.ToBytes()
@@ -344,11 +544,82 @@ func (d *Document) Save(filename string) error {
 	return os.WriteFile(filename, content, 0644)
 }
 
-// ToBytes converts the document to bytes while preserving formatting
+// WriteTo writes the YAML document to w while preserving formatting, implementing io.WriterTo
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	content, err := d.ToBytes()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(content)
+	return int64(n), err
+}
+
+// untagMergeKeys clears the tag of implicit "<<" merge keys and returns the affected nodes
+func untagMergeKeys(node *yaml.Node, keys []*yaml.Node) []*yaml.Node {
+	if node == nil {
+		return keys
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind == yaml.ScalarNode && key.Value == "<<" && key.Tag == "!!merge" && key.Style == 0 {
+				key.Tag = ""
+				keys = append(keys, key)
+			}
+		}
+	}
+	for _, child := range node.Content {
+		keys = untagMergeKeys(child, keys)
+	}
+	return keys
+}
+
+// ToBytes converts the document to bytes while preserving formatting, including CRLF line endings
+// and a byte order mark
 func (d *Document) ToBytes() ([]byte, error) {
+	content, err := d.renderContent()
+	if err != nil {
+		return nil, err
+	}
+	return d.applyLineEndings(content), nil
+}
+
+// renderContent renders the document with LF line endings, like the raw content it is compared with
+func (d *Document) renderContent() ([]byte, error) {
+	render := d.toBytes
+	if d.templates != nil && d.pinned == nil {
+		render = d.renderTemplate
+	}
+	if d.dirty && d.raw == "" {
+		// A document built from scratch has no formatting to preserve; its plain rendering is the base
+		plain, err := render()
+		if err != nil {
+			return nil, err
+		}
+		d.raw = string(plain)
+	}
+	content, err := render()
+	if err != nil {
+		return nil, err
+	}
+
+	// After edits the output becomes the raw content the next rendering preserves
+	if d.dirty {
+		d.raw = string(content)
+		d.dirty = false
+	}
+	return content, nil
+}
+
+// toBytes renders the node tree, using the original content to restore its formatting
+func (d *Document) toBytes() ([]byte, error) {
 	if d.root == nil || len(d.root.Content) == 0 {
 		return []byte{}, nil
 	}
+	if d.pinned != nil {
+		return append([]byte(nil), d.pinned...), nil
+	}
 
 	// Get buffer from pool to reduce allocations
 	buf := bufferPool.Get().(*bytes.Buffer)
@@ -374,7 +645,21 @@ func (d *Document) ToBytes() ([]byte, error) {
 		applyZeroIndentToNodes(d.root, info, "")
 	}
 
-	if err := encoder.Encode(d.root); err != nil {
+	// yaml.v3 writes merge keys as "!!merge <<", so encode them untagged and restore afterwards
+	mergeKeys := untagMergeKeys(d.root, nil)
+	legacyBools := untagYAML11Bools(d.root, nil)
+	schemaScalars := d.untagSchemaScalars()
+	err := encoder.Encode(d.root)
+	for _, key := range mergeKeys {
+		key.Tag = "!!merge"
+	}
+	for _, node := range legacyBools {
+		node.Tag = "!!bool"
+	}
+	for node, tag := range schemaScalars {
+		node.Tag = tag
+	}
+	if err != nil {
 		return nil, err
 	}
 	encoder.Close()
@@ -396,6 +681,33 @@ func (d *Document) ToBytes() ([]byte, error) {
 
 		// Post-process to maintain original style characteristics
 		result = preserveOriginalFormatting(result, d.raw, indentInfo, d.preserveDocumentSeparator)
+
+		if len(d.sequenceSpacing) > 0 {
+			result = applySequenceSpacing(result, d.root, d.sequenceSpacing)
+		}
+		if len(d.explicitKeys) > 0 {
+			result = applyExplicitKeys(result, d.root, d.explicitKeys)
+		}
+		if len(d.trailingCommas) > 0 {
+			result = applyTrailingCommas(result, d.root, d.trailingCommas)
+		}
+		if d.header != nil && d.header.comments == headerComments(d.root) {
+			result = restoreHeader(result, d.header.text)
+		}
+	}
+
+	if d.formattingCache != nil && d.formattingCache.SequenceIndent >= 0 && !d.formattingCache.UseTabs {
+		result = applySequenceIndent(result, d.formattingCache.IndentSize, d.formattingCache.SequenceIndent)
+	}
+	if width := d.serialize.LineWidth; width > 0 {
+		indent := 2
+		if d.formattingCache != nil && d.formattingCache.IndentSize > 0 {
+			indent = d.formattingCache.IndentSize
+		}
+		result = wrapLongLines(result, d.root, width, indent)
+	}
+	if d.alignComments {
+		result = []byte(alignComments(string(result), lineComments(d.root, nil)))
 	}
 
 	// Remove any trailing newlines that might have been added by the encoder
@@ -476,6 +788,15 @@ type FormattingInfo struct {
 	ArrayStyles      map[string]*ArrayStyle // Array formatting styles
 	KeyIndents       map[string]int         // Exact indentation for each key
 	FlowObjectStyles map[string]string      // Original flow object strings to preserve exact formatting
+	QuotedScalars    map[string]string      // Original quoted scalars containing whitespace the encoder would escape
+	SequenceIndent   int                    // Indentation of "-" relative to the parent key, -1 keeps the original layout
+	SectionSpacing   int                    // Blank lines added before top-level keys created by Set
+	SectionBreaks    map[string]int         // Blank lines before specific top-level keys, by key
+	LineEnding       LineEnding             // Line ending of the original, LF or CRLF
+	HasBOM           bool                   // Whether the original started with a UTF-8 byte order mark
+
+	arrayIndents    map[int]bool           // Indentations at which the original had "- " lines
+	pathArrayStyles map[string]*ArrayStyle // Styles of single arrays by full path, set by SetSliceFlowStyle
 }
 
 // detectFormattingInfoOptimized is an optimized version with fewer allocations
@@ -496,6 +817,10 @@ func detectFormattingInfoOptimized(raw string) *FormattingInfo {
 		ArrayStyles:      make(map[string]*ArrayStyle),
 		KeyIndents:       make(map[string]int),
 		FlowObjectStyles: make(map[string]string),
+		QuotedScalars:    make(map[string]string),
+		SequenceIndent:   -1,
+		SectionBreaks:    make(map[string]int),
+		arrayIndents:     make(map[int]bool),
 	}
 
 	// Pre-allocate slices with reasonable capacity
@@ -701,6 +1026,7 @@ func processLineOptimized(line string, lineNum, emptyLinesBefore int, info *Form
 		// Store indentation for array elements using a special key format
 		arrayElementKey := fmt.Sprintf("__array_element_%d__", leadingSpaces)
 		info.KeyIndents[arrayElementKey] = leadingSpaces
+		info.arrayIndents[leadingSpaces] = true
 		return
 	}
 
@@ -813,7 +1139,37 @@ func processLineOptimized(line string, lineNum, emptyLinesBefore int, info *Form
 				info.ScalarStyles[key] = yaml.FoldedStyle
 			}
 		}
+
+		// Remember quoted scalars with tabs, the encoder would rewrite them as escapes
+		if token := extractQuotedToken(trimmedValue); token != "" && strings.Contains(token, "\t") {
+			info.QuotedScalars[key] = token
+		}
+	}
+}
+
+// extractQuotedToken returns the leading single- or double-quoted scalar of value, or "" if there is none
+func extractQuotedToken(value string) string {
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') {
+		return ""
+	}
+
+	quote := value[0]
+	for i := 1; i < len(value); i++ {
+		switch {
+		case quote == '"' && value[i] == '\\':
+			// Skip the escaped character
+			i++
+		case value[i] == quote:
+			// '' is an escaped quote inside a single-quoted scalar
+			if quote == '\'' && i+1 < len(value) && value[i+1] == '\'' {
+				i++
+				continue
+			}
+			return value[:i+1]
+		}
 	}
+
+	return ""
 }
 
 // findBaseIndentationOptimized finds the most appropriate base indentation
@@ -1022,8 +1378,13 @@ func applyZeroIndentToNodes(node *yaml.Node, info *FormattingInfo, path string)
 func preserveOriginalFormatting(newContent []byte, original string, info *FormattingInfo, preserveDocumentSeparator bool) []byte {
 	newStr := string(newContent)
 
+	// An explicit sequence indent re-indents the whole output structurally afterwards
+	structuralIndent := info.SequenceIndent >= 0 && !info.UseTabs
+
 	// Convert spaces to tabs if original used tabs
-	if info.UseTabs {
+	if structuralIndent {
+		// Leave the encoder's indentation for applySequenceIndent
+	} else if info.UseTabs {
 		newStr = convertSpacesToTabs(newStr, info)
 	} else if info.IndentSize != 2 && len(info.KeyIndents) == 0 {
 		// Handle custom space indentation (4, 6, 8 spaces, etc.) only if we don't have exact indents
@@ -1041,7 +1402,9 @@ func preserveOriginalFormatting(newContent []byte, original string, info *Format
 	newStr = applyFlowObjectStyles(newStr, info)
 
 	// Apply exact key indentations
-	newStr = applyExactIndentations(newStr, info)
+	if !structuralIndent {
+		newStr = applyExactIndentations(newStr, info)
+	}
 
 	// Apply empty line patterns (after indentation to avoid conflicts)
 	newStr = applyEmptyLinePatterns(newStr, info)
@@ -1050,7 +1413,12 @@ func preserveOriginalFormatting(newContent []byte, original string, info *Format
 	newStr = preserveFoldedScalars(newStr, original, info)
 
 	// Apply zero-indent array formatting
-	newStr = applyZeroIndentArrays(newStr, info)
+	if !structuralIndent {
+		newStr = applyZeroIndentArrays(newStr, info)
+	}
+
+	// Restore original spelling of quoted scalars with significant whitespace
+	newStr = restoreQuotedScalars(newStr, info)
 
 	// Align inline comments
 	newStr = alignInlineComments(newStr, info)
@@ -1350,6 +1718,51 @@ func alignInlineComments(content string, info *FormattingInfo) string {
 	return strings.Join(lines, "\n")
 }
 
+// restoreQuotedScalars puts back the original quoted form of scalars whose value did not change
+func restoreQuotedScalars(content string, info *FormattingInfo) string {
+	if len(info.QuotedScalars) == 0 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		colonPos := strings.Index(line, ":")
+		if colonPos <= 0 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:colonPos])
+		original, exists := info.QuotedScalars[key]
+		if !exists {
+			continue
+		}
+
+		rest := line[colonPos+1:]
+		value := strings.TrimLeft(rest, " ")
+		current := extractQuotedToken(value)
+		if current == "" || current == original || !sameQuotedValue(current, original) {
+			continue
+		}
+
+		valueStart := colonPos + 1 + len(rest) - len(value)
+		lines[i] = line[:valueStart] + original + value[len(current):]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// sameQuotedValue reports whether two quoted scalars decode to the same string
+func sameQuotedValue(a, b string) bool {
+	var va, vb string
+	if err := yaml.Unmarshal([]byte(a), &va); err != nil {
+		return false
+	}
+	if err := yaml.Unmarshal([]byte(b), &vb); err != nil {
+		return false
+	}
+	return va == vb
+}
+
 // restoreDocumentSeparators adds back document separators if they were in the original
 func restoreDocumentSeparators(content string, info *FormattingInfo, originalContent string, preserveDocumentSeparator bool) string {
 	// Check if the original content actually starts with ---
@@ -1462,6 +1875,9 @@ func applyEmptyLinePatterns(content string, info *FormattingInfo) string {
 			// Apply empty lines if needed
 			if key != "" {
 				emptyLinesCount := info.EmptyLines[key]
+				if breaks := info.SectionBreaks[key]; breaks > emptyLinesCount && line == trimmed {
+					emptyLinesCount = breaks
+				}
 				if emptyLinesCount > 0 && i > 0 && strings.TrimSpace(lines[i-1]) != "" {
 					// Add the specified number of empty lines (truly empty, no indentation)
 					for j := 0; j < emptyLinesCount; j++ {
@@ -1476,6 +1892,436 @@ func applyEmptyLinePatterns(content string, info *FormattingInfo) string {
 	return strings.Join(result, "\n")
 }
 
+// sequenceSpacing records blank lines before the items of a block sequence as originally loaded
+type sequenceSpacing struct {
+	items  []*yaml.Node
+	blanks []int
+}
+
+// blanksBefore returns the number of blank lines to restore before the item at index
+func (s *sequenceSpacing) blanksBefore(item *yaml.Node, index, length int) int {
+	for i, original := range s.items {
+		if original == item {
+			return s.blanks[i]
+		}
+	}
+	// An item replaced in place (e.g. by Set) keeps the spacing of its slot
+	if length == len(s.items) && index < len(s.items) {
+		return s.blanks[index]
+	}
+	return 0
+}
+
+// detectSequenceSpacing finds block sequence items preceded by blank lines in the original content
+func detectSequenceSpacing(root *yaml.Node, content string) map[*yaml.Node]*sequenceSpacing {
+	lines := strings.Split(content, "\n")
+	spacing := make(map[*yaml.Node]*sequenceSpacing)
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.SequenceNode && node.Style&yaml.FlowStyle == 0 {
+			entry := &sequenceSpacing{
+				items:  append([]*yaml.Node(nil), node.Content...),
+				blanks: make([]int, len(node.Content)),
+			}
+			found := false
+			for i, item := range node.Content {
+				// Blank lines before the first item or a commented item are handled elsewhere
+				if i == 0 || item.HeadComment != "" {
+					continue
+				}
+				for line := item.Line - 2; line >= 0 && line < len(lines) && strings.TrimSpace(lines[line]) == ""; line-- {
+					entry.blanks[i]++
+				}
+				if entry.blanks[i] > 0 {
+					found = true
+				}
+			}
+			if found {
+				spacing[node] = entry
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return spacing
+}
+
+// applySequenceSpacing restores blank lines between block sequence items.
+// The rendered content is parsed again to find the output line of every item.
+func applySequenceSpacing(content []byte, root *yaml.Node, spacing map[*yaml.Node]*sequenceSpacing) []byte {
+	var rendered yaml.Node
+	if err := yaml.Unmarshal(content, &rendered); err != nil {
+		return content
+	}
+
+	insert := make(map[int]int) // output line (1-based) -> blank lines to add before it
+	var walk func(node, out *yaml.Node)
+	walk = func(node, out *yaml.Node) {
+		if node.Kind != out.Kind || len(node.Content) != len(out.Content) {
+			return
+		}
+		if entry, ok := spacing[node]; ok && out.Style&yaml.FlowStyle == 0 {
+			for i, item := range node.Content {
+				if i == 0 || item.HeadComment != "" {
+					continue
+				}
+				if blanks := entry.blanksBefore(item, i, len(node.Content)); blanks > 0 {
+					insert[out.Content[i].Line] = blanks
+				}
+			}
+		}
+		for i := range node.Content {
+			walk(node.Content[i], out.Content[i])
+		}
+	}
+	walk(root, &rendered)
+
+	if len(insert) == 0 {
+		return content
+	}
+
+	lines := strings.Split(string(content), "\n")
+	result := make([]string, 0, len(lines)+len(insert))
+	for i, line := range lines {
+		if blanks := insert[i+1]; blanks > 0 && len(result) > 0 && strings.TrimSpace(result[len(result)-1]) != "" {
+			for j := 0; j < blanks; j++ {
+				result = append(result, "")
+			}
+		}
+		result = append(result, line)
+	}
+
+	return []byte(strings.Join(result, "\n"))
+}
+
+// detectExplicitKeys finds scalar mapping keys written as "? key" in the original content.
+// Complex keys are always rendered in explicit form by the encoder and need no tracking.
+func detectExplicitKeys(root *yaml.Node, content string) map[*yaml.Node]bool {
+	lines := strings.Split(content, "\n")
+	keys := make(map[*yaml.Node]bool)
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode && node.Style&yaml.FlowStyle == 0 {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				if key.Kind != yaml.ScalarNode || key.Line < 1 || key.Line > len(lines) {
+					continue
+				}
+				line := lines[key.Line-1]
+				if key.Column-1 <= len(line) && strings.TrimSpace(line[:key.Column-1]) == "?" {
+					keys[key] = true
+				}
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return keys
+}
+
+// applyExplicitKeys splits "key: value" lines of explicit keys back into "? key" and ": value".
+// The rendered content is parsed again to find the output line of every key.
+func applyExplicitKeys(content []byte, root *yaml.Node, keys map[*yaml.Node]bool) []byte {
+	var rendered yaml.Node
+	if err := yaml.Unmarshal(content, &rendered); err != nil {
+		return content
+	}
+
+	split := make(map[int]int) // output line (1-based) -> column where the key starts
+	var walk func(node, out *yaml.Node)
+	walk = func(node, out *yaml.Node) {
+		if node.Kind != out.Kind || len(node.Content) != len(out.Content) {
+			return
+		}
+		if node.Kind == yaml.MappingNode && out.Style&yaml.FlowStyle == 0 {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if keys[node.Content[i]] && out.Content[i].Kind == yaml.ScalarNode {
+					split[out.Content[i].Line] = out.Content[i].Column
+				}
+			}
+		}
+		for i := range node.Content {
+			walk(node.Content[i], out.Content[i])
+		}
+	}
+	walk(root, &rendered)
+
+	if len(split) == 0 {
+		return content
+	}
+
+	lines := strings.Split(string(content), "\n")
+	result := make([]string, 0, len(lines)+len(split))
+	for i, line := range lines {
+		column, ok := split[i+1]
+		if !ok || column-1 > len(line) || strings.TrimSpace(line[:column-1]) != "" {
+			result = append(result, line)
+			continue
+		}
+
+		indent, entry := line[:column-1], line[column-1:]
+		end := keyEnd(entry)
+		if end == -1 {
+			result = append(result, line)
+			continue
+		}
+		result = append(result, indent+"? "+entry[:end])
+		if value := strings.TrimLeft(entry[end+1:], " "); value != "" {
+			result = append(result, indent+": "+value)
+		} else {
+			result = append(result, indent+":")
+		}
+	}
+
+	return []byte(strings.Join(result, "\n"))
+}
+
+// detectTrailingCommas finds flow collections written with a comma after their last item, e.g. [a, b,]
+func detectTrailingCommas(root *yaml.Node, content string) map[*yaml.Node]bool {
+	offsets := lineOffsets(content)
+	commas := make(map[*yaml.Node]bool)
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if (node.Kind == yaml.SequenceNode || node.Kind == yaml.MappingNode) && node.Style&yaml.FlowStyle != 0 {
+			if pos := lastFlowItemEnd(content, offsets, node); pos >= 0 && content[pos] == ',' {
+				commas[node] = true
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return commas
+}
+
+// applyTrailingCommas adds or removes the comma after the last item of tracked flow collections.
+// The rendered content is parsed again to find the output position of every collection.
+func applyTrailingCommas(content []byte, root *yaml.Node, commas map[*yaml.Node]bool) []byte {
+	var rendered yaml.Node
+	if err := yaml.Unmarshal(content, &rendered); err != nil {
+		return content
+	}
+
+	text := string(content)
+	offsets := lineOffsets(text)
+	type edit struct {
+		pos    int
+		insert bool
+	}
+	var edits []edit
+
+	var walk func(node, out *yaml.Node)
+	walk = func(node, out *yaml.Node) {
+		if node.Kind != out.Kind || len(node.Content) != len(out.Content) {
+			return
+		}
+		if enabled, ok := commas[node]; ok && out.Style&yaml.FlowStyle != 0 && len(out.Content) > 0 {
+			if pos := lastFlowItemEnd(text, offsets, out); pos >= 0 {
+				hasComma := text[pos] == ','
+				if enabled && !hasComma {
+					edits = append(edits, edit{pos: pos + 1, insert: true})
+				} else if !enabled && hasComma {
+					edits = append(edits, edit{pos: pos})
+				}
+			}
+		}
+		for i := range node.Content {
+			walk(node.Content[i], out.Content[i])
+		}
+	}
+	walk(root, &rendered)
+
+	if len(edits) == 0 {
+		return content
+	}
+
+	// Apply from the end so earlier positions stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].pos > edits[j].pos })
+	for _, e := range edits {
+		if e.insert {
+			text = text[:e.pos] + "," + text[e.pos:]
+		} else {
+			text = text[:e.pos] + text[e.pos+1:]
+		}
+	}
+
+	return []byte(text)
+}
+
+// lastFlowItemEnd returns the position of the last non-blank character before the closing bracket
+// of a flow collection, which is a comma when the collection has a trailing one.
+// It returns -1 for empty collections and when the collection can't be located in content.
+func lastFlowItemEnd(content string, offsets []int, node *yaml.Node) int {
+	if node.Line < 1 || node.Line > len(offsets) {
+		return -1
+	}
+	open := offsets[node.Line-1] + node.Column - 1
+	if open >= len(content) || (content[open] != '[' && content[open] != '{') {
+		return -1
+	}
+	closePos := findFlowClose(content, open)
+	if closePos == -1 {
+		return -1
+	}
+
+	pos := closePos - 1
+	for pos > open && strings.ContainsRune(" \t\r\n", rune(content[pos])) {
+		pos--
+	}
+	if pos == open {
+		return -1
+	}
+	return pos
+}
+
+// lineOffsets returns the byte offset at which every line of content starts
+func lineOffsets(content string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// SetTrailingComma adds or removes the comma after the last item of the flow collection at path,
+// e.g. [a, b] becomes [a, b,]. It has no effect while the collection is rendered in block style.
+// Collections loaded with a trailing comma keep it automatically.
+func (d *Document) SetTrailingComma(path string, enabled bool) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	node, err := d.getNode(path)
+	if err != nil {
+		return err
+	}
+	if node.Kind != yaml.SequenceNode && node.Kind != yaml.MappingNode {
+		return fmt.Errorf("path %s: %w", path, kindMismatch("sequence or mapping node", node))
+	}
+
+	if d.trailingCommas == nil {
+		d.trailingCommas = make(map[*yaml.Node]bool)
+	}
+	d.trailingCommas[node] = enabled
+
+	d.markDirty()
+	return nil
+}
+
+// headerBlock is the comment block before the first node, e.g. a license header
+type headerBlock struct {
+	text     string // original lines, including blank lines between and after comment groups
+	comments string // the parsed comments the block holds, see headerComments
+}
+
+// detectHeader records the comment lines and blank lines before the first node of content
+func detectHeader(root *yaml.Node, content string) *headerBlock {
+	lines := strings.Split(content, "\n")
+	start, end := headerRange(lines)
+	hasComment := false
+	for _, line := range lines[start:end] {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			hasComment = true
+			break
+		}
+	}
+	if !hasComment || end == len(lines) {
+		return nil
+	}
+
+	return &headerBlock{
+		text:     strings.Join(lines[start:end], "\n"),
+		comments: headerComments(root),
+	}
+}
+
+// headerComments returns the comments yaml.v3 attaches to the top of a document.
+// The header is only restored verbatim while these are unchanged.
+func headerComments(root *yaml.Node) string {
+	if root == nil {
+		return ""
+	}
+	parts := []string{root.HeadComment}
+	if len(root.Content) > 0 {
+		top := root.Content[0]
+		parts = append(parts, top.HeadComment)
+		if len(top.Content) > 0 {
+			parts = append(parts, top.Content[0].HeadComment)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// headerRange returns the lines holding the leading comment block, after a "---" on the first line
+func headerRange(lines []string) (int, int) {
+	start := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		start = 1
+	}
+	end := start
+	for end < len(lines) {
+		trimmed := strings.TrimSpace(lines[end])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		end++
+	}
+	return start, end
+}
+
+// restoreHeader replaces the leading comment block of rendered content with the original header
+func restoreHeader(content []byte, header string) []byte {
+	lines := strings.Split(string(content), "\n")
+	start, end := headerRange(lines)
+	if end == len(lines) {
+		return content
+	}
+
+	result := make([]string, 0, len(lines))
+	result = append(result, lines[:start]...)
+	result = append(result, header)
+	result = append(result, lines[end:]...)
+	return []byte(strings.Join(result, "\n"))
+}
+
+// keyEnd returns the index of the ':' that ends the rendered key at the start of entry, or -1
+func keyEnd(entry string) int {
+	var quote byte
+	for i := 0; i < len(entry); i++ {
+		c := entry[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				if quote == '\'' && i+1 < len(entry) && entry[i+1] == '\'' {
+					i++ // escaped single quote
+				} else {
+					quote = 0
+				}
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case i == 0 && (c == '"' || c == '\''):
+			quote = c
+		case c == ':' && (i+1 == len(entry) || entry[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
 // preserveMultilineFlow preserves multiline flow object formatting
 // isInlineObject checks if a multiline flow object is actually an inline object
 func isInlineObject(lines []string, startIndex int) bool {
@@ -1830,7 +2676,7 @@ func extractArrayElementsFromLine(line string) []string {
 	}
 
 	// Split by comma and trim each element
-	elements := strings.Split(content, ",")
+	elements := splitFlowObjectParts(content)
 	for i, elem := range elements {
 		elements[i] = strings.TrimSpace(elem)
 	}
@@ -2023,7 +2869,7 @@ func extractArrayContent(line string) []string {
 		if strings.TrimSpace(content) == "" {
 			return []string{}
 		}
-		elements := strings.Split(content, ",")
+		elements := splitFlowObjectParts(content)
 		for i, elem := range elements {
 			elements[i] = strings.TrimSpace(elem)
 		}
@@ -2189,21 +3035,40 @@ func isInsideInlineObject(lines []string, lineIndex int) bool {
 
 // applyArrayStyles applies array formatting styles to the content
 func applyArrayStyles(content string, info *FormattingInfo) string {
-	if len(info.ArrayStyles) == 0 {
+	if len(info.ArrayStyles) == 0 && len(info.pathArrayStyles) == 0 {
 		return content
 	}
 
 	lines := strings.Split(content, "\n")
+	// Track document structure to build full paths for keys
+	pathStack := make([]string, 0)
+	indentStack := make([]int, 0)
 
 	for i, line := range lines {
 		trimmed := strings.TrimSpace(line)
 		if strings.Contains(trimmed, ":") {
 			if idx := strings.Index(trimmed, ":"); idx > 0 {
 				key := strings.TrimSpace(trimmed[:idx])
+				currentIndent := getLineIndentation(line)
+
+				// Update path stack based on indentation
+				for len(indentStack) > 0 && indentStack[len(indentStack)-1] >= currentIndent {
+					pathStack = pathStack[:len(pathStack)-1]
+					indentStack = indentStack[:len(indentStack)-1]
+				}
+				pathStack = append(pathStack, key)
+				indentStack = append(indentStack, currentIndent)
+				fullPath := strings.Join(pathStack, ".")
+
+				// A style set for this one array wins over the style of its key name
+				style, exists := info.pathArrayStyles[fullPath]
+				if !exists {
+					style, exists = info.ArrayStyles[key]
+				}
 
 				// Check if this key has a specific array style
 				// But skip keys that are inside inline objects
-				if style, exists := info.ArrayStyles[key]; exists && style.IsFlow && !isInsideInlineObject(lines, i) {
+				if exists && style.IsFlow && !isInsideInlineObject(lines, i) {
 					value := line[idx+1:]
 
 					// Check if we have original multiline flow format stored
@@ -2232,7 +3097,7 @@ func applyArrayStyles(content string, info *FormattingInfo) string {
 							var newArrayContent string
 							if style.IsMultiline && !strings.Contains(arrayContent, "\n") {
 								// Convert single-line to multiline
-								elements := strings.Split(arrayContent, ",")
+								elements := splitFlowObjectParts(arrayContent)
 								for j, elem := range elements {
 									elements[j] = strings.TrimSpace(elem)
 								}
@@ -2241,21 +3106,21 @@ func applyArrayStyles(content string, info *FormattingInfo) string {
 								newArrayContent = "\n" + indent + strings.Join(elements, ",\n"+indent) + "\n"
 							} else if style.HasSpaces {
 								// Add spaces around elements: [1,2,3] -> [ 1 , 2 , 3 ]
-								elements := strings.Split(arrayContent, ",")
+								elements := splitFlowObjectParts(arrayContent)
 								for j, elem := range elements {
 									elements[j] = " " + strings.TrimSpace(elem) + " "
 								}
 								newArrayContent = strings.Join(elements, ",")
 							} else if style.IsCompact {
 								// Remove all spaces: [ 1 , 2 , 3 ] -> [1,2,3]
-								elements := strings.Split(arrayContent, ",")
+								elements := splitFlowObjectParts(arrayContent)
 								for j, elem := range elements {
 									elements[j] = strings.TrimSpace(elem)
 								}
 								newArrayContent = strings.Join(elements, ",")
 							} else {
 								// Default formatting
-								elements := strings.Split(arrayContent, ",")
+								elements := splitFlowObjectParts(arrayContent)
 								for j, elem := range elements {
 									elements[j] = strings.TrimSpace(elem)
 								}
@@ -2329,7 +3194,7 @@ func applyFlowObjectStyles(content string, info *FormattingInfo) string {
 								// This is a collapsed flow object, we need to extract new values
 								// and apply them to the original multiline format
 								newValues := extractFlowObjectValues(currentValue)
-								if len(newValues) > 0 {
+								if len(newValues) > 0 && sameFlowKeys(extractFlowObjectValues(originalStyle), newValues) {
 									// Update the original style with new values
 									updatedStyle := updateFlowObjectWithNewValues(originalStyle, newValues)
 
@@ -2353,7 +3218,8 @@ func applyFlowObjectStyles(content string, info *FormattingInfo) string {
 
 								// Always apply original formatting to preserve spaces, even if values didn't change
 								// This handles cases where YAML encoder strips spaces but we want to preserve them
-								if valuesChanged || currentValue != originalStyle {
+								// Added or removed keys can't be expressed with the original layout
+								if (valuesChanged || currentValue != originalStyle) && sameFlowKeys(originalValues, currentValues) {
 									updatedStyle := updateFlowObjectWithNewValues(originalStyle, currentValues)
 									newLine := line[:valueStart] + " " + updatedStyle
 									lines[i] = newLine
@@ -2375,24 +3241,112 @@ func applyFlowObjectStyles(content string, info *FormattingInfo) string {
 	return strings.Join(lines, "\n")
 }
 
-// updateFlowObjectWithNewValues updates values in original flow object with new values map
+// updateFlowObjectWithNewValues updates values in original flow object with new values map.
+// Nested flow mappings are updated recursively so their own spacing is preserved too.
 func updateFlowObjectWithNewValues(originalStyle string, newValues map[string]string) string {
 	if len(newValues) == 0 {
 		return originalStyle
 	}
 
-	result := originalStyle
-	originalValues := extractFlowObjectValues(originalStyle)
+	open := strings.Index(originalStyle, "{")
+	if open == -1 {
+		return originalStyle
+	}
+	closePos := findFlowClose(originalStyle, open)
+	if closePos == -1 {
+		return originalStyle
+	}
+
+	inner := originalStyle[open+1 : closePos]
+	parts := splitFlowObjectParts(inner)
+	for i, part := range parts {
+		colonPos := strings.Index(part, ":")
+		if colonPos == -1 {
+			continue
+		}
 
-	// Update each value that exists in the new values
-	for key, newValue := range newValues {
-		if originalValue, exists := originalValues[key]; exists && originalValue != newValue {
-			// Replace the old value with new value while preserving surrounding formatting
-			result = replaceValueInFlowObject(result, key, originalValue, newValue)
+		key := strings.TrimSpace(part[:colonPos])
+		newValue, exists := newValues[key]
+		if !exists {
+			continue
 		}
+
+		valuePart := part[colonPos+1:]
+		oldValue := strings.TrimSpace(valuePart)
+		if oldValue == newValue {
+			continue
+		}
+
+		if strings.HasPrefix(oldValue, "{") && strings.HasPrefix(newValue, "{") {
+			nestedValues := extractFlowObjectValues(newValue)
+			if sameFlowKeys(extractFlowObjectValues(oldValue), nestedValues) {
+				newValue = updateFlowObjectWithNewValues(oldValue, nestedValues)
+			}
+		}
+
+		// Replace only the value, keeping the whitespace around it
+		leading := valuePart[:len(valuePart)-len(strings.TrimLeft(valuePart, " \t\n"))]
+		trailing := valuePart[len(strings.TrimRight(valuePart, " \t\n")):]
+		parts[i] = part[:colonPos+1] + leading + newValue + trailing
 	}
 
-	return result
+	updated := strings.Join(parts, ",")
+	if strings.HasSuffix(inner, ",") {
+		// splitFlowObjectParts drops the empty part after a trailing comma
+		updated += ","
+	}
+
+	return originalStyle[:open+1] + updated + originalStyle[closePos:]
+}
+
+// findFlowClose returns the position of the bracket closing the one at open, or -1
+func findFlowClose(s string, open int) int {
+	depth := 0
+	var quote, prev byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+				prev = c
+			}
+			continue
+		}
+
+		atStart := startsFlowScalar(rune(prev))
+		if c != ' ' && c != '\t' && c != '\n' {
+			prev = c
+		}
+		switch c {
+		case '"', '\'':
+			if atStart {
+				quote = c
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// sameFlowKeys checks if two flow objects have exactly the same keys
+func sameFlowKeys(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key := range a {
+		if _, exists := b[key]; !exists {
+			return false
+		}
+	}
+	return true
 }
 
 // extractFlowObjectValues extracts key-value pairs from flow object string
@@ -2423,14 +3377,41 @@ func extractFlowObjectValues(flowStr string) map[string]string {
 	return values
 }
 
-// splitFlowObjectParts splits flow object content by commas, respecting nested structures
+// splitFlowObjectParts splits flow object content by commas, respecting nested structures and quoted scalars
 func splitFlowObjectParts(content string) []string {
 	var parts []string
 	var current strings.Builder
 	depth := 0
+	var quote rune
+	escaped := false
+	var prev rune // last non-space character outside quotes
 
 	for _, r := range content {
+		if quote != 0 {
+			// Inside a quoted scalar commas and brackets are plain characters
+			current.WriteRune(r)
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\' && quote == '"':
+				escaped = true
+			case r == quote:
+				quote = 0
+				prev = r
+			}
+			continue
+		}
+
+		atStart := startsFlowScalar(prev)
+		if !unicode.IsSpace(r) {
+			prev = r
+		}
 		switch r {
+		case '"', '\'':
+			if atStart {
+				quote = r
+			}
+			current.WriteRune(r)
 		case '{', '[':
 			depth++
 			current.WriteRune(r)
@@ -2456,6 +3437,12 @@ func splitFlowObjectParts(content string) []string {
 	return parts
 }
 
+// startsFlowScalar reports whether a scalar begins after prev, the last non-space character read.
+// Quotes only start a quoted scalar there, so the apostrophe in a plain it's stays a plain character.
+func startsFlowScalar(prev rune) bool {
+	return prev == 0 || strings.ContainsRune(":,[{", prev)
+}
+
 // replaceValueInFlowObject replaces a specific value in flow object while preserving formatting
 func replaceValueInFlowObject(flowStr, key, oldValue, newValue string) string {
 	// Use simple string replacement pattern: "key: oldValue" -> "key: newValue"
@@ -2497,7 +3484,7 @@ func applyExactIndentations(content string, info *FormattingInfo) string {
 					newLine := strings.Repeat(" ", exactIndent) + trimmed
 					lines[i] = newLine
 				}
-			} else {
+			} else if !info.arrayIndents[currentIndent] {
 				// Try to find any array element indentation pattern
 				for key, exactIndent := range info.KeyIndents {
 					if strings.HasPrefix(key, "__array_element_") {
@@ -2561,7 +3548,7 @@ func (om OrderedMap) MarshalYAML() (interface{}, error) {
 func nodeToOrderedInterface(node *yaml.Node) (interface{}, error) {
 	switch node.Kind {
 	case yaml.ScalarNode:
-		return scalarToInterface(node)
+		return scalarToInterface(node, SchemaCore)
 	case yaml.SequenceNode:
 		var result []interface{}
 		for _, item := range node.Content {
@@ -2743,36 +3730,49 @@ func copyNodeStyles(source, target *yaml.Node) {
 
 // String returns the YAML document as a string
 // SetCommentAlignment configures how inline comments should be aligned
-func (d *Document) SetCommentAlignment(mode CommentAlignmentMode) {
-	if d.formattingCache == nil {
-		d.formattingCache = detectFormattingInfoOptimized(d.raw)
+func (d *Document) SetCommentAlignment(mode CommentAlignmentMode) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	d.pinned = nil
+	d.formattingInfo().AlignmentMode = mode
+	return nil
+}
+
+// SetSectionSpacing makes Set put n blank lines before top-level keys it creates,
+// separating new sections like hand-written config. The default is 0.
+func (d *Document) SetSectionSpacing(n int) error {
+	if err := d.checkWritable(); err != nil {
+		return err
 	}
-	d.formattingCache.AlignmentMode = mode
+	if n < 0 {
+		n = 0
+	}
+	d.pinned = nil
+	d.formattingInfo().SectionSpacing = n
+	return nil
 }
 
 // SetAbsoluteCommentAlignment aligns all comments to the specified column
-func (d *Document) SetAbsoluteCommentAlignment(column int) {
-	if d.formattingCache == nil {
-		d.formattingCache = detectFormattingInfoOptimized(d.raw)
+func (d *Document) SetAbsoluteCommentAlignment(column int) error {
+	if err := d.checkWritable(); err != nil {
+		return err
 	}
-	d.formattingCache.AlignmentMode = CommentAlignmentAbsolute
-	d.formattingCache.CommentSpacing = column
+	d.pinned = nil
+	info := d.formattingInfo()
+	info.AlignmentMode = CommentAlignmentAbsolute
+	info.CommentSpacing = column
+	return nil
 }
 
 // EnableRelativeCommentAlignment preserves original spacing between values and comments
-func (d *Document) EnableRelativeCommentAlignment() {
-	if d.formattingCache == nil {
-		d.formattingCache = detectFormattingInfoOptimized(d.raw)
-	}
-	d.formattingCache.AlignmentMode = CommentAlignmentRelative
+func (d *Document) EnableRelativeCommentAlignment() error {
+	return d.SetCommentAlignment(CommentAlignmentRelative)
 }
 
 // DisableCommentAlignment disables all comment alignment processing
-func (d *Document) DisableCommentAlignment() {
-	if d.formattingCache == nil {
-		d.formattingCache = detectFormattingInfoOptimized(d.raw)
-	}
-	d.formattingCache.AlignmentMode = CommentAlignmentDisabled
+func (d *Document) DisableCommentAlignment() error {
+	return d.SetCommentAlignment(CommentAlignmentDisabled)
 }
 
 func (d *Document) String() (string, error) {
@@ -2783,6 +3783,72 @@ func (d *Document) String() (string, error) {
 	return string(bytes), nil
 }
 
+// StringWithIndent returns the YAML document rendered with the given indentation width.
+// The rendering happens on a copy, so the document keeps its own formatting for ToBytes and Save.
+func (d *Document) StringWithIndent(indent int) (string, error) {
+	if indent < 1 {
+		return "", fmt.Errorf("invalid indent size: %d", indent)
+	}
+
+	root, err := cloneNode(d.root)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := d.renderCopy(root, func(info *FormattingInfo) {
+		setIndentSize(info, indent)
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// renderCopy renders root using a copy of the document's formatting info changed by adjust
+func (d *Document) renderCopy(root *yaml.Node, adjust func(info *FormattingInfo)) ([]byte, error) {
+	if err := d.syncRaw(); err != nil {
+		return nil, err
+	}
+	// Documents without raw content have no formatting to preserve yet, start from the plain rendering
+	raw := d.raw
+	var info FormattingInfo
+	if raw == "" {
+		content, err := d.renderContent()
+		if err != nil {
+			return nil, err
+		}
+		raw = string(content)
+		info = *detectFormattingInfoOptimized(raw)
+	} else {
+		info = *d.formattingInfo()
+	}
+	adjust(&info)
+
+	preview := &Document{
+		root:                      root,
+		raw:                       raw,
+		arrayRoot:                 d.arrayRoot,
+		trailingNewlines:          d.trailingNewlines,
+		preserveDocumentSeparator: d.preserveDocumentSeparator,
+		exactTrailingNewlines:     d.exactTrailingNewlines,
+		formattingCache:           &info,
+		header:                    d.header,
+		serialize:                 d.serialize,
+		alignComments:             d.alignComments,
+		schema:                    d.schema,
+		schemaTags:                d.schemaTags,
+	}
+	return preview.ToBytes()
+}
+
+// setIndentSize switches formatting info to a new space indentation width
+func setIndentSize(info *FormattingInfo, indent int) {
+	info.IndentSize = indent
+	info.UseTabs = false
+	// Exact per-key indents belong to the original width and would fight the new one
+	info.KeyIndents = make(map[string]int)
+}
+
 // extractCurrentArrayElements extracts array elements from both single-line and multiline formats
 func extractCurrentArrayElements(arrayStr string) []string {
 	if arrayStr == "" {
@@ -1,9 +1,13 @@
 package yamler
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestLoadFile(t *testing.T) {
@@ -78,6 +82,31 @@ func TestLoadBytes(t *testing.T) {
 	}
 }
 
+func TestLoadReader(t *testing.T) {
+	content := "# app\nkey: value   # aligned\n\nlist: [a, b]\n"
+
+	doc, err := LoadReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("LoadReader() error = %v", err)
+	}
+	if value, err := doc.GetString("key"); err != nil || value != "value" {
+		t.Errorf("GetString() = %q, %v; want value", value, err)
+	}
+
+	var out bytes.Buffer
+	n, err := doc.WriteTo(&out)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if out.String() != content || n != int64(len(content)) {
+		t.Errorf("WriteTo() = %d, %q; want %d, %q", n, out.String(), len(content), content)
+	}
+
+	if _, err := LoadReader(iotest.ErrReader(errors.New("broken pipe"))); err == nil || !strings.Contains(err.Error(), "broken pipe") {
+		t.Errorf("LoadReader() error = %v, want broken pipe", err)
+	}
+}
+
 func TestLoad(t *testing.T) {
 	content := "key: value\narray:\n  - item1\n  - item2\n"
 
@@ -350,3 +379,177 @@ provides:
 		t.Errorf("Expected test class to be 'medium.standard', got '%s'", testClass)
 	}
 }
+
+func TestFreeze(t *testing.T) {
+	content := `app:
+  name: myapp
+  ports: [80, 443]
+`
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if doc.IsFrozen() {
+		t.Fatal("IsFrozen() = true before Freeze()")
+	}
+	doc.Freeze()
+	if !doc.IsFrozen() {
+		t.Fatal("IsFrozen() = false after Freeze()")
+	}
+
+	other, err := Load("extra: true")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	mutations := map[string]func() error{
+		"Set":                func() error { return doc.Set("app.name", "other") },
+		"SetString":          func() error { return doc.SetString("app.name", "other") },
+		"SetAll":             func() error { return doc.SetAll("app.*", "other") },
+		"AppendToArray":      func() error { return doc.AppendToArray("app.ports", 8080) },
+		"InsertIntoArray":    func() error { return doc.InsertIntoArray("app.ports", 0, 8080) },
+		"UpdateArrayElement": func() error { return doc.UpdateArrayElement("app.ports", 0, 8080) },
+		"RemoveFromArray":    func() error { return doc.RemoveFromArray("app.ports", 0) },
+		"Merge":              func() error { return doc.Merge(other) },
+		"MergeAt":            func() error { return doc.MergeAt("app", other) },
+
+		// Formatting settings change the output too
+		"SetSerializeOptions":            func() error { return doc.SetSerializeOptions(SerializeOptions{Indent: 4}) },
+		"SetSequenceIndent":              func() error { return doc.SetSequenceIndent(0) },
+		"SetSectionSpacing":              func() error { return doc.SetSectionSpacing(1) },
+		"SetCommentAlignment":            func() error { return doc.SetCommentAlignment(CommentAlignmentDisabled) },
+		"SetAbsoluteCommentAlignment":    func() error { return doc.SetAbsoluteCommentAlignment(20) },
+		"EnableRelativeCommentAlignment": func() error { return doc.EnableRelativeCommentAlignment() },
+		"DisableCommentAlignment":        func() error { return doc.DisableCommentAlignment() },
+	}
+	for name, mutate := range mutations {
+		if err := mutate(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s() error = %v, want ErrReadOnly", name, err)
+		}
+	}
+
+	// Reads keep working and the content is unchanged
+	name, err := doc.GetString("app.name")
+	if err != nil || name != "myapp" {
+		t.Errorf("GetString() = %q, %v; want myapp", name, err)
+	}
+	result, err := doc.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	if result != content {
+		t.Errorf("String() = %q, want %q", result, content)
+	}
+}
+
+func TestFreezeArrayDocument(t *testing.T) {
+	doc, err := Load("- name: first\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	doc.Freeze()
+
+	if err := doc.AddArrayElement(map[string]interface{}{"name": "second"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("AddArrayElement() error = %v, want ErrReadOnly", err)
+	}
+	if err := doc.SetArrayElement(0, "name", "changed"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetArrayElement() error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestDocument_StringWithIndent(t *testing.T) {
+	content := `app:
+  name: myapp  # display name
+  ports: [80, 443]
+  tags:
+    - web
+  database:
+    host: localhost
+`
+
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got, err := doc.StringWithIndent(4)
+	if err != nil {
+		t.Fatalf("StringWithIndent() error = %v", err)
+	}
+	want := `app:
+    name: myapp  # display name
+    ports: [80, 443]
+    tags:
+        - web
+    database:
+        host: localhost
+`
+	if got != want {
+		t.Errorf("StringWithIndent(4) = %q, want %q", got, want)
+	}
+
+	// The document itself keeps its original formatting
+	original, err := doc.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	if original != content {
+		t.Errorf("String() after StringWithIndent = %q, want %q", original, content)
+	}
+
+	// Narrowing a wide document works the same way
+	wide, err := Load("a:\n    b:\n        c: 1\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got, err = wide.StringWithIndent(2)
+	if err != nil {
+		t.Fatalf("StringWithIndent() error = %v", err)
+	}
+	if got != "a:\n  b:\n    c: 1\n" {
+		t.Errorf("StringWithIndent(2) = %q", got)
+	}
+
+	if _, err := doc.StringWithIndent(0); err == nil {
+		t.Error("StringWithIndent(0) should return error")
+	}
+}
+
+func TestPathCache(t *testing.T) {
+	defer SetPathCacheEnabled(true)
+	ClearPathCache()
+
+	cached := func(path string) bool {
+		_, ok := pathCache.Load(path)
+		return ok
+	}
+
+	parts := parsePath("app.db.host")
+	if len(parts) != 3 || !cached("app.db.host") {
+		t.Fatalf("parsePath() = %v, cached = %v", parts, cached("app.db.host"))
+	}
+	if pathCacheSize.Load() != 1 {
+		t.Errorf("pathCacheSize = %d, want 1", pathCacheSize.Load())
+	}
+
+	ClearPathCache()
+	if cached("app.db.host") || pathCacheSize.Load() != 0 {
+		t.Errorf("ClearPathCache() left %d entries", pathCacheSize.Load())
+	}
+
+	SetPathCacheEnabled(false)
+	parts = parsePath("app.db.port")
+	if len(parts) != 3 || cached("app.db.port") {
+		t.Errorf("disabled cache: parsePath() = %v, cached = %v", parts, cached("app.db.port"))
+	}
+
+	SetPathCacheEnabled(true)
+	for i := 0; i < maxPathCacheEntries+10; i++ {
+		parsePath(fmt.Sprintf("key%d.value", i))
+	}
+	if size := pathCacheSize.Load(); size != maxPathCacheEntries {
+		t.Errorf("pathCacheSize = %d, want bound %d", size, maxPathCacheEntries)
+	}
+	ClearPathCache()
+}
@@ -0,0 +1,48 @@
+package yamler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// EditFile loads a YAML file, passes it to fn and writes the file back only if fn changed its content.
+// Lines the edits did not touch keep their original bytes, including spacing and separators that
+// rendering would normalize, so a diff of the file shows only the changed lines.
+// Nothing is written if fn returns an error.
+func EditFile(filename string, fn func(doc *Document) error) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	doc, err := LoadBytes(content)
+	if err != nil {
+		return err
+	}
+
+	// Rendering before the edit tells formatting normalization apart from the changes made by fn
+	before, err := doc.ToBytes()
+	if err != nil {
+		return err
+	}
+	if err := fn(doc); err != nil {
+		return err
+	}
+	after, err := doc.ToBytes()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, after) {
+		return nil
+	}
+
+	result := applyRenderedChanges(string(content), string(before), string(after))
+	if result == string(content) {
+		return nil
+	}
+	return os.WriteFile(filename, []byte(result), info.Mode().Perm())
+}
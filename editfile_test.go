@@ -0,0 +1,167 @@
+package yamler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const editFileInput = `---
+# service config
+server:
+  host:   localhost     # bind address
+  port: 8080
+limits: [1,2,3]
+
+database:
+  name: app
+`
+
+func TestEditFile(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(doc *Document) error
+		want string
+	}{
+		{
+			name: "set keeps untouched lines byte for byte",
+			edit: func(doc *Document) error { return doc.Set("server.port", 9090) },
+			want: strings.Replace(editFileInput, "port: 8080", "port: 9090", 1),
+		},
+		{
+			name: "added key",
+			edit: func(doc *Document) error { return doc.Set("database.user", "admin") },
+			want: editFileInput + "  user: admin\n",
+		},
+		{
+			name: "deleted key",
+			edit: func(doc *Document) error { return doc.Delete("database.name") },
+			want: strings.Replace(editFileInput, "database:\n  name: app\n", "database: {}\n", 1),
+		},
+		{
+			name: "layout change",
+			edit: func(doc *Document) error { return doc.SetTrailingComma("limits", true) },
+			want: strings.Replace(editFileInput, "[1,2,3]", "[1,2,3,]", 1),
+		},
+		{
+			name: "comment change",
+			edit: func(doc *Document) error { return doc.SetComment("server.port", "public", CommentLine) },
+			want: strings.Replace(editFileInput, "port: 8080", "port: 8080 # public", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(editFileInput), 0600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if err := EditFile(path, tt.edit); err != nil {
+				t.Fatalf("EditFile() error = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EditFile() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+				t.Errorf("file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+			}
+		})
+	}
+}
+
+func TestEditFile_Unchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(editFileInput), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	edits := map[string]func(doc *Document) error{
+		"no edit":        func(doc *Document) error { return nil },
+		"same value":     func(doc *Document) error { return doc.Set("server.port", 8080) },
+		"callback error": func(doc *Document) error { doc.Set("server.port", 1); return errors.New("abort") },
+	}
+	for name, edit := range edits {
+		err := EditFile(path, edit)
+		if name == "callback error" {
+			if err == nil || err.Error() != "abort" {
+				t.Errorf("%s: EditFile() error = %v, want abort", name, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: EditFile() error = %v", name, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(old) {
+			t.Errorf("%s: file was rewritten", name)
+		}
+	}
+	if got, _ := os.ReadFile(path); string(got) != editFileInput {
+		t.Errorf("file = %q, want %q", got, editFileInput)
+	}
+
+	if err := EditFile(filepath.Join(t.TempDir(), "missing.yaml"), func(*Document) error { return nil }); err == nil {
+		t.Error("EditFile() of a missing file succeeded")
+	}
+}
+
+func TestEditFile_MixedIndentation(t *testing.T) {
+	input := `app:
+  name: x
+db:
+    host: a
+    opts: b
+list:
+- one
+- two
+`
+	tests := []struct {
+		name string
+		edit func(doc *Document) error
+		want string
+	}{
+		{
+			name: "changed line keeps the block indentation",
+			edit: func(doc *Document) error { return doc.Set("db.host", "c") },
+			want: strings.Replace(input, "host: a", "host: c", 1),
+		},
+		{
+			name: "added key",
+			edit: func(doc *Document) error { return doc.Set("db.user", "u") },
+			want: strings.Replace(input, "    opts: b\n", "    opts: b\n    user: u\n", 1),
+		},
+		{
+			name: "appended item",
+			edit: func(doc *Document) error { return doc.AppendToArray("list", "three") },
+			want: input + "- three\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if err := EditFile(path, tt.edit); err != nil {
+				t.Fatalf("EditFile() error = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EditFile() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
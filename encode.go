@@ -0,0 +1,121 @@
+package yamler
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decode decodes the subtree at path into out, which must be a pointer (e.g. to a struct with yaml tags).
+// An empty path decodes the whole document.
+func (d *Document) Decode(path string, out interface{}) error {
+	node, err := d.getNode(path)
+	if err != nil {
+		return err
+	}
+
+	if err := node.Decode(out); err != nil {
+		return fmt.Errorf("path %s: failed to decode: %w", path, err)
+	}
+	return nil
+}
+
+// Encode writes value (e.g. a struct with yaml tags) to the subtree at path, creating it if needed.
+// Only scalars whose value actually changed are rewritten, so comments, quoting and number formats
+// of untouched keys survive. Keys that value does not have are left in place; use Delete to remove them.
+func (d *Document) Encode(path string, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	var encoded yaml.Node
+	if err := encoded.Encode(value); err != nil {
+		return fmt.Errorf("path %s: failed to encode: %w", path, err)
+	}
+
+	root, err := d.mappingRoot()
+	if err != nil {
+		return err
+	}
+	target, err := getOrCreateNode(root, path)
+	if err != nil {
+		return fmt.Errorf("path %s: %w", path, err)
+	}
+	if path == "" && encoded.Kind != yaml.MappingNode {
+		return fmt.Errorf("path %s: document root requires a mapping, got %T", path, value)
+	}
+
+	if err := reconcileNode(target, &encoded); err != nil {
+		return fmt.Errorf("path %s: %w", path, err)
+	}
+
+	d.markDirty()
+	return nil
+}
+
+// reconcileNode updates target in place to hold the data of source, touching only what differs
+func reconcileNode(target, source *yaml.Node) error {
+	switch {
+	case target.Kind == yaml.MappingNode && source.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(source.Content); i += 2 {
+			key, value := source.Content[i], source.Content[i+1]
+			if existing, found := findKeyInMapping(target, key.Value); found {
+				if err := reconcileNode(existing, value); err != nil {
+					return err
+				}
+				continue
+			}
+			target.Content = append(target.Content, key, value)
+		}
+		return nil
+
+	case target.Kind == yaml.SequenceNode && source.Kind == yaml.SequenceNode:
+		for i, item := range source.Content {
+			if i >= len(target.Content) {
+				target.Content = append(target.Content, item)
+				continue
+			}
+			if err := reconcileNode(target.Content[i], item); err != nil {
+				return err
+			}
+		}
+		if len(target.Content) > len(source.Content) {
+			target.Content = target.Content[:len(source.Content)]
+		}
+		return nil
+	}
+
+	if sameData(target, source) {
+		return nil
+	}
+
+	// Kinds differ or the scalar changed: take the new node, keeping comments and quoting
+	preserveQuoteStyle(target, source)
+	replacement := *source
+	replacement.HeadComment = target.HeadComment
+	replacement.LineComment = target.LineComment
+	replacement.FootComment = target.FootComment
+	if block := target.Style & (yaml.LiteralStyle | yaml.FoldedStyle); block != 0 && target.Kind == yaml.ScalarNode && replacement.Tag == "!!str" {
+		// Literal and folded strings stay block scalars
+		replacement.Style = block
+	}
+	*target = replacement
+	return nil
+}
+
+// sameData checks if two nodes hold the same data, regardless of how it is written (0x1F and 31, 'a' and a)
+func sameData(a, b *yaml.Node) bool {
+	if a.Kind != yaml.AliasNode && a.Kind != b.Kind {
+		return false
+	}
+	// Decode understands every spelling of a value, unlike the stricter scalar conversion of the getters
+	var left, right interface{}
+	if err := a.Decode(&left); err != nil {
+		return false
+	}
+	if err := b.Decode(&right); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
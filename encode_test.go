@@ -0,0 +1,158 @@
+package yamler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testDatabaseConfig struct {
+	Host     string            `yaml:"host"`
+	Port     int               `yaml:"port"`
+	User     string            `yaml:"user"`
+	Replicas []string          `yaml:"replicas"`
+	Options  map[string]string `yaml:"options,omitempty"`
+}
+
+const encodeInput = `# service settings
+database:
+  host: db.local   # primary
+  port: 0x1538
+  user: 'admin'
+  password: secret # not in the struct
+  replicas:
+    - r1.local
+    - r2.local
+cache:
+  ttl: 60
+`
+
+func TestDocument_Decode(t *testing.T) {
+	doc, err := Load(encodeInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var cfg testDatabaseConfig
+	if err := doc.Decode("database", &cfg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := testDatabaseConfig{Host: "db.local", Port: 5432, User: "admin", Replicas: []string{"r1.local", "r2.local"}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Decode() = %+v, want %+v", cfg, want)
+	}
+
+	var ttl int
+	if err := doc.Decode("cache.ttl", &ttl); err != nil || ttl != 60 {
+		t.Errorf("Decode(cache.ttl) = %d, %v, want 60", ttl, err)
+	}
+
+	var all map[string]interface{}
+	if err := doc.Decode("", &all); err != nil || len(all) != 2 {
+		t.Errorf("Decode(\"\") = %v, %v, want both sections", all, err)
+	}
+
+	if err := doc.Decode("missing", &cfg); err == nil {
+		t.Error("Decode() of missing path should fail")
+	}
+	if err := doc.Decode("cache", &ttl); err == nil {
+		t.Error("Decode() of mapping into int should fail")
+	}
+}
+
+func TestDocument_Encode(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *testDatabaseConfig)
+		want   string
+	}{
+		{
+			name:   "unchanged struct keeps formatting",
+			modify: func(cfg *testDatabaseConfig) {},
+			want:   encodeInput,
+		},
+		{
+			name:   "changed scalar",
+			modify: func(cfg *testDatabaseConfig) { cfg.Host = "db.prod" },
+			want:   strings.Replace(encodeInput, "host: db.local", "host: db.prod", 1),
+		},
+		{
+			name:   "changed quoted string keeps quotes",
+			modify: func(cfg *testDatabaseConfig) { cfg.User = "root" },
+			want:   strings.Replace(encodeInput, "user: 'admin'", "user: 'root'", 1),
+		},
+		{
+			name:   "changed number",
+			modify: func(cfg *testDatabaseConfig) { cfg.Port = 6543 },
+			want:   strings.Replace(encodeInput, "port: 0x1538", "port: 6543", 1),
+		},
+		{
+			name:   "appended list item",
+			modify: func(cfg *testDatabaseConfig) { cfg.Replicas = append(cfg.Replicas, "r3.local") },
+			want:   strings.Replace(encodeInput, "    - r2.local\n", "    - r2.local\n    - r3.local\n", 1),
+		},
+		{
+			name:   "new field",
+			modify: func(cfg *testDatabaseConfig) { cfg.Options = map[string]string{"sslmode": "require"} },
+			want:   strings.Replace(encodeInput, "    - r2.local\n", "    - r2.local\n  options:\n    sslmode: require\n", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(encodeInput)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			var cfg testDatabaseConfig
+			if err := doc.Decode("database", &cfg); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			tt.modify(&cfg)
+			if err := doc.Encode("database", cfg); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Encode() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_EncodeNewPath(t *testing.T) {
+	doc, err := Load("app:\n  name: web\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	cfg := testDatabaseConfig{Host: "localhost", Port: 5432, User: "app"}
+	if err := doc.Encode("app.database", cfg); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var got testDatabaseConfig
+	if err := doc.Decode("app.database", &got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.Host != "localhost" || got.Port != 5432 || got.User != "app" {
+		t.Errorf("Decode() after Encode = %+v", got)
+	}
+	if name, _ := doc.GetString("app.name"); name != "web" {
+		t.Errorf("app.name = %q, want web", name)
+	}
+
+	if err := doc.Encode("", 42); err == nil {
+		t.Error("Encode() of a scalar at the root should fail")
+	}
+
+	doc.Freeze()
+	if err := doc.Encode("app.name", "api"); err != ErrReadOnly {
+		t.Errorf("Encode() on frozen document = %v, want ErrReadOnly", err)
+	}
+}
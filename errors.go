@@ -0,0 +1,60 @@
+package yamler
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPathNotFound is wrapped by errors for paths that lead to a missing key or array element.
+// ErrIndexOutOfBounds matches it as well, so errors.Is(err, ErrPathNotFound) covers both.
+var ErrPathNotFound = errors.New("not found")
+
+// ErrTypeMismatch reports a value of another type than an operation expected, such as
+// indexing into a mapping or reading a string as an integer. errors.Is(err, &ErrTypeMismatch{})
+// matches any mismatch; use errors.As for the details.
+type ErrTypeMismatch struct {
+	Expected string // What the operation needs, e.g. "sequence node" or "integer"
+	Actual   string // What the document holds, e.g. "mapping" or "string"
+}
+
+func (e *ErrTypeMismatch) Error() string {
+	if e.Actual == "" {
+		return "expected " + e.Expected
+	}
+	return fmt.Sprintf("expected %s, got %s", e.Expected, e.Actual)
+}
+
+// Is reports whether target is a type mismatch whose non-empty fields equal those of e
+func (e *ErrTypeMismatch) Is(target error) bool {
+	t, ok := target.(*ErrTypeMismatch)
+	return ok && (t.Expected == "" || t.Expected == e.Expected) && (t.Actual == "" || t.Actual == e.Actual)
+}
+
+// ErrIndexOutOfBounds reports an array index outside an array of Length elements.
+// errors.Is(err, &ErrIndexOutOfBounds{}) and errors.Is(err, ErrPathNotFound) both match it.
+type ErrIndexOutOfBounds struct {
+	Index  int
+	Length int
+}
+
+func (e *ErrIndexOutOfBounds) Error() string {
+	return fmt.Sprintf("array index %d out of bounds (length: %d)", e.Index, e.Length)
+}
+
+// Is reports whether target is ErrPathNotFound or another index error
+func (e *ErrIndexOutOfBounds) Is(target error) bool {
+	_, ok := target.(*ErrIndexOutOfBounds)
+	return ok || target == ErrPathNotFound
+}
+
+// kindMismatch returns the type mismatch for a node that is not of the expected kind
+func kindMismatch(expected string, node *yaml.Node) error {
+	return &ErrTypeMismatch{Expected: expected, Actual: string(nodeKind(node))}
+}
+
+// typeMismatch returns the type mismatch for a decoded value that is not of the expected type
+func typeMismatch(expected string, value interface{}) error {
+	return &ErrTypeMismatch{Expected: expected, Actual: fmt.Sprintf("%T", value)}
+}
@@ -0,0 +1,112 @@
+package yamler
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStructuredErrors(t *testing.T) {
+	doc, err := Load("name: app\nport: 8080\nitems:\n  - a\n  - b\nserver:\n  host: localhost\nflags: [yes, maybe]\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		call     func() error
+		notFound bool
+		mismatch *ErrTypeMismatch
+		bounds   *ErrIndexOutOfBounds
+	}{
+		{
+			name:     "missing key",
+			call:     func() error { _, err := doc.Get("server.port"); return err },
+			notFound: true,
+		},
+		{
+			name:     "index out of bounds",
+			call:     func() error { _, err := doc.GetArrayElement("items", 5); return err },
+			notFound: true,
+			bounds:   &ErrIndexOutOfBounds{Index: 5, Length: 2},
+		},
+		{
+			name:     "index in mapping",
+			call:     func() error { _, err := doc.Get("server[0]"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "sequence node", Actual: "mapping"},
+		},
+		{
+			name:     "key below scalar",
+			call:     func() error { _, err := doc.Get("name.first"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "mapping node", Actual: "scalar"},
+		},
+		{
+			name:     "wrong value type",
+			call:     func() error { _, err := doc.GetInt("items"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "integer", Actual: "[]interface {}"},
+		},
+		{
+			name:     "string read as integer",
+			call:     func() error { _, err := doc.GetInt("name"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "integer", Actual: "string"},
+		},
+		{
+			name:     "string read as float",
+			call:     func() error { _, err := doc.GetFloat("name"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "float", Actual: "string"},
+		},
+		{
+			name:     "string read as boolean",
+			call:     func() error { _, err := doc.GetBool("name"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "boolean", Actual: "string"},
+		},
+		{
+			name:     "string element read as integer",
+			call:     func() error { _, err := doc.GetIntSlice("items"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "integer", Actual: "string"},
+		},
+		{
+			name:     "string element read as boolean",
+			call:     func() error { _, err := doc.GetBoolSlice("flags"); return err },
+			mismatch: &ErrTypeMismatch{Expected: "boolean", Actual: "string"},
+		},
+		{
+			name:     "remove out of bounds",
+			call:     func() error { return doc.RemoveFromArray("items", 2) },
+			notFound: true,
+			bounds:   &ErrIndexOutOfBounds{Index: 2, Length: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := errors.Is(err, ErrPathNotFound); got != tt.notFound {
+				t.Errorf("errors.Is(%v, ErrPathNotFound) = %v, want %v", err, got, tt.notFound)
+			}
+
+			var mismatch *ErrTypeMismatch
+			if got := errors.As(err, &mismatch); got != (tt.mismatch != nil) {
+				t.Fatalf("errors.As(%v, *ErrTypeMismatch) = %v", err, got)
+			}
+			if tt.mismatch != nil {
+				if *mismatch != *tt.mismatch {
+					t.Errorf("mismatch = %+v, want %+v", *mismatch, *tt.mismatch)
+				}
+				if !errors.Is(err, &ErrTypeMismatch{}) || !errors.Is(err, &ErrTypeMismatch{Expected: tt.mismatch.Expected}) {
+					t.Errorf("errors.Is(%v, &ErrTypeMismatch{}) = false", err)
+				}
+			}
+
+			var bounds *ErrIndexOutOfBounds
+			if got := errors.As(err, &bounds); got != (tt.bounds != nil) {
+				t.Fatalf("errors.As(%v, *ErrIndexOutOfBounds) = %v", err, got)
+			}
+			if tt.bounds != nil && *bounds != *tt.bounds {
+				t.Errorf("bounds = %+v, want %+v", *bounds, *tt.bounds)
+			}
+		})
+	}
+}
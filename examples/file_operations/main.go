@@ -30,6 +30,9 @@ func main() {
 		log.Fatal("Failed to create new document:", err)
 	}
 
+	// Separate top-level sections with a blank line, like hand-written config
+	doc.SetSectionSpacing(1)
+
 	// Build configuration
 	doc.Set("app.name", "MyApplication")
 	doc.Set("app.version", "1.0.0")
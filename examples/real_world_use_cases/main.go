@@ -140,7 +140,7 @@ features:
 
 	// Development overrides
 	fmt.Println("Development environment configuration:")
-	devDoc := baseDoc // Copy for development
+	devDoc := baseDoc.Clone() // Independent copy for development
 	devDoc.Set("server.port", 3000)
 	devDoc.Set("database.host", "localhost")
 	devDoc.Set("database.ssl", false)
@@ -152,7 +152,7 @@ features:
 
 	// Production overrides
 	fmt.Println("Production environment configuration:")
-	prodDoc, _ := yamler.Load(baseConfig) // Fresh copy for production
+	prodDoc := baseDoc.Clone() // Independent copy for production
 	prodDoc.Set("server.host", "0.0.0.0")
 	prodDoc.Set("server.port", 80)
 	prodDoc.Set("database.host", "prod-db.internal")
@@ -340,19 +340,14 @@ monitoring:
 	for scenario, values := range scenarios {
 		fmt.Printf("Generating configuration for %s:\n", scenario)
 
-		// Apply template values using wildcard patterns for efficiency
-		for placeholder, value := range values {
-			pattern := fmt.Sprintf("{{%s}}", placeholder)
-			// Find all occurrences and replace
-			allValues, _ := doc.GetAll("**")
-			for path, currentValue := range allValues {
-				if strValue, ok := currentValue.(string); ok {
-					if strings.Contains(strValue, pattern) {
-						newValue := strings.ReplaceAll(strValue, pattern, fmt.Sprintf("%v", value))
-						doc.Set(path, newValue)
-					}
-				}
-			}
+		// Replace placeholders; numbers and booleans keep their types
+		unresolved, err := doc.ApplyTemplate(values, yamler.TemplateOptions{})
+		if err != nil {
+			log.Printf("Failed to apply template: %v", err)
+			continue
+		}
+		if len(unresolved) > 0 {
+			fmt.Printf("Unresolved placeholders: %v\n", unresolved)
 		}
 
 		filename := fmt.Sprintf("config-%s.yaml", scenario)
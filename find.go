@@ -0,0 +1,79 @@
+package yamler
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FindValue returns the paths of all scalar values equal to v, in document order,
+// e.g. every occurrence of an image tag. v is compared the way values are read back,
+// so 8080 matches an int field and "8080" only a string.
+func (d *Document) FindValue(v interface{}) ([]string, error) {
+	node, err := interfaceToNode(v)
+	if err != nil {
+		return nil, err
+	}
+	want, err := nodeToInterface(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.FindWhere(func(_ string, value interface{}) bool {
+		return reflect.DeepEqual(value, want)
+	})
+}
+
+// FindWhere returns the paths of all scalar values for which match returns true, in document order.
+// match is called once for every scalar, with its path in the form Get accepts.
+func (d *Document) FindWhere(match func(path string, value interface{}) bool) ([]string, error) {
+	if match == nil {
+		return nil, fmt.Errorf("nil match function")
+	}
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	err = d.walkScalars(root, "", func(path string, value interface{}) {
+		if match(path, value) {
+			paths = append(paths, path)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// walkScalars calls visit with the path and value of every scalar below node, in document order.
+// Aliases of scalars are visited at their own path; aliased collections are not walked again.
+func (d *Document) walkScalars(node *yaml.Node, currentPath string, visit func(path string, value interface{})) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := d.walkScalars(node.Content[i+1], appendPathKey(currentPath, node.Content[i].Value), visit); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for idx, child := range node.Content {
+			if err := d.walkScalars(child, fmt.Sprintf("%s[%d]", currentPath, idx), visit); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		if target := resolveAlias(node); target.Kind == yaml.ScalarNode {
+			return d.walkScalars(target, currentPath, visit)
+		}
+	case yaml.ScalarNode:
+		value, err := d.toInterface(node)
+		if err != nil {
+			return err
+		}
+		visit(currentPath, value)
+	}
+	return nil
+}
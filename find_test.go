@@ -0,0 +1,104 @@
+package yamler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocument_FindValue(t *testing.T) {
+	content := `spec:
+  containers:
+    - name: web
+      image: nginx:1.19
+      port: 8080
+    - name: sidecar
+      image: envoy:1.20
+      port: "8080"
+  initContainers:
+    - name: init
+      image: nginx:1.19
+defaults:
+  image: &img nginx:1.19
+override:
+  image: *img
+`
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{
+			name:  "string in document order",
+			value: "nginx:1.19",
+			want: []string{
+				"spec.containers[0].image",
+				"spec.initContainers[0].image",
+				"defaults.image",
+				"override.image",
+			},
+		},
+		{
+			name:  "int does not match quoted string",
+			value: 8080,
+			want:  []string{"spec.containers[0].port"},
+		},
+		{
+			name:  "string does not match int",
+			value: "8080",
+			want:  []string{"spec.containers[1].port"},
+		},
+		{
+			name:  "no matches",
+			value: "redis",
+			want:  nil,
+		},
+	}
+
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.FindValue(tt.value)
+			if err != nil {
+				t.Fatalf("FindValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_FindWhere(t *testing.T) {
+	doc, err := Load(`- name: web
+  image: nginx:1.19
+- name: db
+  image: postgres:13
+  env:
+    TAG: "1.19"
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got, err := doc.FindWhere(func(path string, v interface{}) bool {
+		s, ok := v.(string)
+		return ok && strings.HasSuffix(s, "1.19")
+	})
+	if err != nil {
+		t.Fatalf("FindWhere() error = %v", err)
+	}
+	want := []string{"[0].image", "[1].env.TAG"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindWhere() = %v, want %v", got, want)
+	}
+
+	if _, err := doc.FindWhere(nil); err == nil {
+		t.Error("FindWhere(nil) should fail")
+	}
+}
@@ -115,7 +115,7 @@ func TestFieldOrderPreservation(t *testing.T) {
 			},
 			expectedOutput: `app:
   name: myapp
-  version: "2.0"
+  version: 2.0
   debug: false
   port: 9090
 `,
@@ -449,3 +449,712 @@ config:
 		})
 	}
 }
+
+// TestQuotedScalarWhitespacePreservation tests that runs of spaces and tabs inside quoted scalars survive edits
+func TestQuotedScalarWhitespacePreservation(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		key            string
+		newValue       interface{}
+		expectedOutput string
+		checkKey       string
+		checkValue     string
+	}{
+		{
+			name:           "double_quoted_spaces_sibling_edit",
+			input:          "banner: \"a    b\"\nport: 80\n",
+			key:            "port",
+			newValue:       8080,
+			expectedOutput: "banner: \"a    b\"\nport: 8080\n",
+			checkKey:       "banner",
+			checkValue:     "a    b",
+		},
+		{
+			name:           "double_quoted_tabs_sibling_edit",
+			input:          "banner: \"a \t\t b\"   # keep\nport: 80\n",
+			key:            "port",
+			newValue:       8080,
+			expectedOutput: "banner: \"a \t\t b\"   # keep\nport: 8080\n",
+			checkKey:       "banner",
+			checkValue:     "a \t\t b",
+		},
+		{
+			name:           "single_quoted_tabs_nested",
+			input:          "app:\n  motd: 'x  \t  y'\n  port: 80\n",
+			key:            "app.port",
+			newValue:       8080,
+			expectedOutput: "app:\n  motd: 'x  \t  y'\n  port: 8080\n",
+			checkKey:       "app.motd",
+			checkValue:     "x  \t  y",
+		},
+		{
+			name:           "set_value_with_spaces",
+			input:          "banner: \"x\"\nport: 80\n",
+			key:            "banner",
+			newValue:       "a     b",
+			expectedOutput: "banner: \"a     b\"\nport: 80\n",
+			checkKey:       "banner",
+			checkValue:     "a     b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if err := doc.Set(tt.key, tt.newValue); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+
+			if result != tt.expectedOutput {
+				t.Errorf("Output mismatch\nExpected:\n%q\nGot:\n%q", tt.expectedOutput, result)
+			}
+
+			reloaded, err := Load(result)
+			if err != nil {
+				t.Fatalf("Load() of output error = %v", err)
+			}
+			value, err := reloaded.GetString(tt.checkKey)
+			if err != nil {
+				t.Fatalf("GetString() error = %v", err)
+			}
+			if value != tt.checkValue {
+				t.Errorf("GetString(%s) = %q, want %q", tt.checkKey, value, tt.checkValue)
+			}
+		})
+	}
+}
+
+func TestNestedFlowObjectPreservation(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		key            string
+		newValue       interface{}
+		expectedOutput string
+	}{
+		{
+			name:           "spaced nested flow object",
+			input:          "config: { db: { host: localhost, port: 5432 } }\nother: 1\n",
+			key:            "config.db.port",
+			newValue:       6543,
+			expectedOutput: "config: { db: { host: localhost, port: 6543 } }\nother: 1\n",
+		},
+		{
+			name:           "compact nested flow object",
+			input:          "config: {db: {host: localhost, port: 5432}, cache: {ttl: 60}}\nother: 1\n",
+			key:            "config.cache.ttl",
+			newValue:       90,
+			expectedOutput: "config: {db: {host: localhost, port: 5432}, cache: {ttl: 90}}\nother: 1\n",
+		},
+		{
+			name:           "nested flow object inside block mapping",
+			input:          "outer:\n  config: {db: {host: localhost, port: 5432}}\n  x: 1\n",
+			key:            "outer.config.db.port",
+			newValue:       6543,
+			expectedOutput: "outer:\n  config: {db: {host: localhost, port: 6543}}\n  x: 1\n",
+		},
+		{
+			name:           "new key is not dropped",
+			input:          "config: { db: { host: localhost, port: 5432 } }\nother: 1\n",
+			key:            "config.cache.ttl",
+			newValue:       90,
+			expectedOutput: "config: {db: {host: localhost, port: 5432}, cache: {ttl: 90}}\nother: 1\n",
+		},
+		{
+			name:           "apostrophe inside a plain scalar",
+			input:          "obj: {msg: it's fine, n: 1}\nother: 1\n",
+			key:            "obj.n",
+			newValue:       2,
+			expectedOutput: "obj: {msg: it's fine, n: 2}\nother: 1\n",
+		},
+		{
+			name:           "apostrophes inside a nested plain scalar",
+			input:          "config: {db: {note: don't, port: 5432}, user: o'brien}\nother: 1\n",
+			key:            "config.db.port",
+			newValue:       6543,
+			expectedOutput: "config: {db: {note: don't, port: 6543}, user: o'brien}\nother: 1\n",
+		},
+		{
+			name:           "quoted value with a comma",
+			input:          "obj: {msg: 'it''s, fine', n: 1}\nother: 1\n",
+			key:            "obj.n",
+			newValue:       2,
+			expectedOutput: "obj: {msg: 'it''s, fine', n: 2}\nother: 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if err := doc.Set(tt.key, tt.newValue); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+
+			if result != tt.expectedOutput {
+				t.Errorf("Output mismatch\nExpected:\n%q\nGot:\n%q", tt.expectedOutput, result)
+			}
+		})
+	}
+}
+
+func TestMergeKeyPositionPreservation(t *testing.T) {
+	input := `defaults: &defaults
+  adapter: postgres
+  host: localhost
+
+development:
+  database: dev
+  <<: *defaults
+  port: 5432
+
+production:
+  <<: *defaults
+  database: prod
+`
+
+	tests := []struct {
+		name     string
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:     "round trip",
+			expected: input,
+		},
+		{
+			name:  "edit sibling after merge key",
+			key:   "development.port",
+			value: 6543,
+			expected: `defaults: &defaults
+  adapter: postgres
+  host: localhost
+
+development:
+  database: dev
+  <<: *defaults
+  port: 6543
+
+production:
+  <<: *defaults
+  database: prod
+`,
+		},
+		{
+			name:  "add key to mapping starting with merge key",
+			key:   "production.pool",
+			value: 10,
+			expected: `defaults: &defaults
+  adapter: postgres
+  host: localhost
+
+development:
+  database: dev
+  <<: *defaults
+  port: 5432
+
+production:
+  <<: *defaults
+  database: prod
+  pool: 10
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if tt.key != "" {
+				if err := doc.Set(tt.key, tt.value); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Output mismatch\nExpected:\n%s\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestBlockSequenceBlankLinePreservation(t *testing.T) {
+	playbook := `- name: Install nginx
+  apt:
+    name: nginx
+
+- name: Start nginx
+  service:
+    name: nginx
+    state: started
+
+
+- name: Open port
+  ufw:
+    port: 80
+`
+
+	tests := []struct {
+		name     string
+		input    string
+		edit     func(doc *Document) error
+		expected string
+	}{
+		{
+			name:     "round trip",
+			input:    playbook,
+			edit:     func(doc *Document) error { return nil },
+			expected: playbook,
+		},
+		{
+			name:  "edit task field",
+			input: playbook,
+			edit: func(doc *Document) error {
+				return doc.SetArrayElement(1, "service.state", "restarted")
+			},
+			expected: `- name: Install nginx
+  apt:
+    name: nginx
+
+- name: Start nginx
+  service:
+    name: nginx
+    state: restarted
+
+
+- name: Open port
+  ufw:
+    port: 80
+`,
+		},
+		{
+			name:  "nested task list with partial grouping",
+			input: "tasks:\n  - a\n\n  - b\n  - c\n\n  - d\nother: 1\n",
+			edit: func(doc *Document) error {
+				return doc.Set("tasks[1]", "B")
+			},
+			expected: "tasks:\n  - a\n\n  - B\n  - c\n\n  - d\nother: 1\n",
+		},
+		{
+			name:  "inserted item keeps neighbours grouped",
+			input: "tasks:\n  - a\n\n  - b\nother: 1\n",
+			edit: func(doc *Document) error {
+				return doc.InsertIntoArray("tasks", 1, "new")
+			},
+			expected: "tasks:\n  - a\n  - new\n\n  - b\nother: 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if err := tt.edit(doc); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Output mismatch\nExpected:\n%q\nGot:\n%q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestQuotedNumericStringPreservation(t *testing.T) {
+	input := `app:
+  name: "MyApp"
+  version: "1.0"      # Current version
+  zip: '01234'
+  enabled: "true"
+  port: 8080
+`
+
+	tests := []struct {
+		name     string
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:  "sibling edit keeps quotes",
+			key:   "app.port",
+			value: 9090,
+			expected: `app:
+  name: "MyApp"
+  version: "1.0"      # Current version
+  zip: '01234'
+  enabled: "true"
+  port: 9090
+`,
+		},
+		{
+			name:  "replacing a quoted version keeps quotes",
+			key:   "app.version",
+			value: "2.0",
+			expected: `app:
+  name: "MyApp"
+  version: "2.0"      # Current version
+  zip: '01234'
+  enabled: "true"
+  port: 8080
+`,
+		},
+		{
+			name:  "single quotes are kept",
+			key:   "app.zip",
+			value: "09999",
+			expected: `app:
+  name: "MyApp"
+  version: "1.0"      # Current version
+  zip: '09999'
+  enabled: "true"
+  port: 8080
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if err := doc.Set(tt.key, tt.value); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Output mismatch\nExpected:\n%s\nGot:\n%s", tt.expected, result)
+			}
+
+			reloaded, err := Load(result)
+			if err != nil {
+				t.Fatalf("Load() of output error = %v", err)
+			}
+			for _, path := range []string{"app.version", "app.zip", "app.enabled"} {
+				if typ, err := reloaded.GetType(path); err != nil || typ != TypeString {
+					t.Errorf("GetType(%s) = %v, %v, want string", path, typ, err)
+				}
+			}
+		})
+	}
+}
+
+func TestExplicitKeyPreservation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		want    interface{}
+	}{
+		{
+			name:    "top level explicit key",
+			content: "? complexkey\n: value\nother: 1\n",
+			path:    "complexkey",
+			want:    "value",
+		},
+		{
+			name:    "nested explicit key",
+			content: "config:\n  ? long key\n  : nested value\n  plain: 2\n",
+			path:    "config.long key",
+			want:    "nested value",
+		},
+		{
+			name:    "explicit key with block value",
+			content: "? key\n:\n  a: 1\n  b: [1, 2]\nname: x\n",
+			path:    "key.a",
+			want:    int64(1),
+		},
+		{
+			name:    "quoted explicit key",
+			content: "? 'it''s'\n: v # note\n",
+			path:    "it's",
+			want:    "v",
+		},
+		{
+			name:    "flow sequence key",
+			content: "? [a, b]\n: pair\nname: x\n",
+			path:    "name",
+			want:    "x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.content {
+				t.Errorf("Output mismatch\nExpected:\n%s\nGot:\n%s", tt.content, result)
+			}
+
+			got, err := doc.Get(tt.path)
+			if err != nil {
+				t.Fatalf("Get(%s) error = %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("Get(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("set keeps explicit layout", func(t *testing.T) {
+		doc, err := Load("config:\n  ? long key\n  : old\n  plain: 2\n")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if err := doc.Set("config.long key", "new"); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+
+		want := "config:\n  ? long key\n  : new\n  plain: 2\n"
+		if result, _ := doc.String(); result != want {
+			t.Errorf("Output mismatch\nExpected:\n%s\nGot:\n%s", want, result)
+		}
+	})
+}
+
+func TestTrailingCommaPreservation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edit    func(doc *Document) error
+		want    string
+	}{
+		{
+			name:    "flow array round trip",
+			content: "tags: [a, b, c,]\nports: [80,443,]\n",
+			want:    "tags: [a, b, c,]\nports: [80,443,]\n",
+		},
+		{
+			name:    "flow map round trip",
+			content: "limits: {cpu: 1, memory: 2,}\n",
+			want:    "limits: {cpu: 1, memory: 2,}\n",
+		},
+		{
+			name:    "append to flow array",
+			content: "tags: [a, b,]\n",
+			edit:    func(doc *Document) error { return doc.AppendToArray("tags", "c") },
+			want:    "tags: [a, b, c,]\n",
+		},
+		{
+			name:    "append to multiline flow array",
+			content: "tags: [\n  a,\n  b,\n]\n",
+			edit:    func(doc *Document) error { return doc.AppendToArray("tags", "c") },
+			want:    "tags: [\n  a,\n  b,\n  c,\n]\n",
+		},
+		{
+			name:    "set flow array element",
+			content: "tags: [a, b,]\n",
+			edit:    func(doc *Document) error { return doc.Set("tags[1]", "c") },
+			want:    "tags: [a, c,]\n",
+		},
+		{
+			name:    "add key to flow map",
+			content: "limits: {cpu: 1,}\n",
+			edit:    func(doc *Document) error { return doc.Set("limits.memory", 2) },
+			want:    "limits: {cpu: 1, memory: 2,}\n",
+		},
+		{
+			name:    "nested flow collections",
+			content: "matrix: [[1, 2,], [3,],]\n",
+			want:    "matrix: [[1, 2,], [3,],]\n",
+		},
+		{
+			name:    "without trailing comma",
+			content: "tags: [a, b]\n",
+			edit:    func(doc *Document) error { return doc.AppendToArray("tags", "c") },
+			want:    "tags: [a, b, c]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if tt.edit != nil {
+				if err := tt.edit(doc); err != nil {
+					t.Fatalf("edit error = %v", err)
+				}
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Output mismatch\nExpected:\n%s\nGot:\n%s", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestDocument_SetTrailingComma(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		enabled bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "add to flow array",
+			content: "tags: [a, b]\nname: x\n",
+			path:    "tags",
+			enabled: true,
+			want:    "tags: [a, b,]\nname: x\n",
+		},
+		{
+			name:    "add to flow map",
+			content: "server:\n  limits: {cpu: 1, memory: 2}\n",
+			path:    "server.limits",
+			enabled: true,
+			want:    "server:\n  limits: {cpu: 1, memory: 2,}\n",
+		},
+		{
+			name:    "remove from flow array",
+			content: "tags: [a, b,]\n",
+			path:    "tags",
+			enabled: false,
+			want:    "tags: [a, b]\n",
+		},
+		{
+			name:    "remove from flow map",
+			content: "limits: {cpu: 1, memory: 2,}\n",
+			path:    "limits",
+			enabled: false,
+			want:    "limits: {cpu: 1, memory: 2}\n",
+		},
+		{
+			name:    "empty array is left alone",
+			content: "tags: []\n",
+			path:    "tags",
+			enabled: true,
+			want:    "tags: []\n",
+		},
+		{
+			name:    "block array is left alone",
+			content: "tags:\n  - a\n",
+			path:    "tags",
+			enabled: true,
+			want:    "tags:\n  - a\n",
+		},
+		{
+			name:    "scalar",
+			content: "name: x\n",
+			path:    "name",
+			wantErr: true,
+		},
+		{
+			name:    "missing path",
+			content: "name: x\n",
+			path:    "missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.SetTrailingComma(tt.path, tt.enabled)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetTrailingComma() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Output mismatch\nExpected:\n%s\nGot:\n%s", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestNestedSequenceIndentPreservation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "sequence inside sequence item",
+			content: "plays:\n  - name: a\n    tasks:\n      - debug: 1\n  - name: b\n",
+		},
+		{
+			name:    "array root with nested sequences",
+			content: "- name: a\n  tasks:\n    - debug: 1\n    - shell: ls\n- name: b\n  hosts: all\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.content {
+				t.Errorf("Output mismatch\nExpected:\n%s\nGot:\n%s", tt.content, result)
+			}
+		})
+	}
+}
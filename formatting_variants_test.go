@@ -1012,3 +1012,65 @@ env: development
 		})
 	}
 }
+
+func TestHeaderCommentPreservation(t *testing.T) {
+	header := "# Copyright 2024 Example Corp.\n# Licensed under the MIT License.\n\n\n# Managed by config-sync, do not edit by hand.\n# Source: configs/base\n\n"
+
+	tests := []struct {
+		name  string
+		body  string
+		edit  func(doc *Document) error
+		check string
+	}{
+		{
+			name:  "deep key edit",
+			body:  "app:\n  server:\n    tls:\n      port: 443\nname: x\n",
+			edit:  func(doc *Document) error { return doc.Set("app.server.tls.port", 8443) },
+			check: "app:\n  server:\n    tls:\n      port: 8443\nname: x\n",
+		},
+		{
+			name:  "new top-level key",
+			body:  "app:\n  name: x\n",
+			edit:  func(doc *Document) error { return doc.Set("version", 2) },
+			check: "app:\n  name: x\nversion: 2\n",
+		},
+		{
+			name:  "first key replaced",
+			body:  "app:\n  name: x\nother: 1\n",
+			edit:  func(doc *Document) error { return doc.Set("app", "flat") },
+			check: "app: flat\nother: 1\n",
+		},
+		{
+			name:  "array root",
+			body:  "- name: web\n  port: 80\n",
+			edit:  func(doc *Document) error { return doc.SetArrayElement(0, "port", 81) },
+			check: "- name: web\n  port: 81\n",
+		},
+		{
+			name:  "reindented output",
+			body:  "app:\n  list:\n    - a\n",
+			edit:  func(doc *Document) error { doc.SetSequenceIndent(0); return nil },
+			check: "app:\n  list:\n  - a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(header + tt.body)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := tt.edit(doc); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if want := header + tt.check; result != want {
+				t.Errorf("Output mismatch\nExpected:\n%q\nGot:\n%q", want, result)
+			}
+		})
+	}
+}
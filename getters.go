@@ -1,36 +1,41 @@
 package yamler
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Get returns a value from the YAML document by its path
+// Paths are resolved like Set, so "[0].tasks[3].name" works in array documents.
 func (d *Document) Get(path string) (interface{}, error) {
-	root, err := d.mappingRoot()
+	node, err := d.getNode(path)
 	if err != nil {
 		return nil, err
 	}
-	if path == "" {
-		return nodeToInterface(root)
-	}
-
-	parts := strings.Split(path, ".")
-	node := root
-	for _, part := range parts {
-		node, err = navigateToNode(node, part, path)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return nodeToInterface(node)
+	return d.toInterface(node)
 }
 
 // navigateToNode navigates to a node based on the path part
 func navigateToNode(node *yaml.Node, part, fullPath string) (*yaml.Node, error) {
+	node = resolveAlias(node)
+
+	// Quoted keys may contain dots and brackets: annotations["prometheus.io/scrape"]
+	if prefix, key, ok := parseQuotedKey(part); ok {
+		if prefix != "" {
+			var err error
+			node, err = navigateToNode(node, prefix, fullPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return navigateToMapKey(node, key, fullPath)
+	}
+
 	// Check if part is an array index
 	if strings.HasSuffix(part, "]") {
 		return navigateToArrayElement(node, part, fullPath)
@@ -52,21 +57,22 @@ func navigateToArrayElement(node *yaml.Node, part, fullPath string) (*yaml.Node,
 		return nil, fmt.Errorf("path %s: invalid array index: %s", fullPath, indexStr)
 	}
 
-	// Get array node
-	if node.Kind != yaml.MappingNode {
-		return nil, fmt.Errorf("path %s: expected mapping node", fullPath)
-	}
-
-	arrayNode, found := findKeyInMapping(node, arrayName)
-	if !found {
-		return nil, fmt.Errorf("path %s: key %s not found", fullPath, arrayName)
+	// Get array node; a bare index like [0] addresses node itself, e.g. the root of an array document,
+	// and nested indices like matrix[0][1] navigate the outer index first
+	arrayNode := node
+	if arrayName != "" {
+		var err error
+		if arrayNode, err = navigateToNode(node, arrayName, fullPath); err != nil {
+			return nil, err
+		}
 	}
 
+	arrayNode = resolveAlias(arrayNode)
 	if arrayNode.Kind != yaml.SequenceNode {
-		return nil, fmt.Errorf("path %s: expected sequence node", fullPath)
+		return nil, fmt.Errorf("path %s: %w", fullPath, kindMismatch("sequence node", arrayNode))
 	}
 	if index < 0 || index >= len(arrayNode.Content) {
-		return nil, fmt.Errorf("path %s: array index out of bounds", fullPath)
+		return nil, fmt.Errorf("path %s: %w", fullPath, &ErrIndexOutOfBounds{Index: index, Length: len(arrayNode.Content)})
 	}
 
 	return arrayNode.Content[index], nil
@@ -75,12 +81,12 @@ func navigateToArrayElement(node *yaml.Node, part, fullPath string) (*yaml.Node,
 // navigateToMapKey navigates to a map key
 func navigateToMapKey(node *yaml.Node, part, fullPath string) (*yaml.Node, error) {
 	if node.Kind != yaml.MappingNode {
-		return nil, fmt.Errorf("path %s: expected mapping node", fullPath)
+		return nil, fmt.Errorf("path %s: %w", fullPath, kindMismatch("mapping node", node))
 	}
 
 	foundNode, found := findKeyInMapping(node, part)
 	if !found {
-		return nil, fmt.Errorf("path %s: key %s not found", fullPath, part)
+		return nil, fmt.Errorf("path %s: key %s %w", fullPath, part, ErrPathNotFound)
 	}
 
 	return foundNode, nil
@@ -102,10 +108,14 @@ func (d *Document) GetString(path string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return asString(path, value)
+}
 
+// asString returns the string value read from path
+func asString(path string, value interface{}) (string, error) {
 	str, ok := value.(string)
 	if !ok {
-		return "", fmt.Errorf("path %s: expected string, got %T", path, value)
+		return "", fmt.Errorf("path %s: %w", path, typeMismatch("string", value))
 	}
 
 	return str, nil
@@ -117,18 +127,22 @@ func (d *Document) GetInt(path string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	return asInt(path, value)
+}
 
+// asInt returns the integer value read from path, parsing numeric strings
+func asInt(path string, value interface{}) (int64, error) {
 	switch v := value.(type) {
 	case int64:
 		return v, nil
 	case string:
 		i, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
-			return 0, fmt.Errorf("path %s: invalid integer value: %v", path, err)
+			return 0, fmt.Errorf("path %s: %w: %w", path, typeMismatch("integer", value), err)
 		}
 		return i, nil
 	default:
-		return 0, fmt.Errorf("path %s: expected integer, got %T", path, value)
+		return 0, fmt.Errorf("path %s: %w", path, typeMismatch("integer", value))
 	}
 }
 
@@ -138,7 +152,11 @@ func (d *Document) GetFloat(path string) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
+	return asFloat(path, value)
+}
 
+// asFloat returns the float value read from path, accepting integers and numeric strings
+func asFloat(path string, value interface{}) (float64, error) {
 	switch v := value.(type) {
 	case float64:
 		return v, nil
@@ -147,11 +165,37 @@ func (d *Document) GetFloat(path string) (float64, error) {
 	case string:
 		f, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			return 0, fmt.Errorf("path %s: invalid float value: %v", path, err)
+			return 0, fmt.Errorf("path %s: %w: %w", path, typeMismatch("float", value), err)
 		}
 		return f, nil
 	default:
-		return 0, fmt.Errorf("path %s: expected float, got %T", path, value)
+		return 0, fmt.Errorf("path %s: %w", path, typeMismatch("float", value))
+	}
+}
+
+// GetNumber returns a numeric value as int64 or float64 depending on its source tag,
+// and whether it was written as a float, so that 1 and 1.0 can be told apart
+func (d *Document) GetNumber(path string) (interface{}, bool, error) {
+	node, err := d.getNode(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch node.ShortTag() {
+	case "!!int":
+		i, err := parseInt(node.Value, d.schema)
+		if err != nil {
+			return nil, false, fmt.Errorf("path %s: invalid integer value: %v", path, err)
+		}
+		return i, false, nil
+	case "!!float":
+		f, err := parseFloat(node.Value)
+		if err != nil {
+			return nil, true, fmt.Errorf("path %s: invalid float value: %v", path, err)
+		}
+		return f, true, nil
+	default:
+		return nil, false, fmt.Errorf("path %s: %w", path, &ErrTypeMismatch{Expected: "number", Actual: node.ShortTag()})
 	}
 }
 
@@ -161,7 +205,11 @@ func (d *Document) GetBool(path string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	return asBool(path, value)
+}
 
+// asBool returns the boolean value read from path, accepting strings like "yes" and "off"
+func asBool(path string, value interface{}) (bool, error) {
 	switch v := value.(type) {
 	case bool:
 		return v, nil
@@ -173,11 +221,80 @@ func (d *Document) GetBool(path string) (bool, error) {
 		case "false", "no", "0", "off":
 			return false, nil
 		default:
-			return false, fmt.Errorf("path %s: invalid boolean value: %s", path, v)
+			return false, fmt.Errorf("path %s: %w: invalid boolean value %s", path, typeMismatch("boolean", value), v)
 		}
 	default:
-		return false, fmt.Errorf("path %s: expected boolean, got %T", path, value)
+		return false, fmt.Errorf("path %s: %w", path, typeMismatch("boolean", value))
+	}
+}
+
+// GetDuration returns a duration written as a string such as "1m30s"
+func (d *Document) GetDuration(path string) (time.Duration, error) {
+	value, err := d.Get(path)
+	if err != nil {
+		return 0, err
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("path %s: %w", path, typeMismatch("duration", value))
+	}
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("path %s: invalid duration value: %v", path, err)
+	}
+	return duration, nil
+}
+
+// GetStringOr returns the string at path, or def if the path is missing or null.
+// A value of another type is still an error.
+func (d *Document) GetStringOr(path, def string) (string, error) {
+	if d.isUnset(path) {
+		return def, nil
+	}
+	return d.GetString(path)
+}
+
+// GetIntOr returns the integer at path, or def if the path is missing or null
+func (d *Document) GetIntOr(path string, def int64) (int64, error) {
+	if d.isUnset(path) {
+		return def, nil
+	}
+	return d.GetInt(path)
+}
+
+// GetFloatOr returns the float at path, or def if the path is missing or null
+func (d *Document) GetFloatOr(path string, def float64) (float64, error) {
+	if d.isUnset(path) {
+		return def, nil
+	}
+	return d.GetFloat(path)
+}
+
+// GetBoolOr returns the boolean at path, or def if the path is missing or null
+func (d *Document) GetBoolOr(path string, def bool) (bool, error) {
+	if d.isUnset(path) {
+		return def, nil
+	}
+	return d.GetBool(path)
+}
+
+// GetDurationOr returns the duration at path, or def if the path is missing or null
+func (d *Document) GetDurationOr(path string, def time.Duration) (time.Duration, error) {
+	if d.isUnset(path) {
+		return def, nil
 	}
+	return d.GetDuration(path)
+}
+
+// isUnset reports whether path is missing or holds null. Paths that cannot exist,
+// such as a key below a scalar, are not unset, so the getter reports them.
+func (d *Document) isUnset(path string) bool {
+	node, err := d.getNode(path)
+	if err != nil {
+		return errors.Is(err, ErrPathNotFound)
+	}
+	return resolveAlias(node).ShortTag() == "!!null"
 }
 
 // GetSlice returns a slice value from the YAML document
@@ -189,13 +306,15 @@ func (d *Document) GetSlice(path string) ([]interface{}, error) {
 
 	slice, ok := value.([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("path %s: expected slice, got %T", path, value)
+		return nil, fmt.Errorf("path %s: %w", path, typeMismatch("slice", value))
 	}
 
 	return slice, nil
 }
 
-// GetMap returns a map value from the YAML document
+// GetMap returns a map value from the YAML document.
+// Keys are returned in string form: 1: a gives "1", and a collection key [x]: y gives "[x]".
+// Use GetMapAny to keep the types of non-string keys.
 func (d *Document) GetMap(path string) (map[string]interface{}, error) {
 	value, err := d.Get(path)
 	if err != nil {
@@ -204,12 +323,106 @@ func (d *Document) GetMap(path string) (map[string]interface{}, error) {
 
 	m, ok := value.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("path %s: expected map, got %T", path, value)
+		return nil, fmt.Errorf("path %s: %w", path, typeMismatch("map", value))
 	}
 
 	return m, nil
 }
 
+// GetMapAny returns a map whose keys keep their YAML types, e.g. int64(1) for 1: a or true for true: b.
+// Values are converted like Get. Collection keys can't be Go map keys and return an error.
+func (d *Document) GetMapAny(path string) (map[interface{}]interface{}, error) {
+	node, err := d.getMappingNode(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[interface{}]interface{}, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := resolveAlias(node.Content[i])
+		if keyNode.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("path %s: unsupported collection key at line %d", path, keyNode.Line)
+		}
+		key, err := scalarToInterface(keyNode, d.schema)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: invalid key %s: %w", path, keyNode.Value, err)
+		}
+		value, err := d.toInterface(node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// GetStringMapString returns a mapping of scalars as strings, e.g. for environment variables.
+// Values are returned as written, so 8080 gives "8080" and 1.0 gives "1.0"; null values give "".
+// A nested mapping or sequence is an error.
+func (d *Document) GetStringMapString(path string) (map[string]string, error) {
+	node, err := d.getMappingNode(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, err := keyString(node.Content[i])
+		if err != nil {
+			return nil, err
+		}
+		value := resolveAlias(node.Content[i+1])
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("path %s: %w", appendPathKey(path, key), kindMismatch("scalar node", value))
+		}
+		if value.Tag == "!!null" {
+			result[key] = ""
+		} else {
+			result[key] = value.Value
+		}
+	}
+	return result, nil
+}
+
+// GetStringMapInt returns a mapping of integers. Numeric strings such as "8080" are parsed like GetInt does.
+func (d *Document) GetStringMapInt(path string) (map[string]int64, error) {
+	node, err := d.getMappingNode(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, err := keyString(node.Content[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.toInterface(node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		n, err := asInt(appendPathKey(path, key), value)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = n
+	}
+	return result, nil
+}
+
+// getMappingNode returns the mapping at path, following an alias
+func (d *Document) getMappingNode(path string) (*yaml.Node, error) {
+	node, err := d.getNode(path)
+	if err != nil {
+		return nil, err
+	}
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", node))
+	}
+	return node, nil
+}
+
 // GetStringSlice returns a string slice from the YAML document
 func (d *Document) GetStringSlice(path string) ([]string, error) {
 	slice, err := d.GetSlice(path)
@@ -221,7 +434,7 @@ func (d *Document) GetStringSlice(path string) ([]string, error) {
 	for i, v := range slice {
 		str, ok := v.(string)
 		if !ok {
-			return nil, fmt.Errorf("path %s: element %d is not a string", path, i)
+			return nil, fmt.Errorf("path %s: element %d: %w", path, i, typeMismatch("string", v))
 		}
 		result[i] = str
 	}
@@ -244,11 +457,11 @@ func (d *Document) GetIntSlice(path string) ([]int64, error) {
 		case string:
 			n, err := strconv.ParseInt(val, 10, 64)
 			if err != nil {
-				return nil, fmt.Errorf("path %s: element %d is not a valid integer", path, i)
+				return nil, fmt.Errorf("path %s: element %d: %w: %w", path, i, typeMismatch("integer", v), err)
 			}
 			result[i] = n
 		default:
-			return nil, fmt.Errorf("path %s: element %d is not an integer", path, i)
+			return nil, fmt.Errorf("path %s: element %d: %w", path, i, typeMismatch("integer", v))
 		}
 	}
 
@@ -272,11 +485,11 @@ func (d *Document) GetFloatSlice(path string) ([]float64, error) {
 		case string:
 			n, err := strconv.ParseFloat(val, 64)
 			if err != nil {
-				return nil, fmt.Errorf("path %s: element %d is not a valid float", path, i)
+				return nil, fmt.Errorf("path %s: element %d: %w: %w", path, i, typeMismatch("float", v), err)
 			}
 			result[i] = n
 		default:
-			return nil, fmt.Errorf("path %s: element %d is not a float", path, i)
+			return nil, fmt.Errorf("path %s: element %d: %w", path, i, typeMismatch("float", v))
 		}
 	}
 
@@ -303,10 +516,10 @@ func (d *Document) GetBoolSlice(path string) ([]bool, error) {
 			case "false", "no", "0", "off":
 				result[i] = false
 			default:
-				return nil, fmt.Errorf("path %s: element %d is not a valid boolean", path, i)
+				return nil, fmt.Errorf("path %s: element %d: %w: invalid boolean value %s", path, i, typeMismatch("boolean", v), val)
 			}
 		default:
-			return nil, fmt.Errorf("path %s: element %d is not a boolean", path, i)
+			return nil, fmt.Errorf("path %s: element %d: %w", path, i, typeMismatch("boolean", v))
 		}
 	}
 
@@ -324,10 +537,179 @@ func (d *Document) GetMapSlice(path string) ([]map[string]interface{}, error) {
 	for i, v := range slice {
 		m, ok := v.(map[string]interface{})
 		if !ok {
-			return nil, fmt.Errorf("path %s: element %d is not a map", path, i)
+			return nil, fmt.Errorf("path %s: element %d: %w", path, i, typeMismatch("map", v))
 		}
 		result[i] = m
 	}
 
 	return result, nil
 }
+
+// GetType returns the type of the value at the specified path.
+// Null values and scalars with custom tags are reported as TypeAny.
+func (d *Document) GetType(path string) (SchemaType, error) {
+	node, err := d.getNode(path)
+	if err != nil {
+		return "", err
+	}
+	return nodeSchemaType(node), nil
+}
+
+// resolveAlias returns the anchored node an alias points to, or the node itself
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// nodeSchemaType maps a YAML node to its schema type
+func nodeSchemaType(node *yaml.Node) SchemaType {
+	node = resolveAlias(node)
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return TypeArray
+	case yaml.MappingNode:
+		return TypeMap
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!str":
+			return TypeString
+		case "!!int":
+			return TypeInt
+		case "!!float":
+			return TypeFloat
+		case "!!bool":
+			return TypeBool
+		}
+	}
+	return TypeAny
+}
+
+// IsArray reports whether the value at the specified path is an array.
+// Missing paths return false.
+func (d *Document) IsArray(path string) bool {
+	t, err := d.GetType(path)
+	return err == nil && t == TypeArray
+}
+
+// IsMap reports whether the value at the specified path is a map.
+// Missing paths return false.
+func (d *Document) IsMap(path string) bool {
+	t, err := d.GetType(path)
+	return err == nil && t == TypeMap
+}
+
+// IsScalar reports whether the value at the specified path is a scalar, including null.
+// Missing paths return false.
+func (d *Document) IsScalar(path string) bool {
+	t, err := d.GetType(path)
+	return err == nil && t != TypeArray && t != TypeMap
+}
+
+// IsSorted reports whether the keys of the mapping at path are in ascending alphabetical order.
+// An empty path checks the top-level keys; the check is not recursive.
+func (d *Document) IsSorted(path string) (bool, error) {
+	node, err := d.getNode(path)
+	if err != nil {
+		return false, err
+	}
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return false, fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", node))
+	}
+
+	for i := 2; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value < node.Content[i-2].Value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Child is a direct child of a mapping or sequence, as returned by Children
+type Child struct {
+	Key  string   // Mapping key, or "[i]" for sequence items
+	Path string   // Full path of the child, usable with Get, Set and Children
+	Kind NodeKind // Shape of the child's value
+}
+
+// Keys returns the key names of the mapping at path in document order, without descending further.
+// An empty path lists the top-level keys.
+func (d *Document) Keys(path string) ([]string, error) {
+	node, err := d.nodeAt(path)
+	if err != nil {
+		return nil, err
+	}
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", node))
+	}
+
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys, nil
+}
+
+// Children returns the direct children of the mapping or sequence at path in document order,
+// with their kind, so a tree view can expand one level at a time. Scalars have no children.
+func (d *Document) Children(path string) ([]Child, error) {
+	node, err := d.nodeAt(path)
+	if err != nil {
+		return nil, err
+	}
+	node = resolveAlias(node)
+
+	var children []Child
+	switch node.Kind {
+	case yaml.MappingNode:
+		children = make([]Child, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			children = append(children, Child{Key: key, Path: appendPathKey(path, key), Kind: nodeKind(node.Content[i+1])})
+		}
+	case yaml.SequenceNode:
+		children = make([]Child, 0, len(node.Content))
+		for i, item := range node.Content {
+			key := fmt.Sprintf("[%d]", i)
+			children = append(children, Child{Key: key, Path: path + key, Kind: nodeKind(item)})
+		}
+	default:
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("mapping or sequence node", node))
+	}
+	return children, nil
+}
+
+// Exists reports whether path leads to a value, which may be null.
+// Malformed paths and paths through scalars report false as well.
+func (d *Document) Exists(path string) bool {
+	_, err := d.nodeAt(path)
+	return err == nil
+}
+
+// Kind returns whether the value at path is a scalar, a mapping, a sequence or null
+func (d *Document) Kind(path string) (NodeKind, error) {
+	node, err := d.nodeAt(path)
+	if err != nil {
+		return "", err
+	}
+	return nodeKind(node), nil
+}
+
+// nodeKind returns the shape of a node's value, following aliases
+func nodeKind(node *yaml.Node) NodeKind {
+	node = resolveAlias(node)
+	switch {
+	case node.Kind == yaml.MappingNode:
+		return KindMapping
+	case node.Kind == yaml.SequenceNode:
+		return KindSequence
+	case node.ShortTag() == "!!null":
+		return KindNull
+	default:
+		return KindScalar
+	}
+}
@@ -1,7 +1,12 @@
 package yamler
 
 import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDocument_Get(t *testing.T) {
@@ -271,6 +276,54 @@ func TestDocument_GetFloat(t *testing.T) {
 	}
 }
 
+func TestDocument_GetNumber(t *testing.T) {
+	doc, err := Load(`replicas: 1
+ratio: 1.0
+big: 0x1F
+exp: 1e3
+inf: .inf
+name: app
+quoted: "1"
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		want        interface{}
+		wantIsFloat bool
+		wantErr     bool
+	}{
+		{path: "replicas", want: int64(1)},
+		{path: "ratio", want: 1.0, wantIsFloat: true},
+		{path: "big", want: int64(31)},
+		{path: "exp", want: 1000.0, wantIsFloat: true},
+		{path: "inf", want: math.Inf(1), wantIsFloat: true},
+		{path: "name", wantErr: true},
+		{path: "quoted", wantErr: true},
+		{path: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, isFloat, err := doc.GetNumber(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetNumber() = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+			if isFloat != tt.wantIsFloat {
+				t.Errorf("GetNumber() isFloat = %v, want %v", isFloat, tt.wantIsFloat)
+			}
+		})
+	}
+}
+
 func TestDocument_GetBool(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -392,6 +445,121 @@ func TestDocument_GetBool(t *testing.T) {
 	}
 }
 
+func TestDocument_GetOrDefault(t *testing.T) {
+	doc, err := Load(`server:
+  host: example.com
+  port: 8080
+  ratio: 0.5
+  debug: true
+  timeout: 1m30s
+  proxy: ~
+workers: [a, b]
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		get     func() (interface{}, error)
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "string present",
+			get:  func() (interface{}, error) { return doc.GetStringOr("server.host", "localhost") },
+			want: "example.com",
+		},
+		{
+			name: "string missing",
+			get:  func() (interface{}, error) { return doc.GetStringOr("server.name", "app") },
+			want: "app",
+		},
+		{
+			name: "string null",
+			get:  func() (interface{}, error) { return doc.GetStringOr("server.proxy", "none") },
+			want: "none",
+		},
+		{
+			name:    "string type mismatch",
+			get:     func() (interface{}, error) { return doc.GetStringOr("server.port", "80") },
+			wantErr: true,
+		},
+		{
+			name: "int present",
+			get:  func() (interface{}, error) { return doc.GetIntOr("server.port", 80) },
+			want: int64(8080),
+		},
+		{
+			name: "int missing parent",
+			get:  func() (interface{}, error) { return doc.GetIntOr("cache.size", 64) },
+			want: int64(64),
+		},
+		{
+			name:    "int type mismatch",
+			get:     func() (interface{}, error) { return doc.GetIntOr("server.debug", 1) },
+			wantErr: true,
+		},
+		{
+			name: "float present",
+			get:  func() (interface{}, error) { return doc.GetFloatOr("server.ratio", 1) },
+			want: 0.5,
+		},
+		{
+			name: "float missing",
+			get:  func() (interface{}, error) { return doc.GetFloatOr("server.weight", 2.5) },
+			want: 2.5,
+		},
+		{
+			name: "bool present",
+			get:  func() (interface{}, error) { return doc.GetBoolOr("server.debug", false) },
+			want: true,
+		},
+		{
+			name: "bool missing",
+			get:  func() (interface{}, error) { return doc.GetBoolOr("server.tls", true) },
+			want: true,
+		},
+		{
+			name: "duration present",
+			get:  func() (interface{}, error) { return doc.GetDurationOr("server.timeout", time.Second) },
+			want: 90 * time.Second,
+		},
+		{
+			name: "duration missing",
+			get:  func() (interface{}, error) { return doc.GetDurationOr("server.idle", time.Minute) },
+			want: time.Minute,
+		},
+		{
+			name:    "duration invalid",
+			get:     func() (interface{}, error) { return doc.GetDurationOr("server.host", time.Minute) },
+			wantErr: true,
+		},
+		{
+			name: "array index out of range",
+			get:  func() (interface{}, error) { return doc.GetStringOr("workers[5]", "c") },
+			want: "c",
+		},
+		{
+			name:    "key below a scalar",
+			get:     func() (interface{}, error) { return doc.GetStringOr("server.host.name", "x") },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDocument_GetSlice(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -515,6 +683,262 @@ func TestDocument_GetMap(t *testing.T) {
 	}
 }
 
+func TestDocument_GetStringMap(t *testing.T) {
+	doc, err := Load(`env:
+  HOST: localhost
+  PORT: 8080
+  RATIO: 1.0
+  DEBUG: true
+  EMPTY:
+  QUOTED: "0755"
+ports:
+  http: 80
+  https: "443"
+  admin: 0x1F40
+mixed:
+  name: app
+  port: 8080
+nested:
+  db:
+    host: localhost
+list: [a, b]
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	t.Run("GetStringMapString", func(t *testing.T) {
+		tests := []struct {
+			path    string
+			want    map[string]string
+			wantErr bool
+		}{
+			{
+				path: "env",
+				want: map[string]string{"HOST": "localhost", "PORT": "8080", "RATIO": "1.0", "DEBUG": "true", "EMPTY": "", "QUOTED": "0755"},
+			},
+			{path: "mixed", want: map[string]string{"name": "app", "port": "8080"}},
+			{path: "nested", wantErr: true},
+			{path: "list", wantErr: true},
+			{path: "missing", wantErr: true},
+		}
+		for _, tt := range tests {
+			got, err := doc.GetStringMapString(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetStringMapString(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+				continue
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStringMapString(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("GetStringMapInt", func(t *testing.T) {
+		tests := []struct {
+			path    string
+			want    map[string]int64
+			wantErr bool
+		}{
+			{path: "ports", want: map[string]int64{"http": 80, "https": 443, "admin": 8000}},
+			{path: "mixed", wantErr: true},
+			{path: "nested", wantErr: true},
+			{path: "list", wantErr: true},
+		}
+		for _, tt := range tests {
+			got, err := doc.GetStringMapInt(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetStringMapInt(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+				continue
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStringMapInt(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("errors name the value", func(t *testing.T) {
+		if _, err := doc.GetStringMapInt("mixed"); err == nil || !strings.Contains(err.Error(), "mixed.name") {
+			t.Errorf("GetStringMapInt() error = %v, want it to name mixed.name", err)
+		}
+		_, err := doc.GetStringMapInt("nested")
+		var mismatch *ErrTypeMismatch
+		if !errors.As(err, &mismatch) || !strings.Contains(err.Error(), "nested.db") {
+			t.Errorf("GetStringMapInt() error = %v, want a type mismatch for nested.db", err)
+		}
+		if _, err := doc.GetStringMapString("nested"); err == nil || !strings.Contains(err.Error(), "nested.db") {
+			t.Errorf("GetStringMapString() error = %v, want it to name nested.db", err)
+		}
+	})
+}
+
+func TestDocument_GetType(t *testing.T) {
+	doc, err := Load(`name: app
+port: 8080
+ratio: 0.5
+debug: true
+empty: null
+tags: [a, b]
+db:
+  host: localhost
+servers:
+  - host: a
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		path       string
+		want       SchemaType
+		wantErr    bool
+		wantArray  bool
+		wantMap    bool
+		wantScalar bool
+	}{
+		{path: "name", want: TypeString, wantScalar: true},
+		{path: "port", want: TypeInt, wantScalar: true},
+		{path: "ratio", want: TypeFloat, wantScalar: true},
+		{path: "debug", want: TypeBool, wantScalar: true},
+		{path: "empty", want: TypeAny, wantScalar: true},
+		{path: "tags", want: TypeArray, wantArray: true},
+		{path: "db", want: TypeMap, wantMap: true},
+		{path: "servers[0]", want: TypeMap, wantMap: true},
+		{path: "servers[0].host", want: TypeString, wantScalar: true},
+		{path: "missing", wantErr: true},
+		{path: "tags[5]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := doc.GetType(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetType() = %q, want %q", got, tt.want)
+			}
+			if doc.IsArray(tt.path) != tt.wantArray {
+				t.Errorf("IsArray() = %v, want %v", !tt.wantArray, tt.wantArray)
+			}
+			if doc.IsMap(tt.path) != tt.wantMap {
+				t.Errorf("IsMap() = %v, want %v", !tt.wantMap, tt.wantMap)
+			}
+			if doc.IsScalar(tt.path) != tt.wantScalar {
+				t.Errorf("IsScalar() = %v, want %v", !tt.wantScalar, tt.wantScalar)
+			}
+		})
+	}
+}
+
+func TestDocument_NonStringKeys(t *testing.T) {
+	doc, err := Load(`codes:
+  1: one
+  2.5: half
+  true: enabled
+  ~: nothing
+  name: x
+pairs:
+  ? [a, b]
+  : pair
+  plain: p
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	t.Run("GetMap coerces keys to strings", func(t *testing.T) {
+		got, err := doc.GetMap("pairs")
+		if err != nil {
+			t.Fatalf("GetMap() error = %v", err)
+		}
+		want := map[string]interface{}{"[a, b]": "pair", "plain": "p"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetMap() = %v, want %v", got, want)
+		}
+
+		codes, err := doc.GetMap("codes")
+		if err != nil {
+			t.Fatalf("GetMap() error = %v", err)
+		}
+		if codes["1"] != "one" || codes["true"] != "enabled" {
+			t.Errorf("GetMap() = %v", codes)
+		}
+	})
+
+	t.Run("GetMapAny keeps key types", func(t *testing.T) {
+		got, err := doc.GetMapAny("codes")
+		if err != nil {
+			t.Fatalf("GetMapAny() error = %v", err)
+		}
+		want := map[interface{}]interface{}{
+			int64(1): "one",
+			2.5:      "half",
+			true:     "enabled",
+			nil:      "nothing",
+			"name":   "x",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetMapAny() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("GetMapAny rejects collection keys", func(t *testing.T) {
+		if _, err := doc.GetMapAny("pairs"); err == nil {
+			t.Error("GetMapAny() expected error for a collection key")
+		}
+	})
+
+	t.Run("GetMapAny on a scalar", func(t *testing.T) {
+		if _, err := doc.GetMapAny("codes.name"); err == nil {
+			t.Error("GetMapAny() expected error for a scalar")
+		}
+	})
+}
+
+func TestDocument_IsSorted(t *testing.T) {
+	doc, err := Load(`alpha: 1
+beta:
+  zeta: 1
+  eta: 2
+gamma:
+  a: 1
+  b: 2
+  b2: 3
+empty: {}
+list: [b, a]
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		want    bool
+		wantErr bool
+	}{
+		{path: "", want: false},
+		{path: "beta", want: false},
+		{path: "gamma", want: true},
+		{path: "empty", want: true},
+		{path: "list", wantErr: true},
+		{path: "alpha", wantErr: true},
+		{path: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := doc.IsSorted(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsSorted() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IsSorted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // Helper function to compare values deeply
 func deepEqual(a, b interface{}) bool {
 	switch v := a.(type) {
@@ -544,3 +968,169 @@ func deepEqual(a, b interface{}) bool {
 		return a == b
 	}
 }
+
+func TestDocument_Keys(t *testing.T) {
+	doc, err := Load(`zeta: 1
+alpha:
+  b: 2
+  a: 1
+defaults: &defaults
+  retries: 3
+service: *defaults
+list: [1, 2]
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "", want: []string{"zeta", "alpha", "defaults", "service", "list"}},
+		{path: "alpha", want: []string{"b", "a"}},
+		{path: "service", want: []string{"retries"}},
+		{path: "list", wantErr: true},
+		{path: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := doc.Keys(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Keys(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Keys(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDocument_Children(t *testing.T) {
+	doc, err := Load(`name: app
+db:
+  host: localhost
+  password: ~
+annotations:
+  prometheus.io/port: "9090"
+ports:
+  - 80
+  - name: https
+    port: 443
+  - [a, b]
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		want    []Child
+		wantErr bool
+	}{
+		{
+			path: "",
+			want: []Child{
+				{Key: "name", Path: "name", Kind: KindScalar},
+				{Key: "db", Path: "db", Kind: KindMapping},
+				{Key: "annotations", Path: "annotations", Kind: KindMapping},
+				{Key: "ports", Path: "ports", Kind: KindSequence},
+			},
+		},
+		{
+			path: "db",
+			want: []Child{
+				{Key: "host", Path: "db.host", Kind: KindScalar},
+				{Key: "password", Path: "db.password", Kind: KindNull},
+			},
+		},
+		{
+			path: "annotations",
+			want: []Child{{Key: "prometheus.io/port", Path: `annotations["prometheus.io/port"]`, Kind: KindScalar}},
+		},
+		{
+			path: "ports",
+			want: []Child{
+				{Key: "[0]", Path: "ports[0]", Kind: KindScalar},
+				{Key: "[1]", Path: "ports[1]", Kind: KindMapping},
+				{Key: "[2]", Path: "ports[2]", Kind: KindSequence},
+			},
+		},
+		{path: "name", wantErr: true},
+		{path: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := doc.Children(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Children(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Children(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+		// Child paths lead back into the document
+		for _, child := range got {
+			if _, err := doc.Get(child.Path); err != nil {
+				t.Errorf("Get(%q) error = %v", child.Path, err)
+			}
+		}
+	}
+
+	arrayDoc, err := Load("- a\n- b: 1\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []Child{{Key: "[0]", Path: "[0]", Kind: KindScalar}, {Key: "[1]", Path: "[1]", Kind: KindMapping}}
+	if got, err := arrayDoc.Children(""); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Children() of array document = %v, %v; want %v", got, err, want)
+	}
+}
+
+func TestDocument_ExistsAndKind(t *testing.T) {
+	doc, err := Load(`name: app
+password: null
+empty:
+db: &db
+  host: localhost
+replica: *db
+ports: [80, 443]
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		exists bool
+		kind   NodeKind
+	}{
+		{path: "", exists: true, kind: KindMapping},
+		{path: "name", exists: true, kind: KindScalar},
+		{path: "password", exists: true, kind: KindNull},
+		{path: "empty", exists: true, kind: KindNull},
+		{path: "db", exists: true, kind: KindMapping},
+		{path: "replica", exists: true, kind: KindMapping},
+		{path: "replica.host", exists: true, kind: KindScalar},
+		{path: "ports", exists: true, kind: KindSequence},
+		{path: "ports[1]", exists: true, kind: KindScalar},
+		{path: "ports[2]", exists: false},
+		{path: "missing", exists: false},
+		{path: "name.first", exists: false},
+		{path: "db.missing", exists: false},
+	}
+	for _, tt := range tests {
+		if got := doc.Exists(tt.path); got != tt.exists {
+			t.Errorf("Exists(%q) = %v, want %v", tt.path, got, tt.exists)
+		}
+		kind, err := doc.Kind(tt.path)
+		if !tt.exists {
+			if err == nil {
+				t.Errorf("Kind(%q) = %v, want error", tt.path, kind)
+			}
+			continue
+		}
+		if err != nil || kind != tt.kind {
+			t.Errorf("Kind(%q) = %v, %v; want %v", tt.path, kind, err, tt.kind)
+		}
+	}
+}
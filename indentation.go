@@ -0,0 +1,216 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FixIndentation normalizes inconsistent indentation so that every nesting level
+// uses the detected base width, e.g. a section indented by 4 in a 2-space file.
+// It returns one report entry per changed line; the global width is not changed.
+func (d *Document) FixIndentation() ([]string, error) {
+	if err := d.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := d.syncRaw(); err != nil {
+		return nil, err
+	}
+	if d.raw == "" {
+		return nil, nil
+	}
+
+	info := d.formattingInfo()
+	if info.UseTabs {
+		return nil, fmt.Errorf("tab indentation is not supported")
+	}
+	base := info.IndentSize
+	if base <= 0 {
+		base = 2
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(d.raw), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	lines := strings.Split(d.raw, "\n")
+	targets := make(map[int]int) // line index -> target indentation
+	collectIndentTargets(&root, 0, base, -1, lines, targets)
+	report := reindentLines(lines, targets)
+
+	if len(report) == 0 {
+		return nil, nil
+	}
+
+	fixed, err := Load(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload fixed YAML: %w", err)
+	}
+	d.typeLike(fixed)
+	d.root = fixed.root
+	d.schemaTags = fixed.schemaTags
+	d.raw = fixed.raw
+	if fixed.formattingCache != nil {
+		fixed.formattingCache.SequenceIndent = info.SequenceIndent
+		fixed.formattingCache.LineEnding = info.LineEnding
+		fixed.formattingCache.HasBOM = info.HasBOM
+	}
+	d.formattingCache = fixed.formattingCache
+	d.sequenceSpacing = fixed.sequenceSpacing
+	d.explicitKeys = fixed.explicitKeys
+	d.trailingCommas = fixed.trailingCommas
+	d.header = fixed.header
+	d.markChanged()
+
+	return report, nil
+}
+
+// reindentLines moves every line to its target indentation and returns one report entry per changed line.
+// Lines without a target (comments, block scalar content) move along with the structure around them.
+func reindentLines(lines []string, targets map[int]int) []string {
+	var report []string
+	delta := 0
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			continue
+		}
+		current := len(line) - len(trimmed)
+
+		target, structural := targets[i]
+		switch {
+		case structural:
+			delta = target - current
+		case strings.HasPrefix(trimmed, "#"):
+			// Comments follow the line they describe when they were aligned with it
+			target = current + delta
+			for j := i + 1; j < len(lines); j++ {
+				if next, ok := targets[j]; ok {
+					if indentOf(lines[j]) == current {
+						target = next
+					}
+					break
+				}
+			}
+		default:
+			// Block scalar and multi-line flow content moves with its key
+			target = current + delta
+		}
+		if target < 0 {
+			target = 0
+		}
+
+		if target != current {
+			lines[i] = strings.Repeat(" ", target) + trimmed
+			report = append(report, fmt.Sprintf("line %d: indent %d -> %d", i+1, current, target))
+		}
+	}
+	return report
+}
+
+// collectIndentTargets records the target indentation of every line that starts a block node.
+// seqIndent places the dashes of sequences under a key; -1 keeps each sequence's own layout.
+func collectIndentTargets(node *yaml.Node, target, base, seqIndent int, lines []string, targets map[int]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectIndentTargets(child, 0, base, seqIndent, lines, targets)
+		}
+	case yaml.MappingNode:
+		if node.Style&yaml.FlowStyle != 0 {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			value := node.Content[i+1]
+			if startsLine(lines, key) {
+				targets[key.Line-1] = target
+			}
+
+			if value.Line <= key.Line || value.Style&yaml.FlowStyle != 0 {
+				continue
+			}
+			switch value.Kind {
+			case yaml.MappingNode:
+				collectIndentTargets(value, target+base, base, seqIndent, lines, targets)
+			case yaml.SequenceNode:
+				switch {
+				case seqIndent >= 0:
+					collectIndentTargets(value, target+seqIndent, base, seqIndent, lines, targets)
+				case value.Column == key.Column:
+					// Keep sequences that sit at their key's column (zero-indent style) there
+					collectIndentTargets(value, target, base, seqIndent, lines, targets)
+				default:
+					collectIndentTargets(value, target+base, base, seqIndent, lines, targets)
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		if node.Style&yaml.FlowStyle != 0 {
+			return
+		}
+		dashColumn := node.Column
+		for _, item := range node.Content {
+			// Find the "-" line the item belongs to; content may start on the next line
+			dashLine := item.Line - 1
+			for dashLine >= 0 && !strings.HasPrefix(strings.TrimLeft(lines[dashLine], " "), "-") {
+				dashLine--
+			}
+			if dashLine < 0 {
+				continue
+			}
+			targets[dashLine] = target
+
+			if item.Kind == yaml.MappingNode || item.Kind == yaml.SequenceNode {
+				// Item content keeps its offset from the dash
+				collectIndentTargets(item, target+item.Column-dashColumn, base, seqIndent, lines, targets)
+			}
+		}
+	}
+}
+
+// startsLine checks if the node is the first token on its line
+func startsLine(lines []string, node *yaml.Node) bool {
+	if node.Line < 1 || node.Line > len(lines) {
+		return false
+	}
+	return indentOf(lines[node.Line-1]) == node.Column-1
+}
+
+// indentOf returns the number of leading spaces of a line
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// SetSequenceIndent sets how far the "-" of block sequences is indented relative to their key,
+// independently of the mapping indentation: 0 gives "key:\n- item", 2 gives "key:\n  - item".
+// A negative value restores the document's original sequence layout.
+func (d *Document) SetSequenceIndent(n int) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if n < 0 {
+		n = -1
+	}
+	d.pinned = nil
+	d.formattingInfo().SequenceIndent = n
+	return nil
+}
+
+// applySequenceIndent re-indents rendered content so that mappings nest by base spaces and the
+// dashes of block sequences sit seqIndent columns right of their key
+func applySequenceIndent(content []byte, base, seqIndent int) []byte {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return content
+	}
+
+	lines := strings.Split(string(content), "\n")
+	targets := make(map[int]int)
+	collectIndentTargets(&root, 0, base, seqIndent, lines, targets)
+	reindentLines(lines, targets)
+
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,251 @@
+package yamler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocument_FixIndentation(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantReport []string
+		want       string
+		wantValues map[string]interface{}
+	}{
+		{
+			name: "mixed widths at the same level",
+			content: `app:
+  name: web
+  db:
+      host: localhost
+      # Default port
+      port: 5432
+cache:
+    ttl: 60
+`,
+			wantReport: []string{
+				"line 4: indent 6 -> 4",
+				"line 5: indent 6 -> 4",
+				"line 6: indent 6 -> 4",
+				"line 8: indent 4 -> 2",
+			},
+			want: `app:
+  name: web
+  db:
+    host: localhost
+    # Default port
+    port: 5432
+cache:
+  ttl: 60
+`,
+		},
+		{
+			name: "sequence items keep their offset",
+			content: `servers:
+  - name: a
+    port: 80
+jobs:
+    - name: build
+      steps:
+          - run: make
+`,
+			wantReport: []string{
+				"line 5: indent 4 -> 2",
+				"line 6: indent 6 -> 4",
+				"line 7: indent 10 -> 6",
+			},
+			wantValues: map[string]interface{}{
+				"servers[0].port":      int64(80),
+				"jobs[0].name":         "build",
+				"jobs[0].steps[0].run": "make",
+			},
+		},
+		{
+			name:       "already consistent",
+			content:    "app:\n  name: web\n  db:\n    host: localhost\n",
+			wantReport: nil,
+			want:       "app:\n  name: web\n  db:\n    host: localhost\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			report, err := doc.FixIndentation()
+			if err != nil {
+				t.Fatalf("FixIndentation() error = %v", err)
+			}
+			if len(report) != len(tt.wantReport) {
+				t.Fatalf("FixIndentation() report = %q, want %q", report, tt.wantReport)
+			}
+			for i := range report {
+				if report[i] != tt.wantReport[i] {
+					t.Errorf("report[%d] = %q, want %q", i, report[i], tt.wantReport[i])
+				}
+			}
+
+			for path, want := range tt.wantValues {
+				got, err := doc.Get(path)
+				if err != nil {
+					t.Fatalf("Get(%s) error = %v", path, err)
+				}
+				if got != want {
+					t.Errorf("Get(%s) = %v, want %v", path, got, want)
+				}
+			}
+			if tt.want == "" {
+				return
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetSequenceIndent(t *testing.T) {
+	content2 := `server:
+  ports:
+    - 80
+  hosts:
+    - name: a
+      tags:
+        - x
+  mode: fast # comment
+list:
+  - 1
+`
+	content4 := `server:
+    ports:
+        - 80
+    hosts:
+        - name: a
+          tags:
+              - x
+    mode: fast # comment
+list:
+    - 1
+`
+
+	tests := []struct {
+		name      string
+		content   string
+		seqIndent int
+		want      string
+	}{
+		{
+			name:      "map 2 sequence 0",
+			content:   content2,
+			seqIndent: 0,
+			want: `server:
+  ports:
+  - 80
+  hosts:
+  - name: a
+    tags:
+    - x
+  mode: fast # comment
+list:
+- 1
+`,
+		},
+		{
+			name:      "map 2 sequence 2",
+			content:   content2,
+			seqIndent: 2,
+			want:      content2,
+		},
+		{
+			name:      "map 2 sequence 4",
+			content:   content2,
+			seqIndent: 4,
+			want: `server:
+  ports:
+      - 80
+  hosts:
+      - name: a
+        tags:
+            - x
+  mode: fast # comment
+list:
+    - 1
+`,
+		},
+		{
+			name:      "map 4 sequence 0",
+			content:   content4,
+			seqIndent: 0,
+			want: `server:
+    ports:
+    - 80
+    hosts:
+    - name: a
+      tags:
+      - x
+    mode: fast # comment
+list:
+- 1
+`,
+		},
+		{
+			name:      "map 4 sequence 2",
+			content:   content4,
+			seqIndent: 2,
+			want: `server:
+    ports:
+      - 80
+    hosts:
+      - name: a
+        tags:
+          - x
+    mode: fast # comment
+list:
+  - 1
+`,
+		},
+		{
+			name:      "map 4 sequence 4",
+			content:   content4,
+			seqIndent: 4,
+			want:      content4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			doc.SetSequenceIndent(tt.seqIndent)
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() =\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// Edits keep the configured layout
+			if err := doc.Set("server.mode", "slow"); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			got, _ = doc.String()
+			want := strings.Replace(tt.want, "mode: fast", "mode: slow", 1)
+			if got != want {
+				t.Errorf("String() after Set =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
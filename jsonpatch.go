@@ -0,0 +1,525 @@
+package yamler
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonPatchOp is one operation of a JSON Patch document
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies a JSON Patch (RFC 6902) document: a JSON array of add, remove, replace,
+// move, copy and test operations whose JSON Pointer paths such as "/spec/containers/0/image"
+// address the YAML document. Comments and formatting of untouched entries are kept, and moved or
+// copied values keep their own. The patch is atomic: if any operation fails, including a test,
+// the document is left unchanged.
+func (d *Document) ApplyJSONPatch(patch []byte) error {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("invalid JSON patch: %w", err)
+	}
+
+	return d.atomically(func() error {
+		for i, op := range ops {
+			if err := d.applyPatchOp(op); err != nil {
+				return fmt.Errorf("patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DiffAsJSONPatch returns an RFC 6902 JSON Patch that turns this document's data into other's,
+// the inverse of ApplyJSONPatch. Changed scalars become replace operations, missing and extra
+// keys remove and add operations, and arrays are compared index by index. Comments and
+// formatting are not part of the patch.
+func (d *Document) DiffAsJSONPatch(other *Document) ([]byte, error) {
+	if other == nil {
+		return nil, fmt.Errorf("other document is nil")
+	}
+	var from, to *yaml.Node
+	if len(d.root.Content) > 0 {
+		from = d.root.Content[0]
+	}
+	if len(other.root.Content) > 0 {
+		to = other.root.Content[0]
+	}
+
+	ops := []jsonPatchOp{}
+	if err := diffNodes("", from, to, &ops); err != nil {
+		return nil, err
+	}
+	return json.Marshal(ops)
+}
+
+// diffNodes appends the operations that turn from into to at pointer
+func diffNodes(pointer string, from, to *yaml.Node, ops *[]jsonPatchOp) error {
+	if from == nil || to == nil {
+		if from == to {
+			return nil
+		}
+		value := json.RawMessage("null")
+		if to != nil {
+			var err error
+			if value, err = nodeJSON(to); err != nil {
+				return err
+			}
+		}
+		*ops = append(*ops, jsonPatchOp{Op: "replace", Path: pointer, Value: value})
+		return nil
+	}
+
+	from, to = resolveAlias(from), resolveAlias(to)
+	switch {
+	case from.Kind == yaml.MappingNode && to.Kind == yaml.MappingNode:
+		return diffMappings(pointer, from, to, ops)
+	case from.Kind == yaml.SequenceNode && to.Kind == yaml.SequenceNode:
+		return diffSequences(pointer, from, to, ops)
+	}
+
+	fromData, err := jsonData(from)
+	if err != nil {
+		return err
+	}
+	toData, err := jsonData(to)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(fromData, toData) {
+		return nil
+	}
+	value, err := nodeJSON(to)
+	if err != nil {
+		return err
+	}
+	*ops = append(*ops, jsonPatchOp{Op: "replace", Path: pointer, Value: value})
+	return nil
+}
+
+// diffMappings compares two mappings: keys of from in order, then the keys only to has
+func diffMappings(pointer string, from, to *yaml.Node, ops *[]jsonPatchOp) error {
+	for i := 0; i+1 < len(from.Content); i += 2 {
+		key := from.Content[i].Value
+		child := pointer + "/" + escapeJSONPointer(key)
+		value, found := findKeyInMapping(to, key)
+		if !found {
+			*ops = append(*ops, jsonPatchOp{Op: "remove", Path: child})
+			continue
+		}
+		if err := diffNodes(child, from.Content[i+1], value, ops); err != nil {
+			return err
+		}
+	}
+	for i := 0; i+1 < len(to.Content); i += 2 {
+		key := to.Content[i].Value
+		if _, found := findKeyInMapping(from, key); found {
+			continue
+		}
+		value, err := nodeJSON(to.Content[i+1])
+		if err != nil {
+			return err
+		}
+		*ops = append(*ops, jsonPatchOp{Op: "add", Path: pointer + "/" + escapeJSONPointer(key), Value: value})
+	}
+	return nil
+}
+
+// diffSequences compares two sequences index by index, removing extra items from the end first
+func diffSequences(pointer string, from, to *yaml.Node, ops *[]jsonPatchOp) error {
+	common := len(from.Content)
+	if len(to.Content) < common {
+		common = len(to.Content)
+	}
+	for i := 0; i < common; i++ {
+		if err := diffNodes(fmt.Sprintf("%s/%d", pointer, i), from.Content[i], to.Content[i], ops); err != nil {
+			return err
+		}
+	}
+	for i := len(from.Content) - 1; i >= common; i-- {
+		*ops = append(*ops, jsonPatchOp{Op: "remove", Path: fmt.Sprintf("%s/%d", pointer, i)})
+	}
+	for i := common; i < len(to.Content); i++ {
+		value, err := nodeJSON(to.Content[i])
+		if err != nil {
+			return err
+		}
+		*ops = append(*ops, jsonPatchOp{Op: "add", Path: fmt.Sprintf("%s/%d", pointer, i), Value: value})
+	}
+	return nil
+}
+
+// nodeJSON encodes a node as JSON, keeping the key order of mappings
+func nodeJSON(node *yaml.Node) (json.RawMessage, error) {
+	node = resolveAlias(node)
+	var buf strings.Builder
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return nil, err
+			}
+			value, err := nodeJSON(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			value, err := nodeJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := jsonData(node)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encoded)
+	}
+	return json.RawMessage(buf.String()), nil
+}
+
+// escapeJSONPointer escapes a mapping key for use as a JSON Pointer reference token
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// applyPatchOp applies a single JSON Patch operation
+func (d *Document) applyPatchOp(op jsonPatchOp) error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("missing value")
+		}
+		value, err := jsonValueNode(op.Value)
+		if err != nil {
+			return err
+		}
+		switch op.Op {
+		case "add":
+			return d.patchAdd(op.Path, value, nil)
+		case "replace":
+			return d.patchReplace(op.Path, value)
+		}
+		return d.patchTest(op.Path, value)
+	case "remove":
+		_, err := d.patchRemove(op.Path)
+		return err
+	case "move":
+		if op.From == op.Path {
+			_, err := d.resolvePointer(op.From)
+			return err
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		loc, err := d.resolvePointer(op.From)
+		if err != nil {
+			return err
+		}
+		key := loc.key()
+		if _, err := d.patchRemove(op.From); err != nil {
+			return err
+		}
+		return d.patchAdd(op.Path, loc.value, key)
+	case "copy":
+		loc, err := d.resolvePointer(op.From)
+		if err != nil {
+			return err
+		}
+		return d.patchAdd(op.Path, cloneSubtree(loc.value), loc.key())
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// patchLocation is the node a JSON Pointer refers to, with the collection holding it
+type patchLocation struct {
+	parent *yaml.Node // nil for the document root
+	token  string     // last reference token of the pointer
+	path   string     // the pointer as a yamler path, for errors
+	value  *yaml.Node // nil if the pointer names a missing mapping key or array position
+	index  int        // position of value in a parent sequence, or of its key in a parent mapping
+}
+
+// key returns the key node of a mapping entry, or nil for array elements and the root
+func (l *patchLocation) key() *yaml.Node {
+	if l.parent == nil || l.parent.Kind != yaml.MappingNode || l.value == nil {
+		return nil
+	}
+	return l.parent.Content[l.index]
+}
+
+// locatePointer resolves every token of a JSON Pointer but the last and looks the last one up.
+// The target itself may be missing, which add uses to create it.
+func (d *Document) locatePointer(pointer string) (*patchLocation, error) {
+	tokens, err := parseJSONPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.root.Content) == 0 {
+		return nil, fmt.Errorf("empty document")
+	}
+	if len(tokens) == 0 {
+		return &patchLocation{value: d.root.Content[0]}, nil
+	}
+
+	node, path := d.root.Content[0], ""
+	for _, token := range tokens[:len(tokens)-1] {
+		loc, err := locateChild(node, token, path)
+		if err != nil {
+			return nil, err
+		}
+		if loc.value == nil {
+			return nil, loc.missing()
+		}
+		node, path = loc.value, loc.path
+	}
+	return locateChild(node, tokens[len(tokens)-1], path)
+}
+
+// resolvePointer resolves a JSON Pointer to an existing node
+func (d *Document) resolvePointer(pointer string) (*patchLocation, error) {
+	loc, err := d.locatePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if loc.value == nil {
+		return nil, loc.missing()
+	}
+	return loc, nil
+}
+
+// missing returns the error for a location whose value does not exist
+func (l *patchLocation) missing() error {
+	if l.parent.Kind == yaml.SequenceNode {
+		return fmt.Errorf("path %s: %w", l.path, &ErrIndexOutOfBounds{Index: l.index, Length: len(l.parent.Content)})
+	}
+	return fmt.Errorf("path %s: key %s %w", l.path, l.token, ErrPathNotFound)
+}
+
+// locateChild looks up one reference token in a mapping or sequence
+func locateChild(node *yaml.Node, token, path string) (*patchLocation, error) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		loc := &patchLocation{parent: node, token: token, path: appendPathKey(path, token)}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == token {
+				loc.value, loc.index = node.Content[i+1], i
+				break
+			}
+		}
+		return loc, nil
+	case yaml.SequenceNode:
+		loc := &patchLocation{parent: node, token: token, path: path + "[" + token + "]", index: len(node.Content)}
+		if token == "-" {
+			return loc, nil
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+			return nil, fmt.Errorf("path %s: invalid array index %q", path, token)
+		}
+		loc.index = index
+		if index < len(node.Content) {
+			loc.value = node.Content[index]
+		}
+		return loc, nil
+	default:
+		return nil, fmt.Errorf("path %s: %w", appendPathKey(path, token), kindMismatch("mapping or sequence node", node))
+	}
+}
+
+// patchAdd adds value at pointer: it replaces an existing mapping value, inserts into a sequence
+// before the given index or appends for "-". key carries the comments of a moved or copied entry.
+func (d *Document) patchAdd(pointer string, value, key *yaml.Node) error {
+	loc, err := d.locatePointer(pointer)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case loc.parent == nil:
+		d.root.Content[0] = value
+		d.arrayRoot = value.Kind == yaml.SequenceNode
+	case loc.parent.Kind == yaml.MappingNode && loc.value != nil:
+		replaceEntryValue(loc.parent.Content[loc.index], loc.value, value)
+		loc.parent.Content[loc.index+1] = value
+	case loc.parent.Kind == yaml.MappingNode:
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: loc.token}
+		if key != nil {
+			copyKeyComments(keyNode, key)
+		}
+		loc.parent.Content = append(loc.parent.Content, keyNode, value)
+	default:
+		if loc.index > len(loc.parent.Content) {
+			return fmt.Errorf("path %s: %w", loc.path, &ErrIndexOutOfBounds{Index: loc.index, Length: len(loc.parent.Content)})
+		}
+		content := append([]*yaml.Node(nil), loc.parent.Content[:loc.index]...)
+		content = append(content, value)
+		loc.parent.Content = append(content, loc.parent.Content[loc.index:]...)
+	}
+	return nil
+}
+
+// patchReplace replaces the existing value at pointer, keeping the comments of its entry
+func (d *Document) patchReplace(pointer string, value *yaml.Node) error {
+	loc, err := d.resolvePointer(pointer)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case loc.parent == nil:
+		d.root.Content[0] = value
+		d.arrayRoot = value.Kind == yaml.SequenceNode
+	case loc.parent.Kind == yaml.MappingNode:
+		replaceEntryValue(loc.parent.Content[loc.index], loc.value, value)
+		loc.parent.Content[loc.index+1] = value
+	default:
+		replaceEntryValue(nil, loc.value, value)
+		loc.parent.Content[loc.index] = value
+	}
+	return nil
+}
+
+// patchRemove removes the existing value at pointer and returns its location
+func (d *Document) patchRemove(pointer string) (*patchLocation, error) {
+	loc, err := d.resolvePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if loc.parent == nil {
+		return nil, fmt.Errorf("cannot remove the document root")
+	}
+
+	removed := map[*yaml.Node]bool{loc.value: true}
+	if err := checkAnchorsUnused(d.root, removed, loc.path); err != nil {
+		return nil, err
+	}
+	removeNodes(loc.parent, removed, d.formattingCache)
+	return loc, nil
+}
+
+// patchTest checks that the value at pointer equals value as JSON data
+func (d *Document) patchTest(pointer string, value *yaml.Node) error {
+	loc, err := d.resolvePointer(pointer)
+	if err != nil {
+		return err
+	}
+	got, err := jsonData(loc.value)
+	if err != nil {
+		return err
+	}
+	want, err := jsonData(value)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("path %s: test failed: value is %s", loc.path, formatJSONData(got))
+	}
+	return nil
+}
+
+// replaceEntryValue moves the comments and quoting of an old value to its replacement, like Set
+func replaceEntryValue(key, old, value *yaml.Node) {
+	preserveQuoteStyle(old, value)
+	value.HeadComment = old.HeadComment
+	value.LineComment = old.LineComment
+	value.FootComment = old.FootComment
+	if key != nil {
+		keepLineCommentOnKey(key, value)
+	}
+}
+
+// parseJSONPointer splits a JSON Pointer (RFC 6901) into unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// jsonValueNode parses a JSON value into a YAML node with block style and plain scalars,
+// so that it is written in the style of the surrounding document
+func jsonValueNode(raw json.RawMessage) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("invalid value %s", raw)
+	}
+	value := doc.Content[0]
+	clearNodeStyles(value)
+	return value, nil
+}
+
+// clearNodeStyles resets the style and position of a parsed node tree
+func clearNodeStyles(node *yaml.Node) {
+	node.Style = 0
+	node.Line, node.Column = 0, 0
+	for _, child := range node.Content {
+		clearNodeStyles(child)
+	}
+}
+
+// jsonData converts a node to the value it has as JSON, so numbers compare by value
+func jsonData(node *yaml.Node) (interface{}, error) {
+	value, err := nodeToInterface(node)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("value is not representable as JSON: %w", err)
+	}
+	var data interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// formatJSONData renders JSON data for error messages
+func formatJSONData(data interface{}) string {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Sprint(data)
+	}
+	return string(encoded)
+}
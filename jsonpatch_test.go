@@ -0,0 +1,225 @@
+package yamler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const jsonPatchInput = `# deployment
+spec:
+  replicas: 2 # scaled by HPA
+  containers:
+    - name: app
+      image: "app:1.0"
+    - name: sidecar
+      image: proxy:2
+  # rollout settings
+  strategy:
+    type: RollingUpdate
+labels:
+  team: core
+`
+
+func TestDocument_ApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "replace keeps comments and quoting",
+			patch: `[{"op": "replace", "path": "/spec/replicas", "value": 5}, {"op": "replace", "path": "/spec/containers/0/image", "value": "app:1.1"}]`,
+			want:  strings.NewReplacer("replicas: 2", "replicas: 5", `"app:1.0"`, `"app:1.1"`).Replace(jsonPatchInput),
+		},
+		{
+			name:  "add key with object value in patch order",
+			patch: `[{"op": "add", "path": "/labels/tier", "value": {"name": "web", "critical": true, "level": "1"}}]`,
+			want:  jsonPatchInput + "  tier:\n    name: web\n    critical: true\n    level: \"1\"\n",
+		},
+		{
+			name:  "add inserts into array and appends with -",
+			patch: `[{"op": "add", "path": "/spec/containers/1", "value": {"name": "init"}}, {"op": "add", "path": "/spec/containers/-", "value": {"name": "last"}}]`,
+			want: strings.Replace(jsonPatchInput, "    - name: sidecar\n      image: proxy:2\n",
+				"    - name: init\n    - name: sidecar\n      image: proxy:2\n    - name: last\n", 1),
+		},
+		{
+			name:  "remove",
+			patch: `[{"op": "remove", "path": "/spec/containers/1"}, {"op": "remove", "path": "/labels/team"}]`,
+			want: strings.NewReplacer("    - name: sidecar\n      image: proxy:2\n", "",
+				"labels:\n  team: core\n", "labels: {}\n").Replace(jsonPatchInput),
+		},
+		{
+			name:  "move keeps the entry's comments",
+			patch: `[{"op": "move", "from": "/spec/strategy", "path": "/strategy"}]`,
+			want: strings.Replace(jsonPatchInput, "  # rollout settings\n  strategy:\n    type: RollingUpdate\n", "", 1) +
+				"# rollout settings\nstrategy:\n  type: RollingUpdate\n",
+		},
+		{
+			name:  "copy",
+			patch: `[{"op": "copy", "from": "/labels", "path": "/spec/selector"}]`,
+			want:  strings.Replace(jsonPatchInput, "    type: RollingUpdate\n", "    type: RollingUpdate\n  selector:\n    team: core\n", 1),
+		},
+		{
+			name:  "test passes with numerically equal value",
+			patch: `[{"op": "test", "path": "/spec/replicas", "value": 2.0}, {"op": "replace", "path": "/labels/team", "value": "infra"}]`,
+			want:  strings.Replace(jsonPatchInput, "team: core", "team: infra", 1),
+		},
+		{
+			name:  "escaped pointer tokens",
+			patch: `[{"op": "add", "path": "/labels/app.kubernetes.io~1name", "value": "web"}]`,
+			want:  jsonPatchInput + "  app.kubernetes.io/name: web\n",
+		},
+		{
+			name:    "failed test leaves document unchanged",
+			patch:   `[{"op": "replace", "path": "/spec/replicas", "value": 9}, {"op": "test", "path": "/labels/team", "value": "infra"}]`,
+			wantErr: `test failed: value is "core"`,
+		},
+		{
+			name:    "replace of missing key",
+			patch:   `[{"op": "replace", "path": "/spec/paused", "value": true}]`,
+			wantErr: "path spec.paused: key paused not found",
+		},
+		{
+			name:    "add below missing parent",
+			patch:   `[{"op": "add", "path": "/metadata/name", "value": "x"}]`,
+			wantErr: "not found",
+		},
+		{
+			name:    "array index out of bounds",
+			patch:   `[{"op": "add", "path": "/spec/containers/5", "value": "x"}]`,
+			wantErr: "out of bounds",
+		},
+		{
+			name:    "move into own child",
+			patch:   `[{"op": "move", "from": "/spec", "path": "/spec/inner"}]`,
+			wantErr: "into itself",
+		},
+		{
+			name:    "unknown operation",
+			patch:   `[{"op": "merge", "path": "/spec"}]`,
+			wantErr: `unknown operation "merge"`,
+		},
+		{
+			name:    "invalid patch",
+			patch:   `{"op": "add"}`,
+			wantErr: "invalid JSON patch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(jsonPatchInput)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			err = doc.ApplyJSONPatch([]byte(tt.patch))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyJSONPatch() error = %v, want %q", err, tt.wantErr)
+				}
+				if got, _ := doc.String(); got != jsonPatchInput {
+					t.Errorf("document after failed patch:\n%s\nwant:\n%s", got, jsonPatchInput)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch() error = %v", err)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyJSONPatch() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_ApplyJSONPatchErrors(t *testing.T) {
+	doc, err := Load("items: [a, b]\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	err = doc.ApplyJSONPatch([]byte(`[{"op": "remove", "path": "/items/2"}]`))
+	var bounds *ErrIndexOutOfBounds
+	if !errors.As(err, &bounds) || bounds.Index != 2 || bounds.Length != 2 {
+		t.Errorf("ApplyJSONPatch() error = %v, want index 2 out of bounds", err)
+	}
+
+	doc.Freeze()
+	if err := doc.ApplyJSONPatch([]byte(`[]`)); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("ApplyJSONPatch() on frozen document error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestDocument_DiffAsJSONPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "equal data with different formatting",
+			from: "a: 1 # one\nlist: [x, y]\n",
+			to:   "a:   1\nlist:\n  - x\n  - y\n",
+			want: `[]`,
+		},
+		{
+			name: "replace, remove and add in document order",
+			from: "name: app\nreplicas: 2\nold: true\n",
+			to:   "name: web\nreplicas: 2\nlabels:\n  tier: web\n  team: core\n",
+			want: `[{"op":"replace","path":"/name","value":"web"},{"op":"remove","path":"/old"},` +
+				`{"op":"add","path":"/labels","value":{"tier":"web","team":"core"}}]`,
+		},
+		{
+			name: "arrays compare by index",
+			from: "items: [a, b, c, d]\n",
+			to:   "items: [a, x]\n",
+			want: `[{"op":"replace","path":"/items/1","value":"x"},{"op":"remove","path":"/items/3"},{"op":"remove","path":"/items/2"}]`,
+		},
+		{
+			name: "array growth and escaped keys",
+			from: "app.io/name: web\nports: [80]\n",
+			to:   "app.io/name: api\nports: [80, 443]\n",
+			want: `[{"op":"replace","path":"/app.io~1name","value":"api"},{"op":"add","path":"/ports/1","value":443}]`,
+		},
+		{
+			name: "type change and aliases",
+			from: "base: &b {x: 1}\nuse: *b\nport: \"80\"\n",
+			to:   "base: {x: 1}\nuse: {x: 1}\nport: 80\n",
+			want: `[{"op":"replace","path":"/port","value":80}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := Load(tt.from)
+			if err != nil {
+				t.Fatalf("Load(from) error = %v", err)
+			}
+			to, err := Load(tt.to)
+			if err != nil {
+				t.Fatalf("Load(to) error = %v", err)
+			}
+			patch, err := from.DiffAsJSONPatch(to)
+			if err != nil {
+				t.Fatalf("DiffAsJSONPatch() error = %v", err)
+			}
+			if string(patch) != tt.want {
+				t.Errorf("DiffAsJSONPatch() = %s, want %s", patch, tt.want)
+			}
+
+			// Applying the patch turns from into to
+			if err := from.ApplyJSONPatch(patch); err != nil {
+				t.Fatalf("ApplyJSONPatch() error = %v", err)
+			}
+			if again, err := from.DiffAsJSONPatch(to); err != nil || string(again) != "[]" {
+				t.Errorf("DiffAsJSONPatch() after applying = %s, %v; want []", again, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineEnding is the newline sequence written at the end of each line
+type LineEnding string
+
+const (
+	// LineEndingKeep keeps the line endings the document was loaded with
+	LineEndingKeep LineEnding = ""
+	// LF writes Unix line endings
+	LF LineEnding = "\n"
+	// CRLF writes Windows line endings
+	CRLF LineEnding = "\r\n"
+)
+
+// ByteOrderMark controls the UTF-8 byte order mark written before the document
+type ByteOrderMark int
+
+const (
+	// KeepBOM writes a byte order mark if the loaded content started with one
+	KeepBOM ByteOrderMark = iota
+	// WriteBOM always starts the output with a byte order mark
+	WriteBOM
+	// OmitBOM never writes a byte order mark
+	OmitBOM
+)
+
+// utf8BOM is the UTF-8 encoding of U+FEFF
+const utf8BOM = "\ufeff"
+
+// stripLineEndings removes a leading byte order mark and turns CRLF line endings into LF,
+// returning the line ending of the first line and whether there was a byte order mark.
+// The rest of the package works on LF content; ToBytes puts the original style back.
+func stripLineEndings(content string) (string, LineEnding, bool) {
+	bom := strings.HasPrefix(content, utf8BOM)
+	content = strings.TrimPrefix(content, utf8BOM)
+
+	ending := LF
+	if idx := strings.IndexByte(content, '\n'); idx > 0 && content[idx-1] == '\r' {
+		ending = CRLF
+	}
+	if strings.Contains(content, "\r\n") {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	return content, ending, bom
+}
+
+// applyLineEndings writes rendered LF content with the document's line endings and byte order mark,
+// as loaded or as forced with SetSerializeOptions
+func (d *Document) applyLineEndings(content []byte) []byte {
+	ending := d.serialize.LineEnding
+	bom := d.serialize.BOM == WriteBOM
+	if info := d.formattingCache; info != nil {
+		if ending == LineEndingKeep {
+			ending = info.LineEnding
+		}
+		if d.serialize.BOM == KeepBOM {
+			bom = info.HasBOM
+		}
+	}
+
+	text := string(content)
+	if ending == CRLF {
+		// Pinned output may already have CRLF endings
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	}
+	if bom && len(text) > 0 && !strings.HasPrefix(text, utf8BOM) {
+		text = utf8BOM + text
+	}
+	if len(text) == len(content) {
+		return content
+	}
+	return []byte(text)
+}
+
+// validateLineEndings checks the line ending and byte order mark settings of serialize options
+func validateLineEndings(ending LineEnding, bom ByteOrderMark) error {
+	switch ending {
+	case LineEndingKeep, LF, CRLF:
+	default:
+		return fmt.Errorf("invalid line ending: %q", string(ending))
+	}
+	if bom < KeepBOM || bom > OmitBOM {
+		return fmt.Errorf("invalid byte order mark setting: %d", bom)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package yamler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDocument_LineEndings(t *testing.T) {
+	content := "\ufeff# settings\r\nname: app # the name\r\nscript: |\r\n  echo hi\r\nports:\r\n  - 80\r\n"
+
+	tests := []struct {
+		name string
+		opts SerializeOptions
+		edit func(doc *Document) error
+		want string
+	}{
+		{
+			name: "unchanged",
+			want: content,
+		},
+		{
+			name: "edits keep CRLF and BOM",
+			edit: func(doc *Document) error {
+				if err := doc.Set("name", "web"); err != nil {
+					return err
+				}
+				return doc.AppendToArray("ports", 443)
+			},
+			want: "\ufeff# settings\r\nname: web # the name\r\nscript: |\r\n  echo hi\r\nports:\r\n  - 80\r\n  - 443\r\n",
+		},
+		{
+			name: "reformatting keeps CRLF and BOM",
+			edit: func(doc *Document) error {
+				return doc.Normalize(DefaultNormalizeOptions())
+			},
+			want: "\ufeff# settings\r\nname: app # the name\r\nscript: |\r\n  echo hi\r\nports:\r\n  - 80\r\n",
+		},
+		{
+			name: "forced LF without BOM",
+			opts: SerializeOptions{LineEnding: LF, BOM: OmitBOM},
+			want: "# settings\nname: app # the name\nscript: |\n  echo hi\nports:\n  - 80\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := LoadBytes([]byte(content))
+			if err != nil {
+				t.Fatalf("LoadBytes() error = %v", err)
+			}
+			if err := doc.SetSerializeOptions(tt.opts); err != nil {
+				t.Fatalf("SetSerializeOptions() error = %v", err)
+			}
+			if tt.edit != nil {
+				if err := tt.edit(doc); err != nil {
+					t.Fatalf("edit error = %v", err)
+				}
+			}
+
+			got, err := doc.ToBytes()
+			if err != nil {
+				t.Fatalf("ToBytes() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToBytes() = %q, want %q", got, tt.want)
+			}
+
+			// Values never see the carriage returns or the byte order mark
+			if script, err := doc.GetString("script"); err != nil || script != "echo hi\n" {
+				t.Errorf("GetString(script) = %q, %v", script, err)
+			}
+		})
+	}
+}
+
+func TestDocument_LineEndingsForced(t *testing.T) {
+	doc, err := Load("a: 1\nb:\n  - x\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := doc.SetSerializeOptions(SerializeOptions{LineEnding: CRLF, BOM: WriteBOM}); err != nil {
+		t.Fatalf("SetSerializeOptions() error = %v", err)
+	}
+
+	want := "\ufeffa: 1\r\nb:\r\n  - x\r\n"
+	if got, _ := doc.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// Freezing pins the output, which must not get a second carriage return
+	doc.Freeze()
+	if got, _ := doc.String(); got != want {
+		t.Errorf("String() after Freeze = %q, want %q", got, want)
+	}
+}
+
+func TestEditFile_KeepsLineEndings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("\ufeffname: app\r\nreplicas: 1\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EditFile(path, func(doc *Document) error {
+		return doc.Set("replicas", 3)
+	}); err != nil {
+		t.Fatalf("EditFile() error = %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\ufeffname: app\r\nreplicas: 3\r\n"; string(got) != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOptions controls how LoadWithOptions parses a document
+type LoadOptions struct {
+	// TreatEmptyAsNull loads empty quoted strings ("" and '') as null values.
+	// They are still written back as they were. Default false.
+	TreatEmptyAsNull bool
+	// StripComments drops all comments on load. Default false, so comments are kept.
+	StripComments bool
+	// StrictDuplicates rejects mappings that define the same key twice.
+	// By default duplicates are accepted and paths resolve to the first occurrence.
+	StrictDuplicates bool
+	// SpecVersion selects how plain scalars are typed: "1.2" (default) or "1.1".
+	// YAML 1.1 also reads y/yes/on and n/no/off as booleans; they are still written back as spelled.
+	// "1.1" is the same as Schema: SchemaYAML11.
+	SpecVersion string
+	// Schema selects how plain scalars are typed: SchemaCore (default), SchemaYAML11 or SchemaJSON.
+	// It decides whether yes/no/on/off are booleans and whether 0755 is octal; values are
+	// always written back as spelled.
+	Schema Schema
+	// CloudFormation keeps short-form intrinsic functions such as !Ref, !Sub and !GetAtt when reading:
+	// getters return values with a custom tag as TaggedValue, and Set writes a TaggedValue back in
+	// short form, so values can be read, changed and set again without losing their tags.
+	CloudFormation bool
+}
+
+// DefaultLoadOptions returns the options Load uses; the zero LoadOptions are the same
+func DefaultLoadOptions() LoadOptions {
+	return LoadOptions{
+		SpecVersion: "1.2",
+	}
+}
+
+// LoadWithOptions creates a new Document from YAML content using the given load options.
+// Load(content) is equivalent to LoadWithOptions(content, DefaultLoadOptions()).
+func LoadWithOptions(content string, opts LoadOptions) (*Document, error) {
+	schema := opts.Schema
+	switch opts.SpecVersion {
+	case "", "1.2":
+	case "1.1":
+		if schema != SchemaCore && schema != SchemaYAML11 {
+			return nil, fmt.Errorf("YAML version 1.1 conflicts with the %s schema", schema)
+		}
+		schema = SchemaYAML11
+	default:
+		return nil, fmt.Errorf("unsupported YAML version: %s", opts.SpecVersion)
+	}
+	if schema < SchemaCore || schema > SchemaJSON {
+		return nil, fmt.Errorf("unsupported schema: %s", schema)
+	}
+
+	doc, err := Load(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.StrictDuplicates {
+		if err := checkDuplicateKeys(doc.root); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	}
+
+	if opts.StripComments && content != "" {
+		stripComments(doc.root)
+		stripped, err := doc.ToBytes()
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = Load(string(stripped)); err != nil {
+			return nil, err
+		}
+	}
+
+	if schema != doc.schema {
+		doc.schema = schema
+		doc.applySchema()
+	}
+	if opts.TreatEmptyAsNull {
+		retagScalars(doc.root, opts)
+	}
+	doc.keepTags = opts.CloudFormation
+
+	return doc, nil
+}
+
+// LoadStrict parses content by the YAML 1.2 core schema, in which "on" and "yes" stay strings and
+// 0755 is the decimal 755, and rejects duplicate keys, which YAML 1.2 forbids
+func LoadStrict(content string) (*Document, error) {
+	opts := DefaultLoadOptions()
+	opts.Schema = SchemaCore
+	opts.StrictDuplicates = true
+	return LoadWithOptions(content, opts)
+}
+
+// checkDuplicateKeys returns an error for the first mapping that repeats a key
+func checkDuplicateKeys(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode || key.Tag == "!!merge" {
+				continue
+			}
+			if seen[key.Value] {
+				return fmt.Errorf("line %d: duplicate key %s", key.Line, key.Value)
+			}
+			seen[key.Value] = true
+		}
+	}
+	for _, child := range node.Content {
+		if err := checkDuplicateKeys(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retagScalars applies load-time typing options to the scalar values below node
+func retagScalars(node *yaml.Node, opts LoadOptions) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		quoted := node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0
+		if opts.TreatEmptyAsNull && quoted && node.Value == "" {
+			node.Tag = "!!null"
+		}
+	}
+
+	// Mapping keys keep their string type
+	start, step := 0, 1
+	if node.Kind == yaml.MappingNode {
+		start, step = 1, 2
+	}
+	for i := start; i < len(node.Content); i += step {
+		retagScalars(node.Content[i], opts)
+	}
+}
+
+// yaml11Bool parses the YAML 1.1 boolean spellings that YAML 1.2 reads as strings
+func yaml11Bool(value string) (bool, bool) {
+	lower := strings.ToLower(value)
+	if lower == "" || (value != lower && value != strings.ToUpper(value) && value != strings.ToUpper(lower[:1])+lower[1:]) {
+		return false, false
+	}
+	switch lower {
+	case "y", "yes", "on":
+		return true, true
+	case "n", "no", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// untagYAML11Bools clears the tag of YAML 1.1 booleans so the encoder writes them as spelled.
+// The returned nodes get their tag back after encoding.
+func untagYAML11Bools(node *yaml.Node, nodes []*yaml.Node) []*yaml.Node {
+	if node == nil {
+		return nodes
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!bool" && node.Style == 0 {
+		if _, ok := yaml11Bool(node.Value); ok {
+			node.Tag = ""
+			nodes = append(nodes, node)
+		}
+	}
+	for _, child := range node.Content {
+		nodes = untagYAML11Bools(child, nodes)
+	}
+	return nodes
+}
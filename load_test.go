@@ -0,0 +1,367 @@
+package yamler
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithOptions(t *testing.T) {
+	content := `# Settings
+enabled: yes # feature flag
+debug: Off
+name: ""
+list: [on, n, x]
+`
+
+	tests := []struct {
+		name       string
+		opts       LoadOptions
+		values     map[string]interface{}
+		wantOutput string
+	}{
+		{
+			name: "defaults match Load",
+			opts: DefaultLoadOptions(),
+			values: map[string]interface{}{
+				"enabled": "yes",
+				"debug":   "Off",
+				"name":    "",
+				"list":    []interface{}{"on", "n", "x"},
+			},
+			wantOutput: content,
+		},
+		{
+			name: "YAML 1.1 booleans",
+			opts: LoadOptions{SpecVersion: "1.1"},
+			values: map[string]interface{}{
+				"enabled": true,
+				"debug":   false,
+				"list":    []interface{}{true, false, "x"},
+			},
+			wantOutput: content,
+		},
+		{
+			name: "empty strings as null",
+			opts: LoadOptions{TreatEmptyAsNull: true},
+			values: map[string]interface{}{
+				"name": nil,
+			},
+			wantOutput: content,
+		},
+		{
+			name: "zero options match Load",
+			opts: LoadOptions{},
+			values: map[string]interface{}{
+				"enabled": "yes",
+			},
+			wantOutput: content,
+		},
+		{
+			name: "drop comments",
+			opts: LoadOptions{StripComments: true},
+			values: map[string]interface{}{
+				"enabled": "yes",
+			},
+			wantOutput: "enabled: yes\ndebug: Off\nname: \"\"\nlist: [on, n, x]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := LoadWithOptions(content, tt.opts)
+			if err != nil {
+				t.Fatalf("LoadWithOptions() error = %v", err)
+			}
+
+			for path, want := range tt.values {
+				got, err := doc.Get(path)
+				if err != nil {
+					t.Fatalf("Get(%s) error = %v", path, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Get(%s) = %#v, want %#v", path, got, want)
+				}
+			}
+
+			output, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if output != tt.wantOutput {
+				t.Errorf("String() =\n%s\nwant:\n%s", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestLoadWithOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    LoadOptions
+		wantErr string
+	}{
+		{
+			name:    "duplicate nested key",
+			content: "a: 1\nb:\n  c: 1\n  c: 2\n",
+			opts:    LoadOptions{StrictDuplicates: true},
+			wantErr: "line 4: duplicate key c",
+		},
+		{
+			name:    "unsupported version",
+			content: "a: 1\n",
+			opts:    LoadOptions{SpecVersion: "2.0"},
+			wantErr: "unsupported YAML version: 2.0",
+		},
+		{
+			name:    "version conflicts with schema",
+			content: "a: 1\n",
+			opts:    LoadOptions{SpecVersion: "1.1", Schema: SchemaJSON},
+			wantErr: "YAML version 1.1 conflicts with the json schema",
+		},
+		{
+			name:    "unsupported schema",
+			content: "a: 1\n",
+			opts:    LoadOptions{Schema: Schema(9)},
+			wantErr: "unsupported schema: Schema(9)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadWithOptions(tt.content, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadWithOptions() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Duplicates are accepted unless StrictDuplicates is set
+	doc, err := LoadWithOptions("a: 1\na: 2\n", DefaultLoadOptions())
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+	if got, _ := doc.Get("a"); got != int64(1) {
+		t.Errorf("Get(a) = %v, want 1", got)
+	}
+}
+
+func TestLoadWithOptionsSchema(t *testing.T) {
+	content := `enabled: on # flag
+mode: 0755
+hex: 0x1F
+octal: 0o17
+big: 1_000
+title: True
+empty: ~
+ratio: .inf
+ports: [yes, 08]
+`
+
+	tests := []struct {
+		name   string
+		schema Schema
+		values map[string]interface{}
+	}{
+		{
+			name:   "core",
+			schema: SchemaCore,
+			values: map[string]interface{}{
+				"enabled": "on",
+				"mode":    int64(755),
+				"hex":     int64(31),
+				"octal":   int64(15),
+				"big":     "1_000",
+				"title":   true,
+				"empty":   nil,
+				"ratio":   math.Inf(1),
+				"ports":   []interface{}{"yes", int64(8)},
+			},
+		},
+		{
+			name:   "YAML 1.1",
+			schema: SchemaYAML11,
+			values: map[string]interface{}{
+				"enabled": true,
+				"mode":    int64(493),
+				"hex":     int64(31),
+				"octal":   "0o17",
+				"big":     int64(1000),
+				"title":   true,
+				"empty":   nil,
+				"ports":   []interface{}{true, "08"},
+			},
+		},
+		{
+			name:   "JSON",
+			schema: SchemaJSON,
+			values: map[string]interface{}{
+				"enabled": "on",
+				"mode":    "0755",
+				"hex":     "0x1F",
+				"big":     "1_000",
+				"title":   "True",
+				"empty":   "~",
+				"ratio":   ".inf",
+				"ports":   []interface{}{"yes", "08"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := LoadWithOptions(content, LoadOptions{Schema: tt.schema})
+			if err != nil {
+				t.Fatalf("LoadWithOptions() error = %v", err)
+			}
+
+			check := func(doc *Document) {
+				t.Helper()
+				for path, want := range tt.values {
+					got, err := doc.Get(path)
+					if err != nil {
+						t.Fatalf("Get(%s) error = %v", path, err)
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("Get(%s) = %#v, want %#v", path, got, want)
+					}
+				}
+			}
+			check(doc)
+			if tt.schema == SchemaCore {
+				// Load types scalars like the default options
+				plain, err := Load(content)
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				check(plain)
+			}
+			if output, _ := doc.String(); output != content {
+				t.Errorf("String() =\n%s\nwant:\n%s", output, content)
+			}
+
+			// Edits, reformatting and copies keep the schema and the spelling of the values
+			if err := doc.Set("added", "True"); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if err := doc.Normalize(DefaultNormalizeOptions()); err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			check(doc)
+			check(doc.Clone())
+			if added, _ := doc.Get("added"); added != "True" {
+				t.Errorf("Get(added) = %#v, want \"True\"", added)
+			}
+			output, _ := doc.String()
+			for _, line := range []string{"mode: 0755\n", "title: True\n", "  - 08\n", "added: \"True\"\n"} {
+				if !strings.Contains(output, line) {
+					t.Errorf("String() after Normalize is missing %q:\n%s", line, output)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	doc, err := LoadStrict("replicas: 3\nenabled: on\nmode: 0644\n")
+	if err != nil {
+		t.Fatalf("LoadStrict() error = %v", err)
+	}
+	if got, _ := doc.Get("enabled"); got != "on" {
+		t.Errorf("Get(enabled) = %#v, want \"on\"", got)
+	}
+	if got, _ := doc.GetInt("mode"); got != 644 {
+		t.Errorf("GetInt(mode) = %d, want 644", got)
+	}
+
+	if _, err := LoadStrict("a: 1\na: 2\n"); err == nil {
+		t.Error("LoadStrict() should reject duplicate keys")
+	}
+}
+
+func TestLoadWithOptionsCloudFormation(t *testing.T) {
+	template := `Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub '${AWS::StackName}-data'
+      Role: !GetAtt Role.Arn
+Outputs:
+  BucketArn:
+    Value: !GetAtt Bucket.Arn
+`
+	opts := DefaultLoadOptions()
+	opts.CloudFormation = true
+	doc, err := LoadWithOptions(template, opts)
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+
+	condition, err := doc.Get("Conditions.IsProd")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	wantCondition := TaggedValue{Tag: "!Equals", Value: []interface{}{TaggedValue{Tag: "!Ref", Value: "Env"}, "prod"}}
+	if !reflect.DeepEqual(condition, wantCondition) {
+		t.Errorf("Get() = %#v, want %#v", condition, wantCondition)
+	}
+
+	// Read, change and write back a mapping holding intrinsic functions
+	properties, err := doc.GetMap("Resources.Bucket.Properties")
+	if err != nil {
+		t.Fatalf("GetMap() error = %v", err)
+	}
+	properties["Role"] = TaggedValue{Tag: "!Ref", Value: "RoleArn"}
+	if err := doc.Set("Resources.Bucket.Properties", properties); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Set("Outputs.BucketArn.Value", TaggedValue{Tag: "!GetAtt", Value: "Bucket.DomainName"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Set("Resources.Bucket.Type", "AWS::S3::AccessPoint"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	want := `Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+Resources:
+  Bucket:
+    Type: AWS::S3::AccessPoint
+    Properties:
+      BucketName: !Sub ${AWS::StackName}-data
+      Role: !Ref RoleArn
+Outputs:
+  BucketArn:
+    Value: !GetAtt Bucket.DomainName
+`
+	if got, _ := doc.String(); got != want {
+		t.Errorf("result:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Decoding into TaggedValue fields keeps tags in any document
+	var output struct {
+		Value TaggedValue `yaml:"Value"`
+	}
+	if err := doc.Decode("Outputs.BucketArn", &output); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if want := (TaggedValue{Tag: "!GetAtt", Value: "Bucket.DomainName"}); output.Value != want {
+		t.Errorf("Decode() = %#v, want %#v", output.Value, want)
+	}
+
+	// Without the option values are read untagged
+	plain, err := Load(template)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if role, _ := plain.Get("Resources.Bucket.Properties.Role"); role != "Role.Arn" {
+		t.Errorf("Get() without CloudFormation = %#v, want %q", role, "Role.Arn")
+	}
+	if err := plain.Set("Value", TaggedValue{Tag: "Ref"}); err == nil {
+		t.Error("Set() with a tag missing \"!\" should fail")
+	}
+}
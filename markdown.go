@@ -0,0 +1,96 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ToMarkdown renders a reference table of every configurable path in the document,
+// with its current value and the comment attached to it.
+// Leaf values always get a row; mappings and arrays of mappings only when they are commented.
+func (d *Document) ToMarkdown() (string, error) {
+	if d.root == nil || len(d.root.Content) == 0 {
+		return "", fmt.Errorf("empty document root")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| Path | Value | Description |\n")
+	sb.WriteString("| --- | --- | --- |\n")
+	writeMarkdownRows(&sb, d.root.Content[0], "", "")
+
+	return sb.String(), nil
+}
+
+// writeMarkdownRows appends table rows for the node and its children
+func writeMarkdownRows(sb *strings.Builder, node *yaml.Node, path, comment string) {
+	node = resolveAlias(node)
+	comment = joinComments(comment, node.HeadComment, node.LineComment)
+
+	switch {
+	case node.Kind == yaml.MappingNode:
+		if path != "" && comment != "" {
+			writeMarkdownRow(sb, path, "", comment)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode := node.Content[i]
+			childPath := appendPathKey(path, keyNode.Value)
+			writeMarkdownRows(sb, node.Content[i+1], childPath, joinComments(keyNode.HeadComment, keyNode.LineComment))
+		}
+	case node.Kind == yaml.SequenceNode && !isScalarSequence(node):
+		if path != "" && comment != "" {
+			writeMarkdownRow(sb, path, "", comment)
+		}
+		for i, item := range node.Content {
+			writeMarkdownRows(sb, item, fmt.Sprintf("%s[%d]", path, i), "")
+		}
+	case node.Kind == yaml.SequenceNode:
+		values := make([]string, len(node.Content))
+		for i, item := range node.Content {
+			values[i] = item.Value
+			comment = joinComments(comment, item.HeadComment, item.LineComment)
+		}
+		writeMarkdownRow(sb, path, "["+strings.Join(values, ", ")+"]", comment)
+	default:
+		writeMarkdownRow(sb, path, node.Value, comment)
+	}
+}
+
+// writeMarkdownRow appends a single table row
+func writeMarkdownRow(sb *strings.Builder, path, value, comment string) {
+	if value != "" {
+		value = "`" + escapeMarkdownCell(value) + "`"
+	}
+	fmt.Fprintf(sb, "| `%s` | %s | %s |\n", escapeMarkdownCell(path), value, escapeMarkdownCell(comment))
+}
+
+// isScalarSequence checks if all sequence items are scalars
+func isScalarSequence(node *yaml.Node) bool {
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// joinComments strips comment markers and joins non-empty comments into one line
+func joinComments(comments ...string) string {
+	var parts []string
+	for _, comment := range comments {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			if line != "" {
+				parts = append(parts, line)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// escapeMarkdownCell escapes characters that would break a table cell
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,75 @@
+package yamler
+
+import (
+	"testing"
+)
+
+func TestDocument_ToMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name: "commented config",
+			content: `# Server section
+server:
+  # Port to listen on
+  port: 8080 # default 80
+  host: localhost
+  tags: [web, api]
+# Database settings
+database:
+  url: "postgres://a|b"
+`,
+			want: "| Path | Value | Description |\n" +
+				"| --- | --- | --- |\n" +
+				"| `server` |  | Server section |\n" +
+				"| `server.port` | `8080` | Port to listen on default 80 |\n" +
+				"| `server.host` | `localhost` |  |\n" +
+				"| `server.tags` | `[web, api]` |  |\n" +
+				"| `database` |  | Database settings |\n" +
+				"| `database.url` | `postgres://a\\|b` |  |\n",
+		},
+		{
+			name: "array of mappings",
+			content: `servers:
+  - name: a # primary
+    port: 80
+  - name: b
+`,
+			want: "| Path | Value | Description |\n" +
+				"| --- | --- | --- |\n" +
+				"| `servers[0].name` | `a` | primary |\n" +
+				"| `servers[0].port` | `80` |  |\n" +
+				"| `servers[1].name` | `b` |  |\n",
+		},
+		{
+			name: "dotted keys",
+			content: `metadata:
+  labels:
+    app.kubernetes.io/name: web # app name
+`,
+			want: "| Path | Value | Description |\n" +
+				"| --- | --- | --- |\n" +
+				"| `metadata.labels[\"app.kubernetes.io/name\"]` | `web` | app name |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			got, err := doc.ToMarkdown()
+			if err != nil {
+				t.Fatalf("ToMarkdown() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ToMarkdown() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,108 +1,197 @@
 package yamler
 
 import (
+	"errors"
 	"fmt"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Merge merges another Document into this one, preserving the formatting of this document
-// and adding/updating values from the other document
-func (d *Document) Merge(other *Document) error {
-	if other == nil {
-		return fmt.Errorf("other document is nil")
-	}
+// ArrayMergeStrategy selects how MergeWithOptions combines two arrays
+type ArrayMergeStrategy struct {
+	mode arrayMergeMode
+	key  string
+}
 
-	otherRoot, err := other.mappingRoot()
-	if err != nil {
-		return fmt.Errorf("other document has invalid root: %w", err)
-	}
+type arrayMergeMode int
 
-	thisRoot, err := d.mappingRoot()
-	if err != nil {
-		return fmt.Errorf("this document has invalid root: %w", err)
-	}
+const (
+	arrayReplace arrayMergeMode = iota
+	arrayAppend
+	arrayMergeByKey
+)
 
-	err = mergeNodes(thisRoot, otherRoot)
-	if err != nil {
-		return err
+var (
+	// ArrayReplace replaces the array with the other document's array (the default)
+	ArrayReplace = ArrayMergeStrategy{mode: arrayReplace}
+	// ArrayAppend appends the other document's items to the array
+	ArrayAppend = ArrayMergeStrategy{mode: arrayAppend}
+)
+
+// MergeByKey deep merges array items that are mappings with the same value for key,
+// like Kubernetes containers merged by name; other items are appended
+func MergeByKey(key string) ArrayMergeStrategy {
+	return ArrayMergeStrategy{mode: arrayMergeByKey, key: key}
+}
+
+// NullHandling selects what a null value in the merged document does
+type NullHandling int
+
+const (
+	// NullOverwrites sets the value to null (the default)
+	NullOverwrites NullHandling = iota
+	// NullIgnored keeps the existing value
+	NullIgnored
+	// NullDeletes removes the key
+	NullDeletes
+)
+
+// MergeOptions controls how MergeWithOptions combines documents.
+// The zero value behaves like Merge: mappings merge recursively, arrays are replaced and null overwrites.
+type MergeOptions struct {
+	ArrayStrategy ArrayMergeStrategy
+	// ArrayStrategies overrides ArrayStrategy for arrays under the given field names at any depth,
+	// e.g. {"tasks": ArrayAppend, "containers": MergeByKey("name")}
+	ArrayStrategies map[string]ArrayMergeStrategy
+	NullHandling    NullHandling
+}
+
+// validate checks that the options can be applied
+func (opts MergeOptions) validate() error {
+	if opts.ArrayStrategy.mode == arrayMergeByKey && opts.ArrayStrategy.key == "" {
+		return fmt.Errorf("merge key is empty")
 	}
+	for field, strategy := range opts.ArrayStrategies {
+		if strategy.mode == arrayMergeByKey && strategy.key == "" {
+			return fmt.Errorf("merge key for %s is empty", field)
+		}
+	}
+	return nil
+}
 
-	// Update the raw content
-	content, err := d.ToBytes()
-	if err != nil {
-		return err
+// arrayStrategyFor returns the strategy for the array under field
+func (opts MergeOptions) arrayStrategyFor(field string) ArrayMergeStrategy {
+	if strategy, ok := opts.ArrayStrategies[field]; ok {
+		return strategy
 	}
-	d.raw = string(content)
+	return opts.ArrayStrategy
+}
 
-	return nil
+// Merge merges another Document into this one, preserving the formatting of this document
+// and adding/updating values from the other document
+func (d *Document) Merge(other *Document) error {
+	return d.MergeWithOptions(other, MergeOptions{})
+}
+
+// MergeWithOptions deep merges another Document into this one using the given strategies
+// for arrays and null values. The zero MergeOptions behaves like Merge.
+// Both documents may be array documents; see MergeAtWithOptions.
+func (d *Document) MergeWithOptions(other *Document, opts MergeOptions) error {
+	return d.MergeAtWithOptions("", other, opts)
 }
 
 // MergeAt merges another Document at the specified path in this document
 func (d *Document) MergeAt(path string, other *Document) error {
+	return d.MergeAtWithOptions(path, other, MergeOptions{})
+}
+
+// MergeAtWithOptions deep merges another Document at the specified path using the given strategies.
+// The path may contain array indices, like "[0].vars" in an array document.
+// Merging an array document into an array combines them with the array strategy,
+// and merging a mapping into an array adds it as an item, or merges it into the matching item with MergeByKey.
+func (d *Document) MergeAtWithOptions(path string, other *Document, opts MergeOptions) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
 	if other == nil {
 		return fmt.Errorf("other document is nil")
 	}
 
-	otherRoot, err := other.mappingRoot()
+	otherRoot, err := other.queryRoot()
 	if err != nil {
 		return fmt.Errorf("other document has invalid root: %w", err)
 	}
 
-	thisRoot, err := d.mappingRoot()
+	thisRoot, err := d.queryRoot()
 	if err != nil {
 		return fmt.Errorf("this document has invalid root: %w", err)
 	}
 
-	// Get or create the target node
-	targetNode, err := getOrCreateNode(thisRoot, path)
-	if err != nil {
-		return fmt.Errorf("failed to get/create target node at path %s: %w", path, err)
+	targetNode, err := d.nodeAt(path)
+	if errors.Is(err, ErrPathNotFound) {
+		// Create the missing path; the new node takes the kind of the merged document
+		targetNode, err = getOrCreateNode(thisRoot, path)
+		if err != nil {
+			return fmt.Errorf("failed to get/create target node at path %s: %w", path, err)
+		}
+	} else if err != nil {
+		return err
+	} else if targetNode.Kind == yaml.MappingNode && otherRoot.Kind == yaml.SequenceNode {
+		return fmt.Errorf("path %s: cannot merge an array into a mapping: %w", path, kindMismatch("mapping node", otherRoot))
 	}
 
-	// Convert target to mapping if it's not already
-	if targetNode.Kind != yaml.MappingNode {
-		targetNode.Kind = yaml.MappingNode
-		targetNode.Tag = "!!map"
-		targetNode.Content = make([]*yaml.Node, 0)
-		targetNode.Value = ""
+	field := ""
+	if parts := splitPath(path); len(parts) > 0 && !isArrayIndex(parts[len(parts)-1]) {
+		field = parts[len(parts)-1]
 	}
 
-	err = mergeNodes(targetNode, otherRoot)
-	if err != nil {
-		return err
+	if targetNode.Kind == yaml.SequenceNode && otherRoot.Kind == yaml.MappingNode {
+		err = mergeItemIntoSequence(targetNode, otherRoot, field, opts)
+	} else {
+		err = mergeNodes(targetNode, otherRoot, field, opts)
 	}
-
-	// Update the raw content
-	content, err := d.ToBytes()
 	if err != nil {
 		return err
 	}
-	d.raw = string(content)
 
+	d.markDirty()
 	return nil
 }
 
-// mergeNodes merges the content of source node into target node
-func mergeNodes(target, source *yaml.Node) error {
+// mergeItemIntoSequence adds a mapping to the target sequence, or deep merges it into the item
+// with the same key when the array strategy for field is MergeByKey
+func mergeItemIntoSequence(target, item *yaml.Node, field string, opts MergeOptions) error {
+	source := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{item}}
+	if strategy := opts.arrayStrategyFor(field); strategy.mode == arrayMergeByKey {
+		return mergeSequenceNodesByKey(target, source, strategy.key, opts)
+	}
+	return appendSequenceNodes(target, source)
+}
+
+// mergeNodes merges the content of source node into target node; field is the key target is stored under
+func mergeNodes(target, source *yaml.Node, field string, opts MergeOptions) error {
 	if source == nil {
 		return nil
 	}
 
 	switch source.Kind {
 	case yaml.MappingNode:
-		return mergeMappingNodes(target, source)
+		return mergeMappingNodes(target, source, opts)
 	case yaml.SequenceNode:
+		strategy := opts.arrayStrategyFor(field)
+		switch {
+		case target.Kind != yaml.SequenceNode:
+			return mergeSequenceNodes(target, source)
+		case strategy.mode == arrayAppend:
+			return appendSequenceNodes(target, source)
+		case strategy.mode == arrayMergeByKey:
+			return mergeSequenceNodesByKey(target, source, strategy.key, opts)
+		}
 		return mergeSequenceNodes(target, source)
 	case yaml.ScalarNode:
 		return mergeScalarNodes(target, source)
+	case yaml.AliasNode:
+		return mergeNodes(target, resolveAlias(source), field, opts)
 	default:
 		return fmt.Errorf("unsupported node kind for merging: %v", source.Kind)
 	}
 }
 
 // mergeMappingNodes merges mapping nodes
-func mergeMappingNodes(target, source *yaml.Node) error {
+func mergeMappingNodes(target, source *yaml.Node, opts MergeOptions) error {
 	// Ensure target is a mapping node
 	if target.Kind != yaml.MappingNode {
 		target.Kind = yaml.MappingNode
@@ -116,7 +205,19 @@ func mergeMappingNodes(target, source *yaml.Node) error {
 		sourceKey := source.Content[i]
 		sourceValue := source.Content[i+1]
 
-		if err := mergeKeyValuePair(target, sourceKey, sourceValue); err != nil {
+		if isNullNode(sourceValue) {
+			switch opts.NullHandling {
+			case NullIgnored:
+				continue
+			case NullDeletes:
+				if existing, found := findKeyInMapping(target, sourceKey.Value); found {
+					removeNodes(target, map[*yaml.Node]bool{existing: true}, nil)
+				}
+				continue
+			}
+		}
+
+		if err := mergeKeyValuePair(target, sourceKey, sourceValue, opts); err != nil {
 			return err
 		}
 	}
@@ -124,14 +225,14 @@ func mergeMappingNodes(target, source *yaml.Node) error {
 }
 
 // mergeKeyValuePair merges a single key-value pair into target
-func mergeKeyValuePair(target, sourceKey, sourceValue *yaml.Node) error {
+func mergeKeyValuePair(target, sourceKey, sourceValue *yaml.Node, opts MergeOptions) error {
 	// Find if key exists in target
 	for j := 0; j < len(target.Content); j += 2 {
 		targetKey := target.Content[j]
 		if targetKey.Value == sourceKey.Value {
 			// Key exists, merge the values
 			targetValue := target.Content[j+1]
-			return mergeNodes(targetValue, sourceValue)
+			return mergeNodes(targetValue, sourceValue, sourceKey.Value, opts)
 		}
 	}
 
@@ -181,6 +282,68 @@ func mergeSequenceNodes(target, source *yaml.Node) error {
 	return nil
 }
 
+// appendSequenceNodes appends the items of source to the target sequence
+func appendSequenceNodes(target, source *yaml.Node) error {
+	for _, item := range source.Content {
+		clonedItem, err := cloneNode(item)
+		if err != nil {
+			return err
+		}
+		target.Content = append(target.Content, clonedItem)
+	}
+	return nil
+}
+
+// mergeSequenceNodesByKey deep merges source items into the target items with the same key field value,
+// and appends source items that have no counterpart
+func mergeSequenceNodesByKey(target, source *yaml.Node, key string, opts MergeOptions) error {
+	for _, item := range source.Content {
+		if match := findItemByKey(target, item, key); match != nil {
+			if err := mergeNodes(match, item, "", opts); err != nil {
+				return err
+			}
+			continue
+		}
+
+		clonedItem, err := cloneNode(item)
+		if err != nil {
+			return err
+		}
+		target.Content = append(target.Content, clonedItem)
+	}
+	return nil
+}
+
+// findItemByKey returns the mapping item of sequence whose key field equals that of item
+func findItemByKey(sequence, item *yaml.Node, key string) *yaml.Node {
+	item = resolveAlias(item)
+	if item.Kind != yaml.MappingNode {
+		return nil
+	}
+	want, found := findKeyInMapping(item, key)
+	if !found {
+		return nil
+	}
+	want = resolveAlias(want)
+
+	for _, candidate := range sequence.Content {
+		candidate = resolveAlias(candidate)
+		if candidate.Kind != yaml.MappingNode {
+			continue
+		}
+		if value, found := findKeyInMapping(candidate, key); found && sameData(resolveAlias(value), want) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// isNullNode checks if a node is an explicit null value
+func isNullNode(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
 // mergeScalarNodes merges scalar nodes
 func mergeScalarNodes(target, source *yaml.Node) error {
 	// For scalars, replace the value but preserve comments
@@ -259,6 +422,9 @@ func getOrCreateNode(root *yaml.Node, path string) (*yaml.Node, error) {
 			}
 
 			if current.Kind != yaml.SequenceNode {
+				if current.Kind == yaml.MappingNode && len(current.Content) > 0 {
+					return nil, kindMismatch("sequence node", current)
+				}
 				current.Kind = yaml.SequenceNode
 				current.Tag = "!!seq"
 				current.Content = make([]*yaml.Node, 0)
@@ -282,6 +448,9 @@ func getOrCreateNode(root *yaml.Node, path string) (*yaml.Node, error) {
 				current.Content = make([]*yaml.Node, 0)
 			}
 			if current.Kind != yaml.MappingNode {
+				if current.Kind == yaml.SequenceNode && len(current.Content) > 0 {
+					return nil, kindMismatch("mapping node", current)
+				}
 				current.Kind = yaml.MappingNode
 				current.Tag = "!!map"
 				current.Content = make([]*yaml.Node, 0)
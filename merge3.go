@@ -0,0 +1,211 @@
+package yamler
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeConflict is a path that ours and theirs changed in different ways since base.
+// The merged document keeps ours' value there.
+type MergeConflict struct {
+	Path string
+	// Reason is "both modified", "both added", "deleted in ours" or "deleted in theirs"
+	Reason string
+	// Values at the path; nil where the path does not exist
+	Base, Ours, Theirs interface{}
+}
+
+// ThreeWayMerge applies the changes theirs made since base onto ours, like git merge, and returns
+// the result with ours' comments and formatting. Mappings merge key by key; sequences and scalars
+// are compared as whole values. Paths both sides changed differently are reported as conflicts
+// and keep ours' value. base may be nil, in which case every key of theirs counts as added.
+func ThreeWayMerge(base, ours, theirs *Document) (*Document, []MergeConflict, error) {
+	if ours == nil || theirs == nil {
+		return nil, nil, fmt.Errorf("ours and theirs documents are required")
+	}
+
+	oursContent, err := ours.ToBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := Load(string(oursContent))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resultRoot, err := result.mappingRoot()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ours document has invalid root: %w", err)
+	}
+	theirsRoot, err := theirs.mappingRoot()
+	if err != nil {
+		return nil, nil, fmt.Errorf("theirs document has invalid root: %w", err)
+	}
+	var baseRoot *yaml.Node
+	if base != nil {
+		if baseRoot, err = base.mappingRoot(); err != nil {
+			return nil, nil, fmt.Errorf("base document has invalid root: %w", err)
+		}
+	}
+
+	m := &threeWayMerger{info: result.formattingCache}
+	if err := m.mergeMappings("", baseRoot, resultRoot, theirsRoot); err != nil {
+		return nil, nil, err
+	}
+
+	result.markDirty()
+	return result, m.conflicts, nil
+}
+
+// threeWayMerger collects conflicts while merging theirs into ours' tree
+type threeWayMerger struct {
+	info      *FormattingInfo
+	conflicts []MergeConflict
+}
+
+// mergeMappings merges the keys of base and theirs into ours, which is changed in place
+func (m *threeWayMerger) mergeMappings(path string, base, ours, theirs *yaml.Node) error {
+	// Keys theirs deleted or changed, in ours' order
+	for i := 0; i+1 < len(ours.Content); i += 2 {
+		key := ours.Content[i].Value
+		if err := m.mergeKey(path, key, base, ours, theirs); err != nil {
+			return err
+		}
+		// mergeKey may have removed the entry
+		if i+1 >= len(ours.Content) || ours.Content[i].Value != key {
+			i -= 2
+		}
+	}
+
+	// Keys only theirs and possibly base have
+	previous := ""
+	for i := 0; i+1 < len(theirs.Content); i += 2 {
+		key := theirs.Content[i].Value
+		if _, inOurs := findKeyInMapping(ours, key); !inOurs {
+			if err := m.addKey(path, key, previous, base, ours, theirs.Content[i], theirs.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		previous = key
+	}
+	return nil
+}
+
+// mergeKey merges one key that ours has
+func (m *threeWayMerger) mergeKey(path, key string, base, ours, theirs *yaml.Node) error {
+	childPath := appendPathKey(path, key)
+	baseValue := lookupMergeKey(base, key)
+	oursValue := lookupMergeKey(ours, key)
+	theirsValue := lookupMergeKey(theirs, key)
+
+	switch {
+	case sameMergeValue(baseValue, theirsValue), sameMergeValue(oursValue, theirsValue):
+		// Theirs did not change it, or both made the same change
+		return nil
+	case theirsValue == nil:
+		if sameMergeValue(baseValue, oursValue) {
+			removeNodes(ours, map[*yaml.Node]bool{oursValue: true}, m.info)
+			return nil
+		}
+		return m.conflict(childPath, "deleted in theirs", baseValue, oursValue, nil)
+	case isMapping(oursValue) && isMapping(theirsValue) && (baseValue == nil || isMapping(baseValue)):
+		return m.mergeMappings(childPath, baseValue, resolveAlias(oursValue), resolveAlias(theirsValue))
+	case sameMergeValue(baseValue, oursValue):
+		replacement, err := cloneNode(theirsValue)
+		if err != nil {
+			return err
+		}
+		return reconcileNode(oursValue, replacement)
+	case baseValue == nil:
+		return m.conflict(childPath, "both added", nil, oursValue, theirsValue)
+	default:
+		return m.conflict(childPath, "both modified", baseValue, oursValue, theirsValue)
+	}
+}
+
+// addKey adds a key that ours does not have, placing it after the key it follows in theirs
+func (m *threeWayMerger) addKey(path, key, previous string, base, ours, theirsKey, theirsValue *yaml.Node) error {
+	baseValue := lookupMergeKey(base, key)
+	if sameMergeValue(baseValue, theirsValue) {
+		// Ours deleted it and theirs left it alone
+		return nil
+	}
+	if baseValue != nil {
+		return m.conflict(appendPathKey(path, key), "deleted in ours", baseValue, nil, theirsValue)
+	}
+
+	keyNode, err := cloneNode(theirsKey)
+	if err != nil {
+		return err
+	}
+	valueNode, err := cloneNode(theirsValue)
+	if err != nil {
+		return err
+	}
+
+	at := len(ours.Content)
+	for i := 0; i+1 < len(ours.Content); i += 2 {
+		if ours.Content[i].Value == previous {
+			at = i + 2
+			break
+		}
+	}
+	if previous == "" {
+		at = 0
+	}
+	content := make([]*yaml.Node, 0, len(ours.Content)+2)
+	content = append(content, ours.Content[:at]...)
+	content = append(content, keyNode, valueNode)
+	ours.Content = append(content, ours.Content[at:]...)
+	return nil
+}
+
+// conflict records a conflict, keeping ours' value
+func (m *threeWayMerger) conflict(path, reason string, base, ours, theirs *yaml.Node) error {
+	c := MergeConflict{Path: path, Reason: reason}
+	for _, v := range []struct {
+		node   *yaml.Node
+		target *interface{}
+	}{{base, &c.Base}, {ours, &c.Ours}, {theirs, &c.Theirs}} {
+		if v.node == nil {
+			continue
+		}
+		value, err := nodeToInterface(resolveAlias(v.node))
+		if err != nil {
+			return fmt.Errorf("path %s: %w", path, err)
+		}
+		*v.target = value
+	}
+	m.conflicts = append(m.conflicts, c)
+	return nil
+}
+
+// lookupMergeKey returns the value of key in a mapping, or nil if the mapping or key is missing
+func lookupMergeKey(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil {
+		return nil
+	}
+	mapping = resolveAlias(mapping)
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	value, found := findKeyInMapping(mapping, key)
+	if !found {
+		return nil
+	}
+	return value
+}
+
+// sameMergeValue checks if two optional nodes hold the same data
+func sameMergeValue(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return sameData(resolveAlias(a), resolveAlias(b))
+}
+
+// isMapping checks if a node is a mapping, following aliases
+func isMapping(node *yaml.Node) bool {
+	return node != nil && resolveAlias(node).Kind == yaml.MappingNode
+}
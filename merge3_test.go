@@ -0,0 +1,164 @@
+package yamler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          string
+		ours          string
+		theirs        string
+		want          string
+		wantConflicts []MergeConflict
+	}{
+		{
+			name:   "independent changes keep ours formatting",
+			base:   "name: app\nport: 80\nreplicas: 1\n",
+			ours:   "# local settings\nname: app   # do not rename\nport: 8080\nreplicas: 1\n",
+			theirs: "name: app\nport: 80\nreplicas: 3\n",
+			want:   "# local settings\nname: app   # do not rename\nport: 8080\nreplicas: 3\n",
+		},
+		{
+			name:   "nested mappings merge key by key",
+			base:   "db:\n  host: localhost\n  pool: 5\n",
+			ours:   "db:\n  host: db.local # ours\n  pool: 5\n",
+			theirs: "db:\n  host: localhost\n  pool: 10\n  timeout: 30\n",
+			want:   "db:\n  host: db.local # ours\n  pool: 10\n  timeout: 30\n",
+		},
+		{
+			name:   "added key follows its neighbour in theirs",
+			base:   "a: 1\nc: 3\n",
+			ours:   "a: 1\nc: 4\n",
+			theirs: "a: 1\nb: 2\nc: 3\n",
+			want:   "a: 1\nb: 2\nc: 4\n",
+		},
+		{
+			name:   "deletions from either side",
+			base:   "a: 1\nb: 2\nc: 3\n",
+			ours:   "a: 1\nb: 2\n",
+			theirs: "a: 1\nc: 3\n",
+			want:   "a: 1\n",
+		},
+		{
+			name:   "same change on both sides",
+			base:   "tags: [a]\n",
+			ours:   "tags: [a, b]\n",
+			theirs: "tags: [a, b]\n",
+			want:   "tags: [a, b]\n",
+		},
+		{
+			name:   "sequence replaced by theirs keeps flow style",
+			base:   "tags: [a, b]\nname: x\n",
+			ours:   "tags: [a, b]\nname: y\n",
+			theirs: "tags:\n  - a\n  - c\nname: x\n",
+			want:   "tags: [a, c]\nname: y\n",
+		},
+		{
+			name:   "conflicting scalar keeps ours",
+			base:   "port: 80\n",
+			ours:   "port: 8080\n",
+			theirs: "port: 9090\n",
+			want:   "port: 8080\n",
+			wantConflicts: []MergeConflict{
+				{Path: "port", Reason: "both modified", Base: int64(80), Ours: int64(8080), Theirs: int64(9090)},
+			},
+		},
+		{
+			name:   "modify and delete conflicts",
+			base:   "a: 1\nb: 2\n",
+			ours:   "a: 5\n",
+			theirs: "b: 6\n",
+			want:   "a: 5\n",
+			wantConflicts: []MergeConflict{
+				{Path: "a", Reason: "deleted in theirs", Base: int64(1), Ours: int64(5)},
+				{Path: "b", Reason: "deleted in ours", Base: int64(2), Theirs: int64(6)},
+			},
+		},
+		{
+			name:   "both added differently",
+			base:   "a: 1\n",
+			ours:   "a: 1\nmode: fast\n",
+			theirs: "a: 1\nmode: safe\n",
+			want:   "a: 1\nmode: fast\n",
+			wantConflicts: []MergeConflict{
+				{Path: "mode", Reason: "both added", Ours: "fast", Theirs: "safe"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := Load(tt.base)
+			if err != nil {
+				t.Fatalf("Load(base) error = %v", err)
+			}
+			ours, err := Load(tt.ours)
+			if err != nil {
+				t.Fatalf("Load(ours) error = %v", err)
+			}
+			theirs, err := Load(tt.theirs)
+			if err != nil {
+				t.Fatalf("Load(theirs) error = %v", err)
+			}
+
+			merged, conflicts, err := ThreeWayMerge(base, ours, theirs)
+			if err != nil {
+				t.Fatalf("ThreeWayMerge() error = %v", err)
+			}
+			got, err := merged.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ThreeWayMerge() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if len(conflicts) != 0 || len(tt.wantConflicts) != 0 {
+				if !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+					t.Errorf("conflicts = %+v, want %+v", conflicts, tt.wantConflicts)
+				}
+			}
+
+			// The inputs are not modified
+			if s, _ := ours.String(); s != tt.ours {
+				t.Errorf("ours changed to:\n%s", s)
+			}
+		})
+	}
+}
+
+func TestThreeWayMerge_Errors(t *testing.T) {
+	doc, err := Load("a: 1\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	list, err := Load("- a\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if _, _, err := ThreeWayMerge(doc, nil, doc); err == nil {
+		t.Error("ThreeWayMerge() with nil ours should fail")
+	}
+	if _, _, err := ThreeWayMerge(doc, doc, list); err == nil {
+		t.Error("ThreeWayMerge() with array theirs should fail")
+	}
+
+	// Without a base every key of theirs counts as added
+	theirs, err := Load("a: 2\nb: 3\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	merged, conflicts, err := ThreeWayMerge(nil, doc, theirs)
+	if err != nil {
+		t.Fatalf("ThreeWayMerge() error = %v", err)
+	}
+	if got, _ := merged.String(); got != "a: 1\nb: 3\n" {
+		t.Errorf("ThreeWayMerge() = %q, want %q", got, "a: 1\nb: 3\n")
+	}
+	if len(conflicts) != 1 || conflicts[0].Reason != "both added" {
+		t.Errorf("conflicts = %+v, want one both added", conflicts)
+	}
+}
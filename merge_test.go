@@ -273,3 +273,348 @@ db:
 		t.Errorf("Comments not preserved correctly\nGot:\n%s\nWant:\n%s", result, expected)
 	}
 }
+
+func TestDocument_MergeWithOptions(t *testing.T) {
+	base := `spec:
+  containers:
+    - name: web # main container
+      image: nginx:1.24
+      env:
+        - name: MODE
+          value: prod
+    - name: sidecar
+      image: envoy
+  tags: [a, b]
+  debug: true
+`
+	tests := []struct {
+		name     string
+		other    string
+		opts     MergeOptions
+		expected string
+	}{
+		{
+			name: "replace arrays by default",
+			other: `spec:
+  tags: [c]
+`,
+			opts: MergeOptions{},
+			expected: `spec:
+  containers:
+    - name: web # main container
+      image: nginx:1.24
+      env:
+        - name: MODE
+          value: prod
+    - name: sidecar
+      image: envoy
+  tags: [c]
+  debug: true
+`,
+		},
+		{
+			name: "append arrays",
+			other: `spec:
+  tags: [c]
+`,
+			opts: MergeOptions{ArrayStrategy: ArrayAppend},
+			expected: `spec:
+  containers:
+    - name: web # main container
+      image: nginx:1.24
+      env:
+        - name: MODE
+          value: prod
+    - name: sidecar
+      image: envoy
+  tags: [a, b, c]
+  debug: true
+`,
+		},
+		{
+			name: "merge containers by name",
+			other: `spec:
+  containers:
+    - name: web
+      image: nginx:1.25
+      env:
+        - name: LOG
+          value: debug
+    - name: metrics
+      image: prom
+`,
+			opts: MergeOptions{ArrayStrategy: MergeByKey("name")},
+			expected: `spec:
+  containers:
+    - name: web # main container
+      image: nginx:1.25
+      env:
+        - name: MODE
+          value: prod
+        - name: LOG
+          value: debug
+    - name: sidecar
+      image: envoy
+    - name: metrics
+      image: prom
+  tags: [a, b]
+  debug: true
+`,
+		},
+		{
+			name: "null overwrites by default",
+			other: `spec:
+  debug: null
+`,
+			opts: MergeOptions{},
+			expected: `spec:
+  containers:
+    - name: web # main container
+      image: nginx:1.24
+      env:
+        - name: MODE
+          value: prod
+    - name: sidecar
+      image: envoy
+  tags: [a, b]
+  debug: null
+`,
+		},
+		{
+			name: "null ignored",
+			other: `spec:
+  debug: null
+  tags: [c]
+`,
+			opts: MergeOptions{NullHandling: NullIgnored},
+			expected: `spec:
+  containers:
+    - name: web # main container
+      image: nginx:1.24
+      env:
+        - name: MODE
+          value: prod
+    - name: sidecar
+      image: envoy
+  tags: [c]
+  debug: true
+`,
+		},
+		{
+			name: "null deletes",
+			other: `spec:
+  debug: ~
+  containers: null
+`,
+			opts: MergeOptions{NullHandling: NullDeletes},
+			expected: `spec:
+  tags: [a, b]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(base)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			other, err := Load(tt.other)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if err := doc.MergeWithOptions(other, tt.opts); err != nil {
+				t.Fatalf("MergeWithOptions() error = %v", err)
+			}
+
+			result, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("MergeWithOptions() result:\n%s\nexpected:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDocument_MergeAtWithOptions(t *testing.T) {
+	doc, err := Load("deploy:\n  ports:\n    - port: 80\n      protocol: TCP\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	other, err := Load("ports:\n  - port: 80\n    protocol: UDP\n  - port: 443\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := doc.MergeAtWithOptions("deploy", other, MergeOptions{ArrayStrategy: MergeByKey("port")}); err != nil {
+		t.Fatalf("MergeAtWithOptions() error = %v", err)
+	}
+	want := "deploy:\n  ports:\n    - port: 80\n      protocol: UDP\n    - port: 443\n"
+	if result, _ := doc.String(); result != want {
+		t.Errorf("MergeAtWithOptions() result:\n%s\nexpected:\n%s", result, want)
+	}
+
+	if err := doc.MergeWithOptions(other, MergeOptions{ArrayStrategy: MergeByKey("")}); err == nil {
+		t.Error("MergeWithOptions() with empty merge key should fail")
+	}
+}
+
+func TestDocument_MergeAtArrays(t *testing.T) {
+	playbook := `- hosts: web
+  vars:
+    port: 80 # http
+  tasks:
+    - name: install
+      apt: nginx
+`
+	tests := []struct {
+		name     string
+		base     string
+		path     string
+		other    string
+		opts     MergeOptions
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:  "append array document to array",
+			base:  playbook,
+			path:  "[0].tasks",
+			other: "- name: start\n  service: nginx\n",
+			opts:  MergeOptions{ArrayStrategy: ArrayAppend},
+			expected: `- hosts: web
+  vars:
+    port: 80 # http
+  tasks:
+    - name: install
+      apt: nginx
+    - name: start
+      service: nginx
+`,
+		},
+		{
+			name:  "replace array by default",
+			base:  playbook,
+			path:  "[0].tasks",
+			other: "- name: start\n",
+			expected: `- hosts: web
+  vars:
+    port: 80 # http
+  tasks:
+    - name: start
+`,
+		},
+		{
+			name:  "mapping is added as an item",
+			base:  playbook,
+			path:  "[0].tasks",
+			other: "name: start\nservice: nginx\n",
+			expected: `- hosts: web
+  vars:
+    port: 80 # http
+  tasks:
+    - name: install
+      apt: nginx
+    - name: start
+      service: nginx
+`,
+		},
+		{
+			name:  "mapping merges into the item with the same key",
+			base:  playbook,
+			path:  "[0].tasks",
+			other: "name: install\napt: apache2\n",
+			opts:  MergeOptions{ArrayStrategy: MergeByKey("name")},
+			expected: `- hosts: web
+  vars:
+    port: 80 # http
+  tasks:
+    - name: install
+      apt: apache2
+`,
+		},
+		{
+			name:  "mapping under an array index",
+			base:  playbook,
+			path:  "[0].vars",
+			other: "port: 8080\nuser: www\n",
+			expected: `- hosts: web
+  vars:
+    port: 8080 # http
+    user: www
+  tasks:
+    - name: install
+      apt: nginx
+`,
+		},
+		{
+			name:  "array documents at the root",
+			base:  playbook,
+			other: "- hosts: db\n",
+			opts:  MergeOptions{ArrayStrategy: ArrayAppend},
+			expected: `- hosts: web
+  vars:
+    port: 80 # http
+  tasks:
+    - name: install
+      apt: nginx
+- hosts: db
+`,
+		},
+		{
+			name:  "strategy per field at any depth",
+			base:  "app:\n  plugins: [auth, cache]\n  jobs:\n    steps: [build, lint]\n    env: [CI=1]\n",
+			path:  "app",
+			other: "plugins: [metrics]\njobs:\n  steps: [test]\n  env: [DEBUG=1]\n",
+			opts:  MergeOptions{ArrayStrategies: map[string]ArrayMergeStrategy{"plugins": ArrayAppend, "steps": ArrayAppend}},
+			expected: `app:
+  plugins: [auth, cache, metrics]
+  jobs:
+    steps: [build, lint, test]
+    env: [DEBUG=1]
+`,
+		},
+		{
+			name:    "array into mapping",
+			base:    playbook,
+			path:    "[0].vars",
+			other:   "- port: 8080\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty merge key for a field",
+			base:    playbook,
+			path:    "[0].tasks",
+			other:   "- name: start\n",
+			opts:    MergeOptions{ArrayStrategies: map[string]ArrayMergeStrategy{"tasks": MergeByKey("")}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := Load(tt.base)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			other, err := Load(tt.other)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = base.MergeAtWithOptions(tt.path, other, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MergeAtWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result, _ := base.String(); result != tt.expected {
+				t.Errorf("MergeAtWithOptions() result:\n%s\nexpected:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
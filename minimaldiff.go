@@ -0,0 +1,375 @@
+package yamler
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxMinimalDiffEdits bounds the line diff of SetMinimalDiff; larger rewrites keep the regular Set output
+const maxMinimalDiffEdits = 1000
+
+// SetMinimalDiff sets a value like Set, then restores every line outside the edited entry
+// from the document's text before the edit, so unrelated formatting (spacing, quoting, indentation)
+// never shows up in the diff. If the restored content would not match the edited data,
+// the regular Set output is kept. The result is what ToBytes returns until the next change.
+func (d *Document) SetMinimalDiff(path string, value interface{}) error {
+	// The current text of the document; ToBytes may already normalize parts of it
+	if err := d.syncRaw(); err != nil {
+		return err
+	}
+	before := d.raw
+	if d.pinned != nil {
+		before = string(d.pinned)
+	}
+	if err := d.Set(path, value); err != nil {
+		return err
+	}
+	after, err := d.renderContent()
+	if err != nil {
+		return err
+	}
+
+	if len(before) == 0 {
+		return nil
+	}
+	start, end, ok := entryLines(after, path)
+	if !ok {
+		return nil
+	}
+
+	merged, ok := reconcileLines(before, string(after), start, end)
+	if !ok || merged == string(after) {
+		return nil
+	}
+
+	// Only keep the reconciled content if it holds exactly the edited data
+	var check yaml.Node
+	if err := yaml.Unmarshal([]byte(merged), &check); err != nil || len(check.Content) == 0 {
+		return nil
+	}
+	got, err := nodeToInterface(check.Content[0])
+	if err != nil {
+		return nil
+	}
+	want, err := nodeToInterface(d.root.Content[0])
+	if err != nil || !reflect.DeepEqual(got, want) {
+		return nil
+	}
+
+	d.raw = merged
+	d.pinned = []byte(merged)
+	return nil
+}
+
+// entryLines returns the 0-based first and last line of the entry at path in rendered content:
+// from its key (or sequence item) to the last line of its value
+func entryLines(content []byte, path string) (int, int, bool) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil || len(root.Content) == 0 {
+		return 0, 0, false
+	}
+
+	node := root.Content[0]
+	parts := splitPathParts(path)
+	if node.Kind == yaml.SequenceNode && !strings.HasPrefix(path, "[") {
+		// Array-root documents address the first element, like Set
+		parts = append([]string{"[0]"}, parts...)
+	}
+
+	var err error
+	for _, part := range parts[:len(parts)-1] {
+		if node, err = navigateToNode(node, part, path); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	last := parts[len(parts)-1]
+	var start int
+	var value *yaml.Node
+	if strings.HasSuffix(last, "]") {
+		if value, err = navigateToNode(node, last, path); err != nil {
+			return 0, 0, false
+		}
+		start = value.Line
+	} else {
+		node = resolveAlias(node)
+		if node.Kind != yaml.MappingNode {
+			return 0, 0, false
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == last {
+				start = node.Content[i].Line
+				value = node.Content[i+1]
+				break
+			}
+		}
+		if value == nil {
+			return 0, 0, false
+		}
+	}
+
+	// Block scalars and multi-line flow values continue below the line of their last node
+	lines := strings.Split(string(content), "\n")
+	end := lastLine(value)
+	if start < 1 || end > len(lines) {
+		return 0, 0, false
+	}
+	indent := indentOf(lines[start-1])
+	for end < len(lines) {
+		next := lines[end]
+		if strings.TrimSpace(next) == "" || indentOf(next) <= indent {
+			break
+		}
+		end++
+	}
+	return start - 1, end - 1, true
+}
+
+// reconcileLines keeps the changes of after that touch lines start..end and
+// reverts every other changed line to its text in before
+func reconcileLines(before, after string, start, end int) (string, bool) {
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+	matches, ok := matchLines(a, b, maxMinimalDiffEdits)
+	if !ok {
+		return "", false
+	}
+
+	result := make([]string, 0, len(b))
+	ai, bi := 0, 0
+	flush := func(aEnd, bEnd int) {
+		// Lines of after in ai..bEnd differ from before in ai..aEnd
+		touched := bi <= end && bEnd > start
+		if bi == bEnd {
+			// Pure deletion: it belongs to the entry when it sits inside or right after it
+			touched = bi >= start && bi <= end+1
+		}
+		if !touched {
+			result = append(result, a[ai:aEnd]...)
+			return
+		}
+		// Lines of the hunk outside the entry pair up with the same number of lines of before
+		pre, post := 0, 0
+		if start > bi {
+			pre = start - bi
+		}
+		if bEnd-1 > end {
+			post = bEnd - 1 - end
+		}
+		if pre+post > aEnd-ai {
+			result = append(result, b[bi:bEnd]...)
+			return
+		}
+		result = append(result, a[ai:ai+pre]...)
+		result = append(result, shiftLines(b[bi+pre:bEnd-post], a[ai+pre:aEnd-post])...)
+		result = append(result, a[aEnd-post:aEnd]...)
+	}
+	for _, m := range matches {
+		if m[0] > ai || m[1] > bi {
+			flush(m[0], m[1])
+		}
+		result = append(result, b[m[1]])
+		ai, bi = m[0]+1, m[1]+1
+	}
+	if ai < len(a) || bi < len(b) {
+		flush(len(a), len(b))
+	}
+
+	return strings.Join(result, "\n"), true
+}
+
+// shiftLines moves the edited lines back to the indentation of the lines they replace,
+// when the renderer moved them along with the rest of the document
+func shiftLines(edited, original []string) []string {
+	if len(edited) == 0 || len(edited) != len(original) {
+		return edited
+	}
+	delta := indentOf(original[0]) - indentOf(edited[0])
+	if delta == 0 {
+		return edited
+	}
+
+	shifted := make([]string, len(edited))
+	for i, line := range edited {
+		shifted[i] = shiftIndent(line, delta)
+	}
+	return shifted
+}
+
+// shiftIndent moves a line delta columns to the right, or to the left for a negative delta.
+// Blank lines and lines that would move past the first column are kept as they are.
+func shiftIndent(line string, delta int) string {
+	target := indentOf(line) + delta
+	if delta == 0 || strings.TrimSpace(line) == "" || target < 0 {
+		return line
+	}
+	return strings.Repeat(" ", target) + strings.TrimLeft(line, " ")
+}
+
+// matchLines returns the pairs of equal lines of a shortest edit script between a and b (Myers' algorithm),
+// or false when more than maxEdits insertions and deletions are needed
+func matchLines(a, b []string, maxEdits int) ([][2]int, bool) {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackMatches(trace, offset, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrackMatches walks the Myers trace back from the end and collects the diagonal moves
+func backtrackMatches(trace [][]int, offset, x, y int) [][2]int {
+	var matches [][2]int
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			matches = append(matches, [2]int{x, y})
+		}
+		if d > 0 {
+			x, y = prevX, prevY
+		}
+	}
+
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+	return matches
+}
+
+// applyRenderedChanges applies the changes between two renderings of a document, before and after
+// an edit, to the original text it was loaded from, like a diff3 merge with the rendering before
+// the edit as base. Lines the edit did not touch keep their original bytes; where the edit and
+// the renderer's normalization change the same lines, the edited lines win. It returns after
+// when the lines cannot be matched or the merged text does not hold the same data.
+func applyRenderedChanges(original, before, after string) string {
+	o := strings.Split(original, "\n")
+	b := strings.Split(before, "\n")
+	a := strings.Split(after, "\n")
+	ours, ok := matchLines(b, o, maxMinimalDiffEdits)
+	if !ok {
+		return after
+	}
+	theirs, ok := matchLines(b, a, maxMinimalDiffEdits)
+	if !ok {
+		return after
+	}
+
+	inOurs := make(map[int]int, len(ours))
+	for _, m := range ours {
+		inOurs[m[0]] = m[1]
+	}
+	fromBase := make(map[int]int, len(theirs))
+	for _, m := range theirs {
+		fromBase[m[1]] = m[0]
+	}
+	result := make([]string, 0, len(a))
+	oi, bi, ai := 0, 0, 0
+	// resolve merges the unstable chunk up to the given line of each side
+	resolve := func(oEnd, bEnd, aEnd int) {
+		switch {
+		case equalStringSlices(o[oi:oEnd], b[bi:bEnd]):
+			result = append(result, a[ai:aEnd]...)
+		case equalStringSlices(a[ai:aEnd], b[bi:bEnd]):
+			result = append(result, o[oi:oEnd]...)
+		case oEnd-oi == bEnd-bi:
+			// The renderer rewrote these lines one for one, so the lines the edit kept map back to the original,
+			// and the edited lines move to the original indentation of the lines before them
+			delta := 0
+			if bEnd > bi {
+				delta = indentDelta(o[oi], b[bi], delta)
+			}
+			for k := ai; k < aEnd; k++ {
+				if j, ok := fromBase[k]; ok && j >= bi && j < bEnd {
+					result = append(result, o[oi+j-bi])
+					delta = indentDelta(o[oi+j-bi], b[j], delta)
+				} else {
+					result = append(result, shiftIndent(a[k], delta))
+				}
+			}
+		default:
+			result = append(result, a[ai:aEnd]...)
+		}
+	}
+	for _, m := range theirs {
+		oLine, stable := inOurs[m[0]]
+		if !stable {
+			continue
+		}
+		resolve(oLine, m[0], m[1])
+		result = append(result, o[oLine])
+		oi, bi, ai = oLine+1, m[0]+1, m[1]+1
+	}
+	resolve(len(o), len(b), len(a))
+
+	merged := strings.Join(result, "\n")
+	if merged == after || !sameDocumentData(merged, after) {
+		return after
+	}
+	return merged
+}
+
+// indentDelta returns how far the renderer moved a line from its original indentation,
+// or fallback when either line is blank
+func indentDelta(original, rendered string, fallback int) int {
+	if strings.TrimSpace(original) == "" || strings.TrimSpace(rendered) == "" {
+		return fallback
+	}
+	return indentOf(original) - indentOf(rendered)
+}
+
+// sameDocumentData reports whether two YAML texts parse to the same data
+func sameDocumentData(a, b string) bool {
+	var nodes [2]yaml.Node
+	var values [2]interface{}
+	for i, content := range []string{a, b} {
+		if err := yaml.Unmarshal([]byte(content), &nodes[i]); err != nil {
+			return false
+		}
+		if len(nodes[i].Content) == 0 {
+			continue
+		}
+		value, err := nodeToInterface(nodes[i].Content[0])
+		if err != nil {
+			return false
+		}
+		values[i] = value
+	}
+	return reflect.DeepEqual(values[0], values[1])
+}
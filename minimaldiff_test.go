@@ -0,0 +1,122 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDocument_SetMinimalDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		path     string
+		value    interface{}
+		wantLine string
+	}{
+		{
+			name:     "aligned colons and flow spacing",
+			input:    "a:   1\nb: 'x'\nc:    \"y\"\nlist: [ 1 ,2 ]\nd: 2\n",
+			path:     "d",
+			value:    3,
+			wantLine: "d: 3",
+		},
+		{
+			name:     "zero-indent sequence in nested mapping",
+			input:    "services:\n  web:\n    image: nginx\n    ports:\n    - \"80:80\"\n  db:\n    image: pg\n",
+			path:     "services.db.image",
+			value:    "postgres:16",
+			wantLine: "    image: postgres:16",
+		},
+		{
+			name:     "comments and blank lines",
+			input:    "# settings\nname: app   # the name\n\nport: 8080 # http\ndebug: false\n",
+			path:     "port",
+			value:    9090,
+			wantLine: "port: 9090 # http",
+		},
+		{
+			name:     "kubernetes manifest",
+			input:    "apiVersion: apps/v1\nkind: Deployment\nspec:\n  replicas: 1\n  template:\n    spec:\n      containers:\n      - name: web\n        image: nginx:1.25\n        args: [ \"--port\" , \"80\" ]\n",
+			path:     "spec.replicas",
+			value:    3,
+			wantLine: "  replicas: 3",
+		},
+		{
+			name:     "array element",
+			input:    "hosts:\n- a.example.com\n-   b.example.com\nflags: {x:  1}\n",
+			path:     "hosts[0]",
+			value:    "c.example.com",
+			wantLine: "- c.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := doc.SetMinimalDiff(tt.path, tt.value); err != nil {
+				t.Fatalf("SetMinimalDiff() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			before := strings.Split(tt.input, "\n")
+			after := strings.Split(got, "\n")
+			if len(before) != len(after) {
+				t.Fatalf("line count changed from %d to %d:\n%s", len(before), len(after), got)
+			}
+			var changed []string
+			for i := range before {
+				if before[i] != after[i] {
+					changed = append(changed, after[i])
+				}
+			}
+			if len(changed) != 1 || changed[0] != tt.wantLine {
+				t.Errorf("changed lines = %q, want [%q]\nGot:\n%s", changed, tt.wantLine, got)
+			}
+
+			reloaded, err := Load(got)
+			if err != nil {
+				t.Fatalf("reload error = %v", err)
+			}
+			want, _ := doc.Get(tt.path)
+			if v, err := reloaded.Get(tt.path); err != nil || fmt.Sprint(v) != fmt.Sprint(want) {
+				t.Errorf("reloaded %s = %v, %v, want %v", tt.path, v, err, want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetMinimalDiffPinnedOutput(t *testing.T) {
+	input := "a:   1\nb: 2\n"
+	doc, err := Load(input)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := doc.SetMinimalDiff("b", 3); err != nil {
+		t.Fatalf("SetMinimalDiff() error = %v", err)
+	}
+	if got, _ := doc.String(); got != "a:   1\nb: 3\n" {
+		t.Errorf("String() = %q, want %q", got, "a:   1\nb: 3\n")
+	}
+
+	// A regular edit renders normally again
+	if err := doc.Set("b", 4); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := doc.String(); !strings.Contains(got, "b: 4") {
+		t.Errorf("String() after Set = %q, want b: 4", got)
+	}
+
+	if err := doc.SetMinimalDiff("missing.key", 1); err != nil {
+		t.Fatalf("SetMinimalDiff() on new path error = %v", err)
+	}
+	if v, err := doc.GetInt("missing.key"); err != nil || v != 1 {
+		t.Errorf("GetInt(missing.key) = %v, %v, want 1", v, err)
+	}
+}
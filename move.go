@@ -0,0 +1,103 @@
+package yamler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Move transplants the key at oldPath to newPath together with its value, comments and scalar style.
+// Within the same mapping the key keeps its position; otherwise it is appended to the mapping at
+// newPath's parent, which is created if missing. Mappings left empty by the move are removed.
+func (d *Document) Move(oldPath, newPath string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	parent, keyIndex, err := d.findMappingEntry(oldPath)
+	if err != nil {
+		return err
+	}
+	if newPath == oldPath {
+		return nil
+	}
+	if strings.HasPrefix(newPath, oldPath+".") || strings.HasPrefix(newPath, oldPath+"[") {
+		return fmt.Errorf("path %s: cannot move a key into itself", oldPath)
+	}
+
+	targetPath, newKey := "", newPath
+	if idx := strings.LastIndex(newPath, "."); idx != -1 {
+		targetPath, newKey = newPath[:idx], newPath[idx+1:]
+	}
+	if newKey == "" || strings.Contains(newKey, "[") {
+		return fmt.Errorf("path %s: expected mapping key", newPath)
+	}
+
+	target, err := d.moveTarget(targetPath)
+	if err != nil {
+		return fmt.Errorf("path %s: %w", newPath, err)
+	}
+	if _, exists := findKeyInMapping(target, newKey); exists {
+		return fmt.Errorf("path %s: key %s already exists", newPath, newKey)
+	}
+
+	keyNode, valueNode := parent.Content[keyIndex], parent.Content[keyIndex+1]
+	if target != parent {
+		removeNodes(parent, map[*yaml.Node]bool{valueNode: true}, d.formattingCache)
+		target.Content = append(target.Content, keyNode, valueNode)
+		d.pruneEmptyParents(oldPath)
+	}
+	keyNode.Value = newKey
+
+	d.markDirty()
+	return nil
+}
+
+// Rename moves the key at path to newPath, see Move. It reads better in migrations
+// such as Rename("network.bind_port", "server.port").
+func (d *Document) Rename(path, newPath string) error {
+	return d.Move(path, newPath)
+}
+
+// moveTarget returns the mapping at path, creating missing mappings along the way
+func (d *Document) moveTarget(path string) (*yaml.Node, error) {
+	node, err := d.getNode(path)
+	if err == nil {
+		node = resolveAlias(node)
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", path)
+		}
+		return node, nil
+	}
+	if !errors.Is(err, ErrPathNotFound) || strings.Contains(path, "[") {
+		return nil, err
+	}
+
+	root, err := d.mappingRoot()
+	if err != nil {
+		return nil, err
+	}
+	return getOrCreateWrapper(root, strings.Split(path, "."), path)
+}
+
+// pruneEmptyParents removes the mappings above path that a move left without keys
+func (d *Document) pruneEmptyParents(path string) {
+	for {
+		idx := strings.LastIndex(path, ".")
+		if idx == -1 {
+			return
+		}
+		path = path[:idx]
+		parent, keyIndex, err := d.findMappingEntry(path)
+		if err != nil {
+			return
+		}
+		value := parent.Content[keyIndex+1]
+		if value.Kind != yaml.MappingNode || len(value.Content) > 0 {
+			return
+		}
+		removeNodes(parent, map[*yaml.Node]bool{value: true}, d.formattingCache)
+	}
+}
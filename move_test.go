@@ -0,0 +1,139 @@
+package yamler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDocument_Move(t *testing.T) {
+	input := `# Legacy settings
+application:
+  app_name: myapp
+  app_version: 1.0.0
+
+network:
+  # Address to listen on
+  bind_address: localhost
+  bind_port: 8080 # public port
+server:
+  timeout: '30s'
+`
+	tests := []struct {
+		name    string
+		oldPath string
+		newPath string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "rename in place keeps position",
+			oldPath: "application.app_name",
+			newPath: "application.name",
+			want:    "# Legacy settings\napplication:\n  name: myapp\n  app_version: 1.0.0\n\nnetwork:\n  # Address to listen on\n  bind_address: localhost\n  bind_port: 8080 # public port\nserver:\n  timeout: '30s'\n",
+		},
+		{
+			name:    "move to another mapping keeps comments",
+			oldPath: "network.bind_port",
+			newPath: "server.port",
+			want:    "# Legacy settings\napplication:\n  app_name: myapp\n  app_version: 1.0.0\n\nnetwork:\n  # Address to listen on\n  bind_address: localhost\nserver:\n  timeout: '30s'\n  port: 8080 # public port\n",
+		},
+		{
+			name:    "head comment moves with the key",
+			oldPath: "network.bind_address",
+			newPath: "server.host",
+			want:    "# Legacy settings\napplication:\n  app_name: myapp\n  app_version: 1.0.0\n\nnetwork:\n  bind_port: 8080 # public port\nserver:\n  timeout: '30s'\n  # Address to listen on\n  host: localhost\n",
+		},
+		{
+			name:    "missing parents are created",
+			oldPath: "server.timeout",
+			newPath: "http.client.timeout",
+			want:    "# Legacy settings\napplication:\n  app_name: myapp\n  app_version: 1.0.0\n\nnetwork:\n  # Address to listen on\n  bind_address: localhost\n  bind_port: 8080 # public port\nhttp:\n  client:\n    timeout: '30s'\n",
+		},
+		{
+			name:    "subtree to top level",
+			oldPath: "application",
+			newPath: "app",
+			want:    "# Legacy settings\napp:\n  app_name: myapp\n  app_version: 1.0.0\n\nnetwork:\n  # Address to listen on\n  bind_address: localhost\n  bind_port: 8080 # public port\nserver:\n  timeout: '30s'\n",
+		},
+		{
+			name:    "existing destination",
+			oldPath: "network.bind_port",
+			newPath: "server.timeout",
+			wantErr: "key timeout already exists",
+		},
+		{
+			name:    "into itself",
+			oldPath: "network",
+			newPath: "network.inner",
+			wantErr: "into itself",
+		},
+		{
+			name:    "destination below a scalar",
+			oldPath: "network.bind_port",
+			newPath: "application.app_name.port",
+			wantErr: "not a mapping",
+		},
+		{
+			name:    "missing source",
+			oldPath: "network.bind_host",
+			newPath: "server.host",
+			wantErr: "key bind_host not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.Move(tt.oldPath, tt.newPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Move() error = %v, want %q", err, tt.wantErr)
+				}
+				if got, _ := doc.String(); got != input {
+					t.Errorf("failed Move() changed the document:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Move() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Move() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_Rename(t *testing.T) {
+	doc, err := Load("network:\n  bind_port: 8080\nserver:\n  host: localhost\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := doc.Rename("network.bind_port", "server.port"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	// network is left empty and removed
+	want := "server:\n  host: localhost\n  port: 8080\n"
+	if got, _ := doc.String(); got != want {
+		t.Errorf("Rename() result:\n%s\nwant:\n%s", got, want)
+	}
+	if port, err := doc.GetInt("server.port"); err != nil || port != 8080 {
+		t.Errorf("GetInt(server.port) = %d, %v", port, err)
+	}
+
+	doc.Freeze()
+	if err := doc.Rename("server.port", "server.p"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Rename() on frozen document error = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,224 @@
+package yamler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MultiDocument is a "---" separated stream of YAML documents, such as a Kubernetes manifest
+// bundle. Each document keeps its own formatting, and the separators, "..." end markers and
+// empty documents between them are written back as they were.
+type MultiDocument struct {
+	entries []multiEntry
+	// Separators and empty documents after the last document
+	trailer string
+}
+
+// multiEntry is one document of a stream with the raw text in front of it
+type multiEntry struct {
+	// Separator line, plus any empty documents and end markers before it
+	prefix string
+	doc    *Document
+}
+
+// LoadAll parses every document of a "---" separated stream.
+// Documents holding only comments are kept as text between their neighbours and are not indexed.
+func LoadAll(content string) (*MultiDocument, error) {
+	m := &MultiDocument{}
+	var pending, body strings.Builder
+
+	flush := func() error {
+		text := body.String()
+		body.Reset()
+		if !hasDocumentContent(text) {
+			pending.WriteString(text)
+			return nil
+		}
+
+		text, end := splitDocumentEnd(text)
+		doc, err := LoadBytes([]byte(text))
+		if err != nil {
+			return fmt.Errorf("document %d: %w", len(m.entries), err)
+		}
+		m.entries = append(m.entries, multiEntry{prefix: pending.String(), doc: doc})
+		pending.Reset()
+		pending.WriteString(end)
+		return nil
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if isDocumentSeparator(line) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			separator, rest := splitSeparatorLine(line)
+			pending.WriteString(separator)
+			body.WriteString(rest)
+			continue
+		}
+		body.WriteString(line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	m.trailer = pending.String()
+
+	return m, nil
+}
+
+// LoadAllFile loads every document of a YAML file
+func LoadAllFile(filename string) (*MultiDocument, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return LoadAll(string(content))
+}
+
+// splitSeparatorLine splits "--- {a: 1}" into the separator and the content that follows it on the same line.
+// Separators followed only by a comment stay whole.
+func splitSeparatorLine(line string) (string, string) {
+	rest := strings.TrimLeft(strings.TrimPrefix(line, "---"), " ")
+	if trimmed := strings.TrimSpace(rest); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return line, ""
+	}
+	return "--- ", rest
+}
+
+// splitDocumentEnd moves trailing "..." end markers of a document out of its body
+func splitDocumentEnd(text string) (string, string) {
+	lines := strings.SplitAfter(text, "\n")
+	cut := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "..." {
+			cut = i
+			continue
+		}
+		if trimmed != "" {
+			break
+		}
+	}
+	return strings.Join(lines[:cut], ""), strings.Join(lines[cut:], "")
+}
+
+// Len returns the number of documents in the stream
+func (m *MultiDocument) Len() int {
+	return len(m.entries)
+}
+
+// Document returns the document at index, or nil if there is none.
+// A negative index counts from the end, so -1 is the last document.
+func (m *MultiDocument) Document(index int) *Document {
+	i, err := elementIndex(index, len(m.entries))
+	if err != nil {
+		return nil
+	}
+	return m.entries[i].doc
+}
+
+// Documents returns all documents of the stream in order
+func (m *MultiDocument) Documents() []*Document {
+	docs := make([]*Document, len(m.entries))
+	for i, entry := range m.entries {
+		docs[i] = entry.doc
+	}
+	return docs
+}
+
+// AddDocument parses content and appends it as a new document at the end of the stream
+func (m *MultiDocument) AddDocument(content string) (*Document, error) {
+	return m.InsertDocument(len(m.entries), content)
+}
+
+// InsertDocument parses content and inserts it as a new document before index.
+// An index equal to Len appends; a negative index counts from the end.
+func (m *MultiDocument) InsertDocument(index int, content string) (*Document, error) {
+	i := index
+	if i < 0 {
+		i += len(m.entries)
+	}
+	if i < 0 || i > len(m.entries) {
+		return nil, fmt.Errorf("document index %d out of bounds (length: %d)", index, len(m.entries))
+	}
+
+	doc, err := LoadBytes([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+
+	m.entries = append(m.entries, multiEntry{})
+	copy(m.entries[i+1:], m.entries[i:])
+	m.entries[i] = multiEntry{prefix: "---\n", doc: doc}
+	return doc, nil
+}
+
+// RemoveDocument removes the document at index together with the separator in front of it.
+// A negative index counts from the end.
+func (m *MultiDocument) RemoveDocument(index int) error {
+	i, err := elementIndex(index, len(m.entries))
+	if err != nil {
+		return fmt.Errorf("document index %d out of bounds (length: %d)", index, len(m.entries))
+	}
+
+	if i == 0 && len(m.entries) > 1 && !hasSeparatorLine(m.entries[0].prefix) {
+		// The next document becomes the first one and needs no separator either
+		m.entries[1].prefix = m.entries[0].prefix
+	}
+	m.entries = append(m.entries[:i], m.entries[i+1:]...)
+	return nil
+}
+
+// ToBytes renders all documents with the separators between them
+func (m *MultiDocument) ToBytes() ([]byte, error) {
+	var out strings.Builder
+	for i, entry := range m.entries {
+		if i > 0 && !hasSeparatorLine(entry.prefix) {
+			out.WriteString("---\n")
+		}
+		out.WriteString(entry.prefix)
+
+		content, err := entry.doc.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		out.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' && (i < len(m.entries)-1 || m.trailer != "") {
+			out.WriteString("\n")
+		}
+	}
+	out.WriteString(m.trailer)
+
+	return []byte(out.String()), nil
+}
+
+// String returns the stream as a string
+func (m *MultiDocument) String() (string, error) {
+	content, err := m.ToBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Save writes all documents to a file
+func (m *MultiDocument) Save(filename string) error {
+	content, err := m.ToBytes()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, content, 0644)
+}
+
+// hasSeparatorLine checks if text contains a "---" document separator
+func hasSeparatorLine(text string) bool {
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if isDocumentSeparator(line) {
+			return true
+		}
+	}
+	return false
+}
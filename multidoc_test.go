@@ -0,0 +1,234 @@
+package yamler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const manifestBundle = `# app manifests
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web  # deployment name
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 80
+...
+---
+# nothing here yet
+--- # config
+apiVersion: v1
+kind: ConfigMap
+data: {mode: prod}
+`
+
+func TestLoadAll(t *testing.T) {
+	m, err := LoadAll(manifestBundle)
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	if m.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", m.Len())
+	}
+
+	for i, want := range []string{"Deployment", "Service", "ConfigMap"} {
+		kind, err := m.Document(i).GetString("kind")
+		if err != nil || kind != want {
+			t.Errorf("Document(%d) kind = %q, %v, want %q", i, kind, err, want)
+		}
+	}
+	if m.Document(3) != nil || m.Document(-4) != nil {
+		t.Error("Document() out of range should return nil")
+	}
+	if m.Document(-1) != m.Documents()[2] {
+		t.Error("Document(-1) should be the last document")
+	}
+
+	got, err := m.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	if got != manifestBundle {
+		t.Errorf("round trip changed the stream:\n%s", got)
+	}
+}
+
+func TestLoadAll_Edit(t *testing.T) {
+	m, err := LoadAll(manifestBundle)
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	if err := m.Document(1).Set("spec.ports[0].port", 8080); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	want := `# app manifests
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web  # deployment name
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 8080
+...
+---
+# nothing here yet
+--- # config
+apiVersion: v1
+kind: ConfigMap
+data: {mode: prod}
+`
+	got, err := m.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("String() = \n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMultiDocument_AddRemove(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		modify func(m *MultiDocument) error
+		want   string
+	}{
+		{
+			name:  "append",
+			input: "a: 1\n---\nb: 2\n",
+			modify: func(m *MultiDocument) error {
+				_, err := m.AddDocument("c: 3\n")
+				return err
+			},
+			want: "a: 1\n---\nb: 2\n---\nc: 3\n",
+		},
+		{
+			name:  "insert first",
+			input: "a: 1\n---\nb: 2\n",
+			modify: func(m *MultiDocument) error {
+				_, err := m.InsertDocument(0, "z: 0\n")
+				return err
+			},
+			want: "---\nz: 0\n---\na: 1\n---\nb: 2\n",
+		},
+		{
+			name:  "remove first without separator",
+			input: "a: 1\n---\nb: 2\n---\nc: 3\n",
+			modify: func(m *MultiDocument) error {
+				return m.RemoveDocument(0)
+			},
+			want: "b: 2\n---\nc: 3\n",
+		},
+		{
+			name:  "remove last",
+			input: "---\na: 1\n---\nb: 2\n",
+			modify: func(m *MultiDocument) error {
+				return m.RemoveDocument(-1)
+			},
+			want: "---\na: 1\n",
+		},
+		{
+			name:  "append after document without final newline",
+			input: "a: 1",
+			modify: func(m *MultiDocument) error {
+				_, err := m.AddDocument("b: 2\n")
+				return err
+			},
+			want: "a: 1\n---\nb: 2\n",
+		},
+		{
+			name:  "inline document after separator",
+			input: "--- {a: 1}\n--- {b: 2}\n",
+			modify: func(m *MultiDocument) error {
+				return m.Document(1).Set("b", 3)
+			},
+			want: "--- {a: 1}\n--- {b: 3}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := LoadAll(tt.input)
+			if err != nil {
+				t.Fatalf("LoadAll() error = %v", err)
+			}
+			if err := tt.modify(m); err != nil {
+				t.Fatalf("modify error = %v", err)
+			}
+			got, err := m.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiDocument_Errors(t *testing.T) {
+	m, err := LoadAll("a: 1\n---\nb: [\n")
+	if err == nil {
+		t.Fatalf("LoadAll() with invalid document = %v, want error", m)
+	}
+
+	m, err = LoadAll("a: 1\n")
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	if err := m.RemoveDocument(1); err == nil {
+		t.Error("RemoveDocument(1) should fail")
+	}
+	if _, err := m.InsertDocument(3, "b: 2\n"); err == nil {
+		t.Error("InsertDocument(3) should fail")
+	}
+	if _, err := m.AddDocument("b: [\n"); err == nil {
+		t.Error("AddDocument() with invalid YAML should fail")
+	}
+}
+
+func TestLoadAllFile(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "bundle.yaml")
+	if err := os.WriteFile(tmpFile, []byte(manifestBundle), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	m, err := LoadAllFile(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadAllFile() error = %v", err)
+	}
+	if err := m.RemoveDocument(2); err != nil {
+		t.Fatalf("RemoveDocument() error = %v", err)
+	}
+	if err := m.Save(tmpFile); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := LoadAllFile(tmpFile)
+	if err != nil {
+		t.Fatalf("LoadAllFile() error = %v", err)
+	}
+	if reloaded.Len() != 2 {
+		t.Errorf("Len() after save = %d, want 2", reloaded.Len())
+	}
+
+	if _, err := LoadAllFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadAllFile() on missing file should fail")
+	}
+}
@@ -0,0 +1,167 @@
+package yamler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NormalizeOptions selects the canonical style Normalize rewrites a document in
+type NormalizeOptions struct {
+	// Indent is the indentation width in spaces; 0 means 2
+	Indent int
+	// Sequences places the dashes of block sequences; SequenceKeep means SequenceIndented
+	Sequences SequenceLayout
+	// SortKeys sorts the keys of every mapping alphabetically
+	SortKeys bool
+	// AlignComments lines up the inline comments of consecutive lines in one column
+	AlignComments bool
+}
+
+// DefaultNormalizeOptions returns the style used by yamler as a formatter:
+// 2-space indentation, indented sequences, original key order and aligned comments
+func DefaultNormalizeOptions() NormalizeOptions {
+	return NormalizeOptions{Indent: 2, Sequences: SequenceIndented, AlignComments: true}
+}
+
+// Normalize reformats the whole document in a canonical style, the opposite of preserving it:
+// flow collections become block collections, blank lines and custom spacing are dropped, and
+// every level is indented the same way. Comments, quoting and anchors are kept.
+// Normalizing a normalized document does not change it, so it can check files in pre-commit hooks.
+func (d *Document) Normalize(opts NormalizeOptions) error {
+	if opts.Indent < 0 {
+		return fmt.Errorf("invalid indent size: %d", opts.Indent)
+	}
+	if opts.Sequences < SequenceKeep || opts.Sequences > SequenceZeroIndented {
+		return fmt.Errorf("invalid sequence layout: %d", opts.Sequences)
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	root, err := d.queryRoot()
+	if err != nil {
+		return err
+	}
+
+	indent := opts.Indent
+	if indent == 0 {
+		indent = 2
+	}
+	seqIndent := indent
+	if opts.Sequences == SequenceZeroIndented {
+		seqIndent = 0
+	}
+
+	normalizeNode(root, opts.SortKeys)
+	info := d.formattingInfo()
+	setIndentSize(info, indent)
+	info.SequenceIndent = seqIndent
+	info.AlignmentMode = CommentAlignmentRelative
+	info.SectionSpacing = 0
+	if err := d.reformatRoot(); err != nil {
+		return err
+	}
+	d.alignComments = opts.AlignComments
+	d.markDirty()
+	return nil
+}
+
+// normalizeNode turns the flow collections below node into block ones and optionally sorts mapping keys.
+// A comment after a flow collection moves to its key, since a block collection has no line of its own.
+func normalizeNode(node *yaml.Node, sortKeys bool) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if isBlockable(value) && value.LineComment != "" && key.LineComment == "" {
+				key.LineComment, value.LineComment = value.LineComment, ""
+			}
+		}
+		if sortKeys {
+			entries := mappingEntries(node)
+			sort.SliceStable(entries, func(i, j int) bool {
+				return entries[i][0].Value < entries[j][0].Value
+			})
+			setMappingEntries(node, entries)
+		}
+	case yaml.DocumentNode, yaml.SequenceNode, yaml.ScalarNode, yaml.AliasNode:
+	}
+
+	if isBlockable(node) {
+		node.Style &^= yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		normalizeNode(child, sortKeys)
+	}
+}
+
+// isBlockable reports whether node is a non-empty collection, which can be written in block style
+func isBlockable(node *yaml.Node) bool {
+	return (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) > 0
+}
+
+// lineComments collects the inline comments below node
+func lineComments(node *yaml.Node, comments map[string]bool) map[string]bool {
+	if comments == nil {
+		comments = make(map[string]bool)
+	}
+	if node.LineComment != "" {
+		comments[node.LineComment] = true
+	}
+	for _, child := range node.Content {
+		lineComments(child, comments)
+	}
+	return comments
+}
+
+// alignComments moves the inline comments of consecutive lines to one column,
+// one space after the longest of their values
+func alignComments(content string, comments map[string]bool) string {
+	lines := strings.Split(content, "\n")
+	starts := make([]int, len(lines))
+	for i, line := range lines {
+		starts[i] = commentStart(line, comments)
+	}
+
+	for i := 0; i < len(lines); {
+		if starts[i] < 0 {
+			i++
+			continue
+		}
+		end := i
+		column := 0
+		for ; end < len(lines) && starts[end] >= 0; end++ {
+			if width := len(strings.TrimRight(lines[end][:starts[end]], " ")); width > column {
+				column = width
+			}
+		}
+		for j := i; j < end; j++ {
+			code := strings.TrimRight(lines[j][:starts[j]], " ")
+			lines[j] = code + strings.Repeat(" ", column-len(code)+1) + lines[j][starts[j]:]
+		}
+		i = end
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentStart returns where the inline comment of a line starts, or -1 if it has none.
+// Only the known comment texts count, so a "#" inside a value is never taken for one.
+func commentStart(line string, comments map[string]bool) int {
+	trimmed := strings.TrimLeft(line, " ")
+	if strings.HasPrefix(trimmed, "#") {
+		return -1
+	}
+	for i := strings.Index(line, " #"); i >= 0; {
+		if comments[line[i+1:]] {
+			return i + 1
+		}
+		next := strings.Index(line[i+1:], " #")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return -1
+}
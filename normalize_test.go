@@ -0,0 +1,133 @@
+package yamler
+
+import (
+	"testing"
+)
+
+func TestDocument_Normalize(t *testing.T) {
+	content := `# service
+name:   app   # the name
+ports: [80, 443]  # flow list
+labels: {tier: web, app: shop}
+
+
+steps:
+- run: build # compile
+  timeout_minutes: 10  # slow
+- test
+url: "http://host/#top" # anchor
+`
+
+	tests := []struct {
+		name    string
+		opts    NormalizeOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default",
+			opts: DefaultNormalizeOptions(),
+			want: `# service
+name: app # the name
+ports:    # flow list
+  - 80
+  - 443
+labels:
+  tier: web
+  app: shop
+steps:
+  - run: build          # compile
+    timeout_minutes: 10 # slow
+  - test
+url: "http://host/#top" # anchor
+`,
+		},
+		{
+			name: "sorted keys, 4 spaces and zero-indented sequences",
+			opts: NormalizeOptions{Indent: 4, Sequences: SequenceZeroIndented, SortKeys: true},
+			want: `labels:
+    app: shop
+    tier: web
+# service
+name: app # the name
+ports: # flow list
+- 80
+- 443
+steps:
+- run: build # compile
+  timeout_minutes: 10 # slow
+- test
+url: "http://host/#top" # anchor
+`,
+		},
+		{
+			name:    "negative indent",
+			opts:    NormalizeOptions{Indent: -1},
+			wantErr: true,
+		},
+		{
+			name:    "unknown sequence layout",
+			opts:    NormalizeOptions{Sequences: SequenceLayout(7)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.Normalize(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// Normalized output is a fixed point, so formatters can check files with it
+			again, err := Load(got)
+			if err != nil {
+				t.Fatalf("Load() normalized error = %v", err)
+			}
+			if err := again.Normalize(tt.opts); err != nil {
+				t.Fatalf("Normalize() again error = %v", err)
+			}
+			if second, _ := again.String(); second != got {
+				t.Errorf("Normalize() is not idempotent:\n%s\nwant:\n%s", second, got)
+			}
+		})
+	}
+}
+
+func TestDocument_NormalizeKeepsStyleAfterEdits(t *testing.T) {
+	doc, err := Load("a: 1  # short\nlonger: {x: 2} # long\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := doc.Normalize(DefaultNormalizeOptions()); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if err := doc.Set("longer.items", []interface{}{"one"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	want := "a: 1    # short\nlonger: # long\n  x: 2\n  items:\n    - one\n"
+	if got, _ := doc.String(); got != want {
+		t.Errorf("String() after edit:\n%s\nwant:\n%s", got, want)
+	}
+
+	clone := doc.Clone()
+	if got, _ := clone.String(); got != want {
+		t.Errorf("Clone().String():\n%s\nwant:\n%s", got, want)
+	}
+}
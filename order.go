@@ -0,0 +1,158 @@
+package yamler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SortOrder selects the direction SortKeys sorts in
+type SortOrder int
+
+const (
+	// Ascending sorts keys alphabetically from A to Z
+	Ascending SortOrder = iota
+	// Descending sorts keys alphabetically from Z to A
+	Descending
+)
+
+// SortKeys sorts the keys of the mapping at path, keeping each key's value and comments.
+// An empty path sorts the top-level keys; the sort is not recursive, like IsSorted.
+func (d *Document) SortKeys(path string, order SortOrder) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if order != Ascending && order != Descending {
+		return fmt.Errorf("invalid sort order: %d", order)
+	}
+
+	mapping, err := d.getNode(path)
+	if err != nil {
+		return err
+	}
+	mapping = resolveAlias(mapping)
+	if mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", mapping))
+	}
+
+	entries := mappingEntries(mapping)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if order == Descending {
+			return entries[i][0].Value > entries[j][0].Value
+		}
+		return entries[i][0].Value < entries[j][0].Value
+	})
+	setMappingEntries(mapping, entries)
+
+	d.markDirty()
+	return nil
+}
+
+// MoveKeyBefore moves the key at path directly before the key at beforePath in the same mapping,
+// e.g. MoveKeyBefore("metadata.labels", "metadata.annotations")
+func (d *Document) MoveKeyBefore(path, beforePath string) error {
+	return d.moveKeyNextTo(path, beforePath, 0)
+}
+
+// MoveKeyAfter moves the key at path directly after the key at afterPath in the same mapping
+func (d *Document) MoveKeyAfter(path, afterPath string) error {
+	return d.moveKeyNextTo(path, afterPath, 1)
+}
+
+// SetBefore sets the value at path like Set and places its key before the sibling key named before,
+// so new keys need not be appended at the end of their mapping
+func (d *Document) SetBefore(path, before string, value interface{}) error {
+	return d.setNextTo(path, before, value, 0)
+}
+
+// SetAfter sets the value at path like Set and places its key after the sibling key named after
+func (d *Document) SetAfter(path, after string, value interface{}) error {
+	return d.setNextTo(path, after, value, 1)
+}
+
+// setNextTo sets a value and moves its key next to a sibling key
+func (d *Document) setNextTo(path, sibling string, value interface{}, offset int) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	siblingPath := sibling
+	if idx := strings.LastIndex(path, "."); idx != -1 {
+		siblingPath = appendPathKey(path[:idx], sibling)
+	}
+	if _, _, err := d.findMappingEntry(siblingPath); err != nil {
+		return err
+	}
+	if err := d.Set(path, value); err != nil {
+		return err
+	}
+	return d.moveKeyNextTo(path, siblingPath, offset)
+}
+
+// moveKeyNextTo moves the key at path before (offset 0) or after (offset 1) the key at anchorPath
+func (d *Document) moveKeyNextTo(path, anchorPath string, offset int) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	mapping, from, err := d.findMappingEntry(path)
+	if err != nil {
+		return err
+	}
+	anchorMapping, anchorIndex, err := d.findMappingEntry(anchorPath)
+	if err != nil {
+		return err
+	}
+	if anchorMapping != mapping {
+		return fmt.Errorf("path %s: %s is not in the same mapping", path, anchorPath)
+	}
+	if from == anchorIndex {
+		return nil
+	}
+	anchorKey := mapping.Content[anchorIndex]
+
+	entries := mappingEntries(mapping)
+	moved := entries[from/2]
+	entries = append(entries[:from/2], entries[from/2+1:]...)
+	to := len(entries)
+	for i, entry := range entries {
+		if entry[0] == anchorKey {
+			to = i + offset
+			break
+		}
+	}
+	entries = append(entries[:to], append([][2]*yaml.Node{moved}, entries[to:]...)...)
+	setMappingEntries(mapping, entries)
+
+	d.markDirty()
+	return nil
+}
+
+// mappingEntries returns the key and value pairs of a mapping
+func mappingEntries(mapping *yaml.Node) [][2]*yaml.Node {
+	entries := make([][2]*yaml.Node, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		entries = append(entries, [2]*yaml.Node{mapping.Content[i], mapping.Content[i+1]})
+	}
+	return entries
+}
+
+// setMappingEntries replaces the entries of a mapping with reordered ones.
+// A foot comment on the last entry closes the mapping, so it stays at the end.
+func setMappingEntries(mapping *yaml.Node, entries [][2]*yaml.Node) {
+	if len(entries) == 0 {
+		return
+	}
+	oldLast := mapping.Content[len(mapping.Content)-2]
+	newLast := entries[len(entries)-1][0]
+	if oldLast != newLast && oldLast.FootComment != "" && newLast.FootComment == "" {
+		newLast.FootComment, oldLast.FootComment = oldLast.FootComment, ""
+	}
+
+	content := make([]*yaml.Node, 0, len(entries)*2)
+	for _, entry := range entries {
+		content = append(content, entry[0], entry[1])
+	}
+	mapping.Content = content
+}
@@ -0,0 +1,173 @@
+package yamler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const orderInput = `metadata:
+  name: web
+  annotations:
+    team: core
+  labels:
+    app: web # selector
+  zone: eu
+  # end of metadata
+spec:
+  replicas: 2
+`
+
+func TestDocument_SortKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		order SortOrder
+		want  string
+	}{
+		{
+			name:  "ascending keeps foot comment at the end",
+			path:  "metadata",
+			order: Ascending,
+			want:  "metadata:\n  annotations:\n    team: core\n  labels:\n    app: web # selector\n  name: web\n  zone: eu\n  # end of metadata\nspec:\n  replicas: 2\n",
+		},
+		{
+			name:  "descending top level",
+			path:  "",
+			order: Descending,
+			want:  "spec:\n  replicas: 2\nmetadata:\n  name: web\n  annotations:\n    team: core\n  labels:\n    app: web # selector\n  zone: eu\n  # end of metadata\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(orderInput)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := doc.SortKeys(tt.path, tt.order); err != nil {
+				t.Fatalf("SortKeys() error = %v", err)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SortKeys() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if tt.order == Ascending {
+				if sorted, err := doc.IsSorted(tt.path); err != nil || !sorted {
+					t.Errorf("IsSorted() after SortKeys() = %v, %v", sorted, err)
+				}
+			}
+		})
+	}
+
+	doc, err := Load(orderInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := doc.SortKeys("metadata.name", Ascending); err == nil {
+		t.Error("SortKeys() on a scalar should fail")
+	}
+}
+
+func TestDocument_MoveKeyBefore(t *testing.T) {
+	tests := []struct {
+		name    string
+		move    func(doc *Document) error
+		want    string
+		wantErr string
+	}{
+		{
+			name: "before",
+			move: func(doc *Document) error {
+				return doc.MoveKeyBefore("metadata.labels", "metadata.annotations")
+			},
+			want: "metadata:\n  name: web\n  labels:\n    app: web # selector\n  annotations:\n    team: core\n  zone: eu\n  # end of metadata\nspec:\n  replicas: 2\n",
+		},
+		{
+			name: "after the last key",
+			move: func(doc *Document) error {
+				return doc.MoveKeyAfter("metadata.name", "metadata.zone")
+			},
+			want: "metadata:\n  annotations:\n    team: core\n  labels:\n    app: web # selector\n  zone: eu\n  name: web\n  # end of metadata\nspec:\n  replicas: 2\n",
+		},
+		{
+			name: "top level",
+			move: func(doc *Document) error {
+				return doc.MoveKeyBefore("spec", "metadata")
+			},
+			want: "spec:\n  replicas: 2\nmetadata:\n  name: web\n  annotations:\n    team: core\n  labels:\n    app: web # selector\n  zone: eu\n  # end of metadata\n",
+		},
+		{
+			name: "different mappings",
+			move: func(doc *Document) error {
+				return doc.MoveKeyBefore("spec.replicas", "metadata.name")
+			},
+			wantErr: "not in the same mapping",
+		},
+		{
+			name: "new key before a sibling",
+			move: func(doc *Document) error {
+				return doc.SetBefore("metadata.namespace", "annotations", "prod")
+			},
+			want: "metadata:\n  name: web\n  namespace: prod\n  annotations:\n    team: core\n  labels:\n    app: web # selector\n  zone: eu\n  # end of metadata\nspec:\n  replicas: 2\n",
+		},
+		{
+			name: "new top-level key after a sibling",
+			move: func(doc *Document) error {
+				return doc.SetAfter("kind", "metadata", "Deployment")
+			},
+			want: "metadata:\n  name: web\n  annotations:\n    team: core\n  labels:\n    app: web # selector\n  zone: eu\n  # end of metadata\nkind: Deployment\nspec:\n  replicas: 2\n",
+		},
+		{
+			name: "missing sibling",
+			move: func(doc *Document) error {
+				return doc.SetBefore("metadata.namespace", "missing", "prod")
+			},
+			wantErr: "key missing not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(orderInput)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			err = tt.move(doc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if got, _ := doc.String(); got != orderInput {
+					t.Errorf("failed move changed the document:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	doc, err := Load(orderInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	doc.Freeze()
+	if err := doc.SortKeys("", Ascending); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SortKeys() on frozen document error = %v, want ErrReadOnly", err)
+	}
+	if err := doc.MoveKeyAfter("spec", "metadata"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("MoveKeyAfter() on frozen document error = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,119 @@
+package yamler
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputOptions controls how ToBytesWithOptions renders a document.
+// The zero value renders exactly like ToBytes.
+type OutputOptions struct {
+	// Indent is the indentation width in spaces; 0 keeps the document's own
+	Indent int
+	// BlockArrays renders every array in block style
+	BlockArrays bool
+	// FlowThreshold renders collections of scalars in flow style when their flow form
+	// fits in this many characters, and in block style otherwise; 0 disables it
+	FlowThreshold int
+	// StripComments removes all comments from the output
+	StripComments bool
+}
+
+// ToBytesWithOptions renders the document with the given output options.
+// Rendering happens on a copy, so the document itself is not changed.
+func (d *Document) ToBytesWithOptions(opts OutputOptions) ([]byte, error) {
+	if opts == (OutputOptions{}) {
+		return d.ToBytes()
+	}
+	if opts.Indent < 0 {
+		return nil, fmt.Errorf("invalid indent size: %d", opts.Indent)
+	}
+	if opts.FlowThreshold < 0 {
+		return nil, fmt.Errorf("invalid flow threshold: %d", opts.FlowThreshold)
+	}
+	if d.root == nil || len(d.root.Content) == 0 {
+		return []byte{}, nil
+	}
+
+	root, err := cloneNode(d.root)
+	if err != nil {
+		return nil, err
+	}
+	if opts.StripComments {
+		stripComments(root)
+	}
+	restyle := opts.BlockArrays || opts.FlowThreshold > 0
+	if restyle {
+		applyCollectionStyles(root, opts)
+	}
+
+	return d.renderCopy(root, func(info *FormattingInfo) {
+		if opts.Indent > 0 {
+			setIndentSize(info, opts.Indent)
+		}
+		if restyle {
+			// Original flow layouts would undo the requested styles
+			info.FlowStyles = make(map[string]bool)
+			info.MultilineFlow = make(map[string]bool)
+			info.ArrayStyles = make(map[string]*ArrayStyle)
+			info.pathArrayStyles = nil
+			info.FlowObjectStyles = make(map[string]string)
+		}
+		if opts.StripComments {
+			info.CommentAlignment = make(map[string]int)
+		}
+	})
+}
+
+// stripComments removes head, line and foot comments from node and its children
+func stripComments(node *yaml.Node) {
+	node.HeadComment = ""
+	node.LineComment = ""
+	node.FootComment = ""
+	for _, child := range node.Content {
+		stripComments(child)
+	}
+}
+
+// applyCollectionStyles sets flow or block style on collections according to the options
+func applyCollectionStyles(node *yaml.Node, opts OutputOptions) {
+	for _, child := range node.Content {
+		applyCollectionStyles(child, opts)
+	}
+
+	if node.Kind != yaml.SequenceNode && node.Kind != yaml.MappingNode {
+		return
+	}
+
+	if opts.FlowThreshold > 0 && len(node.Content) > 0 {
+		if width, ok := flowWidth(node); ok {
+			if width <= opts.FlowThreshold {
+				node.Style |= yaml.FlowStyle
+			} else {
+				node.Style &^= yaml.FlowStyle
+			}
+		}
+	}
+	if opts.BlockArrays && node.Kind == yaml.SequenceNode {
+		node.Style &^= yaml.FlowStyle
+	}
+}
+
+// flowWidth estimates the length of a scalar-only collection in flow style, e.g. [a, b] or {k: v}
+func flowWidth(node *yaml.Node) (int, bool) {
+	width := 2 // brackets
+	for i, child := range node.Content {
+		if child.Kind != yaml.ScalarNode {
+			return 0, false
+		}
+		width += len(child.Value)
+		if child.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			width += 2
+		}
+		if i > 0 {
+			width += 2 // ", " between items, ": " between a key and its value
+		}
+	}
+	return width, true
+}
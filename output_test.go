@@ -0,0 +1,137 @@
+package yamler
+
+import (
+	"testing"
+)
+
+func TestDocument_ToBytesWithOptions(t *testing.T) {
+	content := `# Server settings
+server:
+  port: 8080 # default
+  tags: [web, api]
+  hosts:
+    - a
+    - b
+long:
+  - aaaaaaaaaaaa
+  - bbbbbbbbbbbbbb
+`
+
+	tests := []struct {
+		name    string
+		opts    OutputOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "zero options match ToBytes",
+			opts: OutputOptions{},
+			want: content,
+		},
+		{
+			name: "indent width",
+			opts: OutputOptions{Indent: 4},
+			want: `# Server settings
+server:
+    port: 8080 # default
+    tags: [web, api]
+    hosts:
+        - a
+        - b
+long:
+    - aaaaaaaaaaaa
+    - bbbbbbbbbbbbbb
+`,
+		},
+		{
+			name: "block arrays",
+			opts: OutputOptions{BlockArrays: true},
+			want: `# Server settings
+server:
+  port: 8080 # default
+  tags:
+    - web
+    - api
+  hosts:
+    - a
+    - b
+long:
+  - aaaaaaaaaaaa
+  - bbbbbbbbbbbbbb
+`,
+		},
+		{
+			name: "flow threshold",
+			opts: OutputOptions{FlowThreshold: 12},
+			want: `# Server settings
+server:
+  port: 8080 # default
+  tags: [web, api]
+  hosts: [a, b]
+long:
+  - aaaaaaaaaaaa
+  - bbbbbbbbbbbbbb
+`,
+		},
+		{
+			name: "strip comments",
+			opts: OutputOptions{StripComments: true},
+			want: `server:
+  port: 8080
+  tags: [web, api]
+  hosts:
+    - a
+    - b
+long:
+  - aaaaaaaaaaaa
+  - bbbbbbbbbbbbbb
+`,
+		},
+		{
+			name: "combined",
+			opts: OutputOptions{Indent: 4, BlockArrays: true, StripComments: true},
+			want: `server:
+    port: 8080
+    tags:
+        - web
+        - api
+    hosts:
+        - a
+        - b
+long:
+    - aaaaaaaaaaaa
+    - bbbbbbbbbbbbbb
+`,
+		},
+		{
+			name:    "negative indent",
+			opts:    OutputOptions{Indent: -1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			got, err := doc.ToBytesWithOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToBytesWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToBytesWithOptions() =\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// The document itself must be unchanged
+			if after, _ := doc.String(); after != content {
+				t.Errorf("document changed after ToBytesWithOptions():\n%s", after)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package yamler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// placeholderPattern matches ${VAR}, ${VAR:-default} and {{VAR}} tokens
+var placeholderPattern = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*(?::?-[^}]*)?\}|\{\{\s*[A-Za-z_][A-Za-z0-9_]*\s*\}\}`)
+
+// UnresolvedPlaceholders returns every ${VAR} and {{VAR}} token still present in the document,
+// each once and in document order, so missing values can be reported before the config is used.
+// Expressions such as ${{ env.NAME }} are not placeholders and are skipped.
+func (d *Document) UnresolvedPlaceholders() ([]string, error) {
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	collectPlaceholders(root, add)
+
+	return tokens, nil
+}
+
+// collectPlaceholders reports the placeholder tokens found in node and its children
+func collectPlaceholders(node *yaml.Node, add func(string)) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return
+		}
+		for _, loc := range placeholderPattern.FindAllStringIndex(node.Value, -1) {
+			token := node.Value[loc[0]:loc[1]]
+			if token[0] == '{' && loc[0] > 0 && node.Value[loc[0]-1] == '$' {
+				continue
+			}
+			add(token)
+		}
+	case yaml.MappingNode:
+		// An unquoted {{VAR}} parses as a flow mapping holding the mapping {VAR: null}
+		if name, ok := flowPlaceholder(node); ok {
+			add("{{" + name + "}}")
+			return
+		}
+		for _, child := range node.Content {
+			collectPlaceholders(child, add)
+		}
+	default:
+		for _, child := range node.Content {
+			collectPlaceholders(child, add)
+		}
+	}
+}
+
+// flowPlaceholder recognizes the node an unquoted {{VAR}} is parsed into and returns VAR
+func flowPlaceholder(node *yaml.Node) (string, bool) {
+	if node.Style&yaml.FlowStyle == 0 || len(node.Content) != 2 || node.Content[1].Tag != "!!null" {
+		return "", false
+	}
+	inner := node.Content[0]
+	if inner.Kind != yaml.MappingNode || inner.Style&yaml.FlowStyle == 0 || len(inner.Content) != 2 {
+		return "", false
+	}
+	name, value := inner.Content[0], inner.Content[1]
+	if name.Kind != yaml.ScalarNode || value.Tag != "!!null" || !placeholderPattern.MatchString("{{"+name.Value+"}}") {
+		return "", false
+	}
+	return name.Value, true
+}
+
+// TemplateOptions controls ApplyTemplate
+type TemplateOptions struct {
+	// Delims are the opening and closing placeholder markers, {{ and }} when empty
+	Delims [2]string
+}
+
+// ApplyTemplate replaces placeholders such as {{PORT}} in the document's values with entries of values
+// and returns the placeholders that had no entry, each once and in document order.
+// A value that is exactly one placeholder takes the type of its replacement, so {{PORT}} with 8080
+// becomes an integer; a string replacement for an unquoted placeholder is typed like plain YAML,
+// so "8080" becomes an integer too, while quoted placeholders stay strings. Placeholders inside
+// longer strings are replaced by the formatted value. Keys are left alone, and unresolved
+// unquoted placeholders are kept as quoted strings.
+func (d *Document) ApplyTemplate(values map[string]interface{}, opts TemplateOptions) ([]string, error) {
+	if err := d.checkWritable(); err != nil {
+		return nil, err
+	}
+	left, right := opts.Delims[0], opts.Delims[1]
+	if left == "" && right == "" {
+		left, right = "{{", "}}"
+	}
+	if left == "" || right == "" {
+		return nil, fmt.Errorf("template delimiters must not be empty")
+	}
+
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &templateApplier{
+		pattern:    regexp.MustCompile(regexp.QuoteMeta(left) + `\s*([A-Za-z_][A-Za-z0-9_]*)\s*` + regexp.QuoteMeta(right)),
+		left:       left,
+		right:      right,
+		values:     values,
+		unresolved: make(map[string]bool),
+	}
+	if err := t.apply(root); err != nil {
+		return nil, err
+	}
+	d.markChanged()
+	return t.missing, nil
+}
+
+// templateApplier substitutes placeholders while walking a node tree
+type templateApplier struct {
+	pattern     *regexp.Regexp
+	left, right string // placeholder delimiters
+	values      map[string]interface{}
+	unresolved  map[string]bool
+	missing     []string
+}
+
+// apply substitutes placeholders in the values below node
+func (t *templateApplier) apply(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return t.applyScalar(node)
+	case yaml.MappingNode:
+		// An unquoted {{VAR}} parses as a flow mapping holding the mapping {VAR: null}
+		if name, ok := flowPlaceholder(node); ok && t.left == "{{" && t.right == "}}" {
+			return t.replaceWhole(node, name, "{{"+name+"}}", true)
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := t.apply(node.Content[i]); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := t.apply(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyScalar substitutes the placeholders of a string scalar
+func (t *templateApplier) applyScalar(node *yaml.Node) error {
+	if node.Tag != "!!str" {
+		return nil
+	}
+	locs := t.pattern.FindAllStringSubmatchIndex(node.Value, -1)
+	if len(locs) == 1 && locs[0][0] == 0 && locs[0][1] == len(node.Value) {
+		name := node.Value[locs[0][2]:locs[0][3]]
+		return t.replaceWhole(node, name, node.Value, node.Style == 0)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		token, name := node.Value[loc[0]:loc[1]], node.Value[loc[2]:loc[3]]
+		b.WriteString(node.Value[last:loc[0]])
+		last = loc[1]
+		value, ok := t.values[name]
+		switch {
+		case t.left == "{{" && loc[0] > 0 && node.Value[loc[0]-1] == '$':
+			// ${{ expr }} is a workflow expression, not a placeholder
+			b.WriteString(token)
+		case !ok:
+			t.report(token)
+			b.WriteString(token)
+		default:
+			b.WriteString(fmt.Sprint(value))
+		}
+	}
+	b.WriteString(node.Value[last:])
+	node.Value = b.String()
+	return nil
+}
+
+// replaceWhole replaces a node that is exactly one placeholder with the typed value, keeping its comments
+func (t *templateApplier) replaceWhole(node *yaml.Node, name, token string, plain bool) error {
+	value, ok := t.values[name]
+	if !ok {
+		t.report(token)
+		if node.Kind == yaml.MappingNode {
+			// Keep an unquoted placeholder readable; as a flow mapping it would render as {? {VAR: ''} : ''}
+			node.Kind, node.Tag, node.Style, node.Value, node.Content = yaml.ScalarNode, "!!str", 0, token, nil
+		}
+		return nil
+	}
+
+	var replacement *yaml.Node
+	switch v := value.(type) {
+	case string:
+		if plain {
+			replacement = plainScalarNode(v)
+		} else {
+			replacement = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v, Style: node.Style}
+		}
+	default:
+		replacement = &yaml.Node{}
+		if err := replacement.Encode(value); err != nil {
+			return fmt.Errorf("placeholder %s: %w", token, err)
+		}
+	}
+
+	replacement.HeadComment = node.HeadComment
+	replacement.LineComment = node.LineComment
+	replacement.FootComment = node.FootComment
+	*node = *replacement
+	return nil
+}
+
+// report records an unresolved placeholder once
+func (t *templateApplier) report(token string) {
+	if !t.unresolved[token] {
+		t.unresolved[token] = true
+		t.missing = append(t.missing, token)
+	}
+}
+
+// plainScalarNode returns s as a scalar typed the way YAML types it when written unquoted,
+// or as a string if it would not read back as the same scalar
+func plainScalarNode(s string) *yaml.Node {
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &parsed); err == nil && len(parsed.Content) == 1 {
+		scalar := parsed.Content[0]
+		if scalar.Kind == yaml.ScalarNode && scalar.Style == 0 && scalar.Value == s {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: scalar.Tag, Value: s}
+		}
+	}
+	node := &yaml.Node{}
+	node.SetString(s)
+	return node
+}
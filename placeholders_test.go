@@ -0,0 +1,195 @@
+package yamler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocument_UnresolvedPlaceholders(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "env and template tokens",
+			content: `app:
+  url: "https://${HOST}:${PORT:-80}/api"
+  password: "{{SECRET_DB_PASSWORD}}"
+  token: '{{ API_TOKEN }}'
+  again: ${HOST}
+  port: 8080
+`,
+			want: []string{"${HOST}", "${PORT:-80}", "{{SECRET_DB_PASSWORD}}", "{{ API_TOKEN }}"},
+		},
+		{
+			name:    "unquoted template token",
+			content: "app:\n  name: {{APP_NAME}}\n",
+			want:    []string{"{{APP_NAME}}"},
+		},
+		{
+			name:    "workflow expressions are not placeholders",
+			content: "steps:\n  - run: echo ${{ env.NODE_VERSION }} ${{TOKEN}}\n",
+			want:    nil,
+		},
+		{
+			name:    "array document",
+			content: "- name: ${NAME}\n- name: fixed\n",
+			want:    []string{"${NAME}"},
+		},
+		{
+			name:    "nothing to resolve",
+			content: "app:\n  name: demo\n",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			got, err := doc.UnresolvedPlaceholders()
+			if err != nil {
+				t.Fatalf("UnresolvedPlaceholders() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnresolvedPlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("resolved values disappear", func(t *testing.T) {
+		doc, err := Load("db:\n  host: ${DB_HOST}\n  password: \"{{SECRET_DB_PASSWORD}}\"\n")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if err := doc.Set("db.host", "prod-db.internal"); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+
+		got, err := doc.UnresolvedPlaceholders()
+		if err != nil {
+			t.Fatalf("UnresolvedPlaceholders() error = %v", err)
+		}
+		if want := []string{"{{SECRET_DB_PASSWORD}}"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("UnresolvedPlaceholders() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestDocument_ApplyTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		values         map[string]interface{}
+		opts           TemplateOptions
+		want           string
+		wantUnresolved []string
+	}{
+		{
+			name: "typed replacements",
+			content: `# server
+server:
+  host: {{HOST}}   # bind address
+  port: {{PORT}}
+  debug: {{ DEBUG }}
+  workers: {{WORKERS}}
+  version: "{{VERSION}}"
+`,
+			values: map[string]interface{}{"HOST": "0.0.0.0", "PORT": 8080, "DEBUG": false, "WORKERS": "4", "VERSION": "1.10"},
+			want: `# server
+server:
+  host: 0.0.0.0   # bind address
+  port: 8080
+  debug: false
+  workers: 4
+  version: "1.10"
+`,
+		},
+		{
+			name:    "placeholders inside strings",
+			content: "url: \"https://{{HOST}}:{{PORT}}/api\"\nrun: echo ${{ env.NAME }} {{NAME}}\n",
+			values:  map[string]interface{}{"HOST": "example.com", "PORT": 443, "NAME": "app"},
+			want:    "url: \"https://example.com:443/api\"\nrun: echo ${{ env.NAME }} app\n",
+		},
+		{
+			name:           "unresolved placeholders are reported and kept",
+			content:        "db:\n  user: {{DB_USER}}\n  password: \"{{DB_PASSWORD}}\"\n  dsn: \"{{DB_USER}}@{{DB_HOST}}\"\n",
+			values:         map[string]interface{}{},
+			want:           "db:\n  user: '{{DB_USER}}'\n  password: \"{{DB_PASSWORD}}\"\n  dsn: \"{{DB_USER}}@{{DB_HOST}}\"\n",
+			wantUnresolved: []string{"{{DB_USER}}", "{{DB_PASSWORD}}", "{{DB_HOST}}"},
+		},
+		{
+			name:    "quoted placeholder with non-string value",
+			content: "port: \"{{PORT}}\"\nempty: {{EMPTY}}\n",
+			values:  map[string]interface{}{"PORT": 8080, "EMPTY": ""},
+			want:    "port: 8080\nempty: \"\"\n",
+		},
+		{
+			name:    "custom delimiters",
+			content: "name: ${NAME}\nurl: \"http://${HOST}\"\nkeep: \"{{NAME}}\"\n",
+			values:  map[string]interface{}{"NAME": "app", "HOST": "localhost"},
+			opts:    TemplateOptions{Delims: [2]string{"${", "}"}},
+			want:    "name: app\nurl: \"http://localhost\"\nkeep: \"{{NAME}}\"\n",
+		},
+		{
+			name:    "collection value",
+			content: "tags: {{TAGS}}\n",
+			values:  map[string]interface{}{"TAGS": []string{"a", "b"}},
+			want:    "tags:\n  - a\n  - b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			unresolved, err := doc.ApplyTemplate(tt.values, tt.opts)
+			if err != nil {
+				t.Fatalf("ApplyTemplate() error = %v", err)
+			}
+			if !reflect.DeepEqual(unresolved, tt.wantUnresolved) {
+				t.Errorf("ApplyTemplate() unresolved = %q, want %q", unresolved, tt.wantUnresolved)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyTemplate() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_ApplyTemplateTypes(t *testing.T) {
+	doc, err := Load("port: {{PORT}}\nratio: {{RATIO}}\nname: '{{NAME}}'\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := doc.ApplyTemplate(map[string]interface{}{"PORT": "8080", "RATIO": 0.5, "NAME": "42"}, TemplateOptions{}); err != nil {
+		t.Fatalf("ApplyTemplate() error = %v", err)
+	}
+
+	if port, err := doc.GetInt("port"); err != nil || port != 8080 {
+		t.Errorf("GetInt(port) = %d, %v; want 8080", port, err)
+	}
+	if ratio, err := doc.GetFloat("ratio"); err != nil || ratio != 0.5 {
+		t.Errorf("GetFloat(ratio) = %v, %v; want 0.5", ratio, err)
+	}
+	if name, err := doc.Get("name"); err != nil || name != "42" {
+		t.Errorf("Get(name) = %#v, %v; want string 42", name, err)
+	}
+
+	doc.Freeze()
+	if _, err := doc.ApplyTemplate(nil, TemplateOptions{}); err != ErrReadOnly {
+		t.Errorf("ApplyTemplate() on frozen document error = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,683 @@
+package yamler
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// QueryResult is one match of Query: its path, in the form Get accepts, and its value
+type QueryResult struct {
+	Path  string
+	Value interface{}
+}
+
+// Query selects values with a JSONPath-like expression and returns them in document order.
+// Besides keys, * and ** (as in GetAll), any segment may be followed by:
+//   - [n] - array element; negative indices count from the end
+//   - [*] - every element
+//   - [start:end] - a slice of elements; either bound may be omitted or negative
+//   - [?(filter)] - the elements of an array, or the node itself otherwise, for which filter holds
+//
+// Filters compare @ (the candidate) or @.sub.path with numbers, quoted strings, true, false or null
+// using ==, !=, <, <=, >, >=, combined with &&, || and !; a bare @.key tests that the key exists.
+// For example services.*[?(@.port > 8000)].name returns the names of services above port 8000,
+// and containers[?(@.name == "nginx")] the nginx container.
+func (d *Document) Query(expr string) ([]QueryResult, error) {
+	steps, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := evalQuery([]queryMatch{{path: "", node: root}}, steps)
+	results := make([]QueryResult, 0, len(matches))
+	seen := make(map[string]bool, len(matches))
+	for _, match := range matches {
+		if seen[match.path] {
+			continue
+		}
+		seen[match.path] = true
+
+		value, err := d.toInterface(match.node)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", match.path, err)
+		}
+		results = append(results, QueryResult{Path: match.path, Value: value})
+	}
+	return results, nil
+}
+
+type queryStepKind int
+
+const (
+	stepKey queryStepKind = iota
+	stepWildcard
+	stepRecursive
+	stepIndex
+	stepSlice
+	stepFilter
+)
+
+// queryStep is one segment of a query expression
+type queryStep struct {
+	kind       queryStepKind
+	key        string
+	index      int
+	start, end *int
+	filter     queryFilter
+}
+
+// parseQuery splits a query expression into steps
+func parseQuery(expr string) ([]queryStep, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	rest = strings.TrimPrefix(rest, ".")
+	if rest == "" {
+		return nil, fmt.Errorf("query %s: empty expression", expr)
+	}
+
+	var steps []queryStep
+	for i := 0; i < len(rest); {
+		switch rest[i] {
+		case '[':
+			end, err := findBracketEnd(rest, i)
+			if err != nil {
+				return nil, fmt.Errorf("query %s: %w", expr, err)
+			}
+			step, err := parseBracket(rest[i+1 : end])
+			if err != nil {
+				return nil, fmt.Errorf("query %s: %w", expr, err)
+			}
+			steps = append(steps, step)
+			i = end + 1
+		case '.':
+			if i == 0 || i+1 >= len(rest) || rest[i+1] == '.' || rest[i+1] == '[' {
+				return nil, fmt.Errorf("query %s: empty segment at offset %d", expr, i)
+			}
+			i++
+		default:
+			if i > 0 && rest[i-1] == ']' {
+				return nil, fmt.Errorf("query %s: expected . or [ at offset %d", expr, i)
+			}
+			end := i
+			for end < len(rest) && rest[end] != '.' && rest[end] != '[' {
+				end++
+			}
+			switch name := rest[i:end]; name {
+			case "*":
+				steps = append(steps, queryStep{kind: stepWildcard})
+			case "**":
+				steps = append(steps, queryStep{kind: stepRecursive})
+			default:
+				steps = append(steps, queryStep{kind: stepKey, key: name})
+			}
+			i = end
+		}
+	}
+	return steps, nil
+}
+
+// findBracketEnd returns the index of the "]" closing the bracket at start,
+// skipping brackets nested in filters and quoted strings
+func findBracketEnd(s string, start int) (int, error) {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+			if depth == 0 {
+				if c != ']' {
+					return 0, fmt.Errorf("unbalanced parentheses at offset %d", i)
+				}
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed bracket at offset %d", start)
+}
+
+// parseBracket parses the content of a [...] segment
+func parseBracket(content string) (queryStep, error) {
+	content = strings.TrimSpace(content)
+	switch {
+	case content == "*":
+		return queryStep{kind: stepWildcard}, nil
+	case strings.HasPrefix(content, "?"):
+		inner := strings.TrimSpace(content[1:])
+		if !strings.HasPrefix(inner, "(") || !strings.HasSuffix(inner, ")") {
+			return queryStep{}, fmt.Errorf("filter %s: expected ?(...)", content)
+		}
+		filter, err := parseFilter(inner[1 : len(inner)-1])
+		if err != nil {
+			return queryStep{}, err
+		}
+		return queryStep{kind: stepFilter, filter: filter}, nil
+	case strings.HasPrefix(content, `"`) || strings.HasPrefix(content, "'"):
+		key, err := unquoteQueryString(content)
+		if err != nil {
+			return queryStep{}, err
+		}
+		return queryStep{kind: stepKey, key: key}, nil
+	case strings.Contains(content, ":"):
+		bounds := strings.Split(content, ":")
+		if len(bounds) != 2 {
+			return queryStep{}, fmt.Errorf("invalid slice: [%s]", content)
+		}
+		step := queryStep{kind: stepSlice}
+		for i, bound := range bounds {
+			bound = strings.TrimSpace(bound)
+			if bound == "" {
+				continue
+			}
+			n, err := strconv.Atoi(bound)
+			if err != nil {
+				return queryStep{}, fmt.Errorf("invalid slice bound: %s", bound)
+			}
+			if i == 0 {
+				step.start = &n
+			} else {
+				step.end = &n
+			}
+		}
+		return step, nil
+	}
+
+	n, err := strconv.Atoi(content)
+	if err != nil {
+		return queryStep{}, fmt.Errorf("invalid index: [%s]", content)
+	}
+	return queryStep{kind: stepIndex, index: n}, nil
+}
+
+// unquoteQueryString strips the quotes of a '...' or "..." literal
+func unquoteQueryString(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("unterminated string: %s", s)
+	}
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], `\'`, "'"), nil
+	}
+	return strconv.Unquote(s)
+}
+
+// evalQuery applies the steps to the current matches
+func evalQuery(current []queryMatch, steps []queryStep) []queryMatch {
+	for _, step := range steps {
+		var next []queryMatch
+		for _, match := range current {
+			next = applyQueryStep(next, match, step)
+		}
+		current = next
+	}
+	return current
+}
+
+// applyQueryStep appends the matches of a single step below match
+func applyQueryStep(out []queryMatch, match queryMatch, step queryStep) []queryMatch {
+	node := resolveAlias(match.node)
+	switch step.kind {
+	case stepKey:
+		if node.Kind == yaml.MappingNode {
+			if child, found := findKeyInMapping(node, step.key); found {
+				out = append(out, queryMatch{path: appendPathKey(match.path, step.key), node: child})
+			}
+		}
+	case stepWildcard:
+		out = appendQueryChildren(out, match.path, node)
+	case stepRecursive:
+		out = append(out, match)
+		for _, child := range appendQueryChildren(nil, match.path, node) {
+			out = applyQueryStep(out, child, step)
+		}
+	case stepIndex:
+		if node.Kind == yaml.SequenceNode {
+			if i, err := elementIndex(step.index, len(node.Content)); err == nil {
+				out = append(out, queryMatch{path: fmt.Sprintf("%s[%d]", match.path, i), node: node.Content[i]})
+			}
+		}
+	case stepSlice:
+		if node.Kind == yaml.SequenceNode {
+			start, end := sliceBounds(step.start, step.end, len(node.Content))
+			for i := start; i < end; i++ {
+				out = append(out, queryMatch{path: fmt.Sprintf("%s[%d]", match.path, i), node: node.Content[i]})
+			}
+		}
+	case stepFilter:
+		if node.Kind == yaml.SequenceNode {
+			for _, item := range appendQueryChildren(nil, match.path, node) {
+				if step.filter.match(item.node) {
+					out = append(out, item)
+				}
+			}
+		} else if step.filter.match(node) {
+			out = append(out, match)
+		}
+	}
+	return out
+}
+
+// appendQueryChildren appends the values of a mapping or the elements of a sequence
+func appendQueryChildren(out []queryMatch, path string, node *yaml.Node) []queryMatch {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			out = append(out, queryMatch{path: appendPathKey(path, node.Content[i].Value), node: node.Content[i+1]})
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			out = append(out, queryMatch{path: fmt.Sprintf("%s[%d]", path, i), node: item})
+		}
+	}
+	return out
+}
+
+// sliceBounds resolves optional, possibly negative slice bounds against length, clamping them like Python
+func sliceBounds(start, end *int, length int) (int, int) {
+	resolve := func(bound *int, fallback int) int {
+		if bound == nil {
+			return fallback
+		}
+		n := *bound
+		if n < 0 {
+			n += length
+		}
+		if n < 0 {
+			return 0
+		}
+		if n > length {
+			return length
+		}
+		return n
+	}
+	return resolve(start, 0), resolve(end, length)
+}
+
+// queryFilter is a parsed [?(...)] condition
+type queryFilter interface {
+	match(node *yaml.Node) bool
+}
+
+type filterOr struct{ left, right queryFilter }
+
+func (f filterOr) match(node *yaml.Node) bool { return f.left.match(node) || f.right.match(node) }
+
+type filterAnd struct{ left, right queryFilter }
+
+func (f filterAnd) match(node *yaml.Node) bool { return f.left.match(node) && f.right.match(node) }
+
+type filterNot struct{ inner queryFilter }
+
+func (f filterNot) match(node *yaml.Node) bool { return !f.inner.match(node) }
+
+// filterExists holds when the operand path exists below the candidate
+type filterExists struct{ operand filterOperand }
+
+func (f filterExists) match(node *yaml.Node) bool {
+	_, ok := f.operand.value(node)
+	return ok
+}
+
+// filterCompare compares two operands; missing values never compare
+type filterCompare struct {
+	left, right filterOperand
+	op          string
+}
+
+func (f filterCompare) match(node *yaml.Node) bool {
+	left, ok := f.left.value(node)
+	if !ok {
+		return false
+	}
+	right, ok := f.right.value(node)
+	if !ok {
+		return false
+	}
+	return compareFilterValues(left, right, f.op)
+}
+
+// filterOperand is either an @ path or a literal
+type filterOperand struct {
+	steps   []queryStep
+	isPath  bool
+	literal interface{}
+}
+
+// value returns the operand's value for the candidate node, false if the path does not exist
+func (o filterOperand) value(node *yaml.Node) (interface{}, bool) {
+	if !o.isPath {
+		return o.literal, true
+	}
+	matches := evalQuery([]queryMatch{{node: node}}, o.steps)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	target := resolveAlias(matches[0].node)
+	if target.Kind != yaml.ScalarNode {
+		value, err := nodeToInterface(target)
+		return value, err == nil
+	}
+
+	var value interface{}
+	if err := target.Decode(&value); err != nil {
+		return nil, false
+	}
+	if _, ok := value.(string); !ok && target.ShortTag() == "!!timestamp" {
+		// Dates compare as written
+		value = target.Value
+	}
+	return value, true
+}
+
+// compareFilterValues applies a comparison operator; numbers compare across int and float
+func compareFilterValues(left, right interface{}, op string) bool {
+	if l, ok := filterNumber(left); ok {
+		if r, ok := filterNumber(right); ok {
+			switch op {
+			case "==":
+				return l == r
+			case "!=":
+				return l != r
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			case ">=":
+				return l >= r
+			}
+			return false
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			switch op {
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			case ">=":
+				return l >= r
+			}
+		}
+	}
+
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+	return false
+}
+
+// filterNumber converts numeric filter values to float64
+func filterNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// parseFilter parses the condition inside [?(...)]
+func parseFilter(expr string) (queryFilter, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s: %w", expr, err)
+	}
+	p := &filterParser{tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("filter %s: %w", expr, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("filter %s: unexpected %s", expr, p.tokens[p.pos].text)
+	}
+	return filter, nil
+}
+
+type filterTokenKind int
+
+const (
+	tokenOperator filterTokenKind = iota
+	tokenPath
+	tokenLiteral
+)
+
+type filterToken struct {
+	kind    filterTokenKind
+	text    string
+	literal interface{}
+}
+
+// tokenizeFilter splits a filter into operators, @ paths and literals
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, filterToken{kind: tokenOperator, text: string(c)})
+			i++
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"),
+			strings.HasPrefix(expr[i:], "=="), strings.HasPrefix(expr[i:], "!="),
+			strings.HasPrefix(expr[i:], "<="), strings.HasPrefix(expr[i:], ">="):
+			tokens = append(tokens, filterToken{kind: tokenOperator, text: expr[i : i+2]})
+			i += 2
+		case c == '<' || c == '>' || c == '!':
+			tokens = append(tokens, filterToken{kind: tokenOperator, text: string(c)})
+			i++
+		case c == '@':
+			end := i + 1
+			for end < len(expr) && !strings.ContainsRune(" \t()=!<>&|", rune(expr[end])) {
+				if expr[end] == '[' {
+					close, err := findBracketEnd(expr, end)
+					if err != nil {
+						return nil, err
+					}
+					end = close
+				}
+				end++
+			}
+			tokens = append(tokens, filterToken{kind: tokenPath, text: expr[i:end]})
+			i = end
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != c {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			s, err := unquoteQueryString(expr[i : end+1])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: tokenLiteral, text: expr[i : end+1], literal: s})
+			i = end + 1
+		default:
+			end := i
+			for end < len(expr) && !strings.ContainsRune(" \t()=!<>&|", rune(expr[end])) {
+				end++
+			}
+			word := expr[i:end]
+			if word == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			literal, err := parseFilterLiteral(word)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: tokenLiteral, text: word, literal: literal})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// parseFilterLiteral parses a number, true, false or null
+func parseFilterLiteral(word string) (interface{}, error) {
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if n, err := strconv.ParseInt(word, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(word, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid literal %s", word)
+}
+
+// filterParser is a recursive descent parser over filter tokens:
+// or := and ("||" and)*, and := unary ("&&" unary)*, unary := "!" unary | "(" or ")" | comparison
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peekOperator(text string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOperator && p.tokens[p.pos].text == text
+}
+
+func (p *filterParser) parseOr() (queryFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (queryFilter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = filterAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (queryFilter, error) {
+	switch {
+	case p.peekOperator("!"):
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{inner: inner}, nil
+	case p.peekOperator("("):
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekOperator(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (queryFilter, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.peekOperator(op) {
+			p.pos++
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return filterCompare{left: left, right: right, op: op}, nil
+		}
+	}
+
+	if !left.isPath {
+		return nil, fmt.Errorf("expected comparison after literal")
+	}
+	return filterExists{operand: left}, nil
+}
+
+func (p *filterParser) parseOperand() (filterOperand, error) {
+	if p.pos >= len(p.tokens) {
+		return filterOperand{}, fmt.Errorf("unexpected end of filter")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case tokenLiteral:
+		return filterOperand{literal: token.literal}, nil
+	case tokenPath:
+		path := strings.TrimPrefix(strings.TrimPrefix(token.text, "@"), ".")
+		if path == "" {
+			return filterOperand{isPath: true}, nil
+		}
+		steps, err := parseQuery(path)
+		if err != nil {
+			return filterOperand{}, err
+		}
+		return filterOperand{isPath: true, steps: steps}, nil
+	}
+	return filterOperand{}, fmt.Errorf("unexpected %s", token.text)
+}
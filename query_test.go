@@ -0,0 +1,203 @@
+package yamler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const queryInput = `services:
+  web:
+    name: frontend
+    port: 8080
+    tags: [public, http]
+  api:
+    name: backend
+    port: 9000
+    tls: true
+  cache:
+    name: redis
+    port: 6379
+containers:
+  - name: nginx
+    image: nginx:1.25
+    replicas: 2
+  - name: app
+    image: app:latest
+    replicas: 4
+  - name: sidecar
+    image: envoy
+`
+
+func TestDocument_Query(t *testing.T) {
+	doc, err := Load(queryInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		expr      string
+		wantPaths []string
+		wantFirst interface{}
+	}{
+		{
+			name:      "filter on wildcard matches",
+			expr:      "services.*[?(@.port>8000)].name",
+			wantPaths: []string{"services.web.name", "services.api.name"},
+			wantFirst: "frontend",
+		},
+		{
+			name:      "string equality on array elements",
+			expr:      `containers[?(@.name == "nginx")].image`,
+			wantPaths: []string{"containers[0].image"},
+			wantFirst: "nginx:1.25",
+		},
+		{
+			name:      "single quotes and inequality",
+			expr:      "containers[?(@.name != 'nginx')].name",
+			wantPaths: []string{"containers[1].name", "containers[2].name"},
+			wantFirst: "app",
+		},
+		{
+			name:      "existence",
+			expr:      "containers[?(@.replicas)].name",
+			wantPaths: []string{"containers[0].name", "containers[1].name"},
+			wantFirst: "nginx",
+		},
+		{
+			name:      "negation and boolean operators",
+			expr:      "services.*[?(!@.tls && (@.port < 7000 || @.name == 'frontend'))].port",
+			wantPaths: []string{"services.web.port", "services.cache.port"},
+			wantFirst: int64(8080),
+		},
+		{
+			name:      "boolean literal",
+			expr:      "services.*[?(@.tls == true)].name",
+			wantPaths: []string{"services.api.name"},
+			wantFirst: "backend",
+		},
+		{
+			name:      "slice",
+			expr:      "containers[0:2].name",
+			wantPaths: []string{"containers[0].name", "containers[1].name"},
+			wantFirst: "nginx",
+		},
+		{
+			name:      "open slice with negative start",
+			expr:      "containers[-2:].name",
+			wantPaths: []string{"containers[1].name", "containers[2].name"},
+			wantFirst: "app",
+		},
+		{
+			name:      "negative index",
+			expr:      "containers[-1].image",
+			wantPaths: []string{"containers[2].image"},
+			wantFirst: "envoy",
+		},
+		{
+			name:      "all elements",
+			expr:      "$.containers[*].replicas",
+			wantPaths: []string{"containers[0].replicas", "containers[1].replicas"},
+			wantFirst: int64(2),
+		},
+		{
+			name:      "recursive descent with filter on scalars",
+			expr:      "**.tags[?(@ == 'http')]",
+			wantPaths: []string{"services.web.tags[1]"},
+			wantFirst: "http",
+		},
+		{
+			name:      "recursive descent",
+			expr:      "**.port",
+			wantPaths: []string{"services.web.port", "services.api.port", "services.cache.port"},
+			wantFirst: int64(8080),
+		},
+		{
+			name:      "quoted key",
+			expr:      `services["api"].port`,
+			wantPaths: []string{"services.api.port"},
+			wantFirst: int64(9000),
+		},
+		{
+			name:      "no matches",
+			expr:      "containers[?(@.replicas > 10)]",
+			wantPaths: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := doc.Query(tt.expr)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			paths := make([]string, 0, len(results))
+			for _, r := range results {
+				paths = append(paths, r.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Fatalf("Query() paths = %v, want %v", paths, tt.wantPaths)
+			}
+			if len(results) > 0 && !reflect.DeepEqual(results[0].Value, tt.wantFirst) {
+				t.Errorf("Query() first value = %#v, want %#v", results[0].Value, tt.wantFirst)
+			}
+
+			// Every returned path can be read back with Get
+			for _, r := range results {
+				if value, err := doc.Get(r.Path); err != nil || !reflect.DeepEqual(value, r.Value) {
+					t.Errorf("Get(%s) = %v, %v, want %v", r.Path, value, err, r.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestDocument_QueryArrayRoot(t *testing.T) {
+	doc, err := Load("- name: a\n  size: 1\n- name: b\n  size: 5\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	results, err := doc.Query("[?(@.size >= 5)].name")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Path != "[1].name" || results[0].Value != "b" {
+		t.Errorf("Query() = %+v, want [1].name = b", results)
+	}
+}
+
+func TestDocument_QueryErrors(t *testing.T) {
+	doc, err := Load(queryInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"", "empty expression"},
+		{"services..web", "empty segment"},
+		{"containers[0", "unclosed bracket"},
+		{"containers[x]", "invalid index"},
+		{"containers[1:2:3]", "invalid slice"},
+		{"containers[?(@.name == )]", "unexpected end"},
+		{"containers[?(@.name == 'x)]", "unclosed bracket"},
+		{"containers[?(5)]", "expected comparison"},
+		{"containers[?(@.a == 1 @.b)]", "unexpected @.b"},
+		{"containers[?(@.a == nope)]", "invalid literal"},
+		{"containers[0]name", "expected . or ["},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := doc.Query(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Query(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package yamler
+
+import (
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxQueryCacheEntries bounds the query cache of a document; once full, new patterns are matched without being cached
+const maxQueryCacheEntries = 256
+
+// queryKey identifies a cached wildcard query
+type queryKey struct {
+	root    *yaml.Node
+	pattern string
+	opts    MatchOptions
+}
+
+// queryMatch is a path matched by a wildcard query and the node it points to
+type queryMatch struct {
+	path string
+	node *yaml.Node
+}
+
+// maxNodeIndexEntries bounds the node index of a document; once full, new paths are looked up without being indexed
+const maxNodeIndexEntries = 4096
+
+// nodeKey identifies a path indexed below a root
+type nodeKey struct {
+	root *yaml.Node
+	path string
+}
+
+// indexedNode is the node found at a path, with the path split into parts to find entries affected by an edit
+type indexedNode struct {
+	node  *yaml.Node
+	parts []string
+}
+
+// queryCache holds wildcard query results of a document until it is mutated.
+// Matches keep their nodes, so values are always read fresh from the tree.
+// It also indexes the nodes found by plain path lookups. Every mutation bumps the generation,
+// which invalidates the index, unless the edit carries it over with keepNodeIndex.
+// While such an edit is in progress, lookups neither use nor extend the index.
+type queryCache struct {
+	mu       sync.Mutex
+	disabled bool
+	entries  map[queryKey][]queryMatch
+
+	generation      int
+	editing         int
+	nodes           map[nodeKey]indexedNode
+	nodesGeneration int
+}
+
+func newQueryCache() *queryCache {
+	return &queryCache{entries: make(map[queryKey][]queryMatch)}
+}
+
+// SetQueryCacheEnabled turns caching of wildcard query results (GetAll and friends)
+// and the index of nodes found by Get on or off.
+// Both are on by default and cleared on every mutation; disabling them also clears them.
+func (d *Document) SetQueryCacheEnabled(enabled bool) {
+	if d.queryCache == nil {
+		if !enabled {
+			return
+		}
+		d.queryCache = newQueryCache()
+	}
+
+	c := d.queryCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disabled = !enabled
+	c.entries = make(map[queryKey][]queryMatch)
+	c.nodes = nil
+}
+
+// invalidateQueries drops cached query results after the document changed
+func (d *Document) invalidateQueries() {
+	if c := d.queryCache; c != nil {
+		c.mu.Lock()
+		if len(c.entries) > 0 {
+			c.entries = make(map[queryKey][]queryMatch)
+		}
+		c.generation++
+		c.mu.Unlock()
+	}
+}
+
+// matchNodes returns the paths below root matching the pattern, using the query cache when possible
+func (d *Document) matchNodes(root *yaml.Node, pattern string, opts MatchOptions) ([]queryMatch, error) {
+	c := d.queryCache
+	key := queryKey{root: root, pattern: pattern, opts: opts}
+	if c != nil {
+		c.mu.Lock()
+		matches, ok := c.entries[key]
+		c.mu.Unlock()
+		if ok {
+			return matches, nil
+		}
+	}
+
+	var matches []queryMatch
+	err := walkMatchingNodes(root, pattern, "", opts, func(path string, node *yaml.Node) error {
+		matches = append(matches, queryMatch{path: path, node: node})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil {
+		c.mu.Lock()
+		if !c.disabled && len(c.entries) < maxQueryCacheEntries {
+			c.entries[key] = matches
+		}
+		c.mu.Unlock()
+	}
+	return matches, nil
+}
+
+// currentNodes returns the node index, dropping it first if the document changed since it was built.
+// The caller holds c.mu.
+func (c *queryCache) currentNodes() map[nodeKey]indexedNode {
+	if c.nodesGeneration != c.generation {
+		c.nodes = nil
+		c.nodesGeneration = c.generation
+	}
+	return c.nodes
+}
+
+// lookupNode returns the node at path below root, using the node index when possible.
+// It also reports whether the path goes through an alias; such lookups are not indexed,
+// since edits at the anchor change them.
+func (d *Document) lookupNode(root *yaml.Node, path string) (*yaml.Node, bool, error) {
+	c := d.queryCache
+	key := nodeKey{root: root, path: path}
+	if c != nil {
+		c.mu.Lock()
+		var entry indexedNode
+		ok := false
+		if c.editing == 0 {
+			entry, ok = c.currentNodes()[key]
+		}
+		c.mu.Unlock()
+		if ok {
+			return entry.node, false, nil
+		}
+	}
+
+	node := root
+	aliased := false
+	for _, part := range splitPathParts(path) {
+		aliased = aliased || node.Kind == yaml.AliasNode
+		next, err := navigateToNode(node, part, path)
+		if err != nil {
+			return nil, false, err
+		}
+		node = next
+	}
+
+	if c != nil && !aliased {
+		c.mu.Lock()
+		if c.editing == 0 && !c.disabled {
+			nodes := c.currentNodes()
+			if nodes == nil {
+				nodes = make(map[nodeKey]indexedNode)
+				c.nodes = nodes
+			}
+			if len(nodes) < maxNodeIndexEntries {
+				nodes[key] = indexedNode{node: node, parts: splitPath(path)}
+			}
+		}
+		c.mu.Unlock()
+	}
+	return node, aliased, nil
+}
+
+// beginNodeIndexEdit starts an edit the node index may be carried over, returning the generation
+// to pass to keepNodeIndex. Every call must be paired with endNodeIndexEdit.
+func (d *Document) beginNodeIndexEdit() int {
+	c := d.queryCache
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.editing++
+	return c.generation
+}
+
+// endNodeIndexEdit finishes an edit started with beginNodeIndexEdit
+func (d *Document) endNodeIndexEdit() {
+	if c := d.queryCache; c != nil {
+		c.mu.Lock()
+		c.editing--
+		c.mu.Unlock()
+	}
+}
+
+// keepNodeIndex carries the node index over an edit that started at generation and only changed
+// the nodes at the path parts below root, dropping the entries at that path, above it and below it,
+// and those of other roots. The index stays invalidated if it was already stale when the edit started.
+func (d *Document) keepNodeIndex(generation int, root *yaml.Node, parts []string) {
+	c := d.queryCache
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nodesGeneration != generation {
+		return
+	}
+
+	for key, entry := range c.nodes {
+		if key.root != root || pathPartsOverlap(entry.parts, parts) {
+			delete(c.nodes, key)
+		}
+	}
+	c.nodesGeneration = c.generation
+}
+
+// pathPartsOverlap reports whether one path is a prefix of the other
+func pathPartsOverlap(a, b []string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
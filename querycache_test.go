@@ -0,0 +1,290 @@
+package yamler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDocument_QueryCache(t *testing.T) {
+	content := `services:
+  web:
+    image: nginx
+    port: 80
+  db:
+    image: postgres
+    port: 5432
+`
+
+	tests := []struct {
+		name    string
+		disable bool
+		mutate  func(doc *Document) error
+		pattern string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "set invalidates",
+			mutate:  func(doc *Document) error { return doc.Set("services.web.image", "caddy") },
+			pattern: "services.*.image",
+			want:    map[string]interface{}{"services.web.image": "caddy", "services.db.image": "postgres"},
+		},
+		{
+			name:    "new key is found",
+			mutate:  func(doc *Document) error { return doc.Set("services.cache.image", "redis") },
+			pattern: "services.*.image",
+			want: map[string]interface{}{
+				"services.web.image":   "nginx",
+				"services.db.image":    "postgres",
+				"services.cache.image": "redis",
+			},
+		},
+		{
+			name:    "set all invalidates",
+			mutate:  func(doc *Document) error { return doc.SetAll("services.*.port", 8080) },
+			pattern: "services.*.port",
+			want:    map[string]interface{}{"services.web.port": int64(8080), "services.db.port": int64(8080)},
+		},
+		{
+			name: "rename invalidates",
+			mutate: func(doc *Document) error {
+				_, err := doc.RenameKeyAll("services.*", "image", "container")
+				return err
+			},
+			pattern: "services.*.container",
+			want:    map[string]interface{}{"services.web.container": "nginx", "services.db.container": "postgres"},
+		},
+		{
+			name:    "cache disabled",
+			disable: true,
+			mutate:  func(doc *Document) error { return doc.Set("services.db.image", "mysql") },
+			pattern: "services.*.image",
+			want:    map[string]interface{}{"services.web.image": "nginx", "services.db.image": "mysql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if tt.disable {
+				doc.SetQueryCacheEnabled(false)
+			}
+
+			// Warm the cache with both the queried pattern and the one the mutation uses
+			if _, err := doc.GetAll(tt.pattern); err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if _, err := doc.GetAll("services.*.image"); err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+
+			if err := tt.mutate(doc); err != nil {
+				t.Fatalf("mutate error = %v", err)
+			}
+
+			got, err := doc.GetAll(tt.pattern)
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_FailedEditKeepsState(t *testing.T) {
+	doc, err := Load("base: &b 1\nd: *b\nkey:    x\nname: app\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// The minimal diff output keeps the spacing after key: and is pinned until the next change
+	if err := doc.SetMinimalDiff("name", "web"); err != nil {
+		t.Fatalf("SetMinimalDiff() error = %v", err)
+	}
+	want, _ := doc.String()
+	if _, err := doc.GetAll("*"); err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	edits, cached := doc.edits, len(doc.queryCache.entries)
+
+	if err := doc.Set("d.p", 2); err == nil {
+		t.Fatal("Set() through a scalar alias expected error")
+	}
+	if got, _ := doc.String(); got != want {
+		t.Errorf("String() after failed Set = %q, want %q", got, want)
+	}
+	if doc.edits != edits {
+		t.Errorf("edits = %d after failed Set, want %d", doc.edits, edits)
+	}
+	if len(doc.queryCache.entries) != cached {
+		t.Errorf("failed Set dropped %d cached queries", cached-len(doc.queryCache.entries))
+	}
+}
+
+func TestDocument_QueryCacheReturnsCopies(t *testing.T) {
+	doc, err := Load("items:\n  a:\n    name: x\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	first, err := doc.GetAll("items.*")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	first["items.a"].(map[string]interface{})["name"] = "changed"
+	delete(first, "items.a")
+
+	second, err := doc.GetAll("items.*")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	want := map[string]interface{}{"items.a": map[string]interface{}{"name": "x"}}
+	if !reflect.DeepEqual(second, want) {
+		t.Errorf("GetAll() = %v, want %v", second, want)
+	}
+}
+
+func TestDocument_NodeIndex(t *testing.T) {
+	content := `base: &base
+  image: nginx
+  ports: [80, 443]
+derived: *base
+services:
+  - name: web
+    env:
+      MODE: prod
+  - name: db
+    env:
+      MODE: dev
+labels:
+  app.kubernetes.io/name: web
+`
+	paths := []string{
+		"base.image", "base.ports[1]", "derived.image", "derived.ports[0]",
+		"services[0].name", "services[1].name", "services[1].env.MODE", "services[2].name",
+		`labels["app.kubernetes.io/name"]`, "services", "labels",
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(doc *Document) error
+	}{
+		{
+			name:   "set replaces a subtree",
+			mutate: func(doc *Document) error { return doc.Set("services[1].env", map[string]interface{}{"MODE": "test"}) },
+		},
+		{
+			name:   "set through a quoted key",
+			mutate: func(doc *Document) error { return doc.Set("labels.app\\.kubernetes\\.io/name", "db") },
+		},
+		{
+			name:   "set whole root",
+			mutate: func(doc *Document) error { return doc.Set("", map[string]interface{}{"services": []interface{}{"x"}}) },
+		},
+		{
+			name:   "delete renumbers elements",
+			mutate: func(doc *Document) error { return doc.Delete("services[0]") },
+		},
+		{
+			name:   "delete through an alias",
+			mutate: func(doc *Document) error { return doc.Delete("derived.image") },
+		},
+		{
+			name: "append",
+			mutate: func(doc *Document) error {
+				return doc.AppendToArray("services", map[string]interface{}{"name": "cache"})
+			},
+		},
+		{
+			name:   "move",
+			mutate: func(doc *Document) error { return doc.Move("services[1].env", "services[0].settings") },
+		},
+		{
+			name: "rollback",
+			mutate: func(doc *Document) error {
+				if err := doc.Begin(); err != nil {
+					return err
+				}
+				if err := doc.Set("services[0].name", "api"); err != nil {
+					return err
+				}
+				if _, err := doc.Get("services[0].name"); err != nil {
+					return err
+				}
+				return doc.Rollback()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			// Warm the index with every path, then read them again after the edit
+			for _, path := range paths {
+				doc.Get(path)
+			}
+			if err := tt.mutate(doc); err != nil {
+				t.Fatalf("mutate error = %v", err)
+			}
+
+			out, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			fresh, err := Load(out)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			for _, path := range paths {
+				got, gotErr := doc.Get(path)
+				want, wantErr := fresh.Get(path)
+				if (gotErr != nil) != (wantErr != nil) || !reflect.DeepEqual(got, want) {
+					t.Errorf("Get(%s) = %v, %v; want %v, %v", path, got, gotErr, want, wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestDocument_NodeIndexKeptAcrossSet(t *testing.T) {
+	doc, err := Load("a:\n  b: 1\n  c: 2\nd:\n  e: 3\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, path := range []string{"a.b", "a.c", "d.e"} {
+		if _, err := doc.Get(path); err != nil {
+			t.Fatalf("Get(%s) error = %v", path, err)
+		}
+	}
+
+	if err := doc.Set("a.b", 10); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Only the entries on the edited path are dropped
+	c := doc.queryCache
+	var kept []string
+	for key := range c.currentNodes() {
+		kept = append(kept, key.path)
+	}
+	sort.Strings(kept)
+	if want := []string{"a.c", "d.e"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("indexed paths after Set = %v, want %v", kept, want)
+	}
+	if got, _ := doc.Get("a.b"); got != int64(10) {
+		t.Errorf("Get(a.b) = %v, want 10", got)
+	}
+
+	doc.SetQueryCacheEnabled(false)
+	doc.Get("a.c")
+	if n := len(c.currentNodes()); n != 0 {
+		t.Errorf("disabled index holds %d entries", n)
+	}
+}
@@ -0,0 +1,34 @@
+package yamler
+
+import "strings"
+
+// rawIndex holds the raw content split into lines and the lines where each key appears,
+// so repeated edits of a large document look up the original text without rescanning it
+type rawIndex struct {
+	raw      string
+	lines    []string
+	keyLines map[string][]int
+}
+
+// rawIndex returns the line index of the current raw content, building it if raw changed since
+func (d *Document) rawIndex() *rawIndex {
+	if d.lineIndex == nil || d.lineIndex.raw != d.raw {
+		d.lineIndex = &rawIndex{raw: d.raw, lines: strings.Split(d.raw, "\n")}
+	}
+	return d.lineIndex
+}
+
+// keyLinesOf returns the indexes of the lines whose text before the first colon is key, in order
+func (r *rawIndex) keyLinesOf(key string) []int {
+	if r.keyLines == nil {
+		r.keyLines = make(map[string][]int)
+		for i, line := range r.lines {
+			trimmed := strings.TrimSpace(line)
+			if idx := strings.Index(trimmed, ":"); idx > 0 {
+				k := strings.TrimSpace(trimmed[:idx])
+				r.keyLines[k] = append(r.keyLines[k], i)
+			}
+		}
+	}
+	return r.keyLines[key]
+}
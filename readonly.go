@@ -0,0 +1,94 @@
+package yamler
+
+// ReadOnlyView is a frozen snapshot of a document for sharing between goroutines.
+// It only exposes reads, all of which are safe to call in parallel. Later edits to the
+// document it was taken from are not visible through the view.
+type ReadOnlyView struct {
+	doc *Document
+}
+
+// View returns a read-only snapshot of the document's current content
+func (d *Document) View() (*ReadOnlyView, error) {
+	content, err := d.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	load := Load
+	if d.templates != nil {
+		load = LoadTemplate
+	}
+	snapshot, err := load(string(content))
+	if err != nil {
+		return nil, err
+	}
+	snapshot.pinned = content
+	snapshot.keepTags = d.keepTags
+	d.typeLike(snapshot)
+	snapshot.Freeze()
+	return &ReadOnlyView{doc: snapshot}, nil
+}
+
+// Get returns the value at path, see Document.Get
+func (v *ReadOnlyView) Get(path string) (interface{}, error) {
+	return v.doc.Get(path)
+}
+
+// GetString returns the string value at path
+func (v *ReadOnlyView) GetString(path string) (string, error) {
+	return v.doc.GetString(path)
+}
+
+// GetInt returns the integer value at path
+func (v *ReadOnlyView) GetInt(path string) (int64, error) {
+	return v.doc.GetInt(path)
+}
+
+// GetFloat returns the float value at path
+func (v *ReadOnlyView) GetFloat(path string) (float64, error) {
+	return v.doc.GetFloat(path)
+}
+
+// GetBool returns the boolean value at path
+func (v *ReadOnlyView) GetBool(path string) (bool, error) {
+	return v.doc.GetBool(path)
+}
+
+// GetSlice returns the array at path
+func (v *ReadOnlyView) GetSlice(path string) ([]interface{}, error) {
+	return v.doc.GetSlice(path)
+}
+
+// GetStringSlice returns the string array at path
+func (v *ReadOnlyView) GetStringSlice(path string) ([]string, error) {
+	return v.doc.GetStringSlice(path)
+}
+
+// GetMap returns the mapping at path
+func (v *ReadOnlyView) GetMap(path string) (map[string]interface{}, error) {
+	return v.doc.GetMap(path)
+}
+
+// GetAll returns all values matching a wildcard pattern, see Document.GetAll
+func (v *ReadOnlyView) GetAll(pattern string) (map[string]interface{}, error) {
+	return v.doc.GetAll(pattern)
+}
+
+// Query evaluates a query expression, see Document.Query
+func (v *ReadOnlyView) Query(expr string) ([]QueryResult, error) {
+	return v.doc.Query(expr)
+}
+
+// Decode decodes the value at path into out, see Document.Decode
+func (v *ReadOnlyView) Decode(path string, out interface{}) error {
+	return v.doc.Decode(path, out)
+}
+
+// ToBytes returns the snapshot content
+func (v *ReadOnlyView) ToBytes() ([]byte, error) {
+	return v.doc.ToBytes()
+}
+
+// String returns the snapshot content as a string
+func (v *ReadOnlyView) String() (string, error) {
+	return v.doc.String()
+}
@@ -0,0 +1,103 @@
+package yamler
+
+import (
+	"sync"
+	"testing"
+)
+
+const viewInput = `base: &base
+  timeout: 30
+service:
+  <<: *base
+  name: 'api'   # display name
+  ports: [80, 443]
+`
+
+func TestDocument_View(t *testing.T) {
+	doc, err := Load(viewInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	view, err := doc.View()
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+
+	// Later edits to the document do not reach the view
+	if err := doc.Set("service.name", "web"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if name, err := view.GetString("service.name"); err != nil || name != "api" {
+		t.Errorf("GetString() = %q, %v; want api", name, err)
+	}
+	if got, err := view.String(); err != nil || got != viewInput {
+		t.Errorf("String() = %q, %v; want %q", got, err, viewInput)
+	}
+}
+
+func TestReadOnlyView_ParallelReads(t *testing.T) {
+	doc, err := Load(viewInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	view, err := doc.View()
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if timeout, err := view.GetInt("base.timeout"); err != nil || timeout != 30 {
+					t.Errorf("GetInt() = %d, %v; want 30", timeout, err)
+				}
+				if ports, err := view.GetAll("service.ports[*]"); err != nil || len(ports) != 2 {
+					t.Errorf("GetAll() = %v, %v; want 2 ports", ports, err)
+				}
+				if got, err := view.String(); err != nil || got != viewInput {
+					t.Errorf("String() = %q, %v; want %q", got, err, viewInput)
+				}
+				var service struct{ Name string }
+				if err := view.Decode("service", &service); err != nil || service.Name != "api" {
+					t.Errorf("Decode() = %+v, %v", service, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFreeze_ParallelReads(t *testing.T) {
+	doc, err := Load(viewInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	doc.Freeze()
+	sub, err := doc.SubDocument("service")
+	if err != nil {
+		t.Fatalf("SubDocument() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if got, err := doc.String(); err != nil || got != viewInput {
+					t.Errorf("String() = %q, %v; want %q", got, err, viewInput)
+				}
+				if _, err := sub.ToBytes(); err != nil {
+					t.Errorf("sub-document ToBytes() error = %v", err)
+				}
+				if results, err := doc.Query("**.timeout"); err != nil || len(results) == 0 {
+					t.Errorf("Query() = %v, %v", results, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,116 @@
+package yamler
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRegexpCacheEntries bounds the compiled pattern cache; once full, new patterns are compiled without being cached
+const maxRegexpCacheEntries = 1000
+
+// Cache for compiled GetMatching/SetMatching patterns, so repeated calls with the same pattern compile it once
+var (
+	regexpCache     = sync.Map{} // string -> *regexp.Regexp
+	regexpCacheSize atomic.Int64
+)
+
+// compilePathRegexp compiles a path pattern anchored to the whole path and caches the result
+func compilePathRegexp(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := regexpCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path pattern %s: %w", pattern, err)
+	}
+	if regexpCacheSize.Load() < maxRegexpCacheEntries {
+		if _, loaded := regexpCache.LoadOrStore(pattern, re); !loaded {
+			regexpCacheSize.Add(1)
+		}
+	}
+	return re, nil
+}
+
+// GetMatching returns the values whose path matches the regular expression, in document order.
+// The expression must match the whole path as GetAll reports it, e.g. env(ironment)?s?\..*\.host
+// selects environments.prod.host and envs.dev.host; array elements appear as [0] and keys
+// containing dots as ["a.b"]. Like GetAll, a matching mapping or sequence is returned whole
+// and the search does not descend into it.
+func (d *Document) GetMatching(pattern string) ([]PathValue, error) {
+	matches, err := d.regexpMatches(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PathValue, 0, len(matches))
+	for _, match := range matches {
+		value, err := d.toInterface(match.node)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, PathValue{Path: match.path, Value: value})
+	}
+	return results, nil
+}
+
+// SetMatching sets value at every path matching the regular expression (see GetMatching)
+// and returns the number of paths set. Matches keep their scalar style like with SetAll.
+func (d *Document) SetMatching(pattern string, value interface{}) (int, error) {
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	matches, err := d.regexpMatches(pattern)
+	if err != nil {
+		return 0, err
+	}
+	if len(matches) == 0 {
+		return 0, nil
+	}
+
+	paths := make([]string, 0, len(matches))
+	for _, match := range matches {
+		paths = append(paths, match.path)
+	}
+	return len(paths), d.setAllPreservingStyles(paths, value)
+}
+
+// regexpMatches returns the nodes whose path matches the regular expression, in document order
+func (d *Document) regexpMatches(pattern string) ([]queryMatch, error) {
+	re, err := compilePathRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []queryMatch
+	walkRegexp(root, re, "", &matches)
+	return matches, nil
+}
+
+// walkRegexp collects the nodes below node whose path matches re, without descending into matches
+func walkRegexp(node *yaml.Node, re *regexp.Regexp, currentPath string, matches *[]queryMatch) {
+	if currentPath != "" && re.MatchString(currentPath) {
+		*matches = append(*matches, queryMatch{path: currentPath, node: node})
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkRegexp(node.Content[i+1], re, appendPathKey(currentPath, node.Content[i].Value), matches)
+		}
+	case yaml.SequenceNode:
+		for idx, child := range node.Content {
+			walkRegexp(child, re, fmt.Sprintf("%s[%d]", currentPath, idx), matches)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package yamler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocument_GetMatching(t *testing.T) {
+	content := `environments:
+  prod:
+    host: prod-db
+    port: 5432
+envs:
+  dev:
+    host: dev-db
+    hostname: dev.local
+environment:
+  host: single
+servers:
+  - host: a
+  - host: b
+`
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []PathValue
+		wantErr bool
+	}{
+		{
+			name:    "alternatives wildcards cannot express",
+			pattern: `env(ironment)?s?\..*\.host`,
+			want: []PathValue{
+				{Path: "environments.prod.host", Value: "prod-db"},
+				{Path: "envs.dev.host", Value: "dev-db"},
+			},
+		},
+		{
+			name:    "whole path must match",
+			pattern: `environment\.host`,
+			want: []PathValue{
+				{Path: "environment.host", Value: "single"},
+			},
+		},
+		{
+			name:    "array indices",
+			pattern: `servers\[[0-9]+\]\.host`,
+			want: []PathValue{
+				{Path: "servers[0].host", Value: "a"},
+				{Path: "servers[1].host", Value: "b"},
+			},
+		},
+		{
+			name:    "containers are returned whole",
+			pattern: `envs\.[a-z]+`,
+			want: []PathValue{
+				{Path: "envs.dev", Value: map[string]interface{}{"host": "dev-db", "hostname": "dev.local"}},
+			},
+		},
+		{
+			name:    "no matches",
+			pattern: `.*\.password`,
+			want:    []PathValue{},
+		},
+		{
+			name:    "invalid expression",
+			pattern: `env(`,
+			wantErr: true,
+		},
+	}
+
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.GetMatching(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetMatching() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetMatching() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetMatching(t *testing.T) {
+	doc, err := Load(`environments:
+  prod:
+    host: "prod-db" # primary
+    port: 5432
+envs:
+  dev:
+    host: dev-db
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	n, err := doc.SetMatching(`env(ironment)?s?\..*\.host`, "db.internal")
+	if err != nil {
+		t.Fatalf("SetMatching() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("SetMatching() = %d, want 2", n)
+	}
+
+	want := `environments:
+  prod:
+    host: "db.internal" # primary
+    port: 5432
+envs:
+  dev:
+    host: db.internal
+`
+	if got, _ := doc.String(); got != want {
+		t.Errorf("SetMatching() result:\n%s\nwant:\n%s", got, want)
+	}
+
+	if n, err := doc.SetMatching(`.*\.password`, "x"); err != nil || n != 0 {
+		t.Errorf("SetMatching() without matches = %d, %v", n, err)
+	}
+	if _, err := doc.SetMatching(`[`, "x"); err == nil {
+		t.Error("SetMatching() with invalid expression should fail")
+	}
+}
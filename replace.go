@@ -0,0 +1,142 @@
+package yamler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReplaceOptions controls what ReplaceInStringsWithOptions rewrites.
+// The zero value replaces literal text in string values only.
+type ReplaceOptions struct {
+	// Regexp treats old as a regular expression and new as its replacement template, with $1 expansion
+	Regexp bool
+	// Keys also rewrites mapping keys
+	Keys bool
+	// Comments also rewrites the text of comments
+	Comments bool
+}
+
+// ReplaceInStrings replaces every occurrence of old with new inside string values, keeping
+// their quoting style, and returns the number of values changed. Numbers, booleans, keys
+// and comments are left alone, so replacing "1.19" does not touch version: 1.19.
+func (d *Document) ReplaceInStrings(old, new string) (int, error) {
+	return d.ReplaceInStringsWithOptions(old, new, ReplaceOptions{})
+}
+
+// ReplaceInStringsRegexp replaces the matches of the regular expression pattern inside string values
+// with replacement, which may refer to submatches like $1, and returns the number of values changed
+func (d *Document) ReplaceInStringsRegexp(pattern, replacement string) (int, error) {
+	return d.ReplaceInStringsWithOptions(pattern, replacement, ReplaceOptions{Regexp: true})
+}
+
+// ReplaceInStringsWithOptions replaces old with new inside string values, and optionally keys and comments,
+// returning the number of values, keys and comments changed. It fails without changes if a renamed key
+// would collide with an existing one.
+func (d *Document) ReplaceInStringsWithOptions(old, new string, opts ReplaceOptions) (int, error) {
+	if old == "" {
+		return 0, fmt.Errorf("empty search string")
+	}
+
+	replace := func(s string) string { return strings.ReplaceAll(s, old, new) }
+	if opts.Regexp {
+		re, err := regexp.Compile(old)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pattern %s: %w", old, err)
+		}
+		replace = func(s string) string { return re.ReplaceAllString(s, new) }
+	}
+
+	if _, err := d.queryRoot(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := d.atomically(func() error {
+		r := &stringReplacer{replace: replace, opts: opts}
+		if err := r.walk(d.root); err != nil {
+			return err
+		}
+		count = r.count
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// stringReplacer rewrites the strings of a node tree for ReplaceInStringsWithOptions
+type stringReplacer struct {
+	replace func(string) string
+	opts    ReplaceOptions
+	count   int
+}
+
+// walk rewrites node and its children; aliases are skipped since their anchor is rewritten in place
+func (r *stringReplacer) walk(node *yaml.Node) error {
+	r.rewriteComments(node)
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!str" {
+			r.rewrite(&node.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			r.rewriteComments(key)
+			if r.opts.Keys && key.Kind == yaml.ScalarNode {
+				if err := r.renameKey(node, key); err != nil {
+					return err
+				}
+			}
+			if err := r.walk(node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := r.walk(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rewriteComments rewrites the comments of node if enabled
+func (r *stringReplacer) rewriteComments(node *yaml.Node) {
+	if r.opts.Comments {
+		r.rewrite(&node.HeadComment)
+		r.rewrite(&node.LineComment)
+		r.rewrite(&node.FootComment)
+	}
+}
+
+// renameKey rewrites a key of mapping, failing if the new name is already taken
+func (r *stringReplacer) renameKey(mapping, key *yaml.Node) error {
+	renamed := r.replace(key.Value)
+	if renamed == key.Value {
+		return nil
+	}
+	if _, found := findKeyInMapping(mapping, renamed); found {
+		return fmt.Errorf("key %s: renaming to %s collides with an existing key", key.Value, renamed)
+	}
+	key.Value = renamed
+	r.count++
+	return nil
+}
+
+// rewrite replaces within s and counts the change
+func (r *stringReplacer) rewrite(s *string) {
+	if *s == "" {
+		return
+	}
+	if replaced := r.replace(*s); replaced != *s {
+		*s = replaced
+		r.count++
+	}
+}
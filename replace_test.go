@@ -0,0 +1,167 @@
+package yamler
+
+import (
+	"testing"
+)
+
+func TestDocument_ReplaceInStrings(t *testing.T) {
+	content := `# uses nginx:1.19
+image: nginx:1.19 # was nginx:1.19
+quoted: 'nginx:1.19'
+version: 1.19
+flag: on-call
+nginx:1.19: key
+script: |
+  docker pull nginx:1.19
+  docker run nginx:1.19
+base: &img nginx:1.19
+copy: *img
+`
+
+	tests := []struct {
+		name      string
+		old, new  string
+		opts      ReplaceOptions
+		wantCount int
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "string values only",
+			old:       "nginx:1.19",
+			new:       "nginx:1.25",
+			wantCount: 4,
+			want: `# uses nginx:1.19
+image: nginx:1.25 # was nginx:1.19
+quoted: 'nginx:1.25'
+version: 1.19
+flag: on-call
+nginx:1.19: key
+script: |
+  docker pull nginx:1.25
+  docker run nginx:1.25
+base: &img nginx:1.25
+copy: *img
+`,
+		},
+		{
+			name:      "numbers are not strings",
+			old:       "1.19",
+			new:       "1.25",
+			wantCount: 4,
+			want: `# uses nginx:1.19
+image: nginx:1.25 # was nginx:1.19
+quoted: 'nginx:1.25'
+version: 1.19
+flag: on-call
+nginx:1.19: key
+script: |
+  docker pull nginx:1.25
+  docker run nginx:1.25
+base: &img nginx:1.25
+copy: *img
+`,
+		},
+		{
+			name:      "result stays a string",
+			old:       "on-call",
+			new:       "true",
+			wantCount: 1,
+			want: `# uses nginx:1.19
+image: nginx:1.19 # was nginx:1.19
+quoted: 'nginx:1.19'
+version: 1.19
+flag: "true"
+nginx:1.19: key
+script: |
+  docker pull nginx:1.19
+  docker run nginx:1.19
+base: &img nginx:1.19
+copy: *img
+`,
+		},
+		{
+			name:      "regexp with keys and comments",
+			old:       `nginx:1\.(\d+)`,
+			new:       "nginx:2.$1",
+			opts:      ReplaceOptions{Regexp: true, Keys: true, Comments: true},
+			wantCount: 7,
+			want: `# uses nginx:2.19
+image: nginx:2.19 # was nginx:2.19
+quoted: 'nginx:2.19'
+version: 1.19
+flag: on-call
+nginx:2.19: key
+script: |
+  docker pull nginx:2.19
+  docker run nginx:2.19
+base: &img nginx:2.19
+copy: *img
+`,
+		},
+		{
+			name:    "key collision",
+			old:     "nginx:1.19",
+			new:     "image",
+			opts:    ReplaceOptions{Keys: true},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regexp",
+			old:     "(",
+			opts:    ReplaceOptions{Regexp: true},
+			wantErr: true,
+		},
+		{
+			name:    "empty search string",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			count, err := doc.ReplaceInStringsWithOptions(tt.old, tt.new, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReplaceInStringsWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// A failed replacement leaves the document unchanged
+				if got, _ := doc.String(); got != content {
+					t.Errorf("document changed after error:\n%s", got)
+				}
+				return
+			}
+			if count != tt.wantCount {
+				t.Errorf("ReplaceInStringsWithOptions() = %d, want %d", count, tt.wantCount)
+			}
+			if got, _ := doc.String(); got != tt.want {
+				t.Errorf("ReplaceInStringsWithOptions() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_ReplaceInStringsRegexp(t *testing.T) {
+	doc, err := Load("- image: registry.old.io/app:1.0\n- image: registry.old.io/db:2.1\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	count, err := doc.ReplaceInStringsRegexp(`registry\.old\.io/(\w+)`, "registry.new.io/team/$1")
+	if err != nil || count != 2 {
+		t.Fatalf("ReplaceInStringsRegexp() = %d, %v", count, err)
+	}
+	want := "- image: registry.new.io/team/app:1.0\n- image: registry.new.io/team/db:2.1\n"
+	if got, _ := doc.String(); got != want {
+		t.Errorf("ReplaceInStringsRegexp() result:\n%s\nwant:\n%s", got, want)
+	}
+
+	if count, err := doc.ReplaceInStrings("missing", "x"); err != nil || count != 0 {
+		t.Errorf("ReplaceInStrings() without matches = %d, %v", count, err)
+	}
+}
@@ -0,0 +1,360 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Wrap moves the value at path under a new intermediate key, keeping its slot, subtree and comments.
+// Wrap("server.host", "connection") turns server.host into server.connection.host.
+// newParent may be dotted to add several levels; an existing mapping with that name is reused.
+func (d *Document) Wrap(path, newParent string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if newParent == "" {
+		return fmt.Errorf("path %s: empty parent key", path)
+	}
+
+	parent, keyIndex, err := d.findMappingEntry(path)
+	if err != nil {
+		return err
+	}
+	keyNode := parent.Content[keyIndex]
+	valueNode := parent.Content[keyIndex+1]
+
+	parentKeys := strings.Split(newParent, ".")
+	if existing, found := findKeyInMapping(parent, parentKeys[0]); found {
+		if existing == valueNode {
+			return fmt.Errorf("path %s: cannot wrap a value into itself", path)
+		}
+		// Reuse the existing wrapper and drop the moved entry from its old slot
+		container, err := getOrCreateWrapper(existing, parentKeys[1:], path)
+		if err != nil {
+			return err
+		}
+		if _, exists := findKeyInMapping(container, keyNode.Value); exists {
+			return fmt.Errorf("path %s: key %s already exists under %s", path, keyNode.Value, newParent)
+		}
+		container.Content = append(container.Content, keyNode, valueNode)
+		parent.Content = append(parent.Content[:keyIndex], parent.Content[keyIndex+2:]...)
+	} else {
+		// Build the wrapper chain in place of the original entry
+		wrapper := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		container, err := getOrCreateWrapper(wrapper, parentKeys[1:], path)
+		if err != nil {
+			return err
+		}
+		container.Content = append(container.Content, keyNode, valueNode)
+		parent.Content[keyIndex] = &yaml.Node{Kind: yaml.ScalarNode, Value: parentKeys[0]}
+		parent.Content[keyIndex+1] = wrapper
+	}
+
+	d.markDirty()
+	return nil
+}
+
+// Unwrap replaces the single-child mapping at path with its only entry,
+// hoisting it one level up: server.connection.host becomes server.host.
+func (d *Document) Unwrap(path string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	parent, keyIndex, err := d.findMappingEntry(path)
+	if err != nil {
+		return err
+	}
+	wrapperKey := parent.Content[keyIndex]
+	wrapper := parent.Content[keyIndex+1]
+	if wrapper.Kind != yaml.MappingNode {
+		return fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", wrapper))
+	}
+	if len(wrapper.Content) != 2 {
+		return fmt.Errorf("path %s: expected a single child, got %d", path, len(wrapper.Content)/2)
+	}
+
+	childKey := wrapper.Content[0]
+	childValue := wrapper.Content[1]
+	if childKey.Value != wrapperKey.Value {
+		if _, exists := findKeyInMapping(parent, childKey.Value); exists {
+			return fmt.Errorf("path %s: key %s already exists in parent", path, childKey.Value)
+		}
+	}
+
+	// Keep the wrapper's comments when the hoisted key has none of its own
+	if childKey.HeadComment == "" {
+		childKey.HeadComment = wrapperKey.HeadComment
+	}
+	if childKey.LineComment == "" {
+		childKey.LineComment = wrapperKey.LineComment
+	}
+	if childKey.FootComment == "" {
+		childKey.FootComment = wrapperKey.FootComment
+	}
+
+	parent.Content[keyIndex] = childKey
+	parent.Content[keyIndex+1] = childValue
+
+	d.markDirty()
+	return nil
+}
+
+// RenameKeyAll renames the child key oldKey to newKey in every mapping matched by parentPattern,
+// keeping each key's value, position and comments. It returns the number of keys renamed.
+// Nothing is renamed if newKey already exists in one of the affected mappings.
+func (d *Document) RenameKeyAll(parentPattern, oldKey, newKey string) (int, error) {
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+	if newKey == "" {
+		return 0, fmt.Errorf("pattern %s: empty key", parentPattern)
+	}
+
+	var keys []*yaml.Node
+	err := d.matchBelowRoot(parentPattern, func(path string, node *yaml.Node) error {
+		node = resolveAlias(node)
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		var keyNode *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			switch node.Content[i].Value {
+			case oldKey:
+				keyNode = node.Content[i]
+			case newKey:
+				if oldKey != newKey {
+					return fmt.Errorf("path %s: key %s already exists", path, newKey)
+				}
+			}
+		}
+		if keyNode != nil {
+			keys = append(keys, keyNode)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(keys) == 0 || oldKey == newKey {
+		return len(keys), nil
+	}
+
+	for _, keyNode := range keys {
+		keyNode.Value = newKey
+	}
+	d.markDirty()
+	return len(keys), nil
+}
+
+// matchBelowRoot calls visit for the nodes that match pattern like GetAll, except that the document root
+// never matches itself: where GetAll returns the whole root for "*", the top-level values are visited instead
+func (d *Document) matchBelowRoot(pattern string, visit func(path string, node *yaml.Node) error) error {
+	root, err := d.queryRoot()
+	if err != nil {
+		return err
+	}
+
+	var belowRoot func(path string, node *yaml.Node) error
+	belowRoot = func(path string, node *yaml.Node) error {
+		if path != "" {
+			return visit(path, node)
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				childPath := appendPathKey("", node.Content[i].Value)
+				if err := walkMatchingNodes(node.Content[i+1], pattern, childPath, MatchOptions{}, belowRoot); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				if err := walkMatchingNodes(child, pattern, fmt.Sprintf("[%d]", i), MatchOptions{}, belowRoot); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walkMatchingNodes(root, pattern, "", MatchOptions{}, belowRoot)
+}
+
+// findMappingEntry returns the mapping that holds the last key of path and the index of that key
+func (d *Document) findMappingEntry(path string) (*yaml.Node, int, error) {
+	if path == "" {
+		return nil, 0, fmt.Errorf("empty path")
+	}
+
+	parentPath, key := "", path
+	if idx := strings.LastIndex(path, "."); idx != -1 {
+		parentPath, key = path[:idx], path[idx+1:]
+	}
+	if strings.Contains(key, "[") {
+		return nil, 0, fmt.Errorf("path %s: expected mapping key, got array element", path)
+	}
+
+	parent, err := d.getNode(parentPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if parent.Kind != yaml.MappingNode {
+		return nil, 0, fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", parent))
+	}
+
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			return parent, i, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("path %s: key %s %w", path, key, ErrPathNotFound)
+}
+
+// getOrCreateWrapper walks keys below node, creating mappings that don't exist yet
+func getOrCreateWrapper(node *yaml.Node, keys []string, path string) (*yaml.Node, error) {
+	current := node
+	for _, key := range keys {
+		if current.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("path %s: wrapper %s is not a mapping", path, key)
+		}
+		next, found := findKeyInMapping(current, key)
+		if !found {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			current.Content = append(current.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, next)
+		}
+		current = next
+	}
+	if current.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("path %s: wrapper is not a mapping", path)
+	}
+	return current, nil
+}
+
+// markDirty records a structural change. The raw content is re-rendered by the next ToBytes,
+// so a series of edits is serialized once instead of after every step.
+func (d *Document) markDirty() {
+	d.markChanged()
+	d.dirty = true
+}
+
+// syncRaw brings the raw content up to date with the node tree, for code that reads the current text
+func (d *Document) syncRaw() error {
+	if !d.dirty {
+		return nil
+	}
+	_, err := d.renderContent()
+	return err
+}
+
+// WrapAsMapping turns an array-root document into a mapping with the array under key,
+// e.g. "- a" becomes "key:\n  - a". Comments stay with the items they belong to.
+func (d *Document) WrapAsMapping(key string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+
+	seq, err := d.sequenceRoot()
+	if err != nil {
+		return err
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	// A comment above the whole document stays at the top
+	keyNode.HeadComment, seq.HeadComment = seq.HeadComment, ""
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{keyNode, seq}}
+
+	d.root.Content[0] = mapping
+	return d.reformatRoot()
+}
+
+// UnwrapToArray turns a mapping-root document whose only key holds a sequence into an array-root document.
+// key must name that single key; it is the reverse of WrapAsMapping.
+func (d *Document) UnwrapToArray(key string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	root, err := d.mappingRoot()
+	if err != nil {
+		return err
+	}
+	if len(root.Content) != 2 {
+		return fmt.Errorf("path %s: expected a single top-level key, got %d", key, len(root.Content)/2)
+	}
+	keyNode, seq := root.Content[0], root.Content[1]
+	if keyNode.Value != key {
+		return fmt.Errorf("path %s: key %s %w", key, key, ErrPathNotFound)
+	}
+	if seq.Kind != yaml.SequenceNode {
+		return fmt.Errorf("path %s: %w", key, kindMismatch("sequence node", seq))
+	}
+
+	if seq.HeadComment == "" {
+		seq.HeadComment = keyNode.HeadComment
+	}
+	seq.Style &^= yaml.FlowStyle
+
+	d.root.Content[0] = seq
+	return d.reformatRoot()
+}
+
+// reformatRoot re-renders the document after its root kind changed.
+// Layout recorded for the old structure no longer applies, so the document is rendered
+// plainly in its indentation width and loaded again, like FixIndentation does.
+func (d *Document) reformatRoot() error {
+	info := d.formattingInfo()
+
+	plain := &Document{root: d.root, trailingNewlines: d.trailingNewlines, schema: d.schema, schemaTags: d.schemaTags}
+	content, err := plain.ToBytes()
+	if err != nil {
+		return err
+	}
+	// Without the final newline a block scalar at the end would lose its own
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	// Other widths are laid out structurally, now and on every later render
+	seqIndent := info.SequenceIndent
+	custom := info.IndentSize > 0 && info.IndentSize != 2 && !info.UseTabs
+	if custom {
+		if seqIndent < 0 {
+			seqIndent = info.IndentSize
+		}
+		content = applySequenceIndent(content, info.IndentSize, seqIndent)
+	}
+
+	fresh, err := Load(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to reload restructured YAML: %w", err)
+	}
+	if fresh.formattingCache != nil {
+		if custom {
+			fresh.formattingCache.IndentSize = info.IndentSize
+		}
+		fresh.formattingCache.AlignmentMode = info.AlignmentMode
+		fresh.formattingCache.SequenceIndent = seqIndent
+		fresh.formattingCache.SectionSpacing = info.SectionSpacing
+		fresh.formattingCache.LineEnding = info.LineEnding
+		fresh.formattingCache.HasBOM = info.HasBOM
+	}
+
+	d.typeLike(fresh)
+	d.root = fresh.root
+	d.schemaTags = fresh.schemaTags
+	d.raw = fresh.raw
+	d.arrayRoot = fresh.arrayRoot
+	d.formattingCache = fresh.formattingCache
+	d.sequenceSpacing = fresh.sequenceSpacing
+	d.explicitKeys = fresh.explicitKeys
+	d.trailingCommas = fresh.trailingCommas
+	d.header = fresh.header
+	d.markChanged()
+	return nil
+}
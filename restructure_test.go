@@ -0,0 +1,511 @@
+package yamler
+
+import (
+	"testing"
+)
+
+func TestDocument_Wrap(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		path      string
+		newParent string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "wrap scalar keeps slot and comment",
+			content: `server:
+  # Public host name
+  host: example.com # primary
+  port: 80
+`,
+			path:      "server.host",
+			newParent: "connection",
+			want: `server:
+  connection:
+    # Public host name
+    host: example.com # primary
+  port: 80
+`,
+		},
+		{
+			name: "wrap into existing mapping",
+			content: `server:
+  host: example.com
+  connection:
+    timeout: 30
+`,
+			path:      "server.host",
+			newParent: "connection",
+			want: `server:
+  connection:
+    timeout: 30
+    host: example.com
+`,
+		},
+		{
+			name: "wrap subtree with dotted parent",
+			content: `tls:
+  cert: a.pem
+  key: b.pem
+name: web
+`,
+			path:      "tls",
+			newParent: "spec.security",
+			want: `spec:
+  security:
+    tls:
+      cert: a.pem
+      key: b.pem
+name: web
+`,
+		},
+		{
+			name: "wrap inside array element",
+			content: `servers:
+  - host: a
+    port: 80
+`,
+			path:      "servers[0].host",
+			newParent: "net",
+			want: `servers:
+  - net:
+      host: a
+    port: 80
+`,
+		},
+		{
+			name:      "conflicting key",
+			content:   "host: a\nconnection:\n  host: b\n",
+			path:      "host",
+			newParent: "connection",
+			wantErr:   true,
+		},
+		{
+			name:      "existing scalar parent",
+			content:   "host: a\nconnection: direct\n",
+			path:      "host",
+			newParent: "connection",
+			wantErr:   true,
+		},
+		{
+			name:      "missing path",
+			content:   "host: a\n",
+			path:      "server.host",
+			newParent: "connection",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.Wrap(tt.path, tt.newParent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Wrap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Wrap() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_Unwrap(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "hoist single child",
+			content: `server:
+  connection:
+    host: example.com # primary
+  port: 80
+`,
+			path: "server.connection",
+			want: `server:
+  host: example.com # primary
+  port: 80
+`,
+		},
+		{
+			name: "hoist subtree keeps wrapper comment",
+			content: `# Security settings
+spec:
+  tls:
+    cert: a.pem
+    key: b.pem
+name: web
+`,
+			path: "spec",
+			want: `# Security settings
+tls:
+  cert: a.pem
+  key: b.pem
+name: web
+`,
+		},
+		{
+			name:    "multiple children",
+			content: "server:\n  connection:\n    host: a\n    port: 80\n",
+			path:    "server.connection",
+			wantErr: true,
+		},
+		{
+			name:    "scalar value",
+			content: "server:\n  host: a\n",
+			path:    "server.host",
+			wantErr: true,
+		},
+		{
+			name:    "conflict with sibling",
+			content: "server:\n  host: b\n  connection:\n    host: a\n",
+			path:    "server.connection",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.Unwrap(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unwrap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unwrap() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_RenameKeyAll(t *testing.T) {
+	content := `environments:
+  dev:
+    # Verbose logging
+    debug: true # local only
+    port: 8080
+  prod:
+    port: 80
+    debug: false
+  staging:
+    port: 8081
+`
+
+	tests := []struct {
+		name          string
+		content       string
+		parentPattern string
+		oldKey        string
+		newKey        string
+		wantCount     int
+		want          string
+		wantErr       bool
+	}{
+		{
+			name:          "rename across matches",
+			content:       content,
+			parentPattern: "environments.*",
+			oldKey:        "debug",
+			newKey:        "verbose",
+			wantCount:     2,
+			want: `environments:
+  dev:
+    # Verbose logging
+    verbose: true # local only
+    port: 8080
+  prod:
+    port: 80
+    verbose: false
+  staging:
+    port: 8081
+`,
+		},
+		{
+			name:          "no matches",
+			content:       content,
+			parentPattern: "environments.*",
+			oldKey:        "missing",
+			newKey:        "other",
+			wantCount:     0,
+			want:          content,
+		},
+		{
+			name:          "top-level wildcard matches the sections, not the root",
+			content:       "debug: x\na:\n  debug: true\nb: [debug]\n",
+			parentPattern: "*",
+			oldKey:        "debug",
+			newKey:        "verbose",
+			wantCount:     1,
+			want:          "debug: x\na:\n  verbose: true\nb: [debug]\n",
+		},
+		{
+			name:          "conflict leaves document unchanged",
+			content:       "a:\n  debug: true\nb:\n  debug: false\n  verbose: true\n",
+			parentPattern: "*",
+			oldKey:        "debug",
+			newKey:        "verbose",
+			wantErr:       true,
+			want:          "a:\n  debug: true\nb:\n  debug: false\n  verbose: true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			count, err := doc.RenameKeyAll(tt.parentPattern, tt.oldKey, tt.newKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenameKeyAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if count != tt.wantCount {
+				t.Errorf("RenameKeyAll() count = %d, want %d", count, tt.wantCount)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_WrapAsMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "playbook",
+			content: `- name: web
+  hosts: web # frontend
+  tasks:
+    - debug: hello
+- name: db
+  hosts: db
+`,
+			key: "plays",
+			want: `plays:
+  - name: web
+    hosts: web # frontend
+    tasks:
+      - debug: hello
+  - name: db
+    hosts: db
+`,
+		},
+		{
+			name:    "four space document",
+			content: "-   name: web\n    vars:\n        port: 80\n",
+			key:     "plays",
+			want:    "plays:\n    - name: web\n      vars:\n          port: 80\n",
+		},
+		{
+			name:    "scalar items",
+			content: "- a\n- b\n",
+			key:     "items",
+			want:    "items:\n  - a\n  - b\n",
+		},
+		{
+			name:    "block scalar at the end",
+			content: "- a\n- |\n  echo hi\n",
+			key:     "items",
+			want:    "items:\n  - a\n  - |\n    echo hi\n",
+		},
+		{
+			name:    "mapping root",
+			content: "name: x\n",
+			key:     "items",
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			content: "- a\n",
+			key:     "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.WrapAsMapping(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WrapAsMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("WrapAsMapping() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// The document now behaves like any mapping-root document
+			if err := doc.Set(tt.key+"[0]", "changed"); err != nil {
+				t.Errorf("Set() after WrapAsMapping() error = %v", err)
+			}
+			if _, err := doc.GetArrayDocumentElement(0, ""); err == nil {
+				t.Error("GetArrayDocumentElement() should fail after WrapAsMapping()")
+			}
+
+			// Unwrapping restores an array-root document
+			if err := doc.UnwrapToArray(tt.key); err != nil {
+				t.Fatalf("UnwrapToArray() error = %v", err)
+			}
+			first, err := doc.GetArrayDocumentElement(0, "")
+			if err != nil || first != "changed" {
+				t.Errorf("GetArrayDocumentElement() after UnwrapToArray() = %v, %v", first, err)
+			}
+		})
+	}
+}
+
+func TestDocument_UnwrapToArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "playbook",
+			content: `# All plays
+plays:
+  - name: web
+    hosts: web # frontend
+    tasks:
+      - debug: hello
+  - name: db
+    hosts: db
+`,
+			key: "plays",
+			want: `# All plays
+- name: web
+  hosts: web # frontend
+  tasks:
+    - debug: hello
+- name: db
+  hosts: db
+`,
+		},
+		{
+			name:    "four space document",
+			content: "plays:\n    -   name: web\n        vars:\n            port: 80\n",
+			key:     "plays",
+			want:    "- name: web\n  vars:\n      port: 80\n",
+		},
+		{
+			name:    "zero indent sequence",
+			content: "plays:\n- name: web\n  hosts: all\n",
+			key:     "plays",
+			want:    "- name: web\n  hosts: all\n",
+		},
+		{
+			name:    "flow sequence becomes block",
+			content: "items: [a, b]\n",
+			key:     "items",
+			want:    "- a\n- b\n",
+		},
+		{
+			name:    "several keys",
+			content: "items:\n  - a\nname: x\n",
+			key:     "items",
+			wantErr: true,
+		},
+		{
+			name:    "wrong key",
+			content: "items:\n  - a\n",
+			key:     "plays",
+			wantErr: true,
+		},
+		{
+			name:    "not a sequence",
+			content: "items:\n  a: 1\n",
+			key:     "items",
+			wantErr: true,
+		},
+		{
+			name:    "array root",
+			content: "- a\n",
+			key:     "items",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.UnwrapToArray(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnwrapToArray() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("UnwrapToArray() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			if err := doc.AddArrayElement("extra"); err != nil {
+				t.Errorf("AddArrayElement() after UnwrapToArray() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package yamler
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema selects how plain scalars are typed when a document is loaded
+type Schema int
+
+const (
+	// SchemaCore is the YAML 1.2 core schema: only true/false are booleans,
+	// octals are written 0o755 and 0755 is the decimal 755
+	SchemaCore Schema = iota
+	// SchemaYAML11 is the YAML 1.1 schema of PyYAML and Ansible: yes/no/on/off are booleans too,
+	// 0755 is octal, 0b101 is binary and numbers may contain underscores
+	SchemaYAML11
+	// SchemaJSON is the YAML 1.2 JSON schema: only null, true, false and JSON numbers are typed,
+	// any other plain scalar such as True, ~ or 0x1F is a string; empty values are still null
+	SchemaJSON
+)
+
+// String returns the name of the schema
+func (s Schema) String() string {
+	switch s {
+	case SchemaCore:
+		return "core"
+	case SchemaYAML11:
+		return "yaml1.1"
+	case SchemaJSON:
+		return "json"
+	default:
+		return fmt.Sprintf("Schema(%d)", int(s))
+	}
+}
+
+var (
+	coreInt     = regexp.MustCompile(`^([-+]?[0-9]+|0o[0-7]+|0x[0-9a-fA-F]+)$`)
+	coreFloat   = regexp.MustCompile(`^([-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+	yaml11Int   = regexp.MustCompile(`^([-+]?0b[0-1_]+|[-+]?0[0-7_]+|[-+]?(0|[1-9][0-9_]*)|[-+]?0x[0-9a-fA-F_]+)$`)
+	yaml11Float = regexp.MustCompile(`^([-+]?([0-9][0-9_]*\.[0-9_]*|\.[0-9_]*[0-9][0-9_]*)([eE][-+][0-9]+)?|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+	jsonInt     = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	jsonFloat   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]*)?([eE][-+]?[0-9]+)?$`)
+)
+
+// schemaTag returns the tag schema gives a plain scalar spelled value
+func schemaTag(schema Schema, value string) string {
+	switch schema {
+	case SchemaJSON:
+		switch {
+		case value == "null" || value == "":
+			return "!!null"
+		case value == "true" || value == "false":
+			return "!!bool"
+		case jsonInt.MatchString(value):
+			return "!!int"
+		case jsonFloat.MatchString(value):
+			return "!!float"
+		}
+		return "!!str"
+	case SchemaYAML11:
+		if _, ok := yaml11Bool(value); ok || isBoolSpelling(value) {
+			return "!!bool"
+		}
+		switch {
+		case isNullSpelling(value):
+			return "!!null"
+		case yaml11Int.MatchString(value):
+			return "!!int"
+		case yaml11Float.MatchString(value):
+			return "!!float"
+		}
+		return "!!str"
+	default:
+		switch {
+		case isBoolSpelling(value):
+			return "!!bool"
+		case isNullSpelling(value):
+			return "!!null"
+		case coreInt.MatchString(value):
+			return "!!int"
+		case coreFloat.MatchString(value):
+			return "!!float"
+		}
+		return "!!str"
+	}
+}
+
+// isBoolSpelling reports whether a plain scalar is a boolean in the core schema
+func isBoolSpelling(value string) bool {
+	switch value {
+	case "true", "True", "TRUE", "false", "False", "FALSE":
+		return true
+	}
+	return false
+}
+
+// isNullSpelling reports whether a plain scalar is null in the core and YAML 1.1 schemas
+func isNullSpelling(value string) bool {
+	switch value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// applySchema types the plain scalars below the document root by the document's schema.
+// Scalars typed differently than yaml.v3 would type them are remembered, so they are written as spelled.
+func (d *Document) applySchema() {
+	d.schemaTags = nil
+	if d.root != nil {
+		d.retagPlainScalars(d.root)
+	}
+}
+
+// typeLike gives doc, loaded from content rendered by d, the schema of d
+func (d *Document) typeLike(doc *Document) {
+	if doc.schema != d.schema {
+		doc.schema = d.schema
+		doc.applySchema()
+	}
+}
+
+// retagPlainScalars applies the document's schema to node and its children; keys keep their string type
+func (d *Document) retagPlainScalars(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Style == 0 {
+		resolved := (&yaml.Node{Kind: yaml.ScalarNode, Value: node.Value}).ShortTag()
+		switch resolved {
+		case "!!str", "!!int", "!!float", "!!bool", "!!null":
+			// Timestamps and merge keys are left as yaml.v3 resolves them
+			tag := schemaTag(d.schema, node.Value)
+			node.Tag = tag
+			if tag != resolved {
+				if d.schemaTags == nil {
+					d.schemaTags = make(map[*yaml.Node]string)
+				}
+				d.schemaTags[node] = tag
+			}
+		}
+	}
+
+	start, step := 0, 1
+	if node.Kind == yaml.MappingNode {
+		start, step = 1, 2
+	}
+	for i := start; i < len(node.Content); i += step {
+		d.retagPlainScalars(node.Content[i])
+	}
+}
+
+// untagSchemaScalars clears the tag of the scalars the schema typed so the encoder writes them as spelled.
+// The returned nodes get their tag back after encoding.
+func (d *Document) untagSchemaScalars() map[*yaml.Node]string {
+	untagged := make(map[*yaml.Node]string)
+	for node, tag := range d.schemaTags {
+		// Values changed since loading are written like any other value
+		if node.Tag == tag && node.Style == 0 && schemaTag(d.schema, node.Value) == tag {
+			node.Tag = ""
+			untagged[node] = tag
+		}
+	}
+	return untagged
+}
+
+// parseInt parses an integer scalar: decimal, 0x hexadecimal, 0o octal and, in YAML 1.1, 0b binary,
+// 0-prefixed octal and numbers with underscores
+func parseInt(value string, schema Schema) (int64, error) {
+	digits := strings.ReplaceAll(value, "_", "")
+	sign := ""
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+
+	base := 10
+	switch {
+	case strings.HasPrefix(digits, "0x"):
+		base, digits = 16, digits[2:]
+	case strings.HasPrefix(digits, "0o"):
+		base, digits = 8, digits[2:]
+	case strings.HasPrefix(digits, "0b"):
+		base, digits = 2, digits[2:]
+	case schema == SchemaYAML11 && len(digits) > 1 && digits[0] == '0':
+		base = 8
+	}
+	return strconv.ParseInt(sign+digits, base, 64)
+}
+
+// parseFloat parses a float scalar, including .inf, -.inf and .nan
+func parseFloat(value string) (float64, error) {
+	switch strings.ToLower(strings.TrimLeft(value, "+")) {
+	case ".inf":
+		return math.Inf(1), nil
+	case "-.inf":
+		return math.Inf(-1), nil
+	case ".nan":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64)
+}
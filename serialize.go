@@ -0,0 +1,196 @@
+package yamler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SequenceLayout places the dashes of block sequences relative to their key
+type SequenceLayout int
+
+const (
+	// SequenceKeep keeps the document's own layout
+	SequenceKeep SequenceLayout = iota
+	// SequenceIndented indents the dash one level under its key: "key:\n  - item"
+	SequenceIndented
+	// SequenceZeroIndented puts the dash in the column of its key: "key:\n- item"
+	SequenceZeroIndented
+)
+
+// SerializeOptions is a house style for writing documents, mostly useful for files authored from scratch.
+// Unlike OutputOptions, it is set once with SetSerializeOptions and applies to every later
+// ToBytes, String and Save, and to the values Set adds. The zero value keeps the document's own style.
+type SerializeOptions struct {
+	// Indent is the indentation width in spaces, e.g. 2, 4 or 8; 0 keeps the document's own
+	Indent int
+	// Sequences places the dashes of block sequences
+	Sequences SequenceLayout
+	// LineWidth wraps string values that run past this column onto continuation lines; 0 never wraps
+	LineWidth int
+	// Quote is how string values written by Set are quoted: Plain, SingleQuoted or DoubleQuoted.
+	// Values replacing a quoted string keep its quotes.
+	Quote ScalarStyle
+	// LineEnding forces LF or CRLF line endings; LineEndingKeep writes those the document was loaded with
+	LineEnding LineEnding
+	// BOM adds or drops the UTF-8 byte order mark; KeepBOM writes one if the loaded content had it
+	BOM ByteOrderMark
+}
+
+// SetSerializeOptions makes the document write itself in the given style from now on
+func (d *Document) SetSerializeOptions(opts SerializeOptions) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if opts.Indent < 0 {
+		return fmt.Errorf("invalid indent size: %d", opts.Indent)
+	}
+	if opts.LineWidth < 0 {
+		return fmt.Errorf("invalid line width: %d", opts.LineWidth)
+	}
+	if opts.Sequences < SequenceKeep || opts.Sequences > SequenceZeroIndented {
+		return fmt.Errorf("invalid sequence layout: %d", opts.Sequences)
+	}
+	if err := validateLineEndings(opts.LineEnding, opts.BOM); err != nil {
+		return err
+	}
+	switch opts.Quote {
+	case Plain, SingleQuoted, DoubleQuoted:
+	default:
+		return fmt.Errorf("invalid quote style for new values: %s", opts.Quote)
+	}
+
+	d.pinned = nil
+	info := d.formattingInfo()
+	if opts.Indent > 0 {
+		setIndentSize(info, opts.Indent)
+		// Other widths are laid out structurally, like restructured documents
+		if info.SequenceIndent < 0 {
+			info.SequenceIndent = opts.Indent
+		}
+	}
+	switch opts.Sequences {
+	case SequenceIndented:
+		info.SequenceIndent = info.IndentSize
+	case SequenceZeroIndented:
+		info.SequenceIndent = 0
+	}
+	d.serialize = opts
+	return nil
+}
+
+// newValueStyle returns the node style for string values written by Set
+func (d *Document) newValueStyle() yaml.Style {
+	switch d.serialize.Quote {
+	case SingleQuoted:
+		return yaml.SingleQuotedStyle
+	case DoubleQuoted:
+		return yaml.DoubleQuotedStyle
+	default:
+		return 0
+	}
+}
+
+// quoteNewStrings gives the unquoted string scalars of a new value the quote style; keys stay plain
+func quoteNewStrings(node *yaml.Node, style yaml.Style) {
+	if style == 0 {
+		return
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" && node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			node.Style |= style
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			quoteNewStrings(node.Content[i], style)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			quoteNewStrings(child, style)
+		}
+	}
+}
+
+// wrapLongLines breaks plain and quoted string values running past width at spaces, continuing them on lines
+// indented one level deeper. Output that would read back differently from root is returned unchanged.
+func wrapLongLines(content []byte, root *yaml.Node, width, indent int) []byte {
+	lines := strings.Split(string(content), "\n")
+	wrapped := make([]string, 0, len(lines))
+	changed := false
+	for _, line := range lines {
+		if len(line) <= width {
+			wrapped = append(wrapped, line)
+			continue
+		}
+		split := wrapLine(line, width, indent)
+		changed = changed || len(split) > 1
+		wrapped = append(wrapped, split...)
+	}
+	if !changed {
+		return content
+	}
+
+	result := strings.Join(wrapped, "\n")
+	var check yaml.Node
+	if err := yaml.Unmarshal([]byte(result), &check); err != nil || len(check.Content) == 0 || len(root.Content) == 0 {
+		return content
+	}
+	got, err := nodeToInterface(check.Content[0])
+	if err != nil {
+		return content
+	}
+	want, err := nodeToInterface(root.Content[0])
+	if err != nil || !reflect.DeepEqual(got, want) {
+		return content
+	}
+	return []byte(result)
+}
+
+// wrapLine splits a "key: value" or "- value" line whose value is a plain or quoted scalar of words
+// separated by single spaces; any other line is returned as is
+func wrapLine(line string, width, indent int) []string {
+	if strings.Contains(line, " #") || strings.Contains(line, "\t") {
+		return []string{line}
+	}
+
+	// Find where the value starts, after the dashes of sequence items and the key.
+	// Continuation lines go deeper than the key, or than the dash for a plain item.
+	rest := strings.TrimLeft(line, " ")
+	for strings.HasPrefix(rest, "- ") {
+		rest = rest[2:]
+	}
+	prefixLen := len(line) - len(rest)
+	column := prefixLen
+	if idx := strings.Index(rest, ": "); idx > 0 {
+		prefixLen += idx + 2
+		column += indent
+	} else if prefixLen == len(line)-len(strings.TrimLeft(line, " ")) {
+		// Neither a key nor an item, e.g. a line of a block scalar
+		return []string{line}
+	}
+	value := line[prefixLen:]
+
+	// Quoted scalars fold line breaks into spaces like plain ones
+	quoted := len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0]
+	if value == "" || strings.Contains(value, "  ") || strings.HasSuffix(value, " ") ||
+		(!quoted && (strings.ContainsAny(value[:1], "\"'[]{}|>&*!%@`#,?:-") || strings.Contains(value, ": "))) {
+		return []string{line}
+	}
+
+	pad := strings.Repeat(" ", column)
+	words := strings.Split(value, " ")
+	var result []string
+	current := line[:prefixLen] + words[0]
+	for _, word := range words[1:] {
+		if len(current)+1+len(word) > width {
+			result = append(result, current)
+			current = pad + word
+			continue
+		}
+		current += " " + word
+	}
+	return append(result, current)
+}
@@ -0,0 +1,124 @@
+package yamler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocument_SetSerializeOptions(t *testing.T) {
+	long := strings.Repeat("word ", 12) + "end"
+
+	tests := []struct {
+		name    string
+		content string
+		opts    SerializeOptions
+		edit    func(doc *Document) error
+		want    string
+	}{
+		{
+			name:    "new file in house style",
+			content: "",
+			opts:    SerializeOptions{Indent: 4, Sequences: SequenceZeroIndented, LineWidth: 40, Quote: DoubleQuoted},
+			edit: func(doc *Document) error {
+				if err := doc.Set("app.name", "web"); err != nil {
+					return err
+				}
+				if err := doc.Set("app.port", 8080); err != nil {
+					return err
+				}
+				if err := doc.Set("app.tags", []interface{}{"a", "b"}); err != nil {
+					return err
+				}
+				return doc.Set("app.description", long)
+			},
+			want: `app:
+    name: "web"
+    port: 8080
+    tags:
+    - "a"
+    - "b"
+    description: "word word word word
+        word word word word word word
+        word word end"
+`,
+		},
+		{
+			name:    "indented sequences",
+			content: "list:\n- a\n- b\nmap:\n  nested:\n  - c\n",
+			opts:    SerializeOptions{Sequences: SequenceIndented},
+			want:    "list:\n  - a\n  - b\nmap:\n  nested:\n    - c\n",
+		},
+		{
+			name:    "quote preference keeps existing quotes",
+			content: "a: 'x'\nb: y\n",
+			opts:    SerializeOptions{Quote: DoubleQuoted},
+			edit: func(doc *Document) error {
+				if err := doc.Set("a", "changed"); err != nil {
+					return err
+				}
+				if err := doc.Set("c", map[string]interface{}{"key": "value", "on": true}); err != nil {
+					return err
+				}
+				return doc.Set("d", []interface{}{"s", 1})
+			},
+			want: "a: 'changed'\nb: y\nc:\n  key: \"value\"\n  on: true\nd:\n  - \"s\"\n  - 1\n",
+		},
+		{
+			name:    "wrapping leaves comments and block scalars alone",
+			content: "plain: " + long + "\ncommented: " + long + " # note\nscript: |\n  " + long + "\n",
+			opts:    SerializeOptions{LineWidth: 30},
+			want: "plain: word word word word\n  word word word word word\n  word word word end\ncommented: " + long +
+				" # note\nscript: |\n  " + long + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := doc.SetSerializeOptions(tt.opts); err != nil {
+				t.Fatalf("SetSerializeOptions() error = %v", err)
+			}
+			if tt.edit != nil {
+				if err := tt.edit(doc); err != nil {
+					t.Fatalf("edit error = %v", err)
+				}
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() =\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// The written style is stable across later renders
+			if again, _ := doc.String(); again != got {
+				t.Errorf("second String() =\n%s\nwant:\n%s", again, got)
+			}
+		})
+	}
+}
+
+func TestDocument_SetSerializeOptionsInvalid(t *testing.T) {
+	tests := []SerializeOptions{
+		{Indent: -1},
+		{LineWidth: -1},
+		{Sequences: SequenceLayout(7)},
+		{Quote: Literal},
+		{LineEnding: LineEnding("\r")},
+		{BOM: ByteOrderMark(5)},
+	}
+	for _, opts := range tests {
+		doc, err := Load("a: 1\n")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if err := doc.SetSerializeOptions(opts); err == nil {
+			t.Errorf("SetSerializeOptions(%+v) should fail", opts)
+		}
+	}
+}
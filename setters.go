@@ -2,37 +2,33 @@ package yamler
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Set sets a value at the specified path
+// Set sets a value at the specified path, creating missing intermediate keys.
+// In array documents paths start with an index like "[0].tasks[3].copy.mode";
+// a path without a leading index addresses the first element.
+// A string replacing a number or boolean keeps its type if it is spelled like one, so
+// Set("port", "8081") writes port: 8081; use SetTypedString to always write a string.
 func (d *Document) Set(path string, value interface{}) error {
-	// Set document separator preservation flag for Set() operations
-	d.preserveDocumentSeparator = true
-
-	// Handle both mapping and array root documents
-	if d.isArrayRoot() {
-		// Store the current preserveDocumentSeparator setting
-		preserveFlag := d.preserveDocumentSeparator
-
-		// For array root documents, prepend [0] to the path if it doesn't start with array index
-		if path != "" && !strings.HasPrefix(path, "[") {
-			path = "[0]." + path
-		}
-		err := d.SetArrayElement(0, path[4:], value) // Remove "[0]." prefix
-
-		// Restore the preserveDocumentSeparator setting after SetArrayElement
-		d.preserveDocumentSeparator = preserveFlag
+	generation := d.beginNodeIndexEdit()
+	defer d.endNodeIndexEdit()
+	if err := d.checkWritable(); err != nil {
 		return err
 	}
 
-	root, err := d.mappingRoot()
+	// Set document separator preservation flag for Set() operations
+	d.preserveDocumentSeparator = true
+
+	root, err := d.queryRoot()
 	if err != nil {
 		return err
 	}
+	path = d.rootPath(path)
 	parts := splitPath(path)
 	if len(parts) == 0 {
 		// Empty path — replace entire root
@@ -40,19 +36,61 @@ func (d *Document) Set(path string, value interface{}) error {
 		if err != nil {
 			return err
 		}
-		root.Content = valueNode.Content
-		content, err := d.ToBytes()
-		if err != nil {
-			return err
+		if valueNode.Kind != root.Kind {
+			return fmt.Errorf("path %s: document root requires a %s, got %T", path, nodeKind(root), value)
 		}
-		d.raw = string(content)
+		root.Content = valueNode.Content
+		d.markDirty()
+		d.keepNodeIndex(generation, root, parts)
 		return nil
 	}
 
-	parent, key, err := getOrCreateParentNode(root, parts)
+	// New top-level sections are separated from the previous one by the configured spacing
+	if info := d.formattingCache; info != nil && info.SectionSpacing > 0 && root.Kind == yaml.MappingNode && len(root.Content) > 0 {
+		if _, exists := findKeyInMapping(root, parts[0]); !exists {
+			info.SectionBreaks[parts[0]] = info.SectionSpacing
+		}
+	}
+
+	if err := d.setInNode(root, parts, path, value); err != nil {
+		return err
+	}
+	d.markDirty()
+	d.keepNodeIndex(generation, root, parts)
+	return nil
+}
+
+// SetTypedString sets a string value that is always written quoted, so numeric-looking strings
+// such as "08080" or "1.0" are read back as strings by every YAML parser
+func (d *Document) SetTypedString(path, value string) error {
+	node, err := d.setString(path, value)
 	if err != nil {
 		return err
 	}
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) == 0 {
+		node.Style = yaml.DoubleQuotedStyle
+		if d.serialize.Quote == SingleQuoted {
+			node.Style = yaml.SingleQuotedStyle
+		}
+	}
+	return nil
+}
+
+// rootPath makes a path without a leading index address the first element of an array document
+func (d *Document) rootPath(path string) string {
+	if path == "" || strings.HasPrefix(path, "[") || !d.isArrayRoot() {
+		return path
+	}
+	return "[0]." + path
+}
+
+// setInNode sets value at the path parts below root, creating missing intermediate keys.
+// Unquoted strings of the new value get the quote style of SetSerializeOptions.
+func (d *Document) setInNode(root *yaml.Node, parts []string, path string, value interface{}) error {
+	parent, key, err := getOrCreateParentNode(root, parts)
+	if err != nil {
+		return fmt.Errorf("path %s: %w", path, err)
+	}
 
 	valueNode, err := interfaceToNode(value)
 	if err != nil {
@@ -68,12 +106,18 @@ func (d *Document) Set(path string, value interface{}) error {
 	}
 
 	if parent.Kind == yaml.MappingNode {
+		if isArrayIndex(key) {
+			return fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", parent))
+		}
 		found := false
 		for i := 0; i < len(parent.Content); i += 2 {
 			if parent.Content[i].Value == key {
+				preserveQuoteStyle(parent.Content[i+1], valueNode)
+				d.keepScalarType(parent.Content[i+1], valueNode)
 				valueNode.HeadComment = parent.Content[i+1].HeadComment
 				valueNode.LineComment = parent.Content[i+1].LineComment
 				valueNode.FootComment = parent.Content[i+1].FootComment
+				keepLineCommentOnKey(parent.Content[i], valueNode)
 				parent.Content[i+1] = valueNode
 				found = true
 				break
@@ -87,13 +131,18 @@ func (d *Document) Set(path string, value interface{}) error {
 			parent.Content = append(parent.Content, keyNode, valueNode)
 		}
 	} else if parent.Kind == yaml.SequenceNode {
+		if !isArrayIndex(key) {
+			return fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", parent))
+		}
 		idx, err := parseArrayIndex(key)
 		if err != nil {
 			return err
 		}
 		if idx < 0 || idx >= len(parent.Content) {
-			return fmt.Errorf("array index out of bounds: %d", idx)
+			return fmt.Errorf("path %s: %w", path, &ErrIndexOutOfBounds{Index: idx, Length: len(parent.Content)})
 		}
+		preserveQuoteStyle(parent.Content[idx], valueNode)
+		d.keepScalarType(parent.Content[idx], valueNode)
 		valueNode.HeadComment = parent.Content[idx].HeadComment
 		valueNode.LineComment = parent.Content[idx].LineComment
 		valueNode.FootComment = parent.Content[idx].FootComment
@@ -101,15 +150,125 @@ func (d *Document) Set(path string, value interface{}) error {
 	} else {
 		return fmt.Errorf("parent node is not mapping or sequence")
 	}
+	quoteNewStrings(valueNode, d.newValueStyle())
+	return nil
+}
+
+// SetIf sets the value at the specified path only if the current value equals expected.
+// It returns whether the value was changed. A missing path matches an expected nil.
+func (d *Document) SetIf(path string, expected, value interface{}) (bool, error) {
+	if err := d.checkWritable(); err != nil {
+		return false, err
+	}
+
+	// Normalize expected the same way stored values are read back, so int matches int64
+	expectedNode, err := interfaceToNode(expected)
+	if err != nil {
+		return false, fmt.Errorf("path %s: %w", path, err)
+	}
+	want, err := nodeToInterface(expectedNode)
+	if err != nil {
+		return false, fmt.Errorf("path %s: %w", path, err)
+	}
 
-	content, err := d.ToBytes()
+	current, err := d.Get(path)
+	if err != nil {
+		current = nil
+	}
+	if !reflect.DeepEqual(current, want) {
+		return false, nil
+	}
+
+	if err := d.Set(path, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetSliceFlowStyle sets a slice at the specified path and emits it as a flow array
+// in the given style, e.g. compact [a,b,c] or spaced [ a , b , c ]
+func (d *Document) SetSliceFlowStyle(path string, value interface{}, style ArrayStyle) error {
+	// The style is registered under the full path, so same-named arrays elsewhere keep theirs
+	parts := splitPath(path)
+	if len(parts) == 0 || isArrayIndex(parts[len(parts)-1]) {
+		return fmt.Errorf("path %s: flow style requires a mapping key", path)
+	}
+	valueNode, err := interfaceToNode(value)
+	if err != nil {
+		return fmt.Errorf("path %s: %w", path, err)
+	}
+	if valueNode.Kind != yaml.SequenceNode {
+		return fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", valueNode))
+	}
+
+	if err := d.Set(path, value); err != nil {
+		return err
+	}
+
+	node, err := d.getNode(path)
 	if err != nil {
 		return err
 	}
-	d.raw = string(content)
+	node.Style = yaml.FlowStyle
+
+	style.IsFlow = true
+	info := d.formattingInfo()
+	if info.pathArrayStyles == nil {
+		info.pathArrayStyles = make(map[string]*ArrayStyle)
+	}
+	info.pathArrayStyles[strings.Join(parts, ".")] = &style
+
+	d.markDirty()
 	return nil
 }
 
+// keepLineCommentOnKey moves the line comment of a value that became a block collection onto its key,
+// since "key: value # comment" can only keep the comment as "key: # comment" once the value spans lines
+func keepLineCommentOnKey(key, value *yaml.Node) {
+	if value.LineComment == "" || key.LineComment != "" || value.Style&yaml.FlowStyle != 0 {
+		return
+	}
+	if value.Kind != yaml.MappingNode && value.Kind != yaml.SequenceNode {
+		return
+	}
+	key.LineComment = value.LineComment
+	value.LineComment = ""
+}
+
+// preserveQuoteStyle keeps the quoting of a replaced string, so "1.0" or '01234' stay quoted strings
+func preserveQuoteStyle(old, replacement *yaml.Node) {
+	if old.Kind != yaml.ScalarNode || replacement.Kind != yaml.ScalarNode || replacement.Tag != "!!str" {
+		return
+	}
+	if quoted := old.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle); quoted != 0 {
+		replacement.Style |= quoted
+	}
+}
+
+// keepScalarType gives a string replacing a number or boolean that type again if it is spelled like one,
+// so Set("port", "8081") keeps "port: 8080" a number. Other strings stay strings and are quoted as needed;
+// null values are often placeholders for strings, so they take any string.
+func (d *Document) keepScalarType(old, replacement *yaml.Node) {
+	if old.Kind != yaml.ScalarNode || replacement.Kind != yaml.ScalarNode || replacement.Tag != "!!str" || replacement.Style != 0 {
+		return
+	}
+	tag := old.ShortTag()
+	switch tag {
+	case "!!int", "!!float", "!!bool":
+	default:
+		return
+	}
+	if schemaTag(d.schema, replacement.Value) != tag {
+		return
+	}
+	// The encoder writes the value plain only if yaml.v3 reads it back as that type, or as a YAML 1.1 boolean
+	resolved := (&yaml.Node{Kind: yaml.ScalarNode, Value: replacement.Value}).ShortTag()
+	if _, legacy := yaml11Bool(replacement.Value); resolved != tag && !(tag == "!!bool" && legacy) {
+		return
+	}
+	replacement.Tag = tag
+}
+
 // getOrCreateParentNode returns the parent node and key for replacement/addition
 func getOrCreateParentNode(root *yaml.Node, parts []string) (*yaml.Node, string, error) {
 	current := root
@@ -122,7 +281,11 @@ func getOrCreateParentNode(root *yaml.Node, parts []string) (*yaml.Node, string,
 				return nil, "", err
 			}
 			if current.Kind != yaml.SequenceNode {
+				if current.Kind == yaml.MappingNode && len(current.Content) > 0 {
+					return nil, "", kindMismatch("sequence node", current)
+				}
 				current.Kind = yaml.SequenceNode
+				current.Tag = "!!seq"
 				current.Content = make([]*yaml.Node, 0)
 			}
 			for len(current.Content) <= idx {
@@ -143,7 +306,11 @@ func getOrCreateParentNode(root *yaml.Node, parts []string) (*yaml.Node, string,
 			current.Content = make([]*yaml.Node, 0)
 		}
 		if current.Kind != yaml.MappingNode {
+			if current.Kind == yaml.SequenceNode && len(current.Content) > 0 {
+				return nil, "", kindMismatch("mapping node", current)
+			}
 			current.Kind = yaml.MappingNode
+			current.Tag = "!!map"
 			current.Content = make([]*yaml.Node, 0)
 		}
 		found := false
@@ -187,9 +354,24 @@ func parseArrayIndex(part string) (int, error) {
 	return index, nil
 }
 
-// SetString sets a string value in the YAML document
+// SetString sets a string value in the YAML document. Unlike Set, it writes a string even over
+// a number or boolean; the value is quoted only if it would otherwise read back as another type.
 func (d *Document) SetString(path string, value string) error {
-	return d.Set(path, value)
+	_, err := d.setString(path, value)
+	return err
+}
+
+// setString sets value at path as a string and returns its node
+func (d *Document) setString(path, value string) (*yaml.Node, error) {
+	if err := d.Set(path, value); err != nil {
+		return nil, err
+	}
+	node, err := d.getNode(path)
+	if err != nil {
+		return nil, err
+	}
+	node.Tag = "!!str"
+	return node, nil
 }
 
 // SetInt sets an integer value in the YAML document
@@ -198,10 +380,33 @@ func (d *Document) SetInt(path string, value int64) error {
 }
 
 // SetFloat sets a float value in the YAML document
+// Values are written in plain decimal notation (1200000.0, not 1.2e+06).
 func (d *Document) SetFloat(path string, value float64) error {
 	return d.Set(path, value)
 }
 
+// SetFloatWithFormat sets a float value using a strconv.FormatFloat format ('f', 'e', 'g') and precision.
+// Use it to request scientific notation or a fixed number of decimals.
+func (d *Document) SetFloatWithFormat(path string, value float64, format byte, prec int) error {
+	switch format {
+	case 'f', 'e', 'E', 'g', 'G':
+	default:
+		return fmt.Errorf("path %s: unsupported float format %q", path, format)
+	}
+
+	if err := d.Set(path, value); err != nil {
+		return err
+	}
+
+	node, err := d.getNode(path)
+	if err != nil {
+		return err
+	}
+	node.Value = formatFloat(value, format, prec)
+	d.markDirty()
+	return nil
+}
+
 // SetBool sets a boolean value in the YAML document
 func (d *Document) SetBool(path string, value bool) error {
 	return d.Set(path, value)
@@ -1,6 +1,9 @@
 package yamler
 
 import (
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -140,6 +143,14 @@ func TestDocument_SetString(t *testing.T) {
 			want:    "key:\n  nested: new value\n",
 			wantErr: false,
 		},
+		{
+			name:    "numeric string over a number stays a string",
+			content: "port: 8080",
+			path:    "port",
+			value:   "9090",
+			want:    "port: \"9090\"\n",
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -170,6 +181,99 @@ func TestDocument_SetString(t *testing.T) {
 	}
 }
 
+func TestDocument_SetKeepsScalarType(t *testing.T) {
+	content := `port: 8080 # http
+version: 1.0
+debug: true
+name: '8080'
+image: nginx
+empty: ~
+ports: [80, 443]
+`
+
+	tests := []struct {
+		name      string
+		path      string
+		value     string
+		want      string
+		wantValue interface{}
+	}{
+		{name: "integer", path: "port", value: "9090", want: "port: 9090 # http", wantValue: int64(9090)},
+		{name: "hexadecimal integer", path: "port", value: "0x1F", want: "port: 0x1F # http", wantValue: int64(31)},
+		{name: "leading zero is a string", path: "port", value: "08080", want: `port: "08080" # http`, wantValue: "08080"},
+		{name: "float over integer is a string", path: "port", value: "1.0", want: `port: "1.0" # http`, wantValue: "1.0"},
+		{name: "float", path: "version", value: "2.0", want: "version: 2.0", wantValue: 2.0},
+		{name: "boolean", path: "debug", value: "false", want: "debug: false", wantValue: false},
+		{name: "YAML 1.1 boolean is a string", path: "debug", value: "no", want: "debug: no", wantValue: "no"},
+		{name: "quoted string", path: "name", value: "9090", want: "name: '9090'", wantValue: "9090"},
+		{name: "plain string", path: "image", value: "9090", want: `image: "9090"`, wantValue: "9090"},
+		{name: "null takes strings", path: "empty", value: "8080", want: `empty: "8080"`, wantValue: "8080"},
+		{name: "array item", path: "ports[1]", value: "8443", want: "ports: [80, 8443]", wantValue: int64(8443)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := doc.Set(tt.path, tt.value); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if !strings.Contains(got, tt.want+"\n") {
+				t.Errorf("Set() result:\n%s\nwant line %q", got, tt.want)
+			}
+			if value, _ := doc.Get(tt.path); !reflect.DeepEqual(value, tt.wantValue) {
+				t.Errorf("Get() = %#v, want %#v", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestDocument_SetTypedString(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		quote   ScalarStyle
+		path    string
+		value   string
+		want    string
+	}{
+		{name: "over a number", content: "port: 8080\n", path: "port", value: "08080", want: "port: \"08080\"\n"},
+		{name: "over a float", content: "version: 1.0\n", path: "version", value: "1.0", want: "version: \"1.0\"\n"},
+		{name: "plain word", content: "name: app\n", path: "name", value: "web", want: "name: \"web\"\n"},
+		{name: "keeps single quotes", content: "name: 'app'\n", path: "name", value: "8080", want: "name: '8080'\n"},
+		{name: "house quote style", content: "a: 1\n", quote: SingleQuoted, path: "b", value: "true", want: "a: 1\nb: 'true'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := doc.SetSerializeOptions(SerializeOptions{Quote: tt.quote}); err != nil {
+				t.Fatalf("SetSerializeOptions() error = %v", err)
+			}
+			if err := doc.SetTypedString(tt.path, tt.value); err != nil {
+				t.Fatalf("SetTypedString() error = %v", err)
+			}
+
+			if got, _ := doc.String(); got != tt.want {
+				t.Errorf("SetTypedString() result = %q, want %q", got, tt.want)
+			}
+			if value, _ := doc.Get(tt.path); value != tt.value {
+				t.Errorf("Get() = %#v, want %q", value, tt.value)
+			}
+		})
+	}
+}
+
 func TestDocument_SetInt(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -250,6 +354,34 @@ func TestDocument_SetFloat(t *testing.T) {
 			want:    "key:\n  nested: 123.45\n",
 			wantErr: false,
 		},
+		{
+			name:    "large float without scientific notation",
+			content: "key: value",
+			path:    "key",
+			value:   1.2e7,
+			want:    "key: 12000000.0\n",
+		},
+		{
+			name:    "large float with fraction",
+			content: "key: value",
+			path:    "key",
+			value:   1234567.5,
+			want:    "key: 1234567.5\n",
+		},
+		{
+			name:    "small float",
+			content: "key: value",
+			path:    "key",
+			value:   0.0000015,
+			want:    "key: 0.0000015\n",
+		},
+		{
+			name:    "infinity",
+			content: "key: value",
+			path:    "key",
+			value:   math.Inf(-1),
+			want:    "key: -.inf\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -280,6 +412,55 @@ func TestDocument_SetFloat(t *testing.T) {
 	}
 }
 
+func TestDocument_SetFloatWithFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   float64
+		format  byte
+		prec    int
+		want    string
+		wantErr bool
+	}{
+		{name: "scientific", value: 1.2e7, format: 'e', prec: -1, want: "key: 1.2e+07\n"},
+		{name: "fixed decimals", value: 3.14159, format: 'f', prec: 2, want: "key: 3.14\n"},
+		{name: "whole number stays float", value: 5, format: 'f', prec: 0, want: "key: 5.0\n"},
+		{name: "unsupported format", value: 1, format: 'x', prec: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load("key: value")
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.SetFloatWithFormat("key", tt.value, tt.format, tt.prec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetFloatWithFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetFloatWithFormat() = %q, want %q", got, tt.want)
+			}
+
+			reloaded, err := Load(got)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if _, isFloat, err := reloaded.GetNumber("key"); err != nil || !isFloat {
+				t.Errorf("GetNumber() isFloat = %v, err = %v, want float", isFloat, err)
+			}
+		})
+	}
+}
+
 func TestDocument_SetBool(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -623,3 +804,342 @@ func TestDocument_SetMapSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestDocument_SetSliceFlowStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		value   interface{}
+		style   ArrayStyle
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "compact new array",
+			content: "app:\n  name: x\n",
+			path:    "app.tags",
+			value:   []string{"a", "b", "c"},
+			style:   ArrayStyle{IsCompact: true},
+			want:    "app:\n  name: x\n  tags: [a,b,c]\n",
+		},
+		{
+			name:    "spaced new array",
+			content: "app:\n  name: x\n",
+			path:    "app.tags",
+			value:   []string{"a", "b", "c"},
+			style:   ArrayStyle{HasSpaces: true},
+			want:    "app:\n  name: x\n  tags: [ a , b , c ]\n",
+		},
+		{
+			name:    "default new array",
+			content: "app:\n  name: x\n",
+			path:    "app.tags",
+			value:   []int64{1, 2, 3},
+			style:   ArrayStyle{},
+			want:    "app:\n  name: x\n  tags: [1, 2, 3]\n",
+		},
+		{
+			name:    "block array converted to compact flow",
+			content: "ports:\n  - 80\n  - 443\nname: web\n",
+			path:    "ports",
+			value:   []int64{80, 443, 8080},
+			style:   ArrayStyle{IsCompact: true},
+			want:    "ports: [80,443,8080]\nname: web\n",
+		},
+		{
+			name:    "same-named array elsewhere keeps its style",
+			content: "a:\n  tags: [x, y]\nb:\n  name: z\n",
+			path:    "b.tags",
+			value:   []string{"p", "q"},
+			style:   ArrayStyle{IsCompact: true},
+			want:    "a:\n  tags: [x, y]\nb:\n  name: z\n  tags: [p,q]\n",
+		},
+		{
+			name:    "top-level array keeps nested same-named arrays",
+			content: "a:\n  tags: [ x , y ]\n",
+			path:    "tags",
+			value:   []string{"p", "q"},
+			style:   ArrayStyle{IsCompact: true},
+			want:    "a:\n  tags: [ x , y ]\ntags: [p,q]\n",
+		},
+		{
+			name:    "non-slice value",
+			content: "name: web\n",
+			path:    "name",
+			value:   "api",
+			style:   ArrayStyle{IsCompact: true},
+			wantErr: true,
+		},
+		{
+			name:    "array index",
+			content: "x:\n  - [1, 2]\n",
+			path:    "x[0]",
+			value:   []int64{3, 4},
+			style:   ArrayStyle{IsCompact: true},
+			wantErr: true,
+		},
+		{
+			name:    "document root",
+			content: "name: web\n",
+			path:    "",
+			value:   []int64{3, 4},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.SetSliceFlowStyle(tt.path, tt.value, tt.style)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Document.SetSliceFlowStyle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// A rejected call leaves the document alone
+				if got, _ := doc.String(); got != tt.content {
+					t.Errorf("Document.String() after error = %q, want %q", got, tt.content)
+				}
+				return
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("Document.String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Document.SetSliceFlowStyle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetIf(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		path        string
+		expected    interface{}
+		value       interface{}
+		wantChanged bool
+		wantErr     bool
+		want        string
+	}{
+		{
+			name:        "matching string",
+			content:     "mode: legacy\nport: 80\n",
+			path:        "mode",
+			expected:    "legacy",
+			value:       "modern",
+			wantChanged: true,
+			want:        "mode: modern\nport: 80\n",
+		},
+		{
+			name:        "int matches stored integer",
+			content:     "mode: legacy\nport: 80\n",
+			path:        "port",
+			expected:    80,
+			value:       8080,
+			wantChanged: true,
+			want:        "mode: legacy\nport: 8080\n",
+		},
+		{
+			name:     "precondition fails",
+			content:  "mode: legacy\nport: 80\n",
+			path:     "mode",
+			expected: "other",
+			value:    "modern",
+			want:     "mode: legacy\nport: 80\n",
+		},
+		{
+			name:     "type mismatch fails",
+			content:  "port: \"80\"\n",
+			path:     "port",
+			expected: 80,
+			value:    8080,
+			want:     "port: \"80\"\n",
+		},
+		{
+			name:        "missing path matches nil",
+			content:     "mode: legacy\n",
+			path:        "feature.enabled",
+			expected:    nil,
+			value:       true,
+			wantChanged: true,
+			want:        "mode: legacy\nfeature:\n  enabled: true\n",
+		},
+		{
+			name:     "missing path does not match value",
+			content:  "mode: legacy\n",
+			path:     "feature.enabled",
+			expected: false,
+			value:    true,
+			want:     "mode: legacy\n",
+		},
+		{
+			name:     "unsupported expected type",
+			content:  "mode: legacy\n",
+			path:     "mode",
+			expected: struct{}{},
+			value:    "modern",
+			wantErr:  true,
+			want:     "mode: legacy\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			changed, err := doc.SetIf(tt.path, tt.expected, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetIf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("SetIf() changed = %v, want %v", changed, tt.wantChanged)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetSectionSpacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		spacing int
+		sets    [][2]interface{}
+		want    string
+	}{
+		{
+			name:    "default adds no blank lines",
+			content: "app:\n  name: x\n",
+			spacing: 0,
+			sets:    [][2]interface{}{{"server.port", 80}},
+			want:    "app:\n  name: x\nserver:\n  port: 80\n",
+		},
+		{
+			name:    "one blank line before new sections",
+			content: "app:\n  name: x\n",
+			spacing: 1,
+			sets: [][2]interface{}{
+				{"server.host", "localhost"},
+				{"server.port", 80},
+				{"database.url", "pg"},
+				{"app.debug", true},
+			},
+			want: "app:\n  name: x\n  debug: true\n\nserver:\n  host: localhost\n  port: 80\n\ndatabase:\n  url: pg\n",
+		},
+		{
+			name:    "built from an empty document",
+			content: "",
+			spacing: 2,
+			sets:    [][2]interface{}{{"app.name", "x"}, {"server.host", "h"}},
+			want:    "app:\n  name: x\n\n\nserver:\n  host: h\n",
+		},
+		{
+			name:    "nested keys with the same name are not spaced",
+			content: "app:\n  name: x\n",
+			spacing: 1,
+			sets:    [][2]interface{}{{"name", "top"}},
+			want:    "app:\n  name: x\n\nname: top\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			doc.SetSectionSpacing(tt.spacing)
+
+			for _, set := range tt.sets {
+				if err := doc.Set(set[0].(string), set[1]); err != nil {
+					t.Fatalf("Set(%v) error = %v", set[0], err)
+				}
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() =\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetKeepsCommentsOnTypeChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		value   interface{}
+		want    string
+	}{
+		{
+			name:    "scalar to map",
+			content: "spec:\n  # Mount config here\n  volumeMounts: none # replaced later\n  other: 1\n",
+			path:    "spec.volumeMounts",
+			value:   map[string]interface{}{"name": "config"},
+			want:    "spec:\n  # Mount config here\n  volumeMounts: # replaced later\n    name: config\n  other: 1\n",
+		},
+		{
+			name:    "scalar to list",
+			content: "spec:\n  # Mount config here\n  volumeMounts: none # replaced later\n  other: 1\n",
+			path:    "spec.volumeMounts",
+			value:   []interface{}{map[string]interface{}{"name": "config"}},
+			want:    "spec:\n  # Mount config here\n  volumeMounts: # replaced later\n    - name: config\n  other: 1\n",
+		},
+		{
+			name:    "map to scalar",
+			content: "# Limits\nlimits: # per container\n  cpu: 1\nname: x\n",
+			path:    "limits",
+			value:   "none",
+			want:    "# Limits\nlimits: none # per container\nname: x\n",
+		},
+		{
+			name:    "scalar to scalar",
+			content: "port: 80 # http\n",
+			path:    "port",
+			value:   "eighty",
+			want:    "port: eighty # http\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := doc.Set(tt.path, tt.value); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() =\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
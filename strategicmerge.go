@@ -0,0 +1,327 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Strategic merge patch directives, as written by kubectl and kustomize
+const (
+	patchDirective             = "$patch"
+	retainKeysDirective        = "$retainKeys"
+	deleteFromPrimitivePrefix  = "$deleteFromPrimitiveList/"
+	setElementOrderPrefix      = "$setElementOrder/"
+	defaultStrategicMergeField = "name"
+)
+
+// StrategicMergeOptions controls how ApplyStrategicMergePatchWithOptions matches list items
+type StrategicMergeOptions struct {
+	// MergeKey is the field that identifies the mapping items of a list, "name" if empty
+	MergeKey string
+	// MergeKeys overrides MergeKey for lists under the given field names, e.g. {"volumeMounts": "mountPath"}
+	MergeKeys map[string]string
+}
+
+// mergeKeyFor returns the merge key of the list under field
+func (opts StrategicMergeOptions) mergeKeyFor(field string) string {
+	if key, ok := opts.MergeKeys[field]; ok {
+		return key
+	}
+	if opts.MergeKey != "" {
+		return opts.MergeKey
+	}
+	return defaultStrategicMergeField
+}
+
+// ApplyStrategicMergePatch applies a Kubernetes-style strategic merge patch, matching list items by "name".
+// See ApplyStrategicMergePatchWithOptions.
+func (d *Document) ApplyStrategicMergePatch(patch *Document) error {
+	return d.ApplyStrategicMergePatchWithOptions(patch, StrategicMergeOptions{})
+}
+
+// ApplyStrategicMergePatchWithOptions applies a Kubernetes-style strategic merge patch, keeping the
+// document's comments and formatting. Mappings merge recursively and a null value deletes its key.
+// Lists whose items are mappings with the merge key merge item by item; other lists are replaced.
+// The directives "$patch: delete" (remove a key or list item), "$patch: replace" (replace a mapping
+// or, as a list item, the whole list), "$retainKeys" and "$deleteFromPrimitiveList/<field>" are
+// honored; "$setElementOrder/<field>" is accepted and the existing order kept. The patch is
+// all-or-nothing.
+func (d *Document) ApplyStrategicMergePatchWithOptions(patch *Document, opts StrategicMergeOptions) error {
+	if patch == nil {
+		return fmt.Errorf("patch document is nil")
+	}
+	patchRoot, err := patch.mappingRoot()
+	if err != nil {
+		return fmt.Errorf("patch document has invalid root: %w", err)
+	}
+	root, err := d.mappingRoot()
+	if err != nil {
+		return fmt.Errorf("this document has invalid root: %w", err)
+	}
+
+	return d.atomically(func() error {
+		merger := &strategicMerger{opts: opts, info: d.formattingCache}
+		deleted, err := merger.mergeMapping("", root, patchRoot)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			return fmt.Errorf("cannot delete the document root")
+		}
+		return nil
+	})
+}
+
+// strategicMerger applies a strategic merge patch to a node tree
+type strategicMerger struct {
+	opts StrategicMergeOptions
+	info *FormattingInfo
+}
+
+// mergeMapping merges a patch mapping into target and reports whether the patch deletes target
+func (m *strategicMerger) mergeMapping(path string, target, patch *yaml.Node) (bool, error) {
+	directive, err := patchDirectiveOf(path, patch)
+	if err != nil {
+		return false, err
+	}
+	switch directive {
+	case "delete":
+		return true, nil
+	case "replace":
+		replaced := stripDirectives(cloneSubtree(patch))
+		target.Content = replaced.Content
+		return false, nil
+	}
+
+	var retain []string
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		key, value := patch.Content[i].Value, resolveAlias(patch.Content[i+1])
+		keyPath := appendPathKey(path, key)
+		switch {
+		case key == patchDirective || strings.HasPrefix(key, setElementOrderPrefix):
+			continue
+		case key == retainKeysDirective:
+			if retain, err = scalarValues(keyPath, value); err != nil {
+				return false, err
+			}
+			continue
+		case strings.HasPrefix(key, deleteFromPrimitivePrefix):
+			field := strings.TrimPrefix(key, deleteFromPrimitivePrefix)
+			if err := m.deleteFromPrimitiveList(appendPathKey(path, field), target, field, value); err != nil {
+				return false, err
+			}
+			continue
+		case strings.HasPrefix(key, "$"):
+			return false, fmt.Errorf("path %s: unsupported directive %s", path, key)
+		}
+
+		existing, found := findKeyInMapping(target, key)
+		if isNullNode(value) {
+			if found {
+				removeNodes(target, map[*yaml.Node]bool{existing: true}, m.info)
+			}
+			continue
+		}
+		if !found {
+			if value.Kind == yaml.MappingNode {
+				if directive, err := patchDirectiveOf(keyPath, value); err != nil {
+					return false, err
+				} else if directive == "delete" {
+					continue
+				}
+			}
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+			copyKeyComments(keyNode, patch.Content[i])
+			target.Content = append(target.Content, keyNode, stripDirectives(cloneSubtree(value)))
+			continue
+		}
+
+		deleted, err := m.mergeValue(keyPath, key, existing, value)
+		if err != nil {
+			return false, err
+		}
+		if deleted {
+			removeNodes(target, map[*yaml.Node]bool{existing: true}, m.info)
+		}
+	}
+
+	if retain != nil {
+		keep := make(map[string]bool, len(retain))
+		for _, key := range retain {
+			keep[key] = true
+		}
+		removed := make(map[*yaml.Node]bool)
+		for i := 0; i+1 < len(target.Content); i += 2 {
+			if !keep[target.Content[i].Value] {
+				removed[target.Content[i+1]] = true
+			}
+		}
+		removeNodes(target, removed, m.info)
+	}
+	return false, nil
+}
+
+// mergeValue merges a patch value into the existing value of field and reports whether it is deleted
+func (m *strategicMerger) mergeValue(path, field string, target, patch *yaml.Node) (bool, error) {
+	resolved := resolveAlias(target)
+	switch {
+	case patch.Kind == yaml.MappingNode && resolved.Kind == yaml.MappingNode:
+		return m.mergeMapping(path, resolved, patch)
+	case patch.Kind == yaml.SequenceNode && resolved.Kind == yaml.SequenceNode:
+		return false, m.mergeList(path, field, resolved, patch)
+	case patch.Kind == yaml.MappingNode:
+		if directive, err := patchDirectiveOf(path, patch); err != nil || directive == "delete" {
+			return directive == "delete", err
+		}
+	}
+
+	// Scalars and values of another kind replace the existing value, which keeps its comments
+	replacement := stripDirectives(cloneSubtree(patch))
+	replacement.HeadComment = preserveComment(replacement.HeadComment, target.HeadComment)
+	replacement.LineComment = preserveComment(replacement.LineComment, target.LineComment)
+	replacement.FootComment = preserveComment(replacement.FootComment, target.FootComment)
+	if replacement.Kind == yaml.ScalarNode && target.Kind == yaml.ScalarNode {
+		preserveQuoteStyle(target, replacement)
+	}
+	// Aliases of an anchored value follow the new value; a patched alias is replaced on its own
+	replacement.Anchor = target.Anchor
+	*target = *replacement
+	return false, nil
+}
+
+// mergeList merges a patch list into target: item by item when its items are mappings with the
+// merge key of field, otherwise by replacing the list
+func (m *strategicMerger) mergeList(path, field string, target, patch *yaml.Node) error {
+	key := m.opts.mergeKeyFor(field)
+	var items []*yaml.Node
+	byKey := len(patch.Content) > 0
+	for _, item := range patch.Content {
+		item = resolveAlias(item)
+		if item.Kind == yaml.MappingNode {
+			directive, err := patchDirectiveOf(path, item)
+			if err != nil {
+				return err
+			}
+			if directive == "replace" && len(item.Content) == 2 {
+				// "- $patch: replace" replaces the list with the other items
+				return m.replaceList(target, patch)
+			}
+		}
+		if _, found := findKeyInMapping(item, key); item.Kind != yaml.MappingNode || !found {
+			byKey = false
+		}
+		items = append(items, item)
+	}
+	if !byKey {
+		return m.replaceList(target, patch)
+	}
+
+	for _, item := range items {
+		match := findItemByKey(target, item, key)
+		if match == nil {
+			if directive, _ := patchDirectiveOf(path, item); directive != "delete" {
+				target.Content = append(target.Content, stripDirectives(cloneSubtree(item)))
+			}
+			continue
+		}
+		deleted, err := m.mergeMapping(path, match, item)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			removeNodes(target, map[*yaml.Node]bool{match: true}, m.info)
+		}
+	}
+	return nil
+}
+
+// replaceList replaces the items of target with those of patch, keeping target's flow or block style
+func (m *strategicMerger) replaceList(target, patch *yaml.Node) error {
+	content := make([]*yaml.Node, 0, len(patch.Content))
+	for _, item := range patch.Content {
+		if resolved := resolveAlias(item); resolved.Kind == yaml.MappingNode && len(resolved.Content) == 2 &&
+			resolved.Content[0].Value == patchDirective {
+			continue
+		}
+		content = append(content, stripDirectives(cloneSubtree(item)))
+	}
+	target.Content = content
+	return nil
+}
+
+// deleteFromPrimitiveList removes the given scalar values from the list under field
+func (m *strategicMerger) deleteFromPrimitiveList(path string, target *yaml.Node, field string, values *yaml.Node) error {
+	remove, err := scalarValues(path, values)
+	if err != nil {
+		return err
+	}
+	list, found := findKeyInMapping(target, field)
+	if !found {
+		return nil
+	}
+	list = resolveAlias(list)
+	if list.Kind != yaml.SequenceNode {
+		return fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", list))
+	}
+
+	removed := make(map[*yaml.Node]bool)
+	for _, item := range list.Content {
+		for _, value := range remove {
+			if resolved := resolveAlias(item); resolved.Kind == yaml.ScalarNode && resolved.Value == value {
+				removed[item] = true
+			}
+		}
+	}
+	removeNodes(list, removed, m.info)
+	return nil
+}
+
+// patchDirectiveOf returns the "$patch" directive of a patch mapping, or "" if it has none
+func patchDirectiveOf(path string, node *yaml.Node) (string, error) {
+	value, found := findKeyInMapping(node, patchDirective)
+	if !found {
+		return "", nil
+	}
+	switch directive := resolveAlias(value).Value; directive {
+	case "delete", "replace", "merge":
+		return directive, nil
+	default:
+		return "", fmt.Errorf("path %s: unknown %s directive %q", path, patchDirective, directive)
+	}
+}
+
+// scalarValues returns the values of a list of scalars
+func scalarValues(path string, node *yaml.Node) ([]string, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("sequence node", node))
+	}
+	values := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		item = resolveAlias(item)
+		if item.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("path %s: %w", path, kindMismatch("scalar node", item))
+		}
+		values = append(values, item.Value)
+	}
+	return values, nil
+}
+
+// stripDirectives removes patch directives and null values from content a patch adds
+func stripDirectives(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.HasPrefix(node.Content[i].Value, "$") || isNullNode(node.Content[i+1]) {
+				continue
+			}
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+		node.Content = content
+	}
+	for _, child := range node.Content {
+		stripDirectives(child)
+	}
+	return node
+}
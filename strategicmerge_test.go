@@ -0,0 +1,193 @@
+package yamler
+
+import (
+	"strings"
+	"testing"
+)
+
+const strategicMergeInput = `# web deployment
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: app # main container
+          image: "app:1.0"
+          args: [--verbose, --color]
+          env:
+            - name: MODE
+              value: prod
+            - name: DEBUG
+              value: "false"
+        - name: sidecar
+          image: proxy:2
+      finalizers:
+        - a
+        - b
+metadata:
+  labels:
+    team: core
+    tier: web
+`
+
+func TestDocument_ApplyStrategicMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		opts    StrategicMergeOptions
+		want    string
+		wantErr string
+	}{
+		{
+			name: "list items merge by name",
+			patch: `spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:1.1
+          env:
+            - name: DEBUG
+              value: "true"
+            - name: LOG
+              value: json
+`,
+			want: strings.NewReplacer(
+				`image: "app:1.0"`, `image: "app:1.1"`,
+				"value: \"false\"\n", "value: \"true\"\n            - name: LOG\n              value: json\n",
+			).Replace(strategicMergeInput),
+		},
+		{
+			name: "new list item is appended",
+			patch: `spec:
+  template:
+    spec:
+      containers:
+        - name: metrics
+          image: exporter:1
+`,
+			want: strings.Replace(strategicMergeInput, "          image: proxy:2\n",
+				"          image: proxy:2\n        - name: metrics\n          image: exporter:1\n", 1),
+		},
+		{
+			name: "$patch delete removes a list item and null removes a key",
+			patch: `spec:
+  template:
+    spec:
+      containers:
+        - name: sidecar
+          $patch: delete
+metadata:
+  labels:
+    tier: null
+`,
+			want: strings.NewReplacer("        - name: sidecar\n          image: proxy:2\n", "", "    tier: web\n", "").Replace(strategicMergeInput),
+		},
+		{
+			name: "$patch delete on a mapping removes its key",
+			patch: `metadata:
+  labels:
+    $patch: delete
+`,
+			want: strings.Replace(strategicMergeInput, "metadata:\n  labels:\n    team: core\n    tier: web\n", "metadata: {}\n", 1),
+		},
+		{
+			name: "$patch replace on a mapping",
+			patch: `metadata:
+  labels:
+    $patch: replace
+    app: web
+`,
+			want: strings.Replace(strategicMergeInput, "    team: core\n    tier: web\n", "    app: web\n", 1),
+		},
+		{
+			name: "lists without merge keys are replaced",
+			patch: `spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          args: [--quiet, --json]
+      finalizers: [c]
+`,
+			want: strings.NewReplacer("args: [--verbose, --color]", "args: [--quiet, --json]", "        - a\n        - b\n", "        - c\n").Replace(strategicMergeInput),
+		},
+		{
+			name: "$patch replace list item and $deleteFromPrimitiveList",
+			patch: `spec:
+  template:
+    spec:
+      containers:
+        - $patch: replace
+        - name: only
+          image: only:1
+      $deleteFromPrimitiveList/finalizers: [a]
+      $setElementOrder/containers:
+        - name: only
+`,
+			want: strings.NewReplacer(
+				strategicMergeInput[strings.Index(strategicMergeInput, "        - name: app"):strings.Index(strategicMergeInput, "      finalizers:")],
+				"        - name: only\n          image: only:1\n",
+				"        - a\n", "",
+			).Replace(strategicMergeInput),
+		},
+		{
+			name: "$retainKeys",
+			patch: `metadata:
+  labels:
+    $retainKeys: [team]
+`,
+			want: strings.Replace(strategicMergeInput, "    tier: web\n", "", 1),
+		},
+		{
+			name: "custom merge key",
+			patch: `spec:
+  template:
+    spec:
+      containers:
+        - image: proxy:2
+          name: renamed
+`,
+			opts: StrategicMergeOptions{MergeKeys: map[string]string{"containers": "image"}},
+			want: strings.Replace(strategicMergeInput, "        - name: sidecar\n", "        - name: renamed\n", 1),
+		},
+		{
+			name:    "unknown directive leaves document unchanged",
+			patch:   "spec:\n  replicas: 3\nmetadata:\n  $patch: merge-all\n",
+			wantErr: `unknown $patch directive "merge-all"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(strategicMergeInput)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			patch, err := Load(tt.patch)
+			if err != nil {
+				t.Fatalf("Load(patch) error = %v", err)
+			}
+			err = doc.ApplyStrategicMergePatchWithOptions(patch, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyStrategicMergePatch() error = %v, want %q", err, tt.wantErr)
+				}
+				if got, _ := doc.String(); got != strategicMergeInput {
+					t.Errorf("document after failed patch:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyStrategicMergePatch() error = %v", err)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyStrategicMergePatch() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package yamler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EachDocument loads the "---" separated documents of a file one at a time and passes them to fn,
+// so only a single document is held in memory. Iteration stops at the first error returned by fn.
+// Documents are not written back; call Save or collect their bytes to persist edits.
+func EachDocument(filename string, fn func(i int, d *Document) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var chunk strings.Builder
+	index := 0
+
+	flush := func() error {
+		content := chunk.String()
+		chunk.Reset()
+		if !hasDocumentContent(content) {
+			return nil
+		}
+
+		doc, err := Load(content)
+		if err != nil {
+			return fmt.Errorf("document %d: %w", index, err)
+		}
+		if err := fn(index, doc); err != nil {
+			return err
+		}
+		index++
+		return nil
+	}
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read file: %w", readErr)
+		}
+
+		if isDocumentSeparator(line) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		chunk.WriteString(line)
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	return flush()
+}
+
+// isDocumentSeparator checks if a line starts a new document
+func isDocumentSeparator(line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	return line == "---" || strings.HasPrefix(line, "--- ")
+}
+
+// hasDocumentContent checks if a chunk has anything besides separators, comments and blank lines
+func hasDocumentContent(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || trimmed == "..." || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// StreamDecoder reads a large YAML stream one top-level entry at a time: each key of a mapping
+// root, or each item of a sequence root. Only the current entry is held in memory, and it is
+// parsed only when its Document method is called.
+type StreamDecoder struct {
+	reader *bufio.Reader
+	// Index of the "---" separated document being read
+	document int
+	// Whether the current document has produced an entry yet
+	seenContent bool
+	// Position of the next entry within its document
+	index int
+	// Blank lines, comments and separators not yet assigned to an entry
+	pending []string
+	current *StreamEntry
+	// Text of the current entry so far
+	body    strings.Builder
+	done    bool
+	trailer string
+}
+
+// StreamEntry is one top-level entry of a streamed document
+type StreamEntry struct {
+	// DocumentIndex is the index of the "---" separated document the entry belongs to
+	DocumentIndex int
+	// Index is the position of the entry within its document
+	Index int
+	// Key is the top-level key, or empty for items of a sequence root
+	Key string
+
+	// Blank lines and separators before the entry
+	prefix string
+	// Text of the entry including its head comments
+	raw     string
+	doc     *Document
+	deleted bool
+}
+
+// NewStreamDecoder returns a decoder reading top-level entries from r
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	return &StreamDecoder{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next top-level entry, or io.EOF when the stream is exhausted
+func (s *StreamDecoder) Next() (*StreamEntry, error) {
+	for !s.done {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+		if line != "" {
+			if entry := s.feed(line); entry != nil {
+				return entry, nil
+			}
+		}
+		if err == io.EOF {
+			s.done = true
+			s.trailer = strings.Join(s.pending, "")
+			s.pending = nil
+		}
+	}
+
+	if entry := s.finishEntry(); entry != nil {
+		return entry, nil
+	}
+	return nil, io.EOF
+}
+
+// finishEntry completes the entry being collected, if any
+func (s *StreamDecoder) finishEntry() *StreamEntry {
+	entry := s.current
+	if entry != nil {
+		entry.raw = s.body.String()
+		s.body.Reset()
+		s.current = nil
+	}
+	return entry
+}
+
+// feed adds a line to the current entry and returns the previous entry once a new one starts
+func (s *StreamDecoder) feed(line string) *StreamEntry {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case isDocumentSeparator(line) || trimmed == "...":
+		finished := s.finishEntry()
+		if isDocumentSeparator(line) && s.seenContent {
+			s.document++
+			s.index = 0
+			s.seenContent = false
+		}
+		s.pending = append(s.pending, line)
+		return finished
+
+	case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		s.pending = append(s.pending, line)
+		return nil
+
+	case s.current != nil && continuesEntry(line, s.current):
+		for _, pending := range s.pending {
+			s.body.WriteString(pending)
+		}
+		s.body.WriteString(line)
+		s.pending = nil
+		return nil
+	}
+
+	finished := s.finishEntry()
+	s.current = s.startEntry(line)
+	return finished
+}
+
+// continuesEntry checks if a line belongs to the entry being collected: indented content,
+// zero-indented sequence items under a key, or the closing brackets of a multi-line flow value
+func continuesEntry(line string, entry *StreamEntry) bool {
+	switch line[0] {
+	case ' ', '\t', ']', '}', ',':
+		return true
+	case '-':
+		return entry.Key != ""
+	}
+	return false
+}
+
+// startEntry begins a new entry at a top-level line. Comments directly above it become part
+// of the entry; blank lines and anything above them stay in front of it.
+func (s *StreamDecoder) startEntry(line string) *StreamEntry {
+	split := 0
+	for i, pending := range s.pending {
+		if strings.TrimSpace(pending) == "" || isDocumentSeparator(pending) || strings.TrimSpace(pending) == "..." {
+			split = i + 1
+		}
+	}
+
+	entry := &StreamEntry{
+		DocumentIndex: s.document,
+		Index:         s.index,
+		Key:           streamEntryKey(line),
+		prefix:        strings.Join(s.pending[:split], ""),
+	}
+	for _, pending := range s.pending[split:] {
+		s.body.WriteString(pending)
+	}
+	s.body.WriteString(line)
+	s.pending = nil
+	s.index++
+	s.seenContent = true
+	return entry
+}
+
+// streamEntryKey returns the key of a top-level "key: value" line, unquoting quoted keys
+func streamEntryKey(line string) string {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "?") {
+		return ""
+	}
+
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t'):
+			key := strings.TrimSpace(line[:i])
+			if unquoted, err := strconv.Unquote(key); err == nil && strings.HasPrefix(key, `"`) {
+				return unquoted
+			}
+			if len(key) >= 2 && key[0] == '\'' && key[len(key)-1] == '\'' {
+				return strings.ReplaceAll(key[1:len(key)-1], "''", "'")
+			}
+			return key
+		}
+	}
+	return ""
+}
+
+// Raw returns the text of the entry, including the comments directly above it
+func (e *StreamEntry) Raw() string {
+	return e.raw
+}
+
+// Document parses the entry into a Document holding just this entry, e.g. "key: ..." for a mapping root.
+// Edits to it are written back by EditStream.
+func (e *StreamEntry) Document() (*Document, error) {
+	if e.doc == nil {
+		doc, err := Load(e.raw)
+		if err != nil {
+			return nil, fmt.Errorf("document %d entry %d: %w", e.DocumentIndex, e.Index, err)
+		}
+		e.doc = doc
+	}
+	return e.doc, nil
+}
+
+// Delete drops the entry from the output of EditStream
+func (e *StreamEntry) Delete() {
+	e.deleted = true
+}
+
+// EditStream copies a YAML stream from r to w one top-level entry at a time, calling fn for each.
+// Entries fn does not change are copied byte for byte; only edited entries are rendered again,
+// so memory use stays bounded by the largest entry.
+func EditStream(r io.Reader, w io.Writer, fn func(entry *StreamEntry) error) error {
+	decoder := NewStreamDecoder(r)
+	out := bufio.NewWriter(w)
+
+	for {
+		entry, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+
+		if _, err := out.WriteString(entry.prefix); err != nil {
+			return fmt.Errorf("failed to write stream: %w", err)
+		}
+		if entry.deleted {
+			continue
+		}
+
+		content := entry.raw
+		if entry.doc != nil && entry.doc.edits > 0 {
+			rendered, err := entry.doc.ToBytes()
+			if err != nil {
+				return fmt.Errorf("document %d entry %d: %w", entry.DocumentIndex, entry.Index, err)
+			}
+			content = string(rendered)
+			if strings.HasSuffix(entry.raw, "\n") && !strings.HasSuffix(content, "\n") {
+				content += "\n"
+			}
+		}
+		if _, err := out.WriteString(content); err != nil {
+			return fmt.Errorf("failed to write stream: %w", err)
+		}
+	}
+
+	if _, err := out.WriteString(decoder.trailer); err != nil {
+		return fmt.Errorf("failed to write stream: %w", err)
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write stream: %w", err)
+	}
+	return nil
+}
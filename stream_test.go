@@ -0,0 +1,242 @@
+package yamler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEachDocument(t *testing.T) {
+	content := `# bundle header
+---
+kind: Service
+name: web
+---
+kind: Deployment
+name: web
+replicas: 2
+...
+--- 
+# empty document
+---
+kind: ConfigMap
+name: settings
+`
+	tmpFile := filepath.Join(t.TempDir(), "bundle.yaml")
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var kinds []string
+	err := EachDocument(tmpFile, func(i int, d *Document) error {
+		if i != len(kinds) {
+			t.Errorf("index = %d, want %d", i, len(kinds))
+		}
+		kind, err := d.GetString("kind")
+		if err != nil {
+			return err
+		}
+		kinds = append(kinds, kind)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachDocument() error = %v", err)
+	}
+
+	want := []string{"Service", "Deployment", "ConfigMap"}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("kinds[%d] = %q, want %q", i, kinds[i], want[i])
+		}
+	}
+
+	t.Run("callback error stops iteration", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := EachDocument(tmpFile, func(i int, d *Document) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("EachDocument() error = %v, want %v", err, stop)
+		}
+		if calls != 1 {
+			t.Errorf("calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		err := EachDocument(filepath.Join(t.TempDir(), "missing.yaml"), func(i int, d *Document) error {
+			return nil
+		})
+		if err == nil {
+			t.Error("EachDocument() expected error for missing file")
+		}
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+		if err := os.WriteFile(invalid, []byte("a: 1\n---\nb: [1, 2\n"), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		err := EachDocument(invalid, func(i int, d *Document) error {
+			return nil
+		})
+		if err == nil {
+			t.Error("EachDocument() expected parse error")
+		}
+	})
+}
+
+const streamInput = `# inventory
+version: 3
+
+# hosts by region
+hosts:
+- name: eu-1   # primary
+  ip: 10.0.0.1
+
+- name: us-1
+  ip: 10.0.1.1
+motd: |
+  Welcome
+
+  to the fleet
+ports: [
+  80, 443
+]
+"quoted key": yes
+---
+- name: job-a
+- name: job-b
+`
+
+func TestStreamDecoder(t *testing.T) {
+	decoder := NewStreamDecoder(strings.NewReader(streamInput))
+
+	type entryInfo struct {
+		doc, index int
+		key        string
+		firstLine  string
+	}
+	var got []entryInfo
+	for {
+		entry, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		got = append(got, entryInfo{entry.DocumentIndex, entry.Index, entry.Key, strings.SplitN(entry.Raw(), "\n", 2)[0]})
+	}
+
+	want := []entryInfo{
+		{0, 0, "version", "# inventory"},
+		{0, 1, "hosts", "# hosts by region"},
+		{0, 2, "motd", "motd: |"},
+		{0, 3, "ports", "ports: ["},
+		{0, 4, "quoted key", `"quoted key": yes`},
+		{1, 0, "", "- name: job-a"},
+		{1, 1, "", "- name: job-b"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("entries = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Entries parse on their own
+	decoder = NewStreamDecoder(strings.NewReader(streamInput))
+	decoder.Next()
+	hosts, err := decoder.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	doc, err := hosts.Document()
+	if err != nil {
+		t.Fatalf("Document() error = %v", err)
+	}
+	if ip, err := doc.GetString("hosts[1].ip"); err != nil || ip != "10.0.1.1" {
+		t.Errorf("hosts[1].ip = %q, %v, want 10.0.1.1", ip, err)
+	}
+}
+
+func TestEditStream(t *testing.T) {
+	t.Run("unchanged stream is copied byte for byte", func(t *testing.T) {
+		var out bytes.Buffer
+		err := EditStream(strings.NewReader(streamInput), &out, func(entry *StreamEntry) error {
+			_, err := entry.Document()
+			return err
+		})
+		if err != nil {
+			t.Fatalf("EditStream() error = %v", err)
+		}
+		if out.String() != streamInput {
+			t.Errorf("EditStream() output:\n%s", out.String())
+		}
+	})
+
+	t.Run("targeted edit and delete", func(t *testing.T) {
+		var out bytes.Buffer
+		err := EditStream(strings.NewReader(streamInput), &out, func(entry *StreamEntry) error {
+			switch {
+			case entry.Key == "version":
+				doc, err := entry.Document()
+				if err != nil {
+					return err
+				}
+				return doc.Set("version", 4)
+			case entry.Key == "motd":
+				entry.Delete()
+			case entry.DocumentIndex == 1 && entry.Index == 1:
+				doc, err := entry.Document()
+				if err != nil {
+					return err
+				}
+				return doc.SetArrayElement(0, "name", "job-c")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("EditStream() error = %v", err)
+		}
+
+		want := strings.Replace(streamInput, "version: 3", "version: 4", 1)
+		want = strings.Replace(want, "motd: |\n  Welcome\n\n  to the fleet\n", "", 1)
+		want = strings.Replace(want, "job-b", "job-c", 1)
+		if out.String() != want {
+			t.Errorf("EditStream() output:\n%s\nwant:\n%s", out.String(), want)
+		}
+	})
+
+	t.Run("callback error stops", func(t *testing.T) {
+		stop := errors.New("stop")
+		err := EditStream(strings.NewReader(streamInput), io.Discard, func(entry *StreamEntry) error {
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("EditStream() error = %v, want %v", err, stop)
+		}
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		err := EditStream(strings.NewReader("a: [1, 2\nb: 2\n"), io.Discard, func(entry *StreamEntry) error {
+			_, err := entry.Document()
+			return err
+		})
+		if err == nil {
+			t.Error("EditStream() expected parse error")
+		}
+	})
+}
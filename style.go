@@ -0,0 +1,220 @@
+package yamler
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StyleDiff reports formatting differences between this document and another one,
+// such as indent size, tabs, document markers, quote styles and flow vs block collections.
+// It is meant for documents that are semantically equal; values themselves are not compared
+// and paths that exist in only one document are skipped. An empty result means same style.
+func (d *Document) StyleDiff(other *Document) []string {
+	var diffs []string
+	if other == nil {
+		return diffs
+	}
+
+	info := d.formattingInfo()
+	otherInfo := other.formattingInfo()
+
+	if info.UseTabs != otherInfo.UseTabs {
+		diffs = append(diffs, fmt.Sprintf("indentation: %s vs %s", indentKind(info.UseTabs), indentKind(otherInfo.UseTabs)))
+	} else if info.IndentSize != otherInfo.IndentSize {
+		diffs = append(diffs, fmt.Sprintf("indent size: %d vs %d", info.IndentSize, otherInfo.IndentSize))
+	}
+	if info.HasDocumentStart != otherInfo.HasDocumentStart {
+		diffs = append(diffs, fmt.Sprintf("document start marker: %t vs %t", info.HasDocumentStart, otherInfo.HasDocumentStart))
+	}
+	if info.HasDocumentEnd != otherInfo.HasDocumentEnd {
+		diffs = append(diffs, fmt.Sprintf("document end marker: %t vs %t", info.HasDocumentEnd, otherInfo.HasDocumentEnd))
+	}
+
+	if d.root != nil && len(d.root.Content) > 0 && other.root != nil && len(other.root.Content) > 0 {
+		compareNodeStyles(d.root.Content[0], other.root.Content[0], "", &diffs)
+	}
+
+	return diffs
+}
+
+// formattingInfo returns the cached formatting info, detecting it from raw content if needed
+func (d *Document) formattingInfo() *FormattingInfo {
+	if d.formattingCache == nil {
+		d.formattingCache = detectFormattingInfoOptimized(d.raw)
+	}
+	return d.formattingCache
+}
+
+// compareNodeStyles recursively collects style differences between nodes at the same path
+func compareNodeStyles(a, b *yaml.Node, path string, diffs *[]string) {
+	if a == nil || b == nil || a.Kind != b.Kind {
+		return
+	}
+
+	if styleA, styleB := nodeStyleName(a), nodeStyleName(b); styleA != styleB {
+		name := path
+		if name == "" {
+			name = "(root)"
+		}
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s vs %s", name, styleA, styleB))
+	}
+
+	switch a.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			key := a.Content[i].Value
+			otherValue, found := findKeyInMapping(b, key)
+			if !found {
+				continue
+			}
+
+			childPath := appendPathKey(path, key)
+			compareNodeStyles(a.Content[i+1], otherValue, childPath, diffs)
+		}
+	case yaml.SequenceNode:
+		for i := 0; i < len(a.Content) && i < len(b.Content); i++ {
+			compareNodeStyles(a.Content[i], b.Content[i], fmt.Sprintf("%s[%d]", path, i), diffs)
+		}
+	}
+}
+
+// nodeStyleName returns a human readable name of the node's presentation style
+func nodeStyleName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		if node.Style&yaml.FlowStyle != 0 {
+			return "flow"
+		}
+		return "block"
+	case yaml.ScalarNode:
+		switch {
+		case node.Style&yaml.DoubleQuotedStyle != 0:
+			return "double-quoted"
+		case node.Style&yaml.SingleQuotedStyle != 0:
+			return "single-quoted"
+		case node.Style&yaml.LiteralStyle != 0:
+			return "literal"
+		case node.Style&yaml.FoldedStyle != 0:
+			return "folded"
+		default:
+			return "plain"
+		}
+	default:
+		return ""
+	}
+}
+
+// indentKind describes whether indentation uses tabs or spaces
+func indentKind(useTabs bool) string {
+	if useTabs {
+		return "tabs"
+	}
+	return "spaces"
+}
+
+// ScalarStyle is the presentation style of a scalar value
+type ScalarStyle int
+
+const (
+	// Plain writes the value unquoted, or quoted only where YAML requires it
+	Plain ScalarStyle = iota
+	// DoubleQuoted writes the value in double quotes
+	DoubleQuoted
+	// SingleQuoted writes the value in single quotes
+	SingleQuoted
+	// Literal writes the value as a "|" block keeping its line breaks
+	Literal
+	// Folded writes the value as a ">" block
+	Folded
+)
+
+// String returns the style name as used by StyleDiff
+func (s ScalarStyle) String() string {
+	switch s {
+	case Plain:
+		return "plain"
+	case DoubleQuoted:
+		return "double-quoted"
+	case SingleQuoted:
+		return "single-quoted"
+	case Literal:
+		return "literal"
+	case Folded:
+		return "folded"
+	default:
+		return fmt.Sprintf("ScalarStyle(%d)", int(s))
+	}
+}
+
+// SetStyle sets how the scalar at path is written, e.g. a password DoubleQuoted or a script Literal.
+// Any style other than Plain makes the value a string, so SetStyle("port", DoubleQuoted) turns 8080 into "8080".
+// Multi-line values cannot be plain and are written as literal blocks instead.
+func (d *Document) SetStyle(path string, style ScalarStyle) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	var nodeStyle yaml.Style
+	switch style {
+	case Plain:
+	case DoubleQuoted:
+		nodeStyle = yaml.DoubleQuotedStyle
+	case SingleQuoted:
+		nodeStyle = yaml.SingleQuotedStyle
+	case Literal:
+		nodeStyle = yaml.LiteralStyle
+	case Folded:
+		nodeStyle = yaml.FoldedStyle
+	default:
+		return fmt.Errorf("invalid scalar style: %d", style)
+	}
+
+	key, value, err := d.entryNodes(path)
+	if err != nil {
+		return err
+	}
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("path %s: %w", path, kindMismatch("scalar node", value))
+	}
+	if nodeStyle != 0 && value.Tag != "!!str" {
+		value.Tag = "!!str"
+	}
+	value.Style = nodeStyle
+
+	// Formatting info remembers block and quoted scalars by key name and would restore the old style
+	if key != nil && d.raw != "" {
+		info := d.formattingInfo()
+		delete(info.QuotedScalars, key.Value)
+		if nodeStyle == yaml.LiteralStyle || nodeStyle == yaml.FoldedStyle {
+			info.ScalarStyles[key.Value] = nodeStyle
+		} else {
+			delete(info.ScalarStyles, key.Value)
+		}
+	}
+
+	d.markDirty()
+	return nil
+}
+
+// GetStyle returns how the scalar at path is written
+func (d *Document) GetStyle(path string) (ScalarStyle, error) {
+	_, value, err := d.entryNodes(path)
+	if err != nil {
+		return Plain, err
+	}
+	if value.Kind != yaml.ScalarNode {
+		return Plain, fmt.Errorf("path %s: %w", path, kindMismatch("scalar node", value))
+	}
+	switch {
+	case value.Style&yaml.DoubleQuotedStyle != 0:
+		return DoubleQuoted, nil
+	case value.Style&yaml.SingleQuotedStyle != 0:
+		return SingleQuoted, nil
+	case value.Style&yaml.LiteralStyle != 0:
+		return Literal, nil
+	case value.Style&yaml.FoldedStyle != 0:
+		return Folded, nil
+	default:
+		return Plain, nil
+	}
+}
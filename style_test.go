@@ -0,0 +1,230 @@
+package yamler
+
+import (
+	"testing"
+)
+
+func TestDocument_StyleDiff(t *testing.T) {
+	base := `app:
+  name: "myapp"
+  ports: [80, 443]
+  tags:
+    - web
+labels:
+  app.kubernetes.io/name: "web"
+`
+
+	tests := []struct {
+		name  string
+		other string
+		want  []string
+	}{
+		{
+			name:  "identical style",
+			other: base,
+			want:  nil,
+		},
+		{
+			name: "indent size",
+			other: `app:
+    name: "myapp"
+    ports: [80, 443]
+    tags:
+        - web
+`,
+			want: []string{"indent size: 2 vs 4"},
+		},
+		{
+			name: "quote and collection styles",
+			other: `app:
+  name: 'myapp'
+  ports:
+    - 80
+    - 443
+  tags: [web]
+`,
+			want: []string{
+				"app.name: double-quoted vs single-quoted",
+				"app.ports: flow vs block",
+				"app.tags: block vs flow",
+			},
+		},
+		{
+			name: "document marker and plain scalar",
+			other: `---
+app:
+  name: myapp
+  ports: [80, 443]
+  tags:
+    - web
+`,
+			want: []string{
+				"document start marker: false vs true",
+				"app.name: double-quoted vs plain",
+			},
+		},
+		{
+			name: "value differences are ignored",
+			other: `app:
+  name: "other"
+  ports: [8080]
+  extra: true
+`,
+			want: nil,
+		},
+		{
+			name: "dotted key",
+			other: `labels:
+  app.kubernetes.io/name: web
+`,
+			want: []string{`labels["app.kubernetes.io/name"]: double-quoted vs plain`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(base)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			other, err := Load(tt.other)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			got := doc.StyleDiff(other)
+			if len(got) != len(tt.want) {
+				t.Fatalf("StyleDiff() = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("StyleDiff()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDocument_SetStyle(t *testing.T) {
+	input := `name: app
+port: 8080
+password: 'x'   # secret
+script: |
+  echo hi
+list:
+  - a
+`
+
+	tests := []struct {
+		name    string
+		path    string
+		value   interface{}
+		style   ScalarStyle
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "new value double-quoted",
+			path:  "password",
+			value: "s3cret",
+			style: DoubleQuoted,
+			want:  "name: app\nport: 8080\npassword: \"s3cret\"   # secret\nscript: |\n  echo hi\nlist:\n  - a\n",
+		},
+		{
+			name:  "long script as literal block",
+			path:  "run",
+			value: "make build\nmake test\n",
+			style: Literal,
+			want:  "name: app\nport: 8080\npassword: 'x'   # secret\nscript: |\n  echo hi\nlist:\n  - a\nrun: |\n  make build\n  make test\n",
+		},
+		{
+			name:  "literal back to double-quoted",
+			path:  "script",
+			style: DoubleQuoted,
+			want:  "name: app\nport: 8080\npassword: 'x'   # secret\nscript: \"echo hi\\n\"\nlist:\n  - a\n",
+		},
+		{
+			name:  "quoted back to plain",
+			path:  "password",
+			style: Plain,
+			want:  "name: app\nport: 8080\npassword: x   # secret\nscript: |\n  echo hi\nlist:\n  - a\n",
+		},
+		{
+			name:  "array element single-quoted",
+			path:  "list[0]",
+			style: SingleQuoted,
+			want:  "name: app\nport: 8080\npassword: 'x'   # secret\nscript: |\n  echo hi\nlist:\n  - 'a'\n",
+		},
+		{
+			name:  "quoting a number makes it a string",
+			path:  "port",
+			style: DoubleQuoted,
+			want:  "name: app\nport: \"8080\"\npassword: 'x'   # secret\nscript: |\n  echo hi\nlist:\n  - a\n",
+		},
+		{
+			name:    "not a scalar",
+			path:    "list",
+			style:   DoubleQuoted,
+			wantErr: true,
+		},
+		{
+			name:    "invalid style",
+			path:    "name",
+			style:   ScalarStyle(42),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if tt.value != nil {
+				if err := doc.Set(tt.path, tt.value); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			}
+			err = doc.SetStyle(tt.path, tt.style)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("SetStyle() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetStyle() error = %v", err)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetStyle() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if style, err := doc.GetStyle(tt.path); err != nil || style != tt.style {
+				t.Errorf("GetStyle() = %v, %v; want %v", style, err, tt.style)
+			}
+		})
+	}
+}
+
+func TestDocument_GetStyle(t *testing.T) {
+	doc, err := Load("a: plain\nb: \"double\"\nc: 'single'\nd: |\n  literal\ne: >\n  folded\nf: [x]\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for path, want := range map[string]ScalarStyle{"a": Plain, "b": DoubleQuoted, "c": SingleQuoted, "d": Literal, "e": Folded} {
+		if got, err := doc.GetStyle(path); err != nil || got != want {
+			t.Errorf("GetStyle(%q) = %v, %v; want %v", path, got, err, want)
+		}
+	}
+	if _, err := doc.GetStyle("f"); err == nil {
+		t.Error("GetStyle() on a sequence should fail")
+	}
+	if _, err := doc.GetStyle("missing"); err == nil {
+		t.Error("GetStyle() on a missing path should fail")
+	}
+}
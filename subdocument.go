@@ -0,0 +1,195 @@
+package yamler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SubDocument returns a document rooted at the mapping or sequence at path.
+// The sub-document shares nodes with its parent, so edits made through either are visible in both.
+// It renders with default formatting, since the original text belongs to the parent.
+func (d *Document) SubDocument(path string) (*Document, error) {
+	node, err := d.getNode(path)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("mapping or sequence node", node))
+	}
+
+	sub := &Document{
+		root: &yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{node},
+		},
+		arrayRoot:  node.Kind == yaml.SequenceNode,
+		queryCache: d.queryCache,
+		keepTags:   d.keepTags,
+		schema:     d.schema,
+		schemaTags: d.schemaTags,
+		templates:  d.templates,
+	}
+	if d.frozen {
+		sub.Freeze()
+	}
+	return sub, nil
+}
+
+// Extract returns a new, independent document holding a copy of the mapping or sequence at path,
+// laid out as its own file: the subtree's lines keep their comments, blank lines, quoting and
+// indentation style and move to the left margin, and the head comment of its key becomes the header.
+// If the subtree uses anchors defined outside it, their aliases are expanded and the copy is
+// written with default formatting.
+func (d *Document) Extract(path string) (*Document, error) {
+	if path == "" {
+		return d.Clone(), nil
+	}
+	node, err := d.nodeAt(path)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("mapping or sequence node", node))
+	}
+	load := Load
+	if d.templates != nil {
+		load = LoadTemplate
+	}
+	want, err := nodeToInterface(node)
+	if err != nil {
+		return nil, err
+	}
+
+	// The current text is closest to the original layout; it may lag behind formatting changes,
+	// which the rendered output has
+	if err := d.syncRaw(); err != nil {
+		return nil, err
+	}
+	current := d.raw
+	if d.pinned != nil {
+		current = string(d.pinned)
+	}
+	content, err := d.renderContent()
+	if err != nil {
+		return nil, err
+	}
+	for _, text := range []string{current, string(content)} {
+		if d.templates != nil && text == current {
+			continue
+		}
+		lines, ok := extractLines(text, path)
+		if !ok {
+			continue
+		}
+		if doc, err := load(lines); err == nil && len(doc.root.Content) > 0 {
+			if got, err := nodeToInterface(doc.root.Content[0]); err == nil && reflect.DeepEqual(got, want) {
+				doc.keepTags = d.keepTags
+				d.typeLike(doc)
+				return doc, nil
+			}
+		}
+	}
+
+	copied := cloneSubtree(node)
+	sub := &Document{
+		root:       &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{copied}},
+		arrayRoot:  copied.Kind == yaml.SequenceNode,
+		queryCache: newQueryCache(),
+		templates:  d.templates,
+	}
+	if content, err = sub.ToBytes(); err != nil {
+		return nil, err
+	}
+	doc, err := load(string(content))
+	if err != nil {
+		return nil, err
+	}
+	doc.keepTags = d.keepTags
+	d.typeLike(doc)
+	return doc, nil
+}
+
+// extractLines returns the lines of the block mapping or sequence at path in rendered content,
+// with the head comment of its key, shifted left to the value's column
+func extractLines(content, path string) (string, bool) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil || len(root.Content) == 0 {
+		return "", false
+	}
+	value := root.Content[0]
+	for _, part := range splitPathParts(path) {
+		var err error
+		if value, err = navigateToNode(value, part, path); err != nil {
+			return "", false
+		}
+	}
+	if (value.Kind != yaml.MappingNode && value.Kind != yaml.SequenceNode) || value.Style&yaml.FlowStyle != 0 || value.Line < 1 {
+		return "", false
+	}
+
+	lines := strings.Split(content, "\n")
+	column := value.Column - 1
+	first, last := value.Line-1, lastLine(value)
+	// Block scalar bodies and foot comments continue below the last node
+	for last < len(lines) {
+		line := lines[last]
+		indent := indentOf(line)
+		if strings.TrimSpace(line) == "" || indent < column || (indent == column && !strings.HasPrefix(strings.TrimSpace(line), "#")) {
+			break
+		}
+		last++
+	}
+	if last > len(lines) || len(lines[first]) < column {
+		return "", false
+	}
+
+	var out []string
+	if key := findEntryKey(root.Content[0], value); key != nil && key.HeadComment != "" {
+		// The key's head comment is on the lines right above it
+		count := strings.Count(key.HeadComment, "\n") + 1
+		start := key.Line - 1
+		for start > 0 && count > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "#") {
+			start--
+			count--
+		}
+		for _, line := range lines[start : key.Line-1] {
+			out = append(out, strings.TrimLeft(line, " "))
+		}
+	}
+
+	// A sequence item starts after its "- "
+	lines[first] = strings.Repeat(" ", column) + lines[first][column:]
+	for _, line := range lines[first:last] {
+		trim := column
+		if indent := indentOf(line); indent < trim {
+			trim = indent
+		}
+		out = append(out, line[trim:])
+	}
+	return strings.Join(out, "\n") + "\n", true
+}
+
+// GetOrCreateMap returns a sub-document view of the mapping at path, creating an empty mapping if it doesn't exist.
+// Edits made through the view are written to this document. A path that runs through a scalar is an error,
+// so existing values are never replaced.
+func (d *Document) GetOrCreateMap(path string) (*Document, error) {
+	node, err := d.getNode(path)
+	if errors.Is(err, ErrPathNotFound) {
+		if err := d.Set(path, map[string]interface{}{}); err != nil {
+			return nil, err
+		}
+		return d.SubDocument(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("path %s: %w", path, kindMismatch("mapping node", node))
+	}
+	return d.SubDocument(path)
+}
@@ -0,0 +1,324 @@
+package yamler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocument_SubDocumentGetAll(t *testing.T) {
+	content := `version: "3"
+services:
+  web:
+    image: nginx
+    ports: [80]
+  api:
+    image: node
+    replicas: 2
+servers:
+  - host: a
+    port: 80
+  - host: b
+    port: 81
+`
+
+	tests := []struct {
+		name    string
+		path    string
+		pattern string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "mapping sub-document",
+			path:    "services",
+			pattern: "*.image",
+			want:    map[string]interface{}{"web.image": "nginx", "api.image": "node"},
+		},
+		{
+			name:    "recursive pattern in sub-document",
+			path:    "services",
+			pattern: "**.replicas",
+			want:    map[string]interface{}{"api.replicas": int64(2)},
+		},
+		{
+			name:    "sequence sub-document",
+			path:    "servers",
+			pattern: "[*].host",
+			want:    map[string]interface{}{"[0].host": "a", "[1].host": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			sub, err := doc.SubDocument(tt.path)
+			if err != nil {
+				t.Fatalf("SubDocument() error = %v", err)
+			}
+
+			got, err := sub.GetAll(tt.pattern)
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if !deepEqual(got, tt.want) {
+				t.Errorf("GetAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SubDocumentSharesNodes(t *testing.T) {
+	doc, err := Load("services:\n  web:\n    image: nginx\nname: app\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	sub, err := doc.SubDocument("services")
+	if err != nil {
+		t.Fatalf("SubDocument() error = %v", err)
+	}
+	if err := sub.Set("web.image", "nginx:1.25"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, err := doc.GetString("services.web.image")
+	if err != nil {
+		t.Fatalf("GetString() error = %v", err)
+	}
+	if got != "nginx:1.25" {
+		t.Errorf("parent value = %q, want %q", got, "nginx:1.25")
+	}
+
+	result, err := doc.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	want := "services:\n  web:\n    image: nginx:1.25\nname: app\n"
+	if result != want {
+		t.Errorf("String() = %q, want %q", result, want)
+	}
+
+	if _, err := doc.SubDocument("name"); err == nil {
+		t.Error("SubDocument() expected error for scalar path")
+	}
+	if _, err := doc.SubDocument("missing"); err == nil {
+		t.Error("SubDocument() expected error for missing path")
+	}
+}
+
+func TestDocument_GetAllArrayRoot(t *testing.T) {
+	doc, err := Load("- name: a\n  image: nginx\n- name: b\n  image: node\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got, err := doc.GetAll("[*].image")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	want := map[string]interface{}{"[0].image": "nginx", "[1].image": "node"}
+	if !deepEqual(got, want) {
+		t.Errorf("GetAll() = %v, want %v", got, want)
+	}
+}
+
+func TestDocument_GetOrCreateMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "create missing section",
+			content: "services:\n  web:\n    image: nginx\n",
+			path:    "services.web.monitoring",
+			want:    "services:\n  web:\n    image: nginx\n    monitoring:\n      enabled: true\n      port: 9090\n",
+		},
+		{
+			name:    "edit existing section",
+			content: "monitoring:\n  enabled: false\nname: app\n",
+			path:    "monitoring",
+			want:    "monitoring:\n  enabled: true\n  port: 9090\nname: app\n",
+		},
+		{
+			name:    "existing scalar",
+			content: "monitoring: off\n",
+			path:    "monitoring",
+			wantErr: true,
+		},
+		{
+			name:    "path through a scalar",
+			content: "a: 1\n",
+			path:    "a.b",
+			wantErr: true,
+		},
+		{
+			name:    "path through an array",
+			content: "a: [1, 2]\n",
+			path:    "a.b",
+			wantErr: true,
+		},
+		{
+			name:    "create missing parents",
+			content: "name: app\n",
+			path:    "a.b",
+			want:    "name: app\na:\n  b:\n    enabled: true\n    port: 9090\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			section, err := doc.GetOrCreateMap(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetOrCreateMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// The existing value is left alone
+				if got, _ := doc.String(); got != tt.content {
+					t.Errorf("String() after error = %q, want %q", got, tt.content)
+				}
+				return
+			}
+
+			if err := section.Set("enabled", true); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if err := section.Set("port", 9090); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_Extract(t *testing.T) {
+	content := `version: "3"
+services:
+  # Public API
+  api:
+    image: "app:1.2"     # pinned
+    command: >
+      serve --port 80
+
+    ports: [80, 443]
+    # end of api
+  web:
+    image: nginx
+x-base: &base
+  retries: 3
+jobs:
+  - name: backup
+    <<: *base
+    schedule: '@daily'
+  - name: report
+    steps:
+      - run: make
+`
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "mapping keeps comments, quoting and indentation",
+			path: "services.api",
+			want: "# Public API\nimage: \"app:1.2\"     # pinned\ncommand: >\n  serve --port 80\n\nports: [80, 443]\n# end of api\n",
+		},
+		{
+			name: "sequence item",
+			path: "jobs[1]",
+			want: "name: report\nsteps:\n  - run: make\n",
+		},
+		{
+			name: "sequence",
+			path: "jobs[1].steps",
+			want: "- run: make\n",
+		},
+		{
+			name: "aliases of outside anchors are expanded",
+			path: "jobs[0]",
+			want: "name: backup\nretries: 3\nschedule: '@daily'\n",
+		},
+		{
+			name:    "scalar",
+			path:    "version",
+			wantErr: "expected mapping or sequence node",
+		},
+		{
+			name:    "missing path",
+			path:    "services.db",
+			wantErr: "key db not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			extracted, err := doc.Extract(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Extract() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			got, err := extracted.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() =\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// The extracted document is independent and keeps its layout through edits
+			if err := extracted.Set("added", true); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if original, _ := doc.String(); original != content {
+				t.Errorf("original changed after editing the extracted document:\n%s", original)
+			}
+		})
+	}
+}
+
+func TestDocument_ExtractKeepsIndentation(t *testing.T) {
+	doc, err := Load("services:\n    api:\n        env:\n            MODE: prod   # default\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	extracted, err := doc.Extract("services.api")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if err := extracted.Set("env.DEBUG", false); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	want := "env:\n    MODE: prod   # default\n    DEBUG: false\n"
+	if got, _ := extracted.String(); got != want {
+		t.Errorf("extracted document = %q, want %q", got, want)
+	}
+}
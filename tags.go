@@ -0,0 +1,127 @@
+package yamler
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TaggedValue is a value with a custom tag, such as the CloudFormation intrinsic function !Ref Env.
+// Documents loaded with LoadOptions.CloudFormation return custom-tagged values as TaggedValue,
+// and Set, Encode and the other setters write a TaggedValue in short form: TaggedValue{"!Ref", "Env"}
+// becomes !Ref Env. Decoding into a TaggedValue field keeps the tag in any document.
+type TaggedValue struct {
+	Tag   string
+	Value interface{}
+}
+
+// MarshalYAML writes the value with its tag in short form
+func (t TaggedValue) MarshalYAML() (interface{}, error) {
+	return t.node()
+}
+
+// UnmarshalYAML reads a value with its tag; an untagged value leaves Tag empty
+func (t *TaggedValue) UnmarshalYAML(node *yaml.Node) error {
+	node = resolveAlias(node)
+	value, err := convertContent(node, true, SchemaCore)
+	if err != nil {
+		return err
+	}
+	t.Tag, t.Value = "", value
+	if isCustomTag(node.Tag) {
+		t.Tag = node.Tag
+	}
+	return nil
+}
+
+// node returns the tagged YAML node of the value
+func (t TaggedValue) node() (*yaml.Node, error) {
+	if !strings.HasPrefix(t.Tag, "!") {
+		return nil, fmt.Errorf("invalid tag %q: must start with \"!\"", t.Tag)
+	}
+	node, err := interfaceToNode(t.Value)
+	if err != nil {
+		var encoded yaml.Node
+		if encodeErr := encoded.Encode(t.Value); encodeErr != nil {
+			return nil, err
+		}
+		node = &encoded
+	}
+	node.Tag = t.Tag
+	node.Style |= yaml.TaggedStyle
+	return node, nil
+}
+
+// isCustomTag reports whether tag is an application tag like !Ref rather than a standard !! tag
+func isCustomTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!")
+}
+
+// GetTag returns the tag of the value at path: its explicit tag such as "!!binary" or "!Ref",
+// or the resolved one such as "!!str" or "!!int" for untagged values
+func (d *Document) GetTag(path string) (string, error) {
+	node, err := d.nodeAt(path)
+	if err != nil {
+		return "", err
+	}
+	return resolveAlias(node).ShortTag(), nil
+}
+
+// SetTag writes the value at path with an explicit tag, e.g. SetTag("password", "!vault") or
+// SetTag("logo", "!!binary"). Standard tags must fit the value; an empty tag removes the explicit
+// tag so the value is resolved from its content again.
+func (d *Document) SetTag(path, tag string) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if tag != "" && !strings.HasPrefix(tag, "!") {
+		return fmt.Errorf("invalid tag %q: must start with \"!\"", tag)
+	}
+	node, err := d.nodeAt(path)
+	if err != nil {
+		return err
+	}
+	if node.Kind == yaml.AliasNode {
+		return fmt.Errorf("path %s: cannot tag an alias", path)
+	}
+
+	if strings.HasPrefix(tag, "!!") {
+		kind, collection := standardTagKinds[tag]
+		if !collection {
+			kind = yaml.ScalarNode
+		}
+		if node.Kind != kind {
+			return fmt.Errorf("path %s: tag %s does not fit the node kind", path, tag)
+		}
+	}
+
+	oldTag, oldStyle := node.Tag, node.Style
+	if tag == "" {
+		node.Tag = ""
+		node.Style &^= yaml.TaggedStyle
+		node.Tag = node.ShortTag()
+	} else {
+		node.Tag = tag
+		node.Style |= yaml.TaggedStyle
+	}
+
+	// Reject combinations the document could not be loaded back with, like !!int on "abc"
+	var decoded interface{}
+	if err := node.Decode(&decoded); err != nil {
+		node.Tag, node.Style = oldTag, oldStyle
+		return fmt.Errorf("path %s: tag %s does not fit the value: %w", path, tag, err)
+	}
+
+	d.markDirty()
+	return nil
+}
+
+// standardTagKinds are the node kinds of the standard collection tags; other "!!" tags are scalars
+var standardTagKinds = map[string]yaml.Kind{
+	"!!map":   yaml.MappingNode,
+	"!!set":   yaml.MappingNode,
+	"!!seq":   yaml.SequenceNode,
+	"!!omap":  yaml.SequenceNode,
+	"!!pairs": yaml.SequenceNode,
+}
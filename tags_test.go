@@ -0,0 +1,191 @@
+package yamler
+
+import (
+	"testing"
+)
+
+const tagsInput = `Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub '${AWS::StackName}-data'
+      Subnets: !Split [',', !Ref SubnetList]
+      Role: !GetAtt
+        - Role
+        - Arn
+password: !vault |
+  $ANSIBLE_VAULT;1.1;AES256
+  6162636465
+logo: !!binary aGVsbG8=
+version: !!str 1.10
+port: 8080
+`
+
+func TestDocument_GetTag(t *testing.T) {
+	doc, err := Load(tagsInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"Resources.Bucket.Properties.BucketName": "!Sub",
+		"Resources.Bucket.Properties.Subnets":    "!Split",
+		"Resources.Bucket.Properties.Role":       "!GetAtt",
+		"password":                               "!vault",
+		"logo":                                   "!!binary",
+		"version":                                "!!str",
+		"port":                                   "!!int",
+		"Resources":                              "!!map",
+	}
+	for path, want := range tests {
+		if got, err := doc.GetTag(path); err != nil || got != want {
+			t.Errorf("GetTag(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := doc.GetTag("missing"); err == nil {
+		t.Error("GetTag() on a missing path should fail")
+	}
+}
+
+func TestDocument_SetTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		path    string
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "custom scalar tag",
+			input: "vpc: MyVpc # network\nport: 8080\n",
+			path:  "vpc",
+			tag:   "!Ref",
+			want:  "vpc: !Ref MyVpc # network\nport: 8080\n",
+		},
+		{
+			name:  "standard tag on a number",
+			input: "version: 1.10\n",
+			path:  "version",
+			tag:   "!!str",
+			want:  "version: !!str 1.10\n",
+		},
+		{
+			name:  "binary",
+			input: "logo: aGVsbG8=\n",
+			path:  "logo",
+			tag:   "!!binary",
+			want:  "logo: !!binary aGVsbG8=\n",
+		},
+		{
+			name:  "custom tag on a sequence",
+			input: "subnets:\n  - a\n  - b\n",
+			path:  "subnets",
+			tag:   "!Join",
+			want:  "subnets: !Join\n  - a\n  - b\n",
+		},
+		{
+			name:  "remove tag",
+			input: "vpc: !Ref MyVpc\nport: !!str 8080\n",
+			path:  "port",
+			tag:   "",
+			want:  "vpc: !Ref MyVpc\nport: 8080\n",
+		},
+		{
+			name:    "value does not fit tag",
+			input:   "name: app\n",
+			path:    "name",
+			tag:     "!!int",
+			wantErr: true,
+		},
+		{
+			name:    "scalar tag on a mapping",
+			input:   "db:\n  host: x\n",
+			path:    "db",
+			tag:     "!!str",
+			wantErr: true,
+		},
+		{
+			name:    "missing exclamation mark",
+			input:   "vpc: MyVpc\n",
+			path:    "vpc",
+			tag:     "Ref",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(tt.input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			err = doc.SetTag(tt.path, tt.tag)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("SetTag() expected error")
+				}
+				if got, _ := doc.String(); got != tt.input {
+					t.Errorf("failed SetTag() changed the document:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetTag() error = %v", err)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetTag() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if _, err := Load(got); err != nil {
+				t.Errorf("result does not load: %v", err)
+			}
+		})
+	}
+}
+
+func TestDocument_CustomTagsSurviveEdits(t *testing.T) {
+	doc, err := Load(tagsInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, _ := doc.String(); got != tagsInput {
+		t.Fatalf("round trip changed the document:\n%s", got)
+	}
+
+	if err := doc.Set("Resources.Bucket.Type", "AWS::S3::BucketPolicy"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Set("Resources.Bucket.Properties.Versioning", true); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Set("port", 9090); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Delete("version"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	want := `Resources:
+  Bucket:
+    Type: AWS::S3::BucketPolicy
+    Properties:
+      BucketName: !Sub '${AWS::StackName}-data'
+      Subnets: !Split [',', !Ref SubnetList]
+      Role: !GetAtt
+        - Role
+        - Arn
+      Versioning: true
+password: !vault |
+  $ANSIBLE_VAULT;1.1;AES256
+  6162636465
+logo: !!binary aGVsbG8=
+port: 9090
+`
+	if got, _ := doc.String(); got != want {
+		t.Errorf("after sibling edits:\n%s\nwant:\n%s", got, want)
+	}
+}
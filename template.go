@@ -0,0 +1,211 @@
+package yamler
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateActionPattern matches Go template actions such as {{ .Values.replicaCount }} or {{- end }}
+var templateActionPattern = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+
+// templatePlaceholderPattern matches the placeholders LoadTemplate puts in place of template actions
+var templatePlaceholderPattern = regexp.MustCompile(`__yamler_tpl_\d+__`)
+
+// templateRegistry maps template actions to the placeholders that stand in for them while parsing and rendering.
+// Actions inside a line become plain scalar placeholders. Lines holding only actions become placeholder
+// comments on a neighbouring line: the line above if they are indented deeper, like {{- toYaml . | nindent 4 }}
+// under its key, otherwise the line below, like {{- if .Values.x }} before the block it wraps.
+type templateRegistry struct {
+	placeholders map[string]string // action or action lines -> placeholder
+	actions      map[string]string // inline placeholder -> action
+	after        map[string]string // placeholder -> action lines that follow the line holding it
+	before       map[string]string // placeholder -> action lines that precede the line holding it
+}
+
+// LoadTemplate loads Go-templated YAML such as a Helm chart, where {{ ... }} actions make the content invalid YAML.
+// Actions are kept as opaque text: values like {{ .Values.replicaCount }} read as strings, lines holding only
+// actions such as {{- if .Values.enabled }} are kept in place, and ToBytes writes all of them back byte for byte.
+// Paths into the YAML around the actions can be read and edited as usual.
+func LoadTemplate(content string) (*Document, error) {
+	templates := &templateRegistry{
+		placeholders: make(map[string]string),
+		actions:      make(map[string]string),
+		after:        make(map[string]string),
+		before:       make(map[string]string),
+	}
+	doc, err := Load(templates.protect(content))
+	if err != nil {
+		return nil, err
+	}
+	doc.templates = templates
+	if doc.raw != "" {
+		doc.formattingInfo()
+	}
+	templates.restoreNodes(doc.root)
+	return doc, nil
+}
+
+// IsTemplate reports whether the document was loaded with LoadTemplate
+func (d *Document) IsTemplate() bool {
+	return d.templates != nil
+}
+
+// renderTemplate renders the document with template actions swapped for placeholders, then puts the actions back
+func (d *Document) renderTemplate() ([]byte, error) {
+	raw := d.raw
+	d.raw = d.templates.protect(raw)
+	protected := d.templates.protectNodes(d.root, nil)
+	content, err := d.toBytes()
+	for node, value := range protected {
+		node.Value = value
+	}
+	d.raw = raw
+	if err != nil {
+		return nil, err
+	}
+	return []byte(d.templates.restore(string(content))), nil
+}
+
+// protect replaces the template actions in content with placeholders
+func (t *templateRegistry) protect(content string) string {
+	content = templateActionPattern.ReplaceAllStringFunc(content, func(action string) string {
+		return t.placeholder(action, t.actions)
+	})
+
+	var lines, pending []string
+	host := -1
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		isAction := trimmed != "" && strings.TrimSpace(templatePlaceholderPattern.ReplaceAllString(trimmed, "")) == ""
+		switch {
+		case isAction && len(pending) == 0 && host >= 0 && lineIndent(line) > lineIndent(lines[host]):
+			// Content of the line above, with the blank lines in between
+			block := append(lines[host+1:len(lines):len(lines)], t.restore(line))
+			lines[host] += " #" + t.placeholder("\n"+strings.Join(block, "\n"), t.after)
+			lines = lines[:host+1]
+		case isAction || (trimmed == "" && len(pending) > 0):
+			pending = append(pending, t.restore(line))
+		case trimmed == "":
+			lines = append(lines, line)
+		default:
+			if len(pending) > 0 {
+				line += " #" + t.placeholder("\n"+strings.Join(pending, "\n"), t.before)
+				pending = nil
+			}
+			lines = append(lines, line)
+			host = len(lines) - 1
+		}
+	}
+
+	// Action lines at the end of the document follow the last line; trailing newlines stay at the end
+	trailing := 0
+	for trailing < len(pending) && pending[len(pending)-1-trailing] == "" {
+		trailing++
+	}
+	if pending = pending[:len(pending)-trailing]; len(pending) > 0 {
+		if host < 0 {
+			lines = append(lines, "#"+t.placeholder("\n"+strings.Join(pending, "\n"), t.before))
+		} else {
+			block := append(lines[host+1:len(lines):len(lines)], pending...)
+			lines[host] += " #" + t.placeholder("\n"+strings.Join(block, "\n"), t.after)
+			lines = lines[:host+1]
+		}
+	}
+	lines = append(lines, make([]string, trailing)...)
+	return strings.Join(lines, "\n")
+}
+
+// lineIndent returns the number of leading spaces and tabs of a line
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// placeholder returns the placeholder of text, registering a new one in placeholders if needed.
+// Action lines are registered with a leading newline so they never share a placeholder with an action.
+func (t *templateRegistry) placeholder(text string, placeholders map[string]string) string {
+	if placeholder, ok := t.placeholders[text]; ok {
+		return placeholder
+	}
+	placeholder := fmt.Sprintf("__yamler_tpl_%d__", len(t.placeholders))
+	t.placeholders[text] = placeholder
+	placeholders[placeholder] = strings.TrimPrefix(text, "\n")
+	return placeholder
+}
+
+// restore puts the original action lines and actions back in place of their placeholders
+func (t *templateRegistry) restore(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		var before, after []string
+		for _, placeholder := range templatePlaceholderPattern.FindAllString(line, -1) {
+			idx := strings.Index(line, "#"+placeholder)
+			if idx == -1 {
+				continue
+			}
+			if block, ok := t.before[placeholder]; ok {
+				before = append(before, block)
+			} else if block, ok := t.after[placeholder]; ok {
+				after = append(after, block)
+			} else {
+				continue
+			}
+			line = strings.TrimRight(line[:idx], " \t") + line[idx+len(placeholder)+1:]
+		}
+		lines = append(lines, before...)
+		// A document made of action lines only keeps no line of its own
+		if line != "" || len(before)+len(after) == 0 {
+			lines = append(lines, line)
+		}
+		lines = append(lines, after...)
+	}
+	return templatePlaceholderPattern.ReplaceAllStringFunc(strings.Join(lines, "\n"), func(placeholder string) string {
+		if action, ok := t.actions[placeholder]; ok {
+			return action
+		}
+		return placeholder
+	})
+}
+
+// restoreNodes puts template actions back into scalar values and keys, so getters return them as written
+func (t *templateRegistry) restoreNodes(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "__yamler_tpl_") {
+		node.Value = t.restore(node.Value)
+	}
+	for _, child := range node.Content {
+		t.restoreNodes(child)
+	}
+}
+
+// protectNodes swaps template actions in scalar values and keys for placeholders, returning the original values
+func (t *templateRegistry) protectNodes(node *yaml.Node, protected map[*yaml.Node]string) map[*yaml.Node]string {
+	if node == nil {
+		return protected
+	}
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "{{") {
+		if protected == nil {
+			protected = make(map[*yaml.Node]string)
+		}
+		protected[node] = node.Value
+		node.Value = templateActionPattern.ReplaceAllStringFunc(node.Value, func(action string) string {
+			return t.placeholder(action, t.actions)
+		})
+	}
+	for _, child := range node.Content {
+		protected = t.protectNodes(child, protected)
+	}
+	return protected
+}
+
+// clone returns an independent copy of the registry
+func (t *templateRegistry) clone() *templateRegistry {
+	return &templateRegistry{
+		placeholders: maps.Clone(t.placeholders),
+		actions:      maps.Clone(t.actions),
+		after:        maps.Clone(t.after),
+		before:       maps.Clone(t.before),
+	}
+}
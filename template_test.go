@@ -0,0 +1,151 @@
+package yamler
+
+import (
+	"strings"
+	"testing"
+)
+
+const helmTemplate = `{{- if .Values.enabled }}
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "app.fullname" . }}
+  labels:
+    {{- include "app.labels" . | nindent 4 }}
+spec:
+  {{- if not .Values.autoscaling.enabled }}
+  replicas: {{ .Values.replicaCount }}   # default 1
+  {{- end }}
+  template:
+    spec:
+      containers:
+        - name: {{ .Chart.Name }}
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag | default .Chart.AppVersion }}"
+          ports:
+            - containerPort: 80
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+
+      {{- with .Values.nodeSelector }}
+      nodeSelector:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+{{- end }}
+`
+
+func TestLoadTemplate(t *testing.T) {
+	doc, err := LoadTemplate(helmTemplate)
+	if err != nil {
+		t.Fatalf("LoadTemplate() error = %v", err)
+	}
+	if !doc.IsTemplate() {
+		t.Error("IsTemplate() = false")
+	}
+	if got, _ := doc.String(); got != helmTemplate {
+		t.Errorf("round trip changed the template:\n%s", got)
+	}
+
+	tests := map[string]string{
+		"metadata.name":                          `{{ include "app.fullname" . }}`,
+		"spec.replicas":                          "{{ .Values.replicaCount }}",
+		"spec.template.spec.containers[0].image": "{{ .Values.image.repository }}:{{ .Values.image.tag | default .Chart.AppVersion }}",
+	}
+	for path, want := range tests {
+		if got, err := doc.GetString(path); err != nil || got != want {
+			t.Errorf("GetString(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+}
+
+func TestLoadTemplate_Edits(t *testing.T) {
+	doc, err := LoadTemplate(helmTemplate)
+	if err != nil {
+		t.Fatalf("LoadTemplate() error = %v", err)
+	}
+
+	if err := doc.Set("spec.template.spec.containers[0].ports[0].containerPort", 8080); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Set("metadata.annotations.team", "core"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Set("spec.template.spec.containers[0].imagePullPolicy", "{{ .Values.image.pullPolicy }}"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	want := `{{- if .Values.enabled }}
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "app.fullname" . }}
+  labels:
+    {{- include "app.labels" . | nindent 4 }}
+  annotations:
+    team: core
+spec:
+  {{- if not .Values.autoscaling.enabled }}
+  replicas: {{ .Values.replicaCount }}   # default 1
+  {{- end }}
+  template:
+    spec:
+      containers:
+        - name: {{ .Chart.Name }}
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag | default .Chart.AppVersion }}"
+          ports:
+            - containerPort: 8080
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+          imagePullPolicy: {{ .Values.image.pullPolicy }}
+
+      {{- with .Values.nodeSelector }}
+      nodeSelector:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+{{- end }}
+`
+	got, err := doc.String()
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("after edits:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Copies keep the template actions too
+	clone := doc.Clone()
+	if err := clone.Set("kind", "StatefulSet"); err != nil {
+		t.Fatalf("Set() on clone error = %v", err)
+	}
+	if cloned, _ := clone.String(); cloned != strings.Replace(got, "kind: Deployment", "kind: StatefulSet", 1) {
+		t.Errorf("clone after edit:\n%s", cloned)
+	}
+	view, err := doc.View()
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+	if viewed, _ := view.String(); viewed != got {
+		t.Errorf("View().String() = %q, want %q", viewed, got)
+	}
+}
+
+func TestLoadTemplate_Inline(t *testing.T) {
+	tests := []string{
+		"{{/* multi-line\n   comment */}}\nkey: value\n",
+		"list: [{{ .a }}, b]\n{{ .Values.key }}: x\n",
+		"script: |\n  echo {{ .Values.message }}\nport: 80\n",
+		"ports:\n{{- range .Values.ports }}\n  - {{ . }}\n{{- end }}\n",
+	}
+	for _, input := range tests {
+		doc, err := LoadTemplate(input)
+		if err != nil {
+			t.Fatalf("LoadTemplate(%q) error = %v", input, err)
+		}
+		if got, _ := doc.String(); got != input {
+			t.Errorf("round trip = %q, want %q", got, input)
+		}
+	}
+
+	if _, err := Load("replicas: {{ .Values.replicaCount }}\nname: {{ .Chart.Name }}: x\n"); err == nil {
+		t.Error("Load() of a template is expected to fail")
+	}
+}
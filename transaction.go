@@ -0,0 +1,83 @@
+package yamler
+
+import (
+	"fmt"
+)
+
+// Begin starts a transaction: the changes made until Commit can be undone with Rollback.
+// The saved state includes formatting caches and settings, so a rollback restores the exact output.
+func (d *Document) Begin() error {
+	if d.frozen {
+		return ErrReadOnly
+	}
+	if d.snapshot != nil {
+		return fmt.Errorf("transaction already in progress")
+	}
+	d.snapshot = d.Clone()
+	d.snapshot.edits = d.edits
+	return nil
+}
+
+// Commit keeps the changes made since Begin
+func (d *Document) Commit() error {
+	if d.snapshot == nil {
+		return fmt.Errorf("no transaction in progress")
+	}
+	d.snapshot = nil
+	return nil
+}
+
+// Rollback restores the document to its state at Begin.
+// Sub-document views taken during the transaction no longer share nodes with the document.
+func (d *Document) Rollback() error {
+	saved := d.snapshot
+	if saved == nil {
+		return fmt.Errorf("no transaction in progress")
+	}
+	d.restore(saved)
+	return nil
+}
+
+// restore replaces the document's state with a saved copy, keeping its query cache
+func (d *Document) restore(saved *Document) {
+	cache := d.queryCache
+	*d = *saved
+	d.queryCache = cache
+	d.invalidateQueries()
+}
+
+// Transaction runs fn inside Begin and Commit. If fn returns an error or panics,
+// every change it made is rolled back and the error or panic is passed on.
+func (d *Document) Transaction(fn func(doc *Document) error) error {
+	if err := d.Begin(); err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			d.Rollback()
+		}
+	}()
+
+	if err := fn(d); err != nil {
+		return err
+	}
+	committed = true
+	return d.Commit()
+}
+
+// atomically runs fn as a single edit and restores the document if it fails,
+// so operations made of several steps are all-or-nothing
+func (d *Document) atomically(fn func() error) error {
+	saved := d.Clone()
+	saved.edits, saved.snapshot = d.edits, d.snapshot
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		d.restore(saved)
+		return err
+	}
+	d.markDirty()
+	return nil
+}
@@ -0,0 +1,166 @@
+package yamler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const transactionInput = `# service
+name: api   # display name
+replicas: 2
+
+ports: [80, 443]
+`
+
+func TestDocument_Transaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(doc *Document) error
+		want    string
+		wantErr string
+	}{
+		{
+			name: "all changes kept",
+			fn: func(doc *Document) error {
+				if err := doc.Set("replicas", 3); err != nil {
+					return err
+				}
+				return doc.AppendToArray("ports", 8080)
+			},
+			want: "# service\nname: api   # display name\nreplicas: 3\n\nports: [80, 443, 8080]\n",
+		},
+		{
+			name: "failing set rolls back earlier changes",
+			fn: func(doc *Document) error {
+				if err := doc.Set("name", "web"); err != nil {
+					return err
+				}
+				doc.SetAbsoluteCommentAlignment(30)
+				if err := doc.AppendToArray("ports", 8080); err != nil {
+					return err
+				}
+				// replicas is a scalar, so this fails
+				return doc.AppendToArray("replicas", 1)
+			},
+			want:    transactionInput,
+			wantErr: "replicas",
+		},
+		{
+			name: "error from fn rolls back",
+			fn: func(doc *Document) error {
+				if err := doc.Delete("ports"); err != nil {
+					return err
+				}
+				return errors.New("validation failed")
+			},
+			want:    transactionInput,
+			wantErr: "validation failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(transactionInput)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			err = doc.Transaction(tt.fn)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Transaction() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Transaction() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Transaction() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// The document stays usable after the transaction
+			if err := doc.Set("replicas", 5); err != nil {
+				t.Errorf("Set() after Transaction() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDocument_TransactionPanic(t *testing.T) {
+	doc, err := Load(transactionInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Transaction() swallowed the panic")
+			}
+		}()
+		doc.Transaction(func(doc *Document) error {
+			doc.Set("name", "web")
+			panic("boom")
+		})
+	}()
+
+	if got, _ := doc.String(); got != transactionInput {
+		t.Errorf("document after panic:\n%s\nwant:\n%s", got, transactionInput)
+	}
+}
+
+func TestDocument_BeginRollback(t *testing.T) {
+	doc, err := Load(transactionInput)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := doc.Commit(); err == nil {
+		t.Error("Commit() without Begin() should fail")
+	}
+	if err := doc.Rollback(); err == nil {
+		t.Error("Rollback() without Begin() should fail")
+	}
+
+	if err := doc.Begin(); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := doc.Begin(); err == nil {
+		t.Error("nested Begin() should fail")
+	}
+	if err := doc.Set("name", "web"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if matches, _ := doc.GetAll("name"); matches["name"] != "web" {
+		t.Fatalf("GetAll() = %v, want name web", matches)
+	}
+	if err := doc.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if matches, _ := doc.GetAll("name"); matches["name"] != "api" {
+		t.Errorf("GetAll() after Rollback() = %v, want name api", matches)
+	}
+
+	if err := doc.Begin(); err != nil {
+		t.Fatalf("Begin() after Rollback() error = %v", err)
+	}
+	if err := doc.Set("replicas", 4); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := doc.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if replicas, _ := doc.GetInt("replicas"); replicas != 4 {
+		t.Errorf("replicas after Commit() = %d, want 4", replicas)
+	}
+
+	doc.Freeze()
+	if err := doc.Begin(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Begin() on frozen document error = %v, want ErrReadOnly", err)
+	}
+}
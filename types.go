@@ -13,6 +13,16 @@ const (
 	TypeAny    SchemaType = "any"
 )
 
+// NodeKind is the shape of a YAML value, see Document.Children
+type NodeKind string
+
+const (
+	KindScalar   NodeKind = "scalar"
+	KindMapping  NodeKind = "mapping"
+	KindSequence NodeKind = "sequence"
+	KindNull     NodeKind = "null"
+)
+
 // ValidationRule represents a validation rule for a YAML value
 type ValidationRule struct {
 	Type SchemaType `yaml:"type"`
@@ -4,51 +4,315 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// MatchOptions controls how wildcard patterns select nodes
+type MatchOptions struct {
+	// LeavesOnly excludes mapping and sequence matches; the search continues
+	// below them so that only scalar values are returned
+	LeavesOnly bool
+}
+
+// PathValue is a value matched by a wildcard pattern and its path, in the form Get accepts
+type PathValue struct {
+	Path  string
+	Value interface{}
+}
+
 // GetAll returns all values that match the wildcard pattern
 // Supported patterns:
 //   - config.*.name - matches any key at that level
 //   - config.**.name - matches any nested key (recursive)
 //   - config.db.* - matches all keys under config.db
+//   - tasks[*].name, tasks[0-4].name, tasks[last].name - every, a range of, or the last array element
+//
+// A pattern that ends at a mapping or sequence returns the whole container
+// (e.g. **.database returns each database subtree) and does not descend into it.
+// Use GetAllWithOptions with LeavesOnly to return scalar values only,
+// and GetAllOrdered to iterate over the matches in document order.
 func (d *Document) GetAll(pattern string) (map[string]interface{}, error) {
-	root, err := d.mappingRoot()
+	return d.GetAllWithOptions(pattern, MatchOptions{})
+}
+
+// GetAllWithOptions returns all values that match the wildcard pattern using the given options
+// Sequence roots are supported, with paths starting at the element index (e.g. [*].image).
+func (d *Document) GetAllWithOptions(pattern string, opts MatchOptions) (map[string]interface{}, error) {
+	root, err := d.queryRoot()
 	if err != nil {
 		return nil, err
 	}
 
-	results := make(map[string]interface{})
-	err = findMatchingPaths(root, pattern, "", results)
+	return d.getAllFrom(root, pattern, opts)
+}
+
+// GetAllOrdered returns all values that match the wildcard pattern like GetAll,
+// as a slice in document order so that iterating over the matches is deterministic
+func (d *Document) GetAllOrdered(pattern string) ([]PathValue, error) {
+	return d.GetAllOrderedWithOptions(pattern, MatchOptions{})
+}
+
+// GetAllOrderedWithOptions returns all values that match the wildcard pattern using the given options, in document order
+func (d *Document) GetAllOrderedWithOptions(pattern string, opts MatchOptions) ([]PathValue, error) {
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := d.matchNodes(root, pattern, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	results := make([]PathValue, 0, len(matches))
+	for _, match := range matches {
+		value, err := d.toInterface(match.node)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, PathValue{Path: match.path, Value: value})
+	}
 	return results, nil
 }
 
+// matchingPaths returns the paths matching the pattern in document order
+func (d *Document) matchingPaths(pattern string) ([]string, error) {
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := d.matchNodes(root, pattern, MatchOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(matches))
+	for _, match := range matches {
+		paths = append(paths, match.path)
+	}
+	return paths, nil
+}
+
+// GetAllRelative returns all values under base that match the pattern, keyed by paths relative to base.
+// GetAllRelative("environments.production", "**.host") returns keys like database.host.
+func (d *Document) GetAllRelative(base, pattern string) (map[string]interface{}, error) {
+	if base == "" {
+		return d.GetAll(pattern)
+	}
+
+	node, err := d.getNode(base)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("path %s: %w", base, kindMismatch("mapping or sequence node", node))
+	}
+
+	return d.getAllFrom(node, pattern, MatchOptions{})
+}
+
+// getAllFrom returns the values below root that match the pattern, keyed by path relative to root
+func (d *Document) getAllFrom(root *yaml.Node, pattern string, opts MatchOptions) (map[string]interface{}, error) {
+	matches, err := d.matchNodes(root, pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]interface{}, len(matches))
+	for _, match := range matches {
+		value, err := d.toInterface(match.node)
+		if err != nil {
+			return nil, err
+		}
+		results[match.path] = value
+	}
+	return results, nil
+}
+
+// GetEach returns the scalar values of all paths matching the pattern as one slice in document order.
+// The pattern may combine map wildcards and [*], e.g. services.*.ports[*] returns every port of every service.
+func (d *Document) GetEach(pattern string) ([]interface{}, error) {
+	root, err := d.queryRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	err = walkMatchingNodes(root, pattern, "", MatchOptions{LeavesOnly: true}, func(path string, match *yaml.Node) error {
+		value, err := d.toInterface(match)
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// queryRoot returns the mapping or sequence root that wildcard queries start from
+func (d *Document) queryRoot() (*yaml.Node, error) {
+	if d.isArrayRoot() {
+		return d.sequenceRoot()
+	}
+	return d.mappingRoot()
+}
+
 // SetAll sets a value for all paths that match the wildcard pattern
 // Note: This only works with existing paths, it won't create new ones
 func (d *Document) SetAll(pattern string, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
 	// First, get all matching paths
-	matches, err := d.GetAll(pattern)
+	paths, err := d.matchingPaths(pattern)
 	if err != nil {
 		return err
 	}
+	return d.setAllPreservingStyles(paths, value)
+}
 
-	// Set value for each matching path
-	for path := range matches {
-		err = d.Set(path, value)
+// SetAllExcept sets a value for all paths that match pattern but not excludePattern.
+// A path is also excluded when one of its ancestors matches excludePattern,
+// so excluding environments.development skips everything below it.
+func (d *Document) SetAllExcept(pattern, excludePattern string, value interface{}) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	matches, err := d.matchingPaths(pattern)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, path := range matches {
+		if !isExcludedPath(path, excludePattern) {
+			paths = append(paths, path)
+		}
+	}
+	return d.setAllPreservingStyles(paths, value)
+}
+
+// SetWhere sets value at the paths matching pattern whose current value satisfies match,
+// e.g. only the debug flags that are true, and returns the number of paths set.
+// match is called in document order; matches keep their scalar style like with SetAll.
+func (d *Document) SetWhere(pattern string, match func(path string, value interface{}) bool, value interface{}) (int, error) {
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+	if match == nil {
+		return 0, fmt.Errorf("nil match function")
+	}
+
+	root, err := d.queryRoot()
+	if err != nil {
+		return 0, err
+	}
+	matches, err := d.matchNodes(root, pattern, MatchOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	var paths []string
+	for _, m := range matches {
+		current, err := d.toInterface(m.node)
 		if err != nil {
+			return 0, err
+		}
+		if match(m.path, current) {
+			paths = append(paths, m.path)
+		}
+	}
+	if len(paths) == 0 {
+		return 0, nil
+	}
+	return len(paths), d.setAllPreservingStyles(paths, value)
+}
+
+// setAllPreservingStyles sets value at every path, keeping each match's original
+// scalar style (quoted, plain, literal...) when the new value is type-compatible
+func (d *Document) setAllPreservingStyles(paths []string, value interface{}) error {
+	styles := make(map[string]*yaml.Node, len(paths))
+	for _, path := range paths {
+		if node, err := d.getNode(path); err == nil && node.Kind == yaml.ScalarNode {
+			styles[path] = &yaml.Node{Style: node.Style, Tag: node.ShortTag()}
+		}
+	}
+
+	restyled := false
+	for _, path := range paths {
+		if err := d.Set(path, value); err != nil {
 			return fmt.Errorf("failed to set value at path %s: %w", path, err)
 		}
+
+		original, ok := styles[path]
+		if !ok || original.Style == 0 {
+			continue
+		}
+		node, err := d.getNode(path)
+		if err != nil || node.Kind != yaml.ScalarNode {
+			continue
+		}
+		// Strings can take any scalar style; other types only keep the style of the same type
+		if node.ShortTag() == "!!str" || node.ShortTag() == original.Tag {
+			node.Style = original.Style
+			restyled = true
+		}
 	}
 
+	if restyled {
+		d.markDirty()
+	}
 	return nil
 }
 
+// isExcludedPath checks if a path or any of its ancestors matches the exclude pattern
+func isExcludedPath(path, excludePattern string) bool {
+	if pathMatches(path, excludePattern) {
+		return true
+	}
+
+	for i := 1; i < len(path); i++ {
+		if (path[i] == '.' || path[i] == '[') && pathMatches(path[:i], excludePattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetAllElements returns the element at index of every array matching the wildcard pattern.
+// Results are keyed by the element path, e.g. services.web.ports[0].
+// Matches that are not arrays or are too short for index are skipped.
+func (d *Document) GetAllElements(pattern string, index int) (map[string]interface{}, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("negative array index: %d", index)
+	}
+
+	matches, err := d.GetAll(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]interface{})
+	for path, value := range matches {
+		slice, ok := value.([]interface{})
+		if !ok || index >= len(slice) {
+			continue
+		}
+		results[fmt.Sprintf("%s[%d]", path, index)] = slice[index]
+	}
+
+	return results, nil
+}
+
 // GetKeys returns all keys that match the wildcard pattern (without values)
 func (d *Document) GetKeys(pattern string) ([]string, error) {
 	matches, err := d.GetAll(pattern)
@@ -65,38 +329,159 @@ func (d *Document) GetKeys(pattern string) ([]string, error) {
 	return keys, nil
 }
 
-// findMatchingPaths recursively finds paths that match the pattern
-func findMatchingPaths(node *yaml.Node, pattern, currentPath string, results map[string]interface{}) error {
-	if node == nil {
-		return nil
+// MatchPathsNatural returns all paths that match the wildcard pattern in natural order,
+// so numeric parts compare by value: service2 sorts before service10 and items[2] before items[10]
+func (d *Document) MatchPathsNatural(pattern string) ([]string, error) {
+	matches, err := d.GetAll(pattern)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if current path matches the pattern
-	if pathMatches(currentPath, pattern) {
-		value, err := nodeToInterface(node)
+	paths := make([]string, 0, len(matches))
+	for path := range matches {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return naturalLess(paths[i], paths[j])
+	})
+
+	return paths, nil
+}
+
+// naturalLess compares two strings treating runs of digits as numbers
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare the digit runs by numeric value, ignoring leading zeros
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA := strings.TrimLeft(a[startA:i], "0")
+			numB := strings.TrimLeft(b[startB:j], "0")
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			// Equal values: fewer leading zeros first for a stable order
+			if i-startA != j-startB {
+				return i-startA < j-startB
+			}
+			continue
+		}
+
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+
+	return len(a)-i < len(b)-j
+}
+
+// isDigit checks if a byte is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// findMatchingPaths recursively finds paths that match the pattern
+func findMatchingPaths(node *yaml.Node, pattern, currentPath string, opts MatchOptions, results map[string]interface{}) error {
+	return walkMatchingPaths(node, pattern, currentPath, opts, func(path string, value interface{}) {
+		results[path] = value
+	})
+}
+
+// walkMatchingPaths calls visit for every path that matches the pattern, in document order
+func walkMatchingPaths(node *yaml.Node, pattern, currentPath string, opts MatchOptions, visit func(path string, value interface{})) error {
+	return walkMatchingNodes(node, pattern, currentPath, opts, func(path string, match *yaml.Node) error {
+		value, err := nodeToInterface(match)
 		if err != nil {
 			return err
 		}
-		results[currentPath] = value
+		visit(path, value)
+		return nil
+	})
+}
+
+// indexSelectorPattern matches the array selectors that depend on the array, [0-4] and [last]
+var indexSelectorPattern = regexp.MustCompile(`\[(?:(\d+)-(\d+)|last)\]`)
+
+// walkMatchingNodes calls visit with the node of every path that matches the pattern, in document order
+func walkMatchingNodes(node *yaml.Node, pattern, currentPath string, opts MatchOptions, visit func(path string, node *yaml.Node) error) error {
+	if loc := indexSelectorPattern.FindStringSubmatchIndex(pattern); loc != nil {
+		return walkIndexSelector(node, pattern, loc, currentPath, opts, visit)
+	}
+	return walkPattern(node, pattern, currentPath, opts, visit)
+}
+
+// walkIndexSelector resolves the first [a-b] or [last] selector of pattern against each array its prefix
+// matches, then matches the rest of the pattern below the selected elements
+func walkIndexSelector(node *yaml.Node, pattern string, loc []int, currentPath string, opts MatchOptions, visit func(path string, node *yaml.Node) error) error {
+	prefix, rest := pattern[:loc[0]], pattern[loc[1]:]
+	first, last := -1, -1
+	if loc[2] >= 0 {
+		first, _ = strconv.Atoi(pattern[loc[2]:loc[3]])
+		last, _ = strconv.Atoi(pattern[loc[4]:loc[5]])
+		if first > last {
+			return fmt.Errorf("pattern %s: invalid index range %s", pattern, pattern[loc[0]:loc[1]])
+		}
+	}
+
+	walkSequence := func(path string, seq *yaml.Node) error {
+		seq = resolveAlias(seq)
+		if seq.Kind != yaml.SequenceNode || len(seq.Content) == 0 {
+			return nil
+		}
+		from, to := first, last
+		if from < 0 {
+			// [last]
+			from, to = len(seq.Content)-1, len(seq.Content)-1
+		}
+		for i := from; i <= to && i < len(seq.Content); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := walkMatchingNodes(seq.Content[i], childPath+rest, childPath, opts, visit); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// A leading selector applies to the node itself, as in [last].name on an array root
+	if prefix == "" {
+		return walkSequence(currentPath, node)
+	}
+	return walkPattern(node, prefix, currentPath, MatchOptions{}, walkSequence)
+}
+
+// walkPattern matches a pattern without array selectors, see walkMatchingNodes
+func walkPattern(node *yaml.Node, pattern, currentPath string, opts MatchOptions, visit func(path string, node *yaml.Node) error) error {
+	if node == nil {
 		return nil
 	}
 
+	// Check if current path matches the pattern
+	isContainer := node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode
+	if pathMatches(currentPath, pattern) && !(opts.LeavesOnly && isContainer) {
+		return visit(currentPath, node)
+	}
+
 	switch node.Kind {
 	case yaml.MappingNode:
 		for i := 0; i < len(node.Content); i += 2 {
 			key := node.Content[i].Value
 			childNode := node.Content[i+1]
-
-			var childPath string
-			if currentPath == "" {
-				childPath = key
-			} else {
-				childPath = currentPath + "." + key
-			}
+			childPath := appendPathKey(currentPath, key)
 
 			// Check if we should continue exploring this path
 			if couldMatch(childPath, pattern) {
-				err := findMatchingPaths(childNode, pattern, childPath, results)
+				err := walkPattern(childNode, pattern, childPath, opts, visit)
 				if err != nil {
 					return err
 				}
@@ -109,7 +494,7 @@ func findMatchingPaths(node *yaml.Node, pattern, currentPath string, results map
 
 			// Check if we should continue exploring this path
 			if couldMatch(childPath, pattern) {
-				err := findMatchingPaths(childNode, pattern, childPath, results)
+				err := walkPattern(childNode, pattern, childPath, opts, visit)
 				if err != nil {
 					return err
 				}
@@ -127,8 +512,8 @@ func pathMatches(path, pattern string) bool {
 	}
 
 	// Convert wildcard pattern to regex
-	regexPattern := wildcardToRegex(pattern)
-	matched, err := regexp.MatchString(regexPattern, path)
+	regexPattern := wildcardToRegex(encodeQuotedKeys(pattern))
+	matched, err := regexp.MatchString(regexPattern, encodeQuotedKeys(path))
 	if err != nil {
 		return false
 	}
@@ -139,9 +524,8 @@ func pathMatches(path, pattern string) bool {
 // couldMatch checks if a path could potentially match the pattern
 // (used for optimization to avoid exploring irrelevant branches)
 func couldMatch(path, pattern string) bool {
-	// If path is longer than pattern and pattern doesn't have ** or ends with *, probably won't match
-	pathParts := splitPath(path)
-	patternParts := strings.Split(pattern, ".")
+	pathParts := splitPathSegments(encodeQuotedKeys(path))
+	patternParts := splitPathSegments(encodeQuotedKeys(pattern))
 
 	// Handle ** (recursive wildcard) - always could match
 	for _, part := range patternParts {
@@ -150,40 +534,26 @@ func couldMatch(path, pattern string) bool {
 		}
 	}
 
-	// If path has more parts than pattern and no ** or ending *, won't match
+	// Without ** a match never has more segments than the pattern
 	if len(pathParts) > len(patternParts) {
-		lastPatternPart := patternParts[len(patternParts)-1]
-		if lastPatternPart != "*" && lastPatternPart != "**" {
-			return false
-		}
+		return false
 	}
 
 	// Check if current path could lead to a match
-	for i := 0; i < len(pathParts) && i < len(patternParts); i++ {
+	for i := range pathParts {
 		pathPart := pathParts[i]
 		patternPart := patternParts[i]
 
-		if patternPart == "*" || patternPart == "**" {
-			continue // Wildcard matches anything
-		}
-
-		// Handle array indices in pattern
-		if strings.Contains(patternPart, "[") {
-			patternBase := strings.Split(patternPart, "[")[0]
-			pathBase := strings.Split(pathPart, "[")[0]
-			if patternBase != pathBase {
+		if patternPart == "[*]" {
+			// Index wildcard matches any array index
+			if !isArrayIndex(pathPart) {
 				return false
 			}
 			continue
 		}
 
-		// Handle array indices in path vs pattern
-		if strings.Contains(pathPart, "[") && !strings.Contains(patternPart, "[") {
-			pathBase := strings.Split(pathPart, "[")[0]
-			if pathBase != patternPart {
-				return false
-			}
-			continue
+		if strings.Contains(patternPart, "*") {
+			continue // Wildcard matches anything, leave the exact check to pathMatches
 		}
 
 		if pathPart != patternPart {
@@ -194,6 +564,69 @@ func couldMatch(path, pattern string) bool {
 	return true
 }
 
+// splitPathSegments splits a path or pattern into key and index segments,
+// e.g. containers[0].env[*] -> containers, [0], env, [*]
+func splitPathSegments(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			idx := strings.Index(part, "[")
+			if idx == -1 {
+				segments = append(segments, part)
+				break
+			}
+			if idx > 0 {
+				segments = append(segments, part[:idx])
+				part = part[idx:]
+				continue
+			}
+
+			end := strings.Index(part, "]")
+			if end == -1 {
+				segments = append(segments, part)
+				break
+			}
+			segments = append(segments, part[:end+1])
+			part = part[end+1:]
+		}
+	}
+
+	return segments
+}
+
+// quotedKeyReplacer hides path separators inside quoted keys so they match as one segment
+var quotedKeyReplacer = strings.NewReplacer(".", "\x00", "[", "\x01", "]", "\x02")
+
+// encodeQuotedKeys rewrites quoted keys like a["b.c"] to plain segments with their
+// separators hidden, so a["x.io/y"] matches a.* and the pattern a["*.io/*"]
+func encodeQuotedKeys(path string) string {
+	if !strings.Contains(path, `["`) {
+		return path
+	}
+
+	var sb strings.Builder
+	for _, part := range splitPathParts(path) {
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		prefix, key, ok := parseQuotedKey(part)
+		if !ok {
+			sb.WriteString(part)
+			continue
+		}
+		if prefix != "" {
+			sb.WriteString(prefix)
+			sb.WriteByte('.')
+		}
+		sb.WriteString(quotedKeyReplacer.Replace(key))
+	}
+	return sb.String()
+}
+
 // wildcardToRegex converts a wildcard pattern to a regex pattern
 func wildcardToRegex(pattern string) string {
 	// Escape special regex characters except * and **
@@ -1,6 +1,7 @@
 package yamler
 
 import (
+	"reflect"
 	"sort"
 	"testing"
 )
@@ -73,20 +74,20 @@ servers:
 				"config.production.name":  "prod-app",
 			},
 		},
-		// {
-		// 	name:    "array wildcard",
-		// 	pattern: "servers[*]",
-		// 	expected: map[string]interface{}{
-		// 		"servers[0]": map[string]interface{}{
-		// 			"name": "server1",
-		// 			"host": "host1",
-		// 		},
-		// 		"servers[1]": map[string]interface{}{
-		// 			"name": "server2",
-		// 			"host": "host2",
-		// 		},
-		// 	},
-		// },
+		{
+			name:    "array wildcard",
+			pattern: "servers[*]",
+			expected: map[string]interface{}{
+				"servers[0]": map[string]interface{}{
+					"name": "server1",
+					"host": "host1",
+				},
+				"servers[1]": map[string]interface{}{
+					"name": "server2",
+					"host": "host2",
+				},
+			},
+		},
 		{
 			name:    "exact match",
 			pattern: "app.name",
@@ -382,3 +383,1056 @@ func TestFilterByPattern(t *testing.T) {
 		})
 	}
 }
+
+func TestDocument_GetAllElements(t *testing.T) {
+	yamlContent := `
+services:
+  web:
+    ports: [80, 443]
+  db:
+    ports:
+      - 5432
+  cache:
+    ports: []
+  worker:
+    image: worker:latest
+`
+
+	doc, err := Load(yamlContent)
+	if err != nil {
+		t.Fatalf("Failed to load document: %v", err)
+	}
+
+	result, err := doc.GetAllElements("services.*.ports", 0)
+	if err != nil {
+		t.Fatalf("GetAllElements() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"services.web.ports[0]": int64(80),
+		"services.db.ports[0]":  int64(5432),
+	}
+	if len(result) != len(expected) {
+		t.Errorf("GetAllElements() returned %d results, expected %d: %v", len(result), len(expected), result)
+	}
+	for key, want := range expected {
+		if got, ok := result[key]; !ok || got != want {
+			t.Errorf("GetAllElements()[%s] = %v, want %v", key, got, want)
+		}
+	}
+
+	result, err = doc.GetAllElements("services.*.ports", 1)
+	if err != nil {
+		t.Fatalf("GetAllElements() error = %v", err)
+	}
+	if len(result) != 1 || result["services.web.ports[1]"] != int64(443) {
+		t.Errorf("GetAllElements(index 1) = %v, want only services.web.ports[1] = 443", result)
+	}
+
+	if _, err := doc.GetAllElements("services.*.ports", -1); err == nil {
+		t.Error("GetAllElements() with negative index should return error")
+	}
+}
+
+func TestDocument_GetAllWithOptions(t *testing.T) {
+	yamlContent := `
+environments:
+  production:
+    database:
+      host: prod-db
+      port: 5432
+    cache:
+      ttl: 300
+  staging:
+    database:
+      host: staging-db
+      port: 5433
+`
+
+	doc, err := Load(yamlContent)
+	if err != nil {
+		t.Fatalf("Failed to load document: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		pattern      string
+		opts         MatchOptions
+		expectedKeys []string
+	}{
+		{
+			name:         "containers included by default",
+			pattern:      "**.database",
+			opts:         MatchOptions{},
+			expectedKeys: []string{"environments.production.database", "environments.staging.database"},
+		},
+		{
+			name:         "containers excluded with leaves only",
+			pattern:      "**.database",
+			opts:         MatchOptions{LeavesOnly: true},
+			expectedKeys: []string{},
+		},
+		{
+			name:         "recursive pattern stops at first container",
+			pattern:      "environments.production.**",
+			opts:         MatchOptions{},
+			expectedKeys: []string{"environments.production.cache", "environments.production.database"},
+		},
+		{
+			name:    "recursive pattern descends to leaves",
+			pattern: "environments.production.**",
+			opts:    MatchOptions{LeavesOnly: true},
+			expectedKeys: []string{
+				"environments.production.cache.ttl",
+				"environments.production.database.host",
+				"environments.production.database.port",
+			},
+		},
+		{
+			name:         "leaf pattern unaffected",
+			pattern:      "**.database.host",
+			opts:         MatchOptions{LeavesOnly: true},
+			expectedKeys: []string{"environments.production.database.host", "environments.staging.database.host"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := doc.GetAllWithOptions(tt.pattern, tt.opts)
+			if err != nil {
+				t.Fatalf("GetAllWithOptions() error = %v", err)
+			}
+
+			keys := make([]string, 0, len(result))
+			for key := range result {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			if len(keys) != len(tt.expectedKeys) {
+				t.Fatalf("GetAllWithOptions() keys = %v, want %v", keys, tt.expectedKeys)
+			}
+			for i, key := range keys {
+				if key != tt.expectedKeys[i] {
+					t.Errorf("GetAllWithOptions() keys[%d] = %s, want %s", i, key, tt.expectedKeys[i])
+				}
+			}
+		})
+	}
+
+	// Container matches return the whole subtree
+	result, err := doc.GetAll("environments.staging.database")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	db, ok := result["environments.staging.database"].(map[string]interface{})
+	if !ok || db["host"] != "staging-db" {
+		t.Errorf("GetAll() container match = %v, want database map", result)
+	}
+}
+
+func TestDocument_SetAllExcept(t *testing.T) {
+	yamlContent := `environments:
+  development:
+    debug: true
+    database:
+      debug: true
+  staging:
+    debug: true
+  production:
+    debug: true
+logging:
+  debug: true
+`
+
+	tests := []struct {
+		name           string
+		excludePattern string
+		wantTrue       []string
+	}{
+		{
+			name:           "exclude recursive subtree pattern",
+			excludePattern: "environments.development.**",
+			wantTrue:       []string{"environments.development.database.debug", "environments.development.debug"},
+		},
+		{
+			name:           "exclude ancestor path",
+			excludePattern: "environments.development",
+			wantTrue:       []string{"environments.development.database.debug", "environments.development.debug"},
+		},
+		{
+			name:           "exclude with single wildcard",
+			excludePattern: "environments.*.debug",
+			wantTrue: []string{
+				"environments.development.debug",
+				"environments.production.debug",
+				"environments.staging.debug",
+			},
+		},
+		{
+			name:           "exclude nothing",
+			excludePattern: "missing.**",
+			wantTrue:       []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(yamlContent)
+			if err != nil {
+				t.Fatalf("Failed to load document: %v", err)
+			}
+
+			if err := doc.SetAllExcept("**.debug", tt.excludePattern, false); err != nil {
+				t.Fatalf("SetAllExcept() error = %v", err)
+			}
+
+			result, err := doc.GetAll("**.debug")
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+
+			var gotTrue []string
+			for path, value := range result {
+				if value == true {
+					gotTrue = append(gotTrue, path)
+				}
+			}
+			sort.Strings(gotTrue)
+
+			if len(gotTrue) != len(tt.wantTrue) {
+				t.Fatalf("paths still true = %v, want %v", gotTrue, tt.wantTrue)
+			}
+			for i := range gotTrue {
+				if gotTrue[i] != tt.wantTrue[i] {
+					t.Errorf("paths still true = %v, want %v", gotTrue, tt.wantTrue)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestDocument_GetAllWithArrayIndices(t *testing.T) {
+	yamlContent := `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          env:
+            - name: LOG_LEVEL
+              value: info
+            - name: PORT
+              value: "8080"
+        - name: sidecar
+          env:
+            - name: PROXY
+              value: "on"
+`
+
+	doc, err := Load(yamlContent)
+	if err != nil {
+		t.Fatalf("Failed to load document: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected map[string]interface{}
+	}{
+		{
+			name:    "literal index then wildcard index",
+			pattern: "spec.template.spec.containers[0].env[*].name",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[0].env[0].name": "LOG_LEVEL",
+				"spec.template.spec.containers[0].env[1].name": "PORT",
+			},
+		},
+		{
+			name:    "wildcard index then wildcard index",
+			pattern: "spec.template.spec.containers[*].env[*].name",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[0].env[0].name": "LOG_LEVEL",
+				"spec.template.spec.containers[0].env[1].name": "PORT",
+				"spec.template.spec.containers[1].env[0].name": "PROXY",
+			},
+		},
+		{
+			name:    "wildcard index then literal index",
+			pattern: "spec.template.spec.containers[*].env[0].value",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[0].env[0].value": "info",
+				"spec.template.spec.containers[1].env[0].value": "on",
+			},
+		},
+		{
+			name:    "literal indices only",
+			pattern: "spec.template.spec.containers[1].env[0].name",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[1].env[0].name": "PROXY",
+			},
+		},
+		{
+			name:    "recursive wildcard with literal index",
+			pattern: "**.env[1].name",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[0].env[1].name": "PORT",
+			},
+		},
+		{
+			name:    "last element of every array",
+			pattern: "spec.template.spec.containers[*].env[last].name",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[0].env[1].name": "PORT",
+				"spec.template.spec.containers[1].env[0].name": "PROXY",
+			},
+		},
+		{
+			name:    "index range clipped to the array",
+			pattern: "spec.template.spec.containers[0-4].name",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[0].name": "app",
+				"spec.template.spec.containers[1].name": "sidecar",
+			},
+		},
+		{
+			name:    "range then last",
+			pattern: "spec.template.spec.containers[1-1].env[last].value",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[1].env[0].value": "on",
+			},
+		},
+		{
+			name:    "recursive wildcard with range",
+			pattern: "**.env[1-2]",
+			expected: map[string]interface{}{
+				"spec.template.spec.containers[0].env[1]": map[string]interface{}{"name": "PORT", "value": "8080"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := doc.GetAll(tt.pattern)
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+
+			if len(result) != len(tt.expected) {
+				t.Errorf("GetAll() = %v, expected %v", result, tt.expected)
+			}
+			for key, want := range tt.expected {
+				if got, ok := result[key]; !ok || !reflect.DeepEqual(got, want) {
+					t.Errorf("GetAll()[%s] = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := doc.GetAll("spec.template.spec.containers[3-1].name"); err == nil {
+		t.Error("GetAll() with a reversed range should fail")
+	}
+}
+
+func TestDocument_SetAllArraySelectors(t *testing.T) {
+	input := `tasks:
+  - name: build
+    image: golang:1.20
+  - name: test
+    image: golang:1.20
+  - name: deploy
+    image: alpine:3.18 # pinned
+`
+	tests := []struct {
+		name    string
+		pattern string
+		value   interface{}
+		want    string
+	}{
+		{
+			name:    "every element",
+			pattern: "tasks[*].image",
+			value:   "golang:1.22",
+			want:    "tasks:\n  - name: build\n    image: golang:1.22\n  - name: test\n    image: golang:1.22\n  - name: deploy\n    image: golang:1.22 # pinned\n",
+		},
+		{
+			name:    "range",
+			pattern: "tasks[0-1].image",
+			value:   "golang:1.22",
+			want:    "tasks:\n  - name: build\n    image: golang:1.22\n  - name: test\n    image: golang:1.22\n  - name: deploy\n    image: alpine:3.18 # pinned\n",
+		},
+		{
+			name:    "last",
+			pattern: "tasks[last].image",
+			value:   "alpine:3.19",
+			want:    "tasks:\n  - name: build\n    image: golang:1.20\n  - name: test\n    image: golang:1.20\n  - name: deploy\n    image: alpine:3.19 # pinned\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := doc.SetAll(tt.pattern, tt.value); err != nil {
+				t.Fatalf("SetAll() error = %v", err)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetAll() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	doc, err := Load("- name: a\n- name: b\n")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	values, err := doc.GetEach("[last].name")
+	if err != nil || !reflect.DeepEqual(values, []interface{}{"b"}) {
+		t.Errorf("GetEach([last].name) on array root = %v, %v; want [b]", values, err)
+	}
+}
+
+func TestDocument_MatchPathsNatural(t *testing.T) {
+	yamlContent := `
+services:
+  service10:
+    port: 8010
+  service2:
+    port: 8002
+  service1:
+    port: 8001
+  service100:
+    port: 8100
+  service02:
+    port: 8002
+`
+
+	doc, err := Load(yamlContent)
+	if err != nil {
+		t.Fatalf("Failed to load document: %v", err)
+	}
+
+	paths, err := doc.MatchPathsNatural("services.*.port")
+	if err != nil {
+		t.Fatalf("MatchPathsNatural() error = %v", err)
+	}
+
+	expected := []string{
+		"services.service1.port",
+		"services.service2.port",
+		"services.service02.port",
+		"services.service10.port",
+		"services.service100.port",
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("MatchPathsNatural() = %v, want %v", paths, expected)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("MatchPathsNatural()[%d] = %s, want %s", i, paths[i], expected[i])
+		}
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"service2", "service10", true},
+		{"service10", "service2", false},
+		{"items[2].name", "items[10].name", true},
+		{"a", "b", true},
+		{"abc", "abcd", true},
+		{"abc", "abc", false},
+		{"v1.9", "v1.10", true},
+		{"x007", "x7", false},
+		{"x7", "x007", true},
+	}
+
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDocument_SetAllPreservesMatchStyles(t *testing.T) {
+	content := `services:
+  web:
+    image: "nginx"
+    replicas: '3'
+  api:
+    image: 'api'
+    replicas: 2
+  db:
+    image: postgres
+    replicas: "1"
+`
+
+	tests := []struct {
+		name    string
+		pattern string
+		value   interface{}
+		want    string
+	}{
+		{
+			name:    "mixed quote styles kept for strings",
+			pattern: "services.*.image",
+			value:   "registry/app",
+			want: `services:
+  web:
+    image: "registry/app"
+    replicas: '3'
+  api:
+    image: 'registry/app'
+    replicas: 2
+  db:
+    image: registry/app
+    replicas: "1"
+`,
+		},
+		{
+			name:    "quoted numeric strings stay quoted per match, numbers stay numbers",
+			pattern: "services.*.replicas",
+			value:   "5",
+			want: `services:
+  web:
+    image: "nginx"
+    replicas: '5'
+  api:
+    image: 'api'
+    replicas: 5
+  db:
+    image: postgres
+    replicas: "5"
+`,
+		},
+		{
+			name:    "incompatible type drops quotes",
+			pattern: "services.*.replicas",
+			value:   5,
+			want: `services:
+  web:
+    image: "nginx"
+    replicas: 5
+  api:
+    image: 'api'
+    replicas: 5
+  db:
+    image: postgres
+    replicas: 5
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(content)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if err := doc.SetAll(tt.pattern, tt.value); err != nil {
+				t.Fatalf("SetAll() error = %v", err)
+			}
+
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetAll() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_GetEach(t *testing.T) {
+	content := `services:
+  web:
+    image: nginx
+    ports: [80, 443]
+  api:
+    image: node
+    ports:
+      - 3000
+  worker:
+    image: python
+servers:
+  - name: a
+    tags: [x, y]
+  - name: b
+    tags: [z]
+`
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []interface{}
+	}{
+		{
+			name:    "ports across services",
+			pattern: "services.*.ports[*]",
+			want:    []interface{}{int64(80), int64(443), int64(3000)},
+		},
+		{
+			name:    "map wildcard in document order",
+			pattern: "services.*.image",
+			want:    []interface{}{"nginx", "node", "python"},
+		},
+		{
+			name:    "nested array wildcards",
+			pattern: "servers[*].tags[*]",
+			want:    []interface{}{"x", "y", "z"},
+		},
+		{
+			name:    "containers are skipped",
+			pattern: "services.*",
+			want:    nil,
+		},
+		{
+			name:    "no matches",
+			pattern: "services.*.volumes[*]",
+			want:    nil,
+		},
+	}
+
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.GetEach(tt.pattern)
+			if err != nil {
+				t.Fatalf("GetEach() error = %v", err)
+			}
+			if !deepEqual(got, tt.want) {
+				t.Errorf("GetEach() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_QuotedKeyPaths(t *testing.T) {
+	content := `metadata:
+  name: web
+  annotations:
+    prometheus.io/scrape: "true"
+    prometheus.io/port: "9090"
+    team: platform
+`
+
+	t.Run("wildcard matches dotted keys as one segment", func(t *testing.T) {
+		doc, err := Load(content)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		got, err := doc.GetAll("metadata.annotations.*")
+		if err != nil {
+			t.Fatalf("GetAll() error = %v", err)
+		}
+		want := map[string]interface{}{
+			`metadata.annotations["prometheus.io/scrape"]`: "true",
+			`metadata.annotations["prometheus.io/port"]`:   "9090",
+			"metadata.annotations.team":                    "platform",
+		}
+		if !deepEqual(got, want) {
+			t.Errorf("GetAll() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("quoted key pattern", func(t *testing.T) {
+		doc, err := Load(content)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		got, err := doc.GetAll(`metadata.annotations["prometheus.io/*"]`)
+		if err != nil {
+			t.Fatalf("GetAll() error = %v", err)
+		}
+		want := map[string]interface{}{
+			`metadata.annotations["prometheus.io/scrape"]`: "true",
+			`metadata.annotations["prometheus.io/port"]`:   "9090",
+		}
+		if !deepEqual(got, want) {
+			t.Errorf("GetAll() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("quoted key in Get and Set", func(t *testing.T) {
+		doc, err := Load(content)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		value, err := doc.GetString(`metadata.annotations["prometheus.io/port"]`)
+		if err != nil {
+			t.Fatalf("GetString() error = %v", err)
+		}
+		if value != "9090" {
+			t.Errorf("GetString() = %q, want %q", value, "9090")
+		}
+
+		if err := doc.SetAll(`metadata.annotations["prometheus.io/*"]`, "false"); err != nil {
+			t.Fatalf("SetAll() error = %v", err)
+		}
+		if err := doc.Set(`metadata.annotations["example.com/owner"]`, "ops"); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+
+		got, err := doc.String()
+		if err != nil {
+			t.Fatalf("String() error = %v", err)
+		}
+		want := `metadata:
+  name: web
+  annotations:
+    prometheus.io/scrape: "false"
+    prometheus.io/port: "false"
+    team: platform
+    example.com/owner: ops
+`
+		if got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("quoted key after an index", func(t *testing.T) {
+		doc, err := Load(`list:
+  - a.b: 1
+    name: x
+  - a.b: 2
+`)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		value, err := doc.GetInt(`list[1]["a.b"]`)
+		if err != nil {
+			t.Fatalf("GetInt() error = %v", err)
+		}
+		if value != 2 {
+			t.Errorf("GetInt() = %d, want 2", value)
+		}
+
+		// Paths returned by the wildcard engine can be passed back to Get
+		leaves, err := doc.GetAllWithOptions("list.**", MatchOptions{LeavesOnly: true})
+		if err != nil {
+			t.Fatalf("GetAllWithOptions() error = %v", err)
+		}
+		for path, want := range leaves {
+			got, err := doc.Get(path)
+			if err != nil {
+				t.Errorf("Get(%s) error = %v", path, err)
+			} else if !deepEqual(got, want) {
+				t.Errorf("Get(%s) = %v, want %v", path, got, want)
+			}
+		}
+
+		if err := doc.Set(`list[0]["a.b"]`, 9); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if err := doc.SetAll(`list[*]["a.b"]`, 7); err != nil {
+			t.Fatalf("SetAll() error = %v", err)
+		}
+		if err := doc.Set(`list[1]["c.d"]`, 3); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+
+		got, err := doc.String()
+		if err != nil {
+			t.Fatalf("String() error = %v", err)
+		}
+		want := `list:
+  - a.b: 7
+    name: x
+  - a.b: 7
+    c.d: 3
+`
+		if got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestDocument_GetAllRelative(t *testing.T) {
+	content := `environments:
+  production:
+    database:
+      host: db
+      port: 5432
+    cache:
+      host: redis
+    replicas: [a, b]
+  staging:
+    database:
+      host: staging-db
+`
+
+	tests := []struct {
+		name    string
+		base    string
+		pattern string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:    "recursive under base",
+			base:    "environments.production",
+			pattern: "**.host",
+			want:    map[string]interface{}{"database.host": "db", "cache.host": "redis"},
+		},
+		{
+			name:    "single level wildcard",
+			base:    "environments.production",
+			pattern: "database.*",
+			want:    map[string]interface{}{"database.host": "db", "database.port": int64(5432)},
+		},
+		{
+			name:    "array elements",
+			base:    "environments.production",
+			pattern: "replicas[*]",
+			want:    map[string]interface{}{"replicas[0]": "a", "replicas[1]": "b"},
+		},
+		{
+			name:    "sequence base",
+			base:    "environments.production.replicas",
+			pattern: "[*]",
+			want:    map[string]interface{}{"[0]": "a", "[1]": "b"},
+		},
+		{
+			name:    "empty base uses full paths",
+			base:    "",
+			pattern: "environments.*.database.host",
+			want: map[string]interface{}{
+				"environments.production.database.host": "db",
+				"environments.staging.database.host":    "staging-db",
+			},
+		},
+		{
+			name:    "missing base",
+			base:    "environments.dev",
+			pattern: "*",
+			wantErr: true,
+		},
+		{
+			name:    "scalar base",
+			base:    "environments.production.database.host",
+			pattern: "*",
+			wantErr: true,
+		},
+	}
+
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.GetAllRelative(tt.base, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetAllRelative() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !deepEqual(got, tt.want) {
+				t.Errorf("GetAllRelative() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_SetWhere(t *testing.T) {
+	input := `services:
+  web:
+    debug: true
+    port: 8080
+  api:
+    debug: false
+    port: "9000"
+  cache:
+    debug: true
+    port: 6379
+`
+	tests := []struct {
+		name      string
+		pattern   string
+		match     func(path string, value interface{}) bool
+		value     interface{}
+		wantCount int
+		want      string
+	}{
+		{
+			name:    "flip true flags",
+			pattern: "services.*.debug",
+			match: func(path string, value interface{}) bool {
+				return value == true
+			},
+			value:     false,
+			wantCount: 2,
+			want:      "services:\n  web:\n    debug: false\n    port: 8080\n  api:\n    debug: false\n    port: \"9000\"\n  cache:\n    debug: false\n    port: 6379\n",
+		},
+		{
+			name:    "ports above 8000",
+			pattern: "services.*.port",
+			match: func(path string, value interface{}) bool {
+				switch v := value.(type) {
+				case int64:
+					return v > 8000
+				case string:
+					return v > "8000"
+				}
+				return false
+			},
+			value:     8443,
+			wantCount: 2,
+			want:      "services:\n  web:\n    debug: true\n    port: 8443\n  api:\n    debug: false\n    port: 8443\n  cache:\n    debug: true\n    port: 6379\n",
+		},
+		{
+			name:    "match by path",
+			pattern: "services.*.debug",
+			match: func(path string, value interface{}) bool {
+				return path == "services.api.debug"
+			},
+			value:     true,
+			wantCount: 1,
+			want:      "services:\n  web:\n    debug: true\n    port: 8080\n  api:\n    debug: true\n    port: \"9000\"\n  cache:\n    debug: true\n    port: 6379\n",
+		},
+		{
+			name:    "nothing matches",
+			pattern: "services.*.debug",
+			match: func(path string, value interface{}) bool {
+				return false
+			},
+			value:     true,
+			wantCount: 0,
+			want:      input,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Load(input)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			count, err := doc.SetWhere(tt.pattern, tt.match, tt.value)
+			if err != nil {
+				t.Fatalf("SetWhere() error = %v", err)
+			}
+			if count != tt.wantCount {
+				t.Errorf("SetWhere() count = %d, want %d", count, tt.wantCount)
+			}
+			got, err := doc.String()
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetWhere() result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_GetAllOrdered(t *testing.T) {
+	content := `services:
+  web:
+    image: nginx
+    debug: true
+  api:
+    image: node
+  worker:
+    image: python
+    debug: false
+servers:
+  - name: b
+  - name: a
+`
+
+	tests := []struct {
+		name    string
+		pattern string
+		opts    MatchOptions
+		want    []PathValue
+	}{
+		{
+			name:    "map wildcard in document order",
+			pattern: "services.*.image",
+			want: []PathValue{
+				{Path: "services.web.image", Value: "nginx"},
+				{Path: "services.api.image", Value: "node"},
+				{Path: "services.worker.image", Value: "python"},
+			},
+		},
+		{
+			name:    "recursive wildcard",
+			pattern: "**.debug",
+			want: []PathValue{
+				{Path: "services.web.debug", Value: true},
+				{Path: "services.worker.debug", Value: false},
+			},
+		},
+		{
+			name:    "array elements keep their order",
+			pattern: "servers[*].name",
+			want: []PathValue{
+				{Path: "servers[0].name", Value: "b"},
+				{Path: "servers[1].name", Value: "a"},
+			},
+		},
+		{
+			name:    "leaves only",
+			pattern: "services.*",
+			opts:    MatchOptions{LeavesOnly: true},
+			want:    []PathValue{},
+		},
+		{
+			name:    "containers in document order",
+			pattern: "services.*",
+			want: []PathValue{
+				{Path: "services.web", Value: map[string]interface{}{"image": "nginx", "debug": true}},
+				{Path: "services.api", Value: map[string]interface{}{"image": "node"}},
+				{Path: "services.worker", Value: map[string]interface{}{"image": "python", "debug": false}},
+			},
+		},
+		{
+			name:    "no matches",
+			pattern: "services.*.volumes",
+			want:    []PathValue{},
+		},
+	}
+
+	doc, err := Load(content)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 5; i++ {
+				got, err := doc.GetAllOrderedWithOptions(tt.pattern, tt.opts)
+				if err != nil {
+					t.Fatalf("GetAllOrderedWithOptions() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("GetAllOrderedWithOptions() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if got, err := doc.GetAllOrdered("services.*.image"); err != nil || len(got) != 3 || got[0].Path != "services.web.image" {
+		t.Errorf("GetAllOrdered() = %v, %v", got, err)
+	}
+}